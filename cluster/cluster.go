@@ -2,6 +2,7 @@ package cluster
 
 import (
 	"net/http"
+	"net/url"
 	"time"
 )
 
@@ -16,3 +17,21 @@ type HttpClient interface {
 func init() {
 	client = &http.Client{Timeout: requestTimeout * time.Millisecond}
 }
+
+// SetClient replaces the client which cluster services are checked with. Services which have already been created keep the previous client.
+func SetClient(c HttpClient) {
+	client = c
+}
+
+// ReadEnvironment is a delivery cluster, from which published content can be read
+type ReadEnvironment struct {
+	Name     string
+	ReadURL  *url.URL
+	Username string
+	Password string
+}
+
+// ReadEnvironments is implemented by external services, which know the read environments of the delivery clusters
+type ReadEnvironments interface {
+	ReadEnvironments() []ReadEnvironment
+}
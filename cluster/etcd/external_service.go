@@ -87,6 +87,15 @@ func (e *externalService) String() string {
 	return desc
 }
 
+// ReadEnvironments returns the current read environments of the delivery clusters
+func (e *externalService) ReadEnvironments() []cluster.ReadEnvironment {
+	var envs []cluster.ReadEnvironment
+	for _, env := range e.environmentService.GetEnvironments() {
+		envs = append(envs, cluster.ReadEnvironment{Name: env.name, ReadURL: env.readURL})
+	}
+	return envs
+}
+
 func gtgURLFor(env readEnvironment, serviceName string) string {
 	return env.readURL.String() + "/__" + serviceName + "/__gtg"
 }
@@ -94,6 +94,19 @@ func (e *externalService) String() string {
 	return desc
 }
 
+// ReadEnvironments returns the current read environments of the delivery clusters, with their credentials
+func (e *externalService) ReadEnvironments() []cluster.ReadEnvironment {
+	var envs []cluster.ReadEnvironment
+	for _, env := range e.environmentService.GetEnvironments() {
+		readEnv := cluster.ReadEnvironment{Name: env.name, ReadURL: env.readURL}
+		if env.credentials != nil {
+			readEnv.Username, readEnv.Password = env.credentials.username, env.credentials.password
+		}
+		envs = append(envs, readEnv)
+	}
+	return envs
+}
+
 func gtgURLFor(env readEnvironment, serviceName string) string {
 	return env.readURL.String() + "/__" + serviceName + "/__gtg"
 }
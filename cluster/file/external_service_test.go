@@ -235,3 +235,22 @@ func TestExternalServiceClosesRespBody(t *testing.T) {
 	assert.NoError(t, s.Check(), "The service should be healthy")
 	mock.AssertExpectationsForObjects(t, c, watcher, body)
 }
+
+func TestExternalServiceReadEnvironments(t *testing.T) {
+	envs, err := parseEnvironments("environment1:http://environment1.ft.com,environment2:http://environment2.ft.com", "environment1:user1:password1")
+	assert.NoError(t, err)
+
+	service := &externalService{environmentService: &environmentService{environments: envs}}
+	readEnvs := service.ReadEnvironments()
+	assert.Len(t, readEnvs, 2)
+
+	for _, env := range readEnvs {
+		assert.Equal(t, "http://"+env.Name+".ft.com", env.ReadURL.String())
+		if env.Name == "environment1" {
+			assert.Equal(t, "user1", env.Username)
+			assert.Equal(t, "password1", env.Password)
+		} else {
+			assert.Empty(t, env.Username)
+		}
+	}
+}
@@ -28,6 +28,18 @@ func TestUnhappyNewService(t *testing.T) {
 	assert.Equal(t, urlError.Op, "parse")
 }
 
+func TestNewServiceUsesClient(t *testing.T) {
+	previous := client
+	defer SetClient(previous)
+
+	c := &MockClient{}
+	SetClient(c)
+
+	s, err := NewService("pam", "http://localhost:8080", false)
+	assert.NoError(t, err)
+	assert.Equal(t, c, s.(*clusterService).client)
+}
+
 func TestGTGClosesConnectionsIfHealthy(t *testing.T) {
 	c := &MockClient{}
 	gtg, _ := url.Parse("/__gtg")
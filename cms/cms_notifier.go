@@ -2,14 +2,21 @@ package cms
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httputil"
+	"strings"
+	"time"
 
 	"github.com/Financial-Times/publish-carousel/cluster"
 	"github.com/Financial-Times/publish-carousel/native"
+	"github.com/Financial-Times/publish-carousel/tracing"
 	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Notifier handles the publishing of the content to the cms-notifier
@@ -22,20 +29,58 @@ type cmsNotifier struct {
 	cluster.Service
 	client      cluster.HttpClient
 	notifierURL string
+	path        string
+	headers     map[string]string
+	tracer      trace.Tracer
 }
 
-// NewNotifier returns a new cms notifier instance
-func NewNotifier(notifierURL string, client cluster.HttpClient) (Notifier, error) {
+// NewNotifier returns a new cms notifier instance, which records a span of the tracer provider for every post
+func NewNotifier(notifierURL string, client cluster.HttpClient, tracerProvider trace.TracerProvider) (Notifier, error) {
 	s, err := cluster.NewService("cms-notifier", notifierURL, false)
 	if err != nil {
 		return nil, err
 	}
-	return &cmsNotifier{s, client, notifierURL}, nil
+	return &cmsNotifier{Service: s, client: client, notifierURL: notifierURL, path: notifyPath, tracer: tracing.Tracer(tracerProvider)}, nil
+}
+
+// NewTargetNotifier returns a cms notifier instance which posts to the path of the routing target, adding the target's headers to every request
+func NewTargetNotifier(target Target, client cluster.HttpClient, tracerProvider trace.TracerProvider) (Notifier, error) {
+	s, err := cluster.NewService("cms-notifier-"+target.Name, target.URL, false)
+	if err != nil {
+		return nil, err
+	}
+
+	path := target.Path
+	if strings.TrimSpace(path) == "" {
+		path = notifyPath
+	}
+	return &cmsNotifier{Service: s, client: client, notifierURL: target.URL, path: path, headers: target.Headers, tracer: tracing.Tracer(tracerProvider)}, nil
 }
 
 const notifyPath = "/notify"
 
+// Origin returns the origin system id which is sent for the content, which is the content's own origin system id if it has one, or otherwise the origin of the cycle
+func Origin(origin string, content *native.Content) string {
+	if content.OriginSystemID != "" {
+		return content.OriginSystemID
+	}
+	return origin
+}
+
 func (c *cmsNotifier) Notify(origin string, tid string, content *native.Content, hash string) error {
+	_, err := c.NotifyAttempts(context.Background(), origin, tid, content, hash)
+	return err
+}
+
+// NotifyAttempts posts the content once, within a client span of the trace of the context. The span is sent to the cms notifier as the parent in the traceparent header.
+func (c *cmsNotifier) NotifyAttempts(ctx context.Context, origin string, tid string, content *native.Content, hash string) (int, error) {
+	ctx, span := c.tracer.Start(ctx, tracing.NotifySpan, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attribute.String("transaction_id", tid), attribute.String("url", c.notifierURL+c.path)))
+	err := c.notify(ctx, origin, tid, content, hash)
+	tracing.End(span, err)
+	return 1, err
+}
+
+func (c *cmsNotifier) notify(ctx context.Context, origin string, tid string, content *native.Content, hash string) error {
 	b := new(bytes.Buffer)
 
 	enc := json.NewEncoder(b)
@@ -44,15 +89,17 @@ func (c *cmsNotifier) Notify(origin string, tid string, content *native.Content,
 		return err
 	}
 
-	req, err := http.NewRequest("POST", c.notifierURL+notifyPath, b)
+	req, err := http.NewRequest("POST", c.notifierURL+c.path, b)
 	req.Header.Add("User-Agent", "UPP Publish Carousel")
 	req.Header.Add("Content-Type", content.ContentType)
 	req.Header.Add("X-Request-Id", tid)
 	req.Header.Add("X-Native-Hash", hash)
-	if content.OriginSystemID != "" {
-		origin = content.OriginSystemID
-	}
+	tracing.Propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+	origin = Origin(origin, content)
 	req.Header.Add("X-Origin-System-Id", origin)
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
 	log.WithField("transaction_id", tid).WithField("nativeHash", hash).Info(fmt.Sprintf("Calling CMS notifier with contentType=%s, Origin=%s", content.ContentType, origin))
 
 	if err != nil {
@@ -73,5 +120,5 @@ func (c *cmsNotifier) Notify(origin string, tid string, content *native.Content,
 	dump, _ := httputil.DumpResponse(resp, true)
 	log.Info(string(dump))
 
-	return fmt.Errorf("A non 2xx error code was received by the CMS Notifier! Status: %v", resp.StatusCode)
+	return &NotifyError{StatusCode: resp.StatusCode, RetryAfter: retryAfter(resp.Header.Get("Retry-After"), time.Now())}
 }
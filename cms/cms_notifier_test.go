@@ -1,7 +1,9 @@
 package cms
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -9,9 +11,14 @@ import (
 
 	"github.com/Financial-Times/publish-carousel/cluster"
 	"github.com/Financial-Times/publish-carousel/native"
+	"github.com/Financial-Times/publish-carousel/tracing"
 	"github.com/husobee/vestigo"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func (m *mockNotifierServer) startMockNotifierServer(t *testing.T) *httptest.Server {
@@ -21,6 +28,7 @@ func (m *mockNotifierServer) startMockNotifierServer(t *testing.T) *httptest.Ser
 		assert.Equal(t, "UPP Publish Carousel", ua, "user-agent header")
 
 		tid := r.Header.Get("X-Request-Id")
+		m.traceparents = append(m.traceparents, r.Header.Get("traceparent"))
 		hash := r.Header.Get("X-Native-Hash")
 		origin := r.Header.Get("X-Origin-System-Id")
 		contentType := r.Header.Get("Content-Type")
@@ -57,6 +65,7 @@ func (m *mockNotifierServer) Notify(origin string, tid string, hash string, cont
 
 type mockNotifierServer struct {
 	mock.Mock
+	traceparents []string
 }
 
 func TestNotify(t *testing.T) {
@@ -65,7 +74,7 @@ func TestNotify(t *testing.T) {
 
 	server := mockNotifier.startMockNotifierServer(t)
 
-	notifier, err := NewNotifier(server.URL, &http.Client{})
+	notifier, err := NewNotifier(server.URL, &http.Client{}, nil)
 	assert.NoError(t, err)
 
 	err = notifier.Notify("origin", "tid_1234", &native.Content{Body: map[string]interface{}{"uuid": "uuid"}, ContentType: "application/json"}, "12345")
@@ -73,13 +82,48 @@ func TestNotify(t *testing.T) {
 	mockNotifier.AssertExpectations(t)
 }
 
+func TestNotifySendsTraceparent(t *testing.T) {
+	mockNotifier := new(mockNotifierServer)
+	mockNotifier.On("Notify", "origin", "tid_1234", "12345", "application/json").Return(200)
+
+	server := mockNotifier.startMockNotifierServer(t)
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	notifier, err := NewNotifier(server.URL, &http.Client{}, provider)
+	assert.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		ctx, publish := provider.Tracer("test").Start(context.Background(), tracing.PublishSpan)
+		_, err = NotifyAttempts(ctx, notifier, "origin", "tid_1234", &native.Content{Body: map[string]interface{}{"uuid": "uuid"}, ContentType: "application/json"}, "12345")
+		assert.NoError(t, err)
+		publish.End()
+	}
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 4)
+	require.Len(t, mockNotifier.traceparents, 2)
+
+	for i, traceparent := range mockNotifier.traceparents {
+		notify, publish := spans[2*i], spans[2*i+1]
+		assert.Equal(t, tracing.NotifySpan, notify.Name())
+		assert.Equal(t, trace.SpanKindClient, notify.SpanKind())
+		assert.Equal(t, publish.SpanContext().SpanID(), notify.Parent().SpanID())
+		assert.Equal(t, fmt.Sprintf("00-%v-%v-01", notify.SpanContext().TraceID(), notify.SpanContext().SpanID()), traceparent)
+	}
+
+	assert.NotEqual(t, spans[0].SpanContext().TraceID(), spans[2].SpanContext().TraceID(), "publishes of the same transaction id have their own traces")
+	mockNotifier.AssertExpectations(t)
+}
+
 func TestNotifyWithMsgOrigin(t *testing.T) {
 	mockNotifier := new(mockNotifierServer)
 	mockNotifier.On("Notify", "systemOriginId", "tid_1234", "12345", "application/json").Return(200)
 
 	server := mockNotifier.startMockNotifierServer(t)
 
-	notifier, err := NewNotifier(server.URL, &http.Client{})
+	notifier, err := NewNotifier(server.URL, &http.Client{}, nil)
 	assert.NoError(t, err)
 
 	err = notifier.Notify("origin", "tid_1234", &native.Content{Body: map[string]interface{}{"uuid": "uuid"}, ContentType: "application/json", OriginSystemID: "systemOriginId"}, "12345")
@@ -92,7 +136,7 @@ func TestNotifyFails(t *testing.T) {
 
 	server := mockNotifier.startMockNotifierServer(t)
 
-	notifier, err := NewNotifier(server.URL, &http.Client{})
+	notifier, err := NewNotifier(server.URL, &http.Client{}, nil)
 	assert.NoError(t, err)
 
 	err = notifier.Notify("origin", "tid_1234", &native.Content{Body: map[string]interface{}{"uuid": "uuid"}, ContentType: "application/json"}, "12345")
@@ -101,7 +145,7 @@ func TestNotifyFails(t *testing.T) {
 }
 
 func TestNotifierNotRunning(t *testing.T) {
-	notifier, err := NewNotifier("http://localhost", &http.Client{})
+	notifier, err := NewNotifier("http://localhost", &http.Client{}, nil)
 	assert.NoError(t, err)
 
 	err = notifier.Notify("origin", "tid_1234", &native.Content{}, "12345")
@@ -109,7 +153,7 @@ func TestNotifierNotRunning(t *testing.T) {
 }
 
 func TestJSONFails(t *testing.T) {
-	notifier, err := NewNotifier("http://localhost", &http.Client{})
+	notifier, err := NewNotifier("http://localhost", &http.Client{}, nil)
 	assert.NoError(t, err)
 
 	body := make(map[string]interface{})
@@ -124,7 +168,7 @@ func TestOKGTG(t *testing.T) {
 
 	server := mockNotifier.startMockNotifierServer(t)
 
-	notifier, err := NewNotifier(server.URL, &http.Client{})
+	notifier, err := NewNotifier(server.URL, &http.Client{}, nil)
 	assert.NoError(t, err)
 
 	err = notifier.Check()
@@ -138,7 +182,7 @@ func TestFailingGTG(t *testing.T) {
 
 	server := mockNotifier.startMockNotifierServer(t)
 
-	notifier, err := NewNotifier(server.URL, &http.Client{})
+	notifier, err := NewNotifier(server.URL, &http.Client{}, nil)
 	assert.NoError(t, err)
 
 	err = notifier.Check()
@@ -147,21 +191,21 @@ func TestFailingGTG(t *testing.T) {
 }
 
 func TestNoServer(t *testing.T) {
-	notifier, err := NewNotifier("http://localhost", &http.Client{})
+	notifier, err := NewNotifier("http://localhost", &http.Client{}, nil)
 	assert.NoError(t, err)
 	err = notifier.Check()
 	assert.Error(t, err)
 }
 
 func TestInvalidURL(t *testing.T) {
-	notifier, err := NewNotifier(":#", &http.Client{})
+	notifier, err := NewNotifier(":#", &http.Client{}, nil)
 	assert.Error(t, err)
 	assert.Nil(t, notifier)
 }
 
 func TestCMSNotifierClosesResponseBodies(t *testing.T) {
 	c := &cluster.MockClient{}
-	notifier, err := NewNotifier("/", c)
+	notifier, err := NewNotifier("/", c, nil)
 
 	assert.NoError(t, err)
 
@@ -174,3 +218,8 @@ func TestCMSNotifierClosesResponseBodies(t *testing.T) {
 	notifier.Notify("origin", "tid", &native.Content{}, "hash")
 	mock.AssertExpectationsForObjects(t, c, body)
 }
+
+func TestOrigin(t *testing.T) {
+	assert.Equal(t, "origin", Origin("origin", &native.Content{}))
+	assert.Equal(t, "systemOriginId", Origin("origin", &native.Content{OriginSystemID: "systemOriginId"}))
+}
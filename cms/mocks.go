@@ -18,3 +18,21 @@ func (m *MockNotifier) Check() error {
 	args := m.Called()
 	return args.Error(0)
 }
+
+type MockPooledNotifier struct {
+	MockNotifier
+}
+
+func (m *MockPooledNotifier) Endpoints() []EndpointStatus {
+	args := m.Called()
+	return args.Get(0).([]EndpointStatus)
+}
+
+type MockRoutingNotifier struct {
+	MockNotifier
+}
+
+func (m *MockRoutingNotifier) Targets() []TargetStatus {
+	args := m.Called()
+	return args.Get(0).([]TargetStatus)
+}
@@ -0,0 +1,269 @@
+package cms
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Financial-Times/publish-carousel/cluster"
+	"github.com/Financial-Times/publish-carousel/native"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// The ways in which the pooled notifier can choose the endpoint for each publish
+const (
+	RoundRobin   = "round-robin"
+	LeastLatency = "least-latency"
+)
+
+// latencyWeight is the weight of the latest request in the moving average latency of an endpoint
+const latencyWeight = 0.3
+
+// PoolPolicy configures how the pooled notifier chooses between its endpoints, and when it ejects an unhealthy endpoint
+type PoolPolicy struct {
+	Balancing  string
+	EjectAfter int
+	EjectFor   time.Duration
+}
+
+// DefaultPoolPolicy balances publishes round-robin, and ejects an endpoint for thirty seconds after three consecutive failures
+var DefaultPoolPolicy = PoolPolicy{Balancing: RoundRobin, EjectAfter: 3, EjectFor: 30 * time.Second}
+
+// Validate checks the balancing and ejection settings of the policy
+func (p PoolPolicy) Validate() error {
+	if p.Balancing != RoundRobin && p.Balancing != LeastLatency {
+		return fmt.Errorf("Please provide a valid balancing for the cms notifier endpoints, either %v or %v", RoundRobin, LeastLatency)
+	}
+
+	if p.EjectAfter < 1 {
+		return errors.New("Please provide a number of failures greater than zero, after which a cms notifier endpoint is ejected")
+	}
+
+	if p.EjectFor <= 0 {
+		return errors.New("Please provide a duration greater than zero, for which a cms notifier endpoint is ejected")
+	}
+	return nil
+}
+
+// EndpointStatus describes the health of one of the endpoints of the pooled notifier
+type EndpointStatus struct {
+	URL                 string     `json:"url"`
+	Available           bool       `json:"available"`
+	EjectedUntil        *time.Time `json:"ejectedUntil,omitempty"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	Latency             string     `json:"latency,omitempty"`
+	LastError           string     `json:"lastError,omitempty"`
+}
+
+// EndpointsNotifier is implemented by notifiers which publish to several cms notifier endpoints
+type EndpointsNotifier interface {
+	Endpoints() []EndpointStatus
+}
+
+type endpoint struct {
+	notifier     Notifier
+	url          string
+	failures     int
+	ejectedUntil time.Time
+	latency      time.Duration
+	lastError    string
+}
+
+func (e *endpoint) available(now time.Time) bool {
+	return !now.Before(e.ejectedUntil)
+}
+
+type pooledNotifier struct {
+	lock      *sync.Mutex
+	endpoints []*endpoint
+	policy    PoolPolicy
+	next      int
+	now       func() time.Time
+}
+
+// NewPooledNotifier publishes to several cms notifier endpoints, which are chosen round-robin or by least latency.
+// An endpoint is ejected after the policy's number of consecutive transient failures or a failed GTG check, and is re-admitted once the ejection expires.
+// If a publish to an endpoint fails with a transient error, the publish fails over to the next available endpoint.
+func NewPooledNotifier(notifierURLs []string, client cluster.HttpClient, policy PoolPolicy, tracerProvider trace.TracerProvider) (Notifier, error) {
+	if len(notifierURLs) == 0 {
+		return nil, errors.New("Please provide at least one cms notifier url")
+	}
+
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	pool := &pooledNotifier{lock: &sync.Mutex{}, policy: policy, now: time.Now}
+	for _, u := range notifierURLs {
+		notifier, err := NewNotifier(u, client, tracerProvider)
+		if err != nil {
+			return nil, err
+		}
+		pool.endpoints = append(pool.endpoints, &endpoint{notifier: notifier, url: u})
+	}
+	return pool, nil
+}
+
+// ParseURLs splits a comma separated list of cms notifier urls
+func ParseURLs(urls string) []string {
+	var parsed []string
+	for _, u := range strings.Split(urls, ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			parsed = append(parsed, u)
+		}
+	}
+	return parsed
+}
+
+func (p *pooledNotifier) Notify(origin string, tid string, content *native.Content, hash string) error {
+	_, err := p.NotifyAttempts(context.Background(), origin, tid, content, hash)
+	return err
+}
+
+// NotifyAttempts publishes to the first available endpoint, failing over to the next endpoint after a transient failure, and returns the number of endpoints it posted to
+func (p *pooledNotifier) NotifyAttempts(ctx context.Context, origin string, tid string, content *native.Content, hash string) (int, error) {
+	var err error
+	attempts := 0
+	for _, e := range p.candidates() {
+		if attempts > 0 && ctx.Err() != nil {
+			break
+		}
+
+		attempts++
+		start := p.now()
+		_, err = NotifyAttempts(ctx, e.notifier, origin, tid, content, hash)
+		p.record(e, p.now().Sub(start), err)
+
+		if err == nil || !Transient(err) {
+			return attempts, err
+		}
+		log.WithField("transaction_id", tid).WithField("endpoint", e.url).WithError(err).Warn("Failing over to the next cms notifier endpoint")
+	}
+	return attempts, err
+}
+
+// candidates returns the available endpoints in the order they should be tried. If every endpoint is ejected, they are all tried, starting with the one which will be re-admitted first.
+func (p *pooledNotifier) candidates() []*endpoint {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	now := p.now()
+	var available []*endpoint
+	for i := range p.endpoints {
+		e := p.endpoints[(p.next+i)%len(p.endpoints)]
+		if e.available(now) {
+			available = append(available, e)
+		}
+	}
+	p.next = (p.next + 1) % len(p.endpoints)
+
+	if len(available) == 0 {
+		available = append(available, p.endpoints...)
+		sort.SliceStable(available, func(i, j int) bool { return available[i].ejectedUntil.Before(available[j].ejectedUntil) })
+		return available
+	}
+
+	if p.policy.Balancing == LeastLatency {
+		sort.SliceStable(available, func(i, j int) bool { return available[i].latency < available[j].latency })
+	}
+	return available
+}
+
+// record updates the passive health of the endpoint. Permanent failures are caused by the content rather than the endpoint, so are not counted against it.
+func (p *pooledNotifier) record(e *endpoint, latency time.Duration, err error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if err == nil {
+		e.failures = 0
+		e.lastError = ""
+		if e.latency == 0 {
+			e.latency = latency
+		} else {
+			e.latency = time.Duration(latencyWeight*float64(latency) + (1-latencyWeight)*float64(e.latency))
+		}
+		return
+	}
+
+	if !Transient(err) {
+		return
+	}
+
+	e.failures++
+	e.lastError = err.Error()
+	if e.failures >= p.policy.EjectAfter {
+		p.eject(e)
+	}
+}
+
+// eject removes the endpoint from the pool until the ejection expires. It is then re-admitted on probation, so a single further failure ejects it again.
+func (p *pooledNotifier) eject(e *endpoint) {
+	if e.available(p.now()) {
+		log.WithField("endpoint", e.url).WithField("failures", e.failures).WithField("lastError", e.lastError).Warn("Ejecting unhealthy cms notifier endpoint")
+	}
+	e.ejectedUntil = p.now().Add(p.policy.EjectFor)
+	e.failures = p.policy.EjectAfter - 1
+}
+
+// Check checks the GTG of every endpoint, ejecting those which fail. It returns an error if no endpoint is available.
+func (p *pooledNotifier) Check() error {
+	errs := make([]error, len(p.endpoints))
+
+	var wg sync.WaitGroup
+	for i, e := range p.endpoints {
+		wg.Add(1)
+		go func(i int, e *endpoint) {
+			defer wg.Done()
+			errs[i] = e.notifier.Check()
+		}(i, e)
+	}
+	wg.Wait()
+
+	p.lock.Lock()
+	for i, e := range p.endpoints {
+		if errs[i] != nil {
+			e.lastError = errs[i].Error()
+			p.eject(e)
+		}
+	}
+	p.lock.Unlock()
+
+	for _, status := range p.Endpoints() {
+		if status.Available {
+			return nil
+		}
+	}
+	return fmt.Errorf("No cms notifier endpoint is available: %v", endpointsJSON(p.Endpoints()))
+}
+
+// Endpoints returns the status of every endpoint
+func (p *pooledNotifier) Endpoints() []EndpointStatus {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	now := p.now()
+	var statuses []EndpointStatus
+	for _, e := range p.endpoints {
+		status := EndpointStatus{URL: e.url, Available: e.available(now), ConsecutiveFailures: e.failures, LastError: e.lastError}
+		if !status.Available {
+			until := e.ejectedUntil
+			status.EjectedUntil = &until
+		}
+		if e.latency > 0 {
+			status.Latency = e.latency.String()
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+func endpointsJSON(statuses []EndpointStatus) string {
+	b, _ := json.Marshal(statuses)
+	return string(b)
+}
@@ -0,0 +1,191 @@
+package cms
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Financial-Times/publish-carousel/native"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func newTestPool(policy PoolPolicy, clock *testClock, notifiers ...*MockNotifier) *pooledNotifier {
+	pool := &pooledNotifier{lock: &sync.Mutex{}, policy: policy, now: clock.Now}
+	for i, n := range notifiers {
+		pool.endpoints = append(pool.endpoints, &endpoint{notifier: n, url: string(rune('a' + i))})
+	}
+	return pool
+}
+
+func TestPoolRoundRobin(t *testing.T) {
+	content := &native.Content{}
+	a, b := new(MockNotifier), new(MockNotifier)
+	a.On("Notify", "origin", mock.AnythingOfType("string"), content, "hash").Return(nil)
+	b.On("Notify", "origin", mock.AnythingOfType("string"), content, "hash").Return(nil)
+
+	pool := newTestPool(DefaultPoolPolicy, &testClock{now: time.Now()}, a, b)
+	for _, tid := range []string{"tid_1", "tid_2", "tid_3", "tid_4"} {
+		assert.NoError(t, pool.Notify("origin", tid, content, "hash"))
+	}
+
+	a.AssertCalled(t, "Notify", "origin", "tid_1", content, "hash")
+	a.AssertCalled(t, "Notify", "origin", "tid_3", content, "hash")
+	b.AssertCalled(t, "Notify", "origin", "tid_2", content, "hash")
+	b.AssertCalled(t, "Notify", "origin", "tid_4", content, "hash")
+}
+
+func TestPoolFailsOverAndEjects(t *testing.T) {
+	content := &native.Content{}
+	a, b := new(MockNotifier), new(MockNotifier)
+	a.On("Notify", "origin", "tid", content, "hash").Return(&NotifyError{StatusCode: http.StatusServiceUnavailable})
+	b.On("Notify", "origin", "tid", content, "hash").Return(nil)
+
+	clock := &testClock{now: time.Now()}
+	pool := newTestPool(PoolPolicy{Balancing: RoundRobin, EjectAfter: 2, EjectFor: time.Minute}, clock, a, b)
+
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, pool.Notify("origin", "tid", content, "hash"), "failed publishes fail over to the next endpoint")
+	}
+	a.AssertNumberOfCalls(t, "Notify", 2)
+	b.AssertNumberOfCalls(t, "Notify", 4)
+
+	statuses := pool.Endpoints()
+	require.Len(t, statuses, 2)
+	assert.False(t, statuses[0].Available)
+	assert.Equal(t, "A non 2xx error code was received by the CMS Notifier! Status: 503", statuses[0].LastError)
+	require.NotNil(t, statuses[0].EjectedUntil)
+	assert.Equal(t, clock.now.Add(time.Minute), *statuses[0].EjectedUntil)
+	assert.True(t, statuses[1].Available)
+
+	clock.now = clock.now.Add(time.Minute)
+	assert.True(t, pool.Endpoints()[0].Available, "the endpoint is re-admitted once the ejection expires")
+
+	assert.NoError(t, pool.Notify("origin", "tid", content, "hash"))
+	assert.NoError(t, pool.Notify("origin", "tid", content, "hash"))
+	a.AssertNumberOfCalls(t, "Notify", 3)
+	assert.False(t, pool.Endpoints()[0].Available, "a re-admitted endpoint is ejected again after a single failure")
+}
+
+func TestPoolReportsEveryEndpointItPostedTo(t *testing.T) {
+	content := &native.Content{}
+	a, b, c := new(MockNotifier), new(MockNotifier), new(MockNotifier)
+	a.On("Notify", "origin", "tid", content, "hash").Return(&NotifyError{StatusCode: http.StatusServiceUnavailable})
+	b.On("Notify", "origin", "tid", content, "hash").Return(&NotifyError{StatusCode: http.StatusServiceUnavailable})
+	c.On("Notify", "origin", "tid", content, "hash").Return(nil)
+
+	pool := newTestPool(DefaultPoolPolicy, &testClock{now: time.Now()}, a, b, c)
+	attempts, err := pool.NotifyAttempts(context.Background(), "origin", "tid", content, "hash")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+}
+
+func TestRetriesCountThePoolsFailovers(t *testing.T) {
+	content := &native.Content{}
+	a, b, c := new(MockNotifier), new(MockNotifier), new(MockNotifier)
+	for _, n := range []*MockNotifier{a, b, c} {
+		n.On("Notify", "origin", "tid", content, "hash").Return(&NotifyError{StatusCode: http.StatusServiceUnavailable})
+	}
+
+	var sleeps []time.Duration
+	pool := newTestPool(PoolPolicy{Balancing: RoundRobin, EjectAfter: 10, EjectFor: time.Minute}, &testClock{now: time.Now()}, a, b, c)
+	attempts, err := newTestRetryingNotifier(pool, &sleeps).NotifyAttempts(context.Background(), "origin", "tid", content, "hash")
+	assert.EqualError(t, err, "A non 2xx error code was received by the CMS Notifier! Status: 503 (after 3 attempts)")
+	assert.Equal(t, 3, attempts)
+	assert.Empty(t, sleeps, "failing over to every endpoint uses up the attempts, so there is no retry")
+
+	a.AssertNumberOfCalls(t, "Notify", 1)
+	b.AssertNumberOfCalls(t, "Notify", 1)
+	c.AssertNumberOfCalls(t, "Notify", 1)
+}
+
+func TestPoolDoesNotFailOverPermanentErrors(t *testing.T) {
+	content := &native.Content{}
+	a, b := new(MockNotifier), new(MockNotifier)
+	a.On("Notify", "origin", "tid", content, "hash").Return(&NotifyError{StatusCode: http.StatusBadRequest})
+
+	pool := newTestPool(DefaultPoolPolicy, &testClock{now: time.Now()}, a, b)
+	err := pool.Notify("origin", "tid", content, "hash")
+	assert.Equal(t, &NotifyError{StatusCode: http.StatusBadRequest}, err)
+
+	b.AssertNotCalled(t, "Notify", "origin", "tid", content, "hash")
+	assert.Equal(t, 0, pool.Endpoints()[0].ConsecutiveFailures, "permanent failures are not counted against the endpoint")
+}
+
+func TestPoolTriesEjectedEndpointsWhenNoneAreAvailable(t *testing.T) {
+	content := &native.Content{}
+	a, b := new(MockNotifier), new(MockNotifier)
+	a.On("Notify", "origin", "tid", content, "hash").Return(nil)
+
+	clock := &testClock{now: time.Now()}
+	pool := newTestPool(DefaultPoolPolicy, clock, a, b)
+	pool.endpoints[0].ejectedUntil = clock.now.Add(time.Second)
+	pool.endpoints[1].ejectedUntil = clock.now.Add(time.Minute)
+
+	assert.NoError(t, pool.Notify("origin", "tid", content, "hash"))
+	a.AssertNumberOfCalls(t, "Notify", 1)
+}
+
+func TestPoolLeastLatency(t *testing.T) {
+	content := &native.Content{}
+	a, b := new(MockNotifier), new(MockNotifier)
+	a.On("Notify", "origin", "tid", content, "hash").Return(nil)
+	b.On("Notify", "origin", "tid", content, "hash").Return(nil)
+
+	pool := newTestPool(PoolPolicy{Balancing: LeastLatency, EjectAfter: 3, EjectFor: time.Minute}, &testClock{now: time.Now()}, a, b)
+	pool.endpoints[0].latency = 200 * time.Millisecond
+	pool.endpoints[1].latency = 50 * time.Millisecond
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, pool.Notify("origin", "tid", content, "hash"))
+	}
+	a.AssertNotCalled(t, "Notify", "origin", "tid", content, "hash")
+	b.AssertNumberOfCalls(t, "Notify", 3)
+}
+
+func TestPoolCheckEjectsFailingGTG(t *testing.T) {
+	a, b := new(MockNotifier), new(MockNotifier)
+	a.On("Check").Return(errors.New("GTG for cms-notifier returned a non-200 code: 503"))
+	b.On("Check").Return(nil)
+
+	pool := newTestPool(DefaultPoolPolicy, &testClock{now: time.Now()}, a, b)
+	assert.NoError(t, pool.Check(), "the pool is healthy while one endpoint is available")
+	assert.False(t, pool.Endpoints()[0].Available)
+	assert.Equal(t, "GTG for cms-notifier returned a non-200 code: 503", pool.Endpoints()[0].LastError)
+
+	b.ExpectedCalls = nil
+	b.On("Check").Return(errors.New("GTG for cms-notifier returned a non-200 code: 500"))
+
+	err := pool.Check()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "No cms notifier endpoint is available: ")
+	assert.Contains(t, err.Error(), `"url":"b"`)
+}
+
+func TestNewPooledNotifier(t *testing.T) {
+	pool, err := NewPooledNotifier(ParseURLs("http://notifier-1:8080, http://notifier-2:8080,"), &http.Client{}, DefaultPoolPolicy, nil)
+	require.NoError(t, err)
+
+	statuses := pool.(EndpointsNotifier).Endpoints()
+	require.Len(t, statuses, 2)
+	assert.Equal(t, "http://notifier-1:8080", statuses[0].URL)
+	assert.Equal(t, "http://notifier-2:8080", statuses[1].URL)
+
+	_, err = NewPooledNotifier(nil, &http.Client{}, DefaultPoolPolicy, nil)
+	assert.EqualError(t, err, "Please provide at least one cms notifier url")
+
+	_, err = NewPooledNotifier([]string{"http://notifier-1:8080"}, &http.Client{}, PoolPolicy{Balancing: "random", EjectAfter: 1, EjectFor: time.Second}, nil)
+	assert.EqualError(t, err, "Please provide a valid balancing for the cms notifier endpoints, either round-robin or least-latency")
+}
@@ -0,0 +1,170 @@
+package cms
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Financial-Times/publish-carousel/native"
+	log "github.com/sirupsen/logrus"
+)
+
+// RetryPolicy configures how many times a transient notify failure is retried, and how long to wait between attempts
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// DefaultRetryPolicy makes up to three attempts, waiting around one and then two seconds between them
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Second, MaxBackoff: 30 * time.Second}
+
+// NotifyError is returned by the cms notifier when it receives a non 2xx response
+type NotifyError struct {
+	StatusCode int
+	RetryAfter time.Duration
+}
+
+func (e *NotifyError) Error() string {
+	return fmt.Sprintf("A non 2xx error code was received by the CMS Notifier! Status: %v", e.StatusCode)
+}
+
+// RetryError is returned by the retrying notifier when every attempt to notify fails, or a permanent failure is received
+type RetryError struct {
+	Attempts int
+	Err      error
+}
+
+func (e *RetryError) Error() string {
+	if e.Attempts == 1 {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%v (after %v attempts)", e.Err.Error(), e.Attempts)
+}
+
+// AttemptingNotifier is implemented by notifiers which can report how many attempts a notify took, and which stop retrying once the context is cancelled
+type AttemptingNotifier interface {
+	NotifyAttempts(ctx context.Context, origin string, tid string, content *native.Content, hash string) (attempts int, err error)
+}
+
+// NotifyAttempts notifies with the notifier, and returns the number of attempts it took. Notifiers which do not retry always make a single attempt.
+func NotifyAttempts(ctx context.Context, notifier Notifier, origin string, tid string, content *native.Content, hash string) (int, error) {
+	if n, ok := notifier.(AttemptingNotifier); ok {
+		return n.NotifyAttempts(ctx, origin, tid, content, hash)
+	}
+	return 1, notifier.Notify(origin, tid, content, hash)
+}
+
+// Transient returns true for notify failures which may succeed if they are retried, which are network errors, timeouts, 429 and 5xx responses. All other failures, including other 4xx responses, are permanent.
+func Transient(err error) bool {
+	switch e := err.(type) {
+	case *NotifyError:
+		return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+	case net.Error:
+		return true
+	}
+	return false
+}
+
+type retryingNotifier struct {
+	Notifier
+	policy RetryPolicy
+	sleep  func(ctx context.Context, d time.Duration) error
+}
+
+// NewRetryingNotifier retries transient failures of the notifier with jittered exponential backoff. A Retry-After from a 429 or 503 response is always honoured, even if it is longer than the maximum backoff, unless the context is cancelled or reaches its deadline while waiting.
+func NewRetryingNotifier(notifier Notifier, policy RetryPolicy) Notifier {
+	return &retryingNotifier{Notifier: notifier, policy: policy, sleep: sleep}
+}
+
+func (r *retryingNotifier) Notify(origin string, tid string, content *native.Content, hash string) error {
+	_, err := r.NotifyAttempts(context.Background(), origin, tid, content, hash)
+	return err
+}
+
+// Endpoints returns the status of the endpoints of the notifier which is retried, if it has several
+func (r *retryingNotifier) Endpoints() []EndpointStatus {
+	if n, ok := r.Notifier.(EndpointsNotifier); ok {
+		return n.Endpoints()
+	}
+	return nil
+}
+
+// NotifyAttempts retries transient failures until the policy's maximum attempts have been made. Each post made by the notifier which is retried counts as an attempt, including those made by a pool failing over between its endpoints.
+func (r *retryingNotifier) NotifyAttempts(ctx context.Context, origin string, tid string, content *native.Content, hash string) (int, error) {
+	backoff := r.policy.InitialBackoff
+	attempt := 0
+	for {
+		made, err := NotifyAttempts(ctx, r.Notifier, origin, tid, content, hash)
+		attempt += made
+		if err == nil {
+			return attempt, nil
+		}
+
+		if !Transient(err) || attempt >= r.policy.MaxAttempts {
+			return attempt, &RetryError{Attempts: attempt, Err: err}
+		}
+
+		wait := jitter(backoff)
+		if e, ok := err.(*NotifyError); ok && e.RetryAfter > 0 {
+			wait = e.RetryAfter
+		}
+
+		log.WithField("transaction_id", tid).WithField("attempt", attempt).WithField("wait", wait.String()).WithError(err).Info("Retrying notify after a transient failure")
+		if r.sleep(ctx, wait) != nil {
+			log.WithField("transaction_id", tid).WithField("attempt", attempt).Info("Stopped retrying notify, as the cycle was stopped")
+			return attempt, &RetryError{Attempts: attempt, Err: err}
+		}
+
+		if backoff *= 2; backoff > r.policy.MaxBackoff {
+			backoff = r.policy.MaxBackoff
+		}
+	}
+}
+
+// sleep waits for the duration, or until the context is cancelled, in which case the context's error is returned
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// jitter returns a random duration between half and all of the backoff
+func jitter(backoff time.Duration) time.Duration {
+	if backoff <= 1 {
+		return backoff
+	}
+	half := backoff / 2
+	return half + time.Duration(rand.Int63n(int64(backoff-half)+1))
+}
+
+// retryAfter parses the Retry-After header, which is either a number of seconds or an http date
+func retryAfter(header string, now time.Time) time.Duration {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(header); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(header); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
@@ -0,0 +1,192 @@
+package cms
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Financial-Times/publish-carousel/native"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func newTestRetryingNotifier(notifier Notifier, sleeps *[]time.Duration) *retryingNotifier {
+	return &retryingNotifier{Notifier: notifier, policy: RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Second, MaxBackoff: 10 * time.Second}, sleep: func(ctx context.Context, d time.Duration) error {
+		*sleeps = append(*sleeps, d)
+		return ctx.Err()
+	}}
+}
+
+func TestTransient(t *testing.T) {
+	assert.True(t, Transient(&NotifyError{StatusCode: http.StatusServiceUnavailable}))
+	assert.True(t, Transient(&NotifyError{StatusCode: http.StatusInternalServerError}))
+	assert.True(t, Transient(&NotifyError{StatusCode: http.StatusTooManyRequests}))
+	assert.True(t, Transient(timeoutError{}))
+
+	assert.False(t, Transient(&NotifyError{StatusCode: http.StatusBadRequest}))
+	assert.False(t, Transient(&NotifyError{StatusCode: http.StatusUnprocessableEntity}))
+	assert.False(t, Transient(errors.New("json: unsupported type")))
+}
+
+func TestRetryTransientFailures(t *testing.T) {
+	content := &native.Content{Body: map[string]interface{}{"uuid": "uuid"}}
+
+	notifier := new(MockNotifier)
+	notifier.On("Notify", "origin", "tid_1234", content, "12345").Return(timeoutError{}).Once()
+	notifier.On("Notify", "origin", "tid_1234", content, "12345").Return(&NotifyError{StatusCode: http.StatusBadGateway}).Once()
+	notifier.On("Notify", "origin", "tid_1234", content, "12345").Return(nil).Once()
+
+	var sleeps []time.Duration
+	attempts, err := newTestRetryingNotifier(notifier, &sleeps).NotifyAttempts(context.Background(), "origin", "tid_1234", content, "12345")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+
+	require.Len(t, sleeps, 2)
+	assert.True(t, sleeps[0] >= 500*time.Millisecond && sleeps[0] <= time.Second, "the first backoff is jittered between half and all of the initial backoff")
+	assert.True(t, sleeps[1] >= time.Second && sleeps[1] <= 2*time.Second, "the backoff doubles")
+	notifier.AssertExpectations(t)
+}
+
+func TestRetryGivesUpAfterMaxAttempts(t *testing.T) {
+	content := &native.Content{}
+
+	notifier := new(MockNotifier)
+	notifier.On("Notify", "origin", "tid_1234", content, "12345").Return(&NotifyError{StatusCode: http.StatusServiceUnavailable})
+
+	var sleeps []time.Duration
+	attempts, err := newTestRetryingNotifier(notifier, &sleeps).NotifyAttempts(context.Background(), "origin", "tid_1234", content, "12345")
+	assert.Equal(t, 3, attempts)
+	assert.EqualError(t, err, "A non 2xx error code was received by the CMS Notifier! Status: 503 (after 3 attempts)")
+	assert.Len(t, sleeps, 2)
+	notifier.AssertNumberOfCalls(t, "Notify", 3)
+}
+
+func TestRetryStopsWhenContextIsCancelled(t *testing.T) {
+	content := &native.Content{}
+
+	notifier := new(MockNotifier)
+	notifier.On("Notify", "origin", "tid_1234", content, "12345").Return(&NotifyError{StatusCode: http.StatusServiceUnavailable})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var sleeps []time.Duration
+	attempts, err := newTestRetryingNotifier(notifier, &sleeps).NotifyAttempts(ctx, "origin", "tid_1234", content, "12345")
+	assert.Equal(t, 1, attempts)
+	assert.EqualError(t, err, "A non 2xx error code was received by the CMS Notifier! Status: 503")
+	assert.Len(t, sleeps, 1)
+	notifier.AssertNumberOfCalls(t, "Notify", 1)
+}
+
+func TestSleepIsCancelledWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	assert.Equal(t, context.Canceled, sleep(ctx, time.Minute))
+	assert.True(t, time.Since(start) < time.Minute)
+	assert.NoError(t, sleep(context.Background(), time.Millisecond))
+}
+
+func TestPermanentFailuresAreNotRetried(t *testing.T) {
+	content := &native.Content{}
+
+	notifier := new(MockNotifier)
+	notifier.On("Notify", "origin", "tid_1234", content, "12345").Return(&NotifyError{StatusCode: http.StatusBadRequest})
+
+	var sleeps []time.Duration
+	attempts, err := newTestRetryingNotifier(notifier, &sleeps).NotifyAttempts(context.Background(), "origin", "tid_1234", content, "12345")
+	assert.Equal(t, 1, attempts)
+	assert.EqualError(t, err, "A non 2xx error code was received by the CMS Notifier! Status: 400")
+	assert.Empty(t, sleeps)
+	notifier.AssertNumberOfCalls(t, "Notify", 1)
+}
+
+func TestRetryHonoursRetryAfter(t *testing.T) {
+	content := &native.Content{}
+
+	notifier := new(MockNotifier)
+	notifier.On("Notify", "origin", "tid_1234", content, "12345").Return(&NotifyError{StatusCode: http.StatusTooManyRequests, RetryAfter: 7 * time.Second}).Once()
+	notifier.On("Notify", "origin", "tid_1234", content, "12345").Return(nil).Once()
+
+	var sleeps []time.Duration
+	attempts, err := newTestRetryingNotifier(notifier, &sleeps).NotifyAttempts(context.Background(), "origin", "tid_1234", content, "12345")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, []time.Duration{7 * time.Second}, sleeps)
+}
+
+func TestRetryAfterLongerThanMaxBackoffIsWaitedFor(t *testing.T) {
+	content := &native.Content{}
+
+	notifier := new(MockNotifier)
+	notifier.On("Notify", "origin", "tid_1234", content, "12345").Return(&NotifyError{StatusCode: http.StatusServiceUnavailable, RetryAfter: time.Minute}).Once()
+	notifier.On("Notify", "origin", "tid_1234", content, "12345").Return(nil).Once()
+
+	var sleeps []time.Duration
+	attempts, err := newTestRetryingNotifier(notifier, &sleeps).NotifyAttempts(context.Background(), "origin", "tid_1234", content, "12345")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, []time.Duration{time.Minute}, sleeps, "the Retry-After should be honoured, rather than giving up")
+}
+
+func TestRetryAfterStopsAtTheContextDeadline(t *testing.T) {
+	content := &native.Content{}
+
+	notifier := new(MockNotifier)
+	notifier.On("Notify", "origin", "tid_1234", content, "12345").Return(&NotifyError{StatusCode: http.StatusServiceUnavailable, RetryAfter: time.Hour})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	r := &retryingNotifier{Notifier: notifier, policy: RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Second, MaxBackoff: 10 * time.Second}, sleep: sleep}
+
+	start := time.Now()
+	attempts, err := r.NotifyAttempts(ctx, "origin", "tid_1234", content, "12345")
+	assert.EqualError(t, err, "A non 2xx error code was received by the CMS Notifier! Status: 503")
+	assert.Equal(t, 1, attempts)
+	assert.True(t, time.Since(start) < time.Minute, "the wait should end at the context deadline")
+}
+
+func TestNotifyAttemptsWithoutRetries(t *testing.T) {
+	content := &native.Content{}
+
+	notifier := new(MockNotifier)
+	notifier.On("Notify", "origin", "tid_1234", content, "12345").Return(nil)
+
+	attempts, err := NotifyAttempts(context.Background(), notifier, "origin", "tid_1234", content, "12345")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestNotifierReturnsRetryAfter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "5")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	notifier, err := NewNotifier(server.URL, &http.Client{}, nil)
+	require.NoError(t, err)
+
+	err = notifier.Notify("origin", "tid_1234", &native.Content{Body: map[string]interface{}{"uuid": "uuid"}}, "12345")
+	assert.Equal(t, &NotifyError{StatusCode: http.StatusTooManyRequests, RetryAfter: 5 * time.Second}, err)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2017, time.May, 1, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, 120*time.Second, retryAfter("120", now))
+	assert.Equal(t, 30*time.Second, retryAfter("Mon, 01 May 2017 12:00:30 GMT", now))
+	assert.Equal(t, time.Duration(0), retryAfter("Mon, 01 May 2017 11:00:00 GMT", now))
+	assert.Equal(t, time.Duration(0), retryAfter("soon", now))
+	assert.Equal(t, time.Duration(0), retryAfter("", now))
+}
@@ -0,0 +1,402 @@
+package cms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Financial-Times/publish-carousel/cluster"
+	"github.com/Financial-Times/publish-carousel/native"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// DefaultTarget is the name of the target which posts to the cms notifier configured by the cms-notifier-url. Content which matches no route is sent to it.
+const DefaultTarget = "default"
+
+// maxPendingFanOuts limits the number of partly failed publishes for which the routing notifier remembers the targets which succeeded
+const maxPendingFanOuts = 10000
+
+// Target is an endpoint which content can be routed to. If no path is provided, content is posted to /notify.
+type Target struct {
+	Name    string            `yaml:"name"`
+	URL     string            `yaml:"url"`
+	Path    string            `yaml:"path"`
+	Headers map[string]string `yaml:"headers"`
+}
+
+// Route sends the content of its collections, origins and content types to each of its targets. A route without any collections, origins or content types matches all content.
+type Route struct {
+	Name         string   `yaml:"name"`
+	Collections  []string `yaml:"collections"`
+	Origins      []string `yaml:"origins"`
+	ContentTypes []string `yaml:"contentTypes"`
+	Targets      []string `yaml:"targets"`
+}
+
+// Routes configures the targets which content is posted to. The first route which matches some content is used.
+type Routes struct {
+	Targets []Target `yaml:"targets"`
+	Routes  []Route  `yaml:"routes"`
+}
+
+// LoadRoutes reads the routes from the provided yaml file. If no file is provided, all content is sent to the default cms notifier.
+func LoadRoutes(routesFile string) (*Routes, error) {
+	if strings.TrimSpace(routesFile) == "" {
+		return nil, nil
+	}
+
+	fileData, err := ioutil.ReadFile(routesFile)
+	if err != nil {
+		return nil, err
+	}
+
+	routes := &Routes{}
+	if err := yaml.Unmarshal(fileData, routes); err != nil {
+		return nil, err
+	}
+
+	if err := routes.Validate(); err != nil {
+		return nil, err
+	}
+	return routes, nil
+}
+
+// Validate checks every target has a unique name and a url, and every route has targets which exist
+func (r *Routes) Validate() error {
+	names := map[string]bool{DefaultTarget: true}
+	for _, target := range r.Targets {
+		if strings.TrimSpace(target.Name) == "" {
+			return errors.New("Please provide a name for every notifier target")
+		}
+
+		if names[target.Name] {
+			return fmt.Errorf("Notifier target %v is defined more than once, or uses the reserved name %v", target.Name, DefaultTarget)
+		}
+		names[target.Name] = true
+
+		if strings.TrimSpace(target.URL) == "" {
+			return fmt.Errorf("Please provide a url for notifier target %v", target.Name)
+		}
+
+		if target.Path != "" && !strings.HasPrefix(target.Path, "/") {
+			return fmt.Errorf("The path of notifier target %v must start with a /", target.Name)
+		}
+	}
+
+	for i, route := range r.Routes {
+		if strings.TrimSpace(route.Name) == "" {
+			return fmt.Errorf("Please provide a name for notifier route %v", i+1)
+		}
+
+		if len(route.Targets) == 0 {
+			return fmt.Errorf("Please provide at least one target for notifier route %v", route.Name)
+		}
+
+		for _, t := range route.Targets {
+			if !names[t] {
+				return fmt.Errorf("Notifier route %v uses target %v, which is not defined", route.Name, t)
+			}
+		}
+	}
+	return nil
+}
+
+// Matches returns true if the route matches the collection, effective origin and content type of the content
+func (r Route) Matches(origin string, content *native.Content) bool {
+	contentType := strings.TrimSpace(strings.Split(content.ContentType, ";")[0])
+	return matchesAny(content.Collection, r.Collections) && matchesAny(Origin(origin, content), r.Origins) && matchesAny(contentType, r.ContentTypes)
+}
+
+func matchesAny(val string, options []string) bool {
+	if len(options) == 0 {
+		return true
+	}
+
+	for _, option := range options {
+		if strings.EqualFold(val, option) {
+			return true
+		}
+	}
+	return false
+}
+
+// TargetStatus counts the successful and failed publishes to one of the targets of the routing notifier
+type TargetStatus struct {
+	Name        string           `json:"name"`
+	URL         string           `json:"url,omitempty"`
+	Succeeded   int              `json:"succeeded"`
+	Failed      int              `json:"failed"`
+	LastSuccess *time.Time       `json:"lastSuccess,omitempty"`
+	LastFailure *time.Time       `json:"lastFailure,omitempty"`
+	LastError   string           `json:"lastError,omitempty"`
+	Endpoints   []EndpointStatus `json:"endpoints,omitempty"`
+}
+
+// TargetsNotifier is implemented by notifiers which route content to several targets
+type TargetsNotifier interface {
+	Targets() []TargetStatus
+}
+
+// FanOutError is returned by the routing notifier when content is sent to several targets, and at least one of them fails. Succeeded lists the targets which have the content, including those which succeeded on an earlier attempt.
+type FanOutError struct {
+	Targets   int
+	Succeeded []string
+	Failed    map[string]error
+}
+
+// FailedTargets returns the names of the targets which failed, in order
+func (e *FanOutError) FailedTargets() []string {
+	var names []string
+	for name := range e.Failed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (e *FanOutError) Error() string {
+	var failures []string
+	for _, name := range e.FailedTargets() {
+		failures = append(failures, fmt.Sprintf("%v: %v", name, e.Failed[name].Error()))
+	}
+	return fmt.Sprintf("Failed to notify %v of %v targets: %v", len(e.Failed), e.Targets, strings.Join(failures, "; "))
+}
+
+type target struct {
+	name        string
+	url         string
+	notifier    Notifier
+	succeeded   int
+	failed      int
+	lastSuccess time.Time
+	lastFailure time.Time
+	lastError   string
+}
+
+type routingNotifier struct {
+	lock    *sync.Mutex
+	routes  []Route
+	targets map[string]*target
+	order   []*target
+	pending map[string]map[string]bool
+	now     func() time.Time
+}
+
+// NewRoutingNotifier sends content to the targets of the first route which matches it, or to the default notifier if no route matches.
+// Content is sent to every target of its route concurrently, and the publish fails if any of them fail. Transient failures of each target are retried with the retry policy.
+// If some of the targets fail, the targets which succeeded are remembered, so that when the same content is published again it is only sent to the targets which failed.
+func NewRoutingNotifier(routes Routes, defaultNotifier Notifier, client cluster.HttpClient, policy RetryPolicy, tracerProvider trace.TracerProvider) (Notifier, error) {
+	if err := routes.Validate(); err != nil {
+		return nil, err
+	}
+
+	r := &routingNotifier{lock: &sync.Mutex{}, routes: routes.Routes, targets: make(map[string]*target), pending: make(map[string]map[string]bool), now: time.Now}
+	r.add(&target{name: DefaultTarget, notifier: defaultNotifier})
+
+	for _, t := range routes.Targets {
+		notifier, err := NewTargetNotifier(t, client, tracerProvider)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid url for notifier target %v: %v", t.Name, err)
+		}
+
+		if policy.MaxAttempts > 1 {
+			notifier = NewRetryingNotifier(notifier, policy)
+		}
+		r.add(&target{name: t.Name, url: t.URL, notifier: notifier})
+	}
+	return r, nil
+}
+
+func (r *routingNotifier) add(t *target) {
+	r.targets[t.name] = t
+	r.order = append(r.order, t)
+}
+
+// route returns the targets of the first route which matches the content
+func (r *routingNotifier) route(origin string, content *native.Content) []*target {
+	for _, route := range r.routes {
+		if !route.Matches(origin, content) {
+			continue
+		}
+
+		var targets []*target
+		for _, name := range route.Targets {
+			targets = append(targets, r.targets[name])
+		}
+		return targets
+	}
+	return []*target{r.targets[DefaultTarget]}
+}
+
+func (r *routingNotifier) Notify(origin string, tid string, content *native.Content, hash string) error {
+	_, err := r.NotifyAttempts(context.Background(), origin, tid, content, hash)
+	return err
+}
+
+// NotifyAttempts notifies every target of the content's route which does not already have the content, and returns the most attempts any of the targets took
+func (r *routingNotifier) NotifyAttempts(ctx context.Context, origin string, tid string, content *native.Content, hash string) (int, error) {
+	targets := r.route(origin, content)
+	if len(targets) == 1 {
+		return r.notify(ctx, targets[0], origin, tid, content, hash)
+	}
+
+	all := len(targets)
+	succeeded, targets := r.remaining(hash, targets)
+	if len(succeeded) > 0 {
+		log.WithField("transaction_id", tid).WithField("succeeded", strings.Join(succeeded, ",")).Info("Only notifying the targets which failed to receive the content last time")
+	}
+
+	attempts := make([]int, len(targets))
+	errs := make([]error, len(targets))
+
+	var wg sync.WaitGroup
+	for i, t := range targets {
+		wg.Add(1)
+		go func(i int, t *target) {
+			defer wg.Done()
+			attempts[i], errs[i] = r.notify(ctx, t, origin, tid, content, hash)
+		}(i, t)
+	}
+	wg.Wait()
+
+	max := 0
+	failed := make(map[string]error)
+	for i, t := range targets {
+		if attempts[i] > max {
+			max = attempts[i]
+		}
+		if errs[i] != nil {
+			failed[t.name] = errs[i]
+		} else {
+			succeeded = append(succeeded, t.name)
+		}
+	}
+	sort.Strings(succeeded)
+	r.remember(hash, succeeded, len(failed) > 0)
+
+	if len(failed) > 0 {
+		return max, &FanOutError{Targets: all, Succeeded: succeeded, Failed: failed}
+	}
+	return max, nil
+}
+
+// remaining returns the names of the targets which already have the content with the hash, and the targets which still need to be notified
+func (r *routingNotifier) remaining(hash string, targets []*target) ([]string, []*target) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	done := r.pending[hash]
+	if len(done) == 0 {
+		return nil, targets
+	}
+
+	var succeeded []string
+	var remaining []*target
+	for _, t := range targets {
+		if done[t.name] {
+			succeeded = append(succeeded, t.name)
+		} else {
+			remaining = append(remaining, t)
+		}
+	}
+	return succeeded, remaining
+}
+
+// remember records the targets which have the content with the hash while some targets are still failing, and forgets them once every target has succeeded
+func (r *routingNotifier) remember(hash string, succeeded []string, failed bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if !failed || len(succeeded) == 0 {
+		delete(r.pending, hash)
+		return
+	}
+
+	if _, ok := r.pending[hash]; !ok && len(r.pending) >= maxPendingFanOuts {
+		log.WithField("pending", len(r.pending)).Warn("Too many partly failed publishes, so the content will be sent to every target if it is published again")
+		return
+	}
+
+	done := make(map[string]bool)
+	for _, name := range succeeded {
+		done[name] = true
+	}
+	r.pending[hash] = done
+}
+
+func (r *routingNotifier) notify(ctx context.Context, t *target, origin string, tid string, content *native.Content, hash string) (int, error) {
+	attempts, err := NotifyAttempts(ctx, t.notifier, origin, tid, content, hash)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if err != nil {
+		log.WithField("transaction_id", tid).WithField("target", t.name).WithError(err).Warn("Failed to notify target")
+		t.failed++
+		t.lastFailure = r.now()
+		t.lastError = err.Error()
+		return attempts, err
+	}
+
+	t.succeeded++
+	t.lastSuccess = r.now()
+	return attempts, nil
+}
+
+// Check checks the GTG of every target, and returns an error if any of them fail
+func (r *routingNotifier) Check() error {
+	errs := make([]error, len(r.order))
+
+	var wg sync.WaitGroup
+	for i, t := range r.order {
+		wg.Add(1)
+		go func(i int, t *target) {
+			defer wg.Done()
+			errs[i] = t.notifier.Check()
+		}(i, t)
+	}
+	wg.Wait()
+
+	var failures []string
+	for i, t := range r.order {
+		if errs[i] != nil {
+			failures = append(failures, fmt.Sprintf("%v: %v", t.name, errs[i].Error()))
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("CMS notifier targets are unhealthy: %v", strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// Targets returns the publish counts of every target, including the endpoints of those which have several
+func (r *routingNotifier) Targets() []TargetStatus {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var statuses []TargetStatus
+	for _, t := range r.order {
+		status := TargetStatus{Name: t.name, URL: t.url, Succeeded: t.succeeded, Failed: t.failed, LastError: t.lastError}
+		if !t.lastSuccess.IsZero() {
+			lastSuccess := t.lastSuccess
+			status.LastSuccess = &lastSuccess
+		}
+		if !t.lastFailure.IsZero() {
+			lastFailure := t.lastFailure
+			status.LastFailure = &lastFailure
+		}
+		if n, ok := t.notifier.(EndpointsNotifier); ok {
+			status.Endpoints = n.Endpoints()
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
@@ -0,0 +1,251 @@
+package cms
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Financial-Times/publish-carousel/native"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeRoutesFile(t *testing.T, data string) string {
+	f, err := ioutil.TempFile("", "routes")
+	require.NoError(t, err)
+	defer f.Close()
+
+	_, err = f.WriteString(data)
+	require.NoError(t, err)
+	return f.Name()
+}
+
+func newTestRouter(routes []Route, defaultNotifier Notifier, targets map[string]Notifier) *routingNotifier {
+	r := &routingNotifier{lock: &sync.Mutex{}, routes: routes, targets: make(map[string]*target), pending: make(map[string]map[string]bool), now: time.Now}
+	r.add(&target{name: DefaultTarget, notifier: defaultNotifier})
+	for _, name := range []string{"eu", "us"} {
+		if n, ok := targets[name]; ok {
+			r.add(&target{name: name, url: "http://" + name + "-notifier:8080", notifier: n})
+		}
+	}
+	return r
+}
+
+func TestLoadRoutes(t *testing.T) {
+	file := writeRoutesFile(t, `targets:
+  - name: video
+    url: http://video-notifier:8080
+    path: /notify/video
+    headers:
+      X-Policy: video
+routes:
+  - name: videos
+    collections: [video]
+    contentTypes: [application/vnd.ft-upp-video+json]
+    targets: [video, default]
+`)
+	defer os.Remove(file)
+
+	routes, err := LoadRoutes(file)
+	require.NoError(t, err)
+	assert.Equal(t, []Target{{Name: "video", URL: "http://video-notifier:8080", Path: "/notify/video", Headers: map[string]string{"X-Policy": "video"}}}, routes.Targets)
+	assert.Equal(t, []Route{{Name: "videos", Collections: []string{"video"}, ContentTypes: []string{"application/vnd.ft-upp-video+json"}, Targets: []string{"video", DefaultTarget}}}, routes.Routes)
+}
+
+func TestLoadRoutesNoFile(t *testing.T) {
+	routes, err := LoadRoutes("")
+	assert.NoError(t, err)
+	assert.Nil(t, routes)
+
+	_, err = LoadRoutes("/does/not/exist.yml")
+	assert.Error(t, err)
+}
+
+func TestRoutesValidate(t *testing.T) {
+	target := Target{Name: "eu", URL: "http://eu-notifier:8080"}
+
+	assert.NoError(t, (&Routes{Targets: []Target{target}, Routes: []Route{{Name: "all", Targets: []string{"eu", DefaultTarget}}}}).Validate())
+	assert.EqualError(t, (&Routes{Targets: []Target{{URL: "http://eu-notifier:8080"}}}).Validate(), "Please provide a name for every notifier target")
+	assert.EqualError(t, (&Routes{Targets: []Target{target, target}}).Validate(), "Notifier target eu is defined more than once, or uses the reserved name default")
+	assert.EqualError(t, (&Routes{Targets: []Target{{Name: DefaultTarget, URL: "http://eu-notifier:8080"}}}).Validate(), "Notifier target default is defined more than once, or uses the reserved name default")
+	assert.EqualError(t, (&Routes{Targets: []Target{{Name: "eu"}}}).Validate(), "Please provide a url for notifier target eu")
+	assert.EqualError(t, (&Routes{Targets: []Target{{Name: "eu", URL: "http://eu-notifier:8080", Path: "notify"}}}).Validate(), "The path of notifier target eu must start with a /")
+	assert.EqualError(t, (&Routes{Routes: []Route{{Targets: []string{"eu"}}}}).Validate(), "Please provide a name for notifier route 1")
+	assert.EqualError(t, (&Routes{Routes: []Route{{Name: "all"}}}).Validate(), "Please provide at least one target for notifier route all")
+	assert.EqualError(t, (&Routes{Routes: []Route{{Name: "all", Targets: []string{"us"}}}}).Validate(), "Notifier route all uses target us, which is not defined")
+}
+
+func TestRouteMatches(t *testing.T) {
+	route := Route{Collections: []string{"video"}, Origins: []string{"http://cmdb.ft.com/systems/next-video-editor"}, ContentTypes: []string{"application/json"}}
+
+	content := &native.Content{Collection: "video", ContentType: "application/json; charset=utf-8"}
+	assert.True(t, route.Matches("http://cmdb.ft.com/systems/next-video-editor", content))
+	assert.False(t, route.Matches("http://cmdb.ft.com/systems/methode-web-pub", content))
+
+	content.OriginSystemID = "http://cmdb.ft.com/systems/next-video-editor"
+	assert.True(t, route.Matches("http://cmdb.ft.com/systems/methode-web-pub", content), "the origin of the content takes precedence over the origin of the cycle")
+
+	assert.False(t, route.Matches("", &native.Content{Collection: "methode", ContentType: "application/json", OriginSystemID: "http://cmdb.ft.com/systems/next-video-editor"}))
+	assert.True(t, Route{}.Matches("", &native.Content{Collection: "methode"}), "a route without criteria matches all content")
+}
+
+func TestRouterSendsUnmatchedContentToDefault(t *testing.T) {
+	content := &native.Content{Collection: "methode"}
+	defaultNotifier, eu := new(MockNotifier), new(MockNotifier)
+	defaultNotifier.On("Notify", "origin", "tid", content, "hash").Return(nil)
+
+	router := newTestRouter([]Route{{Name: "video", Collections: []string{"video"}, Targets: []string{"eu"}}}, defaultNotifier, map[string]Notifier{"eu": eu})
+	attempts, err := router.NotifyAttempts(context.Background(), "origin", "tid", content, "hash")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, attempts)
+
+	defaultNotifier.AssertExpectations(t)
+	eu.AssertNotCalled(t, "Notify", "origin", "tid", content, "hash")
+	assert.Equal(t, 1, router.Targets()[0].Succeeded)
+}
+
+func TestRouterReturnsErrorOfSingleTarget(t *testing.T) {
+	content := &native.Content{Collection: "video"}
+	defaultNotifier, eu := new(MockNotifier), new(MockNotifier)
+	eu.On("Notify", "origin", "tid", content, "hash").Return(&NotifyError{StatusCode: http.StatusBadRequest})
+
+	router := newTestRouter([]Route{{Name: "video", Collections: []string{"video"}, Targets: []string{"eu"}}}, defaultNotifier, map[string]Notifier{"eu": eu})
+	err := router.Notify("origin", "tid", content, "hash")
+	assert.Equal(t, &NotifyError{StatusCode: http.StatusBadRequest}, err)
+}
+
+func TestRouterFansOutToEveryTarget(t *testing.T) {
+	content := &native.Content{Collection: "video"}
+	defaultNotifier, eu, us := new(MockNotifier), new(MockNotifier), new(MockNotifier)
+	eu.On("Notify", "origin", "tid", content, "hash").Return(nil)
+	us.On("Notify", "origin", "tid", content, "hash").Return(&NotifyError{StatusCode: http.StatusServiceUnavailable})
+
+	router := newTestRouter([]Route{{Name: "both", Targets: []string{"eu", "us"}}}, defaultNotifier, map[string]Notifier{"eu": eu, "us": us})
+	err := router.Notify("origin", "tid", content, "hash")
+	assert.EqualError(t, err, "Failed to notify 1 of 2 targets: us: A non 2xx error code was received by the CMS Notifier! Status: 503")
+
+	eu.AssertExpectations(t)
+	us.AssertExpectations(t)
+
+	statuses := router.Targets()
+	require.Len(t, statuses, 3)
+	assert.Equal(t, 0, statuses[0].Succeeded+statuses[0].Failed)
+
+	assert.Equal(t, "eu", statuses[1].Name)
+	assert.Equal(t, "http://eu-notifier:8080", statuses[1].URL)
+	assert.Equal(t, 1, statuses[1].Succeeded)
+	assert.NotNil(t, statuses[1].LastSuccess)
+	assert.Nil(t, statuses[1].LastFailure)
+
+	assert.Equal(t, "us", statuses[2].Name)
+	assert.Equal(t, 1, statuses[2].Failed)
+	assert.NotNil(t, statuses[2].LastFailure)
+	assert.Equal(t, "A non 2xx error code was received by the CMS Notifier! Status: 503", statuses[2].LastError)
+}
+
+func TestRouterOnlyRetriesFailedTargets(t *testing.T) {
+	content := &native.Content{Collection: "video"}
+	eu, us := new(MockNotifier), new(MockNotifier)
+	eu.On("Notify", "origin", "tid", content, "hash").Return(nil).Once()
+	us.On("Notify", "origin", "tid", content, "hash").Return(&NotifyError{StatusCode: http.StatusServiceUnavailable}).Once()
+	us.On("Notify", "origin", "tid", content, "hash").Return(nil).Once()
+
+	router := newTestRouter([]Route{{Name: "both", Targets: []string{"eu", "us"}}}, new(MockNotifier), map[string]Notifier{"eu": eu, "us": us})
+
+	_, err := router.NotifyAttempts(context.Background(), "origin", "tid", content, "hash")
+	require.IsType(t, &FanOutError{}, err)
+	assert.Equal(t, []string{"eu"}, err.(*FanOutError).Succeeded)
+	assert.Equal(t, []string{"us"}, err.(*FanOutError).FailedTargets())
+
+	_, err = router.NotifyAttempts(context.Background(), "origin", "tid", content, "hash")
+	assert.NoError(t, err)
+	eu.AssertNumberOfCalls(t, "Notify", 1)
+	us.AssertNumberOfCalls(t, "Notify", 2)
+	assert.Empty(t, router.pending, "the content is forgotten once every target has it")
+
+	eu.On("Notify", "origin", "tid", content, "hash").Return(nil).Once()
+	us.On("Notify", "origin", "tid", content, "hash").Return(nil).Once()
+	assert.NoError(t, router.Notify("origin", "tid", content, "hash"))
+	eu.AssertNumberOfCalls(t, "Notify", 2)
+}
+
+func TestRouterReportsMostAttempts(t *testing.T) {
+	content := &native.Content{}
+	eu, us := new(MockNotifier), new(MockNotifier)
+	eu.On("Notify", "origin", "tid", content, "hash").Return(nil)
+	us.On("Notify", "origin", "tid", content, "hash").Return(&NotifyError{StatusCode: http.StatusServiceUnavailable}).Once()
+	us.On("Notify", "origin", "tid", content, "hash").Return(nil).Once()
+
+	var sleeps []time.Duration
+	router := newTestRouter([]Route{{Name: "both", Targets: []string{"eu", "us"}}}, new(MockNotifier), map[string]Notifier{"eu": eu, "us": newTestRetryingNotifier(us, &sleeps)})
+
+	attempts, err := router.NotifyAttempts(context.Background(), "origin", "tid", content, "hash")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+}
+
+func TestRouterCheck(t *testing.T) {
+	defaultNotifier, eu := new(MockNotifier), new(MockNotifier)
+	defaultNotifier.On("Check").Return(nil)
+	eu.On("Check").Return(nil).Once()
+
+	router := newTestRouter(nil, defaultNotifier, map[string]Notifier{"eu": eu})
+	assert.NoError(t, router.Check())
+
+	eu.On("Check").Return(errors.New("GTG for cms-notifier-eu returned a non-200 code: 503"))
+	assert.EqualError(t, router.Check(), "CMS notifier targets are unhealthy: eu: GTG for cms-notifier-eu returned a non-200 code: 503")
+}
+
+func TestRouterListsEndpointsOfTargets(t *testing.T) {
+	pool := new(MockPooledNotifier)
+	pool.On("Endpoints").Return([]EndpointStatus{{URL: "http://notifier-1:8080", Available: true}})
+
+	router := newTestRouter(nil, pool, nil)
+	assert.Equal(t, []TargetStatus{{Name: DefaultTarget, Endpoints: []EndpointStatus{{URL: "http://notifier-1:8080", Available: true}}}}, router.Targets())
+}
+
+func TestTargetNotifierPostsToPathWithHeaders(t *testing.T) {
+	var path, policy, origin string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		policy = r.Header.Get("X-Policy")
+		origin = r.Header.Get("X-Origin-System-Id")
+	}))
+	defer server.Close()
+
+	notifier, err := NewTargetNotifier(Target{Name: "video", URL: server.URL, Path: "/notify/video", Headers: map[string]string{"X-Policy": "video"}}, &http.Client{}, nil)
+	require.NoError(t, err)
+
+	err = notifier.Notify("origin", "tid_1234", &native.Content{Body: map[string]interface{}{"uuid": "uuid"}}, "12345")
+	assert.NoError(t, err)
+	assert.Equal(t, "/notify/video", path)
+	assert.Equal(t, "video", policy)
+	assert.Equal(t, "origin", origin)
+
+	notifier, err = NewTargetNotifier(Target{Name: "eu", URL: server.URL}, &http.Client{}, nil)
+	require.NoError(t, err)
+	assert.NoError(t, notifier.Notify("origin", "tid_1234", &native.Content{Body: map[string]interface{}{"uuid": "uuid"}}, "12345"))
+	assert.Equal(t, notifyPath, path)
+}
+
+func TestNewRoutingNotifier(t *testing.T) {
+	routes := Routes{Targets: []Target{{Name: "eu", URL: "http://eu-notifier:8080"}}, Routes: []Route{{Name: "all", Targets: []string{DefaultTarget, "eu"}}}}
+
+	notifier, err := NewRoutingNotifier(routes, new(MockNotifier), &http.Client{}, DefaultRetryPolicy, nil)
+	require.NoError(t, err)
+
+	statuses := notifier.(TargetsNotifier).Targets()
+	require.Len(t, statuses, 2)
+	assert.Equal(t, DefaultTarget, statuses[0].Name)
+	assert.Equal(t, "http://eu-notifier:8080", statuses[1].URL)
+	assert.IsType(t, &retryingNotifier{}, notifier.(*routingNotifier).targets["eu"].notifier)
+
+	_, err = NewRoutingNotifier(Routes{Routes: []Route{{Name: "all"}}}, new(MockNotifier), &http.Client{}, DefaultRetryPolicy, nil)
+	assert.EqualError(t, err, "Please provide at least one target for notifier route all")
+}
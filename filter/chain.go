@@ -0,0 +1,71 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Financial-Times/publish-carousel/native"
+)
+
+// UnmatchedRule is the name under which content is skipped when a chain has include rules, but none of them match the content
+const UnmatchedRule = "unmatched"
+
+// ImagesRule is the name of the default rule, which skips native content whose type is image
+const ImagesRule = "images"
+
+// DefaultRules are evaluated before the filters of every cycle, unless the cycle publishes images or configures its own images rule
+func DefaultRules() []Rule {
+	return []Rule{{Name: ImagesRule, Action: ExcludeAction, Condition: Condition{Field: "type", Values: []string{"image"}}}}
+}
+
+// Chain decides which native content a cycle publishes. The rules are evaluated in order, and the first rule which matches the content decides whether it is published.
+// If no rule matches, the content is published, unless the chain has any include rules.
+type Chain struct {
+	rules       []compiledRule
+	hasIncludes bool
+	now         func() time.Time
+}
+
+// NewChain validates the rules, which must have unique names
+func NewChain(rules []Rule) (*Chain, error) {
+	chain := &Chain{now: time.Now}
+	names := make(map[string]bool)
+
+	for _, rule := range rules {
+		compiled, err := rule.compile()
+		if err != nil {
+			return nil, err
+		}
+
+		name := strings.ToLower(rule.Name)
+		if names[name] || name == UnmatchedRule {
+			return nil, fmt.Errorf("Filter rule names must be unique, and cannot be %v: %v", UnmatchedRule, rule.Name)
+		}
+		names[name] = true
+
+		chain.rules = append(chain.rules, compiled)
+		chain.hasIncludes = chain.hasIncludes || compiled.include
+	}
+
+	return chain, nil
+}
+
+// Skip returns the name of the rule which decided the fate of the content, and true if the content should not be published. A nil chain publishes all content.
+func (c *Chain) Skip(content *native.Content) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+
+	now := c.now()
+	for _, rule := range c.rules {
+		if rule.matches(content, now) {
+			return rule.Name, !rule.include
+		}
+	}
+
+	if c.hasIncludes {
+		return UnmatchedRule, true
+	}
+	return "", false
+}
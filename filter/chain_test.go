@@ -0,0 +1,164 @@
+package filter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Financial-Times/publish-carousel/native"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func article(body map[string]interface{}) *native.Content {
+	return &native.Content{Body: body, ContentType: "application/json; charset=utf-8", OriginSystemID: "http://cmdb.ft.com/systems/methode-web-pub"}
+}
+
+func TestDefaultRulesSkipImages(t *testing.T) {
+	chain, err := NewChain(DefaultRules())
+	require.NoError(t, err)
+
+	rule, skip := chain.Skip(article(map[string]interface{}{"type": "Image"}))
+	assert.True(t, skip)
+	assert.Equal(t, ImagesRule, rule)
+
+	_, skip = chain.Skip(article(map[string]interface{}{"type": "Article"}))
+	assert.False(t, skip)
+
+	_, skip = chain.Skip(article(map[string]interface{}{}))
+	assert.False(t, skip, "content without a type is published")
+}
+
+func TestNilChainPublishesEverything(t *testing.T) {
+	var chain *Chain
+	_, skip := chain.Skip(article(map[string]interface{}{"type": "Image"}))
+	assert.False(t, skip)
+}
+
+func TestFirstMatchingRuleDecides(t *testing.T) {
+	chain, err := NewChain([]Rule{
+		{Name: "fastft", Action: "include", Condition: Condition{Field: "$.brands[*].id", Values: []string{"fastft"}}},
+		{Name: "wordpress", Action: "exclude", Condition: Condition{OriginSystems: []string{"http://cmdb.ft.com/systems/wordpress"}}},
+	})
+	require.NoError(t, err)
+
+	content := article(map[string]interface{}{"brands": []interface{}{map[string]interface{}{"id": "FastFT"}}})
+	content.OriginSystemID = "http://cmdb.ft.com/systems/wordpress"
+
+	rule, skip := chain.Skip(content)
+	assert.False(t, skip)
+	assert.Equal(t, "fastft", rule)
+
+	content.Body = map[string]interface{}{}
+	rule, skip = chain.Skip(content)
+	assert.True(t, skip)
+	assert.Equal(t, "wordpress", rule)
+
+	content.OriginSystemID = "http://cmdb.ft.com/systems/methode-web-pub"
+	rule, skip = chain.Skip(content)
+	assert.True(t, skip, "content which matches no include rule is skipped")
+	assert.Equal(t, UnmatchedRule, rule)
+}
+
+func TestExcludeOnlyChainPublishesUnmatchedContent(t *testing.T) {
+	chain, err := NewChain([]Rule{{Name: "videos", Action: "exclude", Condition: Condition{ContentTypes: []string{"application/vnd.ft-upp-video+json"}}}})
+	require.NoError(t, err)
+
+	_, skip := chain.Skip(article(map[string]interface{}{}))
+	assert.False(t, skip)
+
+	content := article(map[string]interface{}{})
+	content.ContentType = "application/vnd.ft-upp-video+json; version=1"
+	rule, skip := chain.Skip(content)
+	assert.True(t, skip, "content type parameters are ignored")
+	assert.Equal(t, "videos", rule)
+}
+
+func TestFieldPresenceRule(t *testing.T) {
+	chain, err := NewChain([]Rule{{Name: "no-body", Action: "exclude", Condition: Condition{Field: "bodyXML"}}})
+	require.NoError(t, err)
+
+	_, skip := chain.Skip(article(map[string]interface{}{"bodyXML": "<body/>"}))
+	assert.True(t, skip)
+
+	_, skip = chain.Skip(article(map[string]interface{}{"bodyXML": nil}))
+	assert.False(t, skip)
+}
+
+func TestPublishDateRule(t *testing.T) {
+	now := time.Date(2017, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	chain, err := NewChain([]Rule{{Name: "last-week", Action: "include", Condition: Condition{DateField: "publishedDate", PublishedAfter: "168h", PublishedBefore: "2017-05-10T00:00:00Z"}}})
+	require.NoError(t, err)
+	chain.now = func() time.Time { return now }
+
+	tests := map[string]bool{
+		"2017-05-09T12:00:00Z":      false,
+		"2017-05-03T12:00:00Z":      false,
+		"2017-05-03T11:59:59Z":      true,
+		"2017-05-10T00:00:00Z":      true,
+		"2017-05-09T23:00:00-02:00": true,
+		"not a date":                true,
+	}
+
+	for published, skipped := range tests {
+		_, skip := chain.Skip(article(map[string]interface{}{"publishedDate": published}))
+		assert.Equal(t, skipped, skip, published)
+	}
+
+	_, skip := chain.Skip(article(map[string]interface{}{}))
+	assert.True(t, skip, "content without a publish date does not match")
+}
+
+func TestBodySizeRule(t *testing.T) {
+	chain, err := NewChain([]Rule{{Name: "huge", Action: "exclude", Condition: Condition{MinBodySize: 100}}})
+	require.NoError(t, err)
+
+	_, skip := chain.Skip(article(map[string]interface{}{"bodyXML": strings.Repeat("a", 100)}))
+	assert.True(t, skip)
+
+	_, skip = chain.Skip(article(map[string]interface{}{"bodyXML": "a"}))
+	assert.False(t, skip)
+
+	chain, err = NewChain([]Rule{{Name: "tiny", Action: "exclude", Condition: Condition{MaxBodySize: 20}}})
+	require.NoError(t, err)
+
+	_, skip = chain.Skip(article(map[string]interface{}{"bodyXML": "a"}))
+	assert.True(t, skip)
+}
+
+func TestAllConditionsMustMatch(t *testing.T) {
+	chain, err := NewChain([]Rule{{Name: "methode-images", Action: "exclude", Condition: Condition{Field: "type", Values: []string{"image"}, OriginSystems: []string{"http://cmdb.ft.com/systems/methode-web-pub"}}}})
+	require.NoError(t, err)
+
+	content := article(map[string]interface{}{"type": "Image"})
+	_, skip := chain.Skip(content)
+	assert.True(t, skip)
+
+	content.OriginSystemID = "http://cmdb.ft.com/systems/wordpress"
+	_, skip = chain.Skip(content)
+	assert.False(t, skip)
+}
+
+func TestInvalidRules(t *testing.T) {
+	tests := map[string][]Rule{
+		"Please provide a name for every filter rule":                                        {{Action: "exclude", Condition: Condition{Field: "type"}}},
+		"Please provide an action for filter rule images, either include or exclude":         {{Name: "images", Action: "skip", Condition: Condition{Field: "type"}}},
+		"Filter rule images: Please provide at least one condition":                          {{Name: "images", Action: "exclude"}},
+		"Filter rule images: Please provide the field for the values":                        {{Name: "images", Action: "exclude", Condition: Condition{Values: []string{"image"}}}},
+		"Filter rule images: Invalid index in field path brands[x]":                          {{Name: "images", Action: "exclude", Condition: Condition{Field: "brands[x]"}}},
+		"Filter rule old: Please provide the date field for the publish date range":          {{Name: "old", Action: "exclude", Condition: Condition{PublishedBefore: "720h"}}},
+		"Filter rule old: Please provide an RFC3339 timestamp or a duration, not last month": {{Name: "old", Action: "exclude", Condition: Condition{DateField: "publishedDate", PublishedBefore: "last month"}}},
+		"Filter rule huge: Please provide a valid body size range":                           {{Name: "huge", Action: "exclude", Condition: Condition{MinBodySize: 100, MaxBodySize: 10}}},
+		"Filter rule names must be unique, and cannot be unmatched: Images":                  {{Name: "images", Action: "exclude", Condition: Condition{Field: "type"}}, {Name: "Images", Action: "exclude", Condition: Condition{Field: "type"}}},
+		"Filter rule names must be unique, and cannot be unmatched: unmatched":               {{Name: "unmatched", Action: "exclude", Condition: Condition{Field: "type"}}},
+	}
+
+	for expected, rules := range tests {
+		_, err := NewChain(rules)
+		assert.EqualError(t, err, expected)
+	}
+
+	_, err := NewChain([]Rule{{Name: "old", Action: "exclude", Condition: Condition{DateField: "publishedDate", DateType: "unix", PublishedBefore: "720h"}}})
+	assert.Error(t, err)
+}
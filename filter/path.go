@@ -0,0 +1,103 @@
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+const wildcard = -1
+
+type pathSegment struct {
+	field   string
+	indexes []int
+}
+
+// parsePath parses a JSONPath-style path into the body of the native content, i.e. $.annotations[*].predicate or brands[0].id. The leading $ is optional.
+func parsePath(path string) ([]pathSegment, error) {
+	path = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(path), "$"), ".")
+	if path == "" {
+		return nil, fmt.Errorf("Please provide a field path")
+	}
+
+	var segments []pathSegment
+	for _, part := range strings.Split(path, ".") {
+		field := part
+		var indexes []int
+
+		if i := strings.Index(part, "["); i >= 0 {
+			field = part[:i]
+			for _, index := range strings.Split(strings.TrimSuffix(part[i+1:], "]"), "][") {
+				if index == "*" {
+					indexes = append(indexes, wildcard)
+					continue
+				}
+
+				n, err := strconv.Atoi(index)
+				if err != nil || n < 0 {
+					return nil, fmt.Errorf("Invalid index in field path %v", path)
+				}
+				indexes = append(indexes, n)
+			}
+		}
+
+		if field == "" {
+			return nil, fmt.Errorf("Invalid field path %v", path)
+		}
+		segments = append(segments, pathSegment{field: field, indexes: indexes})
+	}
+
+	return segments, nil
+}
+
+// resolvePath returns every value in the document at the path. Wildcard indexes can match many values, whereas missing fields match none.
+func resolvePath(doc interface{}, segments []pathSegment) []interface{} {
+	values := []interface{}{doc}
+	for _, segment := range segments {
+		var next []interface{}
+		for _, val := range values {
+			field, ok := fieldOf(val, segment.field)
+			if !ok {
+				continue
+			}
+			next = append(next, indexInto(field, segment.indexes)...)
+		}
+		values = next
+	}
+	return values
+}
+
+func fieldOf(val interface{}, field string) (interface{}, bool) {
+	switch m := val.(type) {
+	case map[string]interface{}:
+		v, ok := m[field]
+		return v, ok && v != nil
+	case bson.M:
+		v, ok := m[field]
+		return v, ok && v != nil
+	}
+	return nil, false
+}
+
+func indexInto(val interface{}, indexes []int) []interface{} {
+	values := []interface{}{val}
+	for _, index := range indexes {
+		var next []interface{}
+		for _, v := range values {
+			arr, ok := v.([]interface{})
+			if !ok {
+				continue
+			}
+
+			if index == wildcard {
+				next = append(next, arr...)
+			} else if index < len(arr) {
+				next = append(next, arr[index])
+			}
+		}
+		values = next
+	}
+	return values
+}
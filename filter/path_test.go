@@ -0,0 +1,50 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestResolvePath(t *testing.T) {
+	body := map[string]interface{}{
+		"type": "Article",
+		"brands": []interface{}{
+			map[string]interface{}{"id": "brand-1"},
+			bson.M{"id": "brand-2"},
+		},
+		"content": bson.M{"lastModified": "2017-05-01T12:00:00Z", "missing": nil},
+		"matrix":  []interface{}{[]interface{}{1, 2}, []interface{}{3}},
+	}
+
+	tests := []struct {
+		path     string
+		expected []interface{}
+	}{
+		{"type", []interface{}{"Article"}},
+		{"$.type", []interface{}{"Article"}},
+		{"$.brands[*].id", []interface{}{"brand-1", "brand-2"}},
+		{"brands[1].id", []interface{}{"brand-2"}},
+		{"brands[2].id", nil},
+		{"content.lastModified", []interface{}{"2017-05-01T12:00:00Z"}},
+		{"content.missing", nil},
+		{"matrix[0][*]", []interface{}{1, 2}},
+		{"matrix[*][0]", []interface{}{1, 3}},
+		{"type.nested", nil},
+	}
+
+	for _, test := range tests {
+		path, err := parsePath(test.path)
+		require.NoError(t, err, test.path)
+		assert.Equal(t, test.expected, resolvePath(body, path), test.path)
+	}
+}
+
+func TestParseInvalidPaths(t *testing.T) {
+	for _, path := range []string{"", "$", "brands[x].id", "brands[-1]", "a..b", "[0]"} {
+		_, err := parsePath(path)
+		assert.Error(t, err, path)
+	}
+}
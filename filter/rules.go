@@ -0,0 +1,246 @@
+package filter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Financial-Times/publish-carousel/native"
+)
+
+const (
+	// IncludeAction publishes the content which matches the rule
+	IncludeAction = "include"
+	// ExcludeAction skips the content which matches the rule
+	ExcludeAction = "exclude"
+)
+
+// Rule includes or excludes the native content which matches all of its conditions. At least one condition is required.
+type Rule struct {
+	Name      string `yaml:"name" json:"name"`
+	Action    string `yaml:"action" json:"action"`
+	Condition `yaml:",inline"`
+}
+
+// Condition matches native content which meets all of the configured criteria
+type Condition struct {
+	// Field is a JSONPath-style path into the body of the content. If Values are given, any value at the path must equal one of them (ignoring case), otherwise the field must be present.
+	Field  string   `yaml:"field" json:"field,omitempty"`
+	Values []string `yaml:"values" json:"values,omitempty"`
+
+	ContentTypes  []string `yaml:"contentTypes" json:"contentTypes,omitempty"`
+	OriginSystems []string `yaml:"originSystems" json:"originSystems,omitempty"`
+
+	// DateField is the path of the publish date in the body of the content, which is stored as DateType (an RFC3339 string by default). PublishedAfter and PublishedBefore are either RFC3339 timestamps, or durations before now, i.e. 720h.
+	DateField       string `yaml:"dateField" json:"dateField,omitempty"`
+	DateType        string `yaml:"dateType" json:"dateType,omitempty"`
+	PublishedAfter  string `yaml:"publishedAfter" json:"publishedAfter,omitempty"`
+	PublishedBefore string `yaml:"publishedBefore" json:"publishedBefore,omitempty"`
+
+	// MinBodySize and MaxBodySize bound the size of the json body in bytes
+	MinBodySize int `yaml:"minBodySize" json:"minBodySize,omitempty"`
+	MaxBodySize int `yaml:"maxBodySize" json:"maxBodySize,omitempty"`
+}
+
+type compiledRule struct {
+	Predicate
+	Name    string
+	include bool
+}
+
+func (r Rule) compile() (compiledRule, error) {
+	compiled := compiledRule{Name: r.Name}
+
+	if strings.TrimSpace(r.Name) == "" {
+		return compiled, fmt.Errorf("Please provide a name for every filter rule")
+	}
+
+	switch strings.ToLower(r.Action) {
+	case IncludeAction:
+		compiled.include = true
+	case ExcludeAction:
+	default:
+		return compiled, fmt.Errorf("Please provide an action for filter rule %v, either %v or %v", r.Name, IncludeAction, ExcludeAction)
+	}
+
+	predicate, err := r.Condition.compile()
+	if err != nil {
+		return compiled, fmt.Errorf("Filter rule %v: %v", r.Name, err)
+	}
+
+	compiled.Predicate = predicate
+	return compiled, nil
+}
+
+// Predicate is a compiled condition
+type Predicate struct {
+	Condition
+	path        []pathSegment
+	dateConfig  native.CollectionConfig
+	hasBodySize bool
+}
+
+// NewPredicate validates the condition, which must have at least one criterion
+func NewPredicate(condition Condition) (*Predicate, error) {
+	predicate, err := condition.compile()
+	if err != nil {
+		return nil, err
+	}
+	return &predicate, nil
+}
+
+func (r Condition) compile() (Predicate, error) {
+	compiled := Predicate{Condition: r}
+	conditions := 0
+
+	if r.Field != "" {
+		path, err := parsePath(r.Field)
+		if err != nil {
+			return compiled, err
+		}
+		compiled.path = path
+		conditions++
+	} else if len(r.Values) > 0 {
+		return compiled, fmt.Errorf("Please provide the field for the values")
+	}
+
+	if len(r.ContentTypes) > 0 {
+		conditions++
+	}
+
+	if len(r.OriginSystems) > 0 {
+		conditions++
+	}
+
+	if r.PublishedAfter != "" || r.PublishedBefore != "" {
+		if strings.TrimSpace(r.DateField) == "" {
+			return compiled, fmt.Errorf("Please provide the date field for the publish date range")
+		}
+
+		compiled.dateConfig = native.CollectionConfig{TimestampField: "content." + strings.TrimPrefix(strings.TrimPrefix(r.DateField, "$"), "."), TimestampType: r.DateType}
+		if err := compiled.dateConfig.Validate(); err != nil {
+			return compiled, err
+		}
+
+		for _, bound := range []string{r.PublishedAfter, r.PublishedBefore} {
+			if _, err := parseBound(bound, time.Now()); bound != "" && err != nil {
+				return compiled, fmt.Errorf("Please provide an RFC3339 timestamp or a duration, not %v", bound)
+			}
+		}
+		conditions++
+	}
+
+	if r.MinBodySize < 0 || r.MaxBodySize < 0 || (r.MaxBodySize > 0 && r.MaxBodySize < r.MinBodySize) {
+		return compiled, fmt.Errorf("Please provide a valid body size range")
+	}
+
+	if r.MinBodySize > 0 || r.MaxBodySize > 0 {
+		compiled.hasBodySize = true
+		conditions++
+	}
+
+	if conditions == 0 {
+		return compiled, fmt.Errorf("Please provide at least one condition")
+	}
+
+	return compiled, nil
+}
+
+// parseBound parses an RFC3339 timestamp, or a duration before now
+func parseBound(bound string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, bound); err == nil {
+		return t, nil
+	}
+
+	d, err := time.ParseDuration(bound)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return now.Add(-d), nil
+}
+
+// Matches returns true if the content meets every criterion of the condition
+func (r *Predicate) Matches(content *native.Content) bool {
+	return r.matches(content, time.Now())
+}
+
+func (r *Predicate) matches(content *native.Content, now time.Time) bool {
+	if r.path != nil && !r.matchesField(content) {
+		return false
+	}
+
+	if len(r.ContentTypes) > 0 && !oneOf(mediaType(content.ContentType), r.ContentTypes) {
+		return false
+	}
+
+	if len(r.OriginSystems) > 0 && !oneOf(content.OriginSystemID, r.OriginSystems) {
+		return false
+	}
+
+	if r.PublishedAfter != "" || r.PublishedBefore != "" {
+		if !r.matchesDate(content, now) {
+			return false
+		}
+	}
+
+	if r.hasBodySize && !r.matchesBodySize(content) {
+		return false
+	}
+
+	return true
+}
+
+func (r *Predicate) matchesField(content *native.Content) bool {
+	values := resolvePath(content.Body, r.path)
+	if len(r.Values) == 0 {
+		return len(values) > 0
+	}
+
+	for _, val := range values {
+		if oneOf(fmt.Sprintf("%v", val), r.Values) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Predicate) matchesDate(content *native.Content, now time.Time) bool {
+	published, ok := r.dateConfig.Timestamp(content)
+	if !ok {
+		return false
+	}
+
+	if after, err := parseBound(r.PublishedAfter, now); r.PublishedAfter != "" && (err != nil || published.Before(after)) {
+		return false
+	}
+
+	if before, err := parseBound(r.PublishedBefore, now); r.PublishedBefore != "" && (err != nil || !published.Before(before)) {
+		return false
+	}
+
+	return true
+}
+
+func (r *Predicate) matchesBodySize(content *native.Content) bool {
+	data, err := json.Marshal(content.Body)
+	if err != nil {
+		return false
+	}
+
+	size := len(data)
+	return size >= r.MinBodySize && (r.MaxBodySize == 0 || size <= r.MaxBodySize)
+}
+
+func mediaType(contentType string) string {
+	return strings.TrimSpace(strings.Split(contentType, ";")[0])
+}
+
+func oneOf(val string, options []string) bool {
+	for _, option := range options {
+		if strings.EqualFold(val, option) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,161 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DirectProxy disables the proxy, including any proxy set by the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables
+const DirectProxy = "direct"
+
+// Config configures the outbound http clients of the Carousel
+type Config struct {
+	Timeout               time.Duration
+	CheckTimeout          time.Duration
+	DialTimeout           time.Duration
+	KeepAlive             time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+	IdleConnTimeout       time.Duration
+	MaxIdleConns          int
+	MaxIdleConnsPerHost   int
+	MaxConnsPerHost       int
+	Proxy                 string
+	CACertFile            string
+	CertFile              string
+	KeyFile               string
+	Credentials           []Credential
+}
+
+// DefaultConfig uses the timeouts of http.DefaultTransport, with a 30 second timeout for each request and a 4.5 second timeout for healthchecks
+var DefaultConfig = Config{
+	Timeout:             30 * time.Second,
+	CheckTimeout:        4500 * time.Millisecond,
+	DialTimeout:         30 * time.Second,
+	KeepAlive:           30 * time.Second,
+	TLSHandshakeTimeout: 10 * time.Second,
+	IdleConnTimeout:     90 * time.Second,
+	MaxIdleConns:        100,
+	MaxIdleConnsPerHost: 100,
+}
+
+// Clients share a single transport, so that connections are pooled between every outbound request
+type Clients struct {
+	// Client is used for publishes, verification and trace exports
+	Client *http.Client
+	// CheckClient has the shorter healthcheck timeout, and is used for the GTG checks of cluster services and read environments
+	CheckClient *http.Client
+}
+
+// New builds the outbound http clients from the config
+func New(config Config) (*Clients, error) {
+	transport, err := newTransport(config)
+	if err != nil {
+		return nil, err
+	}
+
+	var rt http.RoundTripper = transport
+	if len(config.Credentials) > 0 {
+		rt = &credentialsTransport{base: transport, credentials: config.Credentials}
+	}
+
+	return &Clients{
+		Client:      &http.Client{Transport: rt, Timeout: config.Timeout},
+		CheckClient: &http.Client{Transport: rt, Timeout: config.CheckTimeout},
+	}, nil
+}
+
+func newTransport(config Config) (*http.Transport, error) {
+	proxy, err := proxyFor(config.Proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig, err := newTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{Timeout: config.DialTimeout, KeepAlive: config.KeepAlive}
+	return &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dialer.DialContext,
+		TLSClientConfig:       tlsConfig,
+		TLSHandshakeTimeout:   config.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: config.ResponseHeaderTimeout,
+		IdleConnTimeout:       config.IdleConnTimeout,
+		MaxIdleConns:          config.MaxIdleConns,
+		MaxIdleConnsPerHost:   config.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       config.MaxConnsPerHost,
+		ForceAttemptHTTP2:     true,
+	}, nil
+}
+
+// proxyFor returns the proxy of the transport. If no proxy is configured, the proxy environment variables are used.
+func proxyFor(proxy string) (func(*http.Request) (*url.URL, error), error) {
+	switch strings.TrimSpace(proxy) {
+	case "":
+		return http.ProxyFromEnvironment, nil
+	case DirectProxy:
+		return nil, nil
+	}
+
+	proxyURL, err := url.Parse(proxy)
+	if err != nil || proxyURL.Scheme == "" || proxyURL.Host == "" {
+		return nil, fmt.Errorf("Invalid outbound http proxy %v, please provide a url such as http://proxy:3128, or %v", proxy, DirectProxy)
+	}
+	return http.ProxyURL(proxyURL), nil
+}
+
+// newTLSConfig trusts the system certificates and the optional CA certificate, and presents the optional client certificate
+func newTLSConfig(config Config) (*tls.Config, error) {
+	tlsConfig := &tls.Config{}
+
+	if config.CACertFile != "" {
+		pem, err := ioutil.ReadFile(config.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read the outbound http CA certificate: %v", err)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("The outbound http CA certificate %v contains no PEM certificates", config.CACertFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if (config.CertFile == "") != (config.KeyFile == "") {
+		return nil, errors.New("Please provide both the certificate and the key of the outbound http client certificate")
+	}
+
+	if config.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to load the outbound http client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// InDir resolves a relative file path against the directory, so that certificates can be read from the credentials directory
+func InDir(dir string, file string) string {
+	if strings.TrimSpace(file) == "" || filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(dir, file)
+}
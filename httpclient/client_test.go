@@ -0,0 +1,104 @@
+package httpclient
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTempFile(t *testing.T, prefix string, data string) string {
+	f, err := ioutil.TempFile("", prefix)
+	require.NoError(t, err)
+	defer f.Close()
+
+	_, err = f.WriteString(data)
+	require.NoError(t, err)
+	return f.Name()
+}
+
+func TestNewUsesTimeouts(t *testing.T) {
+	clients, err := New(DefaultConfig)
+	require.NoError(t, err)
+
+	assert.Equal(t, DefaultConfig.Timeout, clients.Client.Timeout)
+	assert.Equal(t, DefaultConfig.CheckTimeout, clients.CheckClient.Timeout)
+	assert.Equal(t, clients.Client.Transport, clients.CheckClient.Transport, "the clients share their connection pool")
+
+	transport := clients.Client.Transport.(*http.Transport)
+	assert.Equal(t, 100, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, DefaultConfig.IdleConnTimeout, transport.IdleConnTimeout)
+	assert.Equal(t, DefaultConfig.TLSHandshakeTimeout, transport.TLSHandshakeTimeout)
+}
+
+func TestProxy(t *testing.T) {
+	req := httptest.NewRequest("GET", "http://notifier:8080/notify", nil)
+
+	proxy, err := proxyFor("http://proxy:3128")
+	require.NoError(t, err)
+	proxyURL, err := proxy(req)
+	assert.NoError(t, err)
+	assert.Equal(t, &url.URL{Scheme: "http", Host: "proxy:3128"}, proxyURL)
+
+	proxy, err = proxyFor(DirectProxy)
+	assert.NoError(t, err)
+	assert.Nil(t, proxy)
+
+	_, err = proxyFor("proxy")
+	assert.EqualError(t, err, "Invalid outbound http proxy proxy, please provide a url such as http://proxy:3128, or direct")
+}
+
+func TestNewTrustsCACertificate(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	clients, err := New(DefaultConfig)
+	require.NoError(t, err)
+	_, err = clients.Client.Get(server.URL)
+	assert.Error(t, err, "the test server is not trusted by default")
+
+	caFile := writeTempFile(t, "ca", string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})))
+	defer os.Remove(caFile)
+
+	config := DefaultConfig
+	config.CACertFile = caFile
+	clients, err = New(config)
+	require.NoError(t, err)
+
+	resp, err := clients.Client.Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestNewInvalidCertificates(t *testing.T) {
+	notPEM := writeTempFile(t, "ca", "not a certificate")
+	defer os.Remove(notPEM)
+
+	config := DefaultConfig
+	config.CACertFile = notPEM
+	_, err := New(config)
+	assert.EqualError(t, err, "The outbound http CA certificate "+notPEM+" contains no PEM certificates")
+
+	config = DefaultConfig
+	config.CertFile = "client.crt"
+	_, err = New(config)
+	assert.EqualError(t, err, "Please provide both the certificate and the key of the outbound http client certificate")
+
+	config.KeyFile = notPEM
+	config.CertFile = notPEM
+	_, err = New(config)
+	assert.Error(t, err)
+}
+
+func TestInDir(t *testing.T) {
+	assert.Equal(t, "/configs/credentials/client.crt", InDir("/configs/credentials", "client.crt"))
+	assert.Equal(t, "/etc/ssl/client.crt", InDir("/configs/credentials", "/etc/ssl/client.crt"))
+	assert.Equal(t, "", InDir("/configs/credentials", ""))
+}
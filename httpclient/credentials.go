@@ -0,0 +1,110 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Credential authenticates the requests to a destination host, either with a bearer token or with basic auth.
+// The host is matched against the host of each request, without its port unless the credential's host has one. A host of *.example.com matches every subdomain of example.com.
+type Credential struct {
+	Host        string `yaml:"host"`
+	BearerToken string `yaml:"bearerToken"`
+	Username    string `yaml:"username"`
+	Password    string `yaml:"password"`
+}
+
+type credentialsFile struct {
+	Credentials []Credential `yaml:"credentials"`
+}
+
+// LoadCredentials reads the per destination credentials from the provided yaml file. If the file does not exist, no requests are authenticated.
+func LoadCredentials(file string) ([]Credential, error) {
+	if strings.TrimSpace(file) == "" {
+		return nil, nil
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	setup := credentialsFile{}
+	if err := yaml.Unmarshal(data, &setup); err != nil {
+		return nil, err
+	}
+
+	for _, c := range setup.Credentials {
+		if err := c.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return setup.Credentials, nil
+}
+
+// Validate checks the credential has a host, and either a bearer token or a username
+func (c Credential) Validate() error {
+	if strings.TrimSpace(c.Host) == "" {
+		return errors.New("Please provide the host of every outbound http credential")
+	}
+
+	if c.BearerToken != "" && c.Username != "" {
+		return fmt.Errorf("Please provide either a bearer token or a username and password for %v, not both", c.Host)
+	}
+
+	if c.BearerToken == "" && c.Username == "" {
+		return fmt.Errorf("Please provide a bearer token or a username and password for %v", c.Host)
+	}
+	return nil
+}
+
+// Matches returns true if the credential is for the host of the request
+func (c Credential) Matches(req *http.Request) bool {
+	host := req.URL.Hostname()
+	if strings.Contains(c.Host, ":") {
+		host = req.URL.Host
+	}
+
+	if strings.HasPrefix(c.Host, "*.") {
+		return strings.HasSuffix(strings.ToLower(host), strings.ToLower(c.Host[1:]))
+	}
+	return strings.EqualFold(host, c.Host)
+}
+
+// credentialsTransport adds the first matching credential to each request, unless the request is already authenticated
+type credentialsTransport struct {
+	base        http.RoundTripper
+	credentials []Credential
+}
+
+func (t *credentialsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Authorization") != "" {
+		return t.base.RoundTrip(req)
+	}
+
+	for _, c := range t.credentials {
+		if !c.Matches(req) {
+			continue
+		}
+
+		// a RoundTripper must not modify the request it was given
+		authenticated := req.Clone(req.Context())
+		if c.BearerToken != "" {
+			authenticated.Header.Set("Authorization", "Bearer "+c.BearerToken)
+		} else {
+			authenticated.SetBasicAuth(c.Username, c.Password)
+		}
+		return t.base.RoundTrip(authenticated)
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,85 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadCredentials(t *testing.T) {
+	file := writeTempFile(t, "credentials", `credentials:
+  - host: notifier.example.com
+    bearerToken: token
+  - host: "*.ft.com"
+    username: user
+    password: pass
+`)
+	defer os.Remove(file)
+
+	credentials, err := LoadCredentials(file)
+	assert.NoError(t, err)
+	assert.Equal(t, []Credential{{Host: "notifier.example.com", BearerToken: "token"}, {Host: "*.ft.com", Username: "user", Password: "pass"}}, credentials)
+}
+
+func TestLoadCredentialsNoFile(t *testing.T) {
+	credentials, err := LoadCredentials("")
+	assert.NoError(t, err)
+	assert.Nil(t, credentials)
+
+	credentials, err = LoadCredentials("/does/not/exist.yml")
+	assert.NoError(t, err)
+	assert.Nil(t, credentials)
+}
+
+func TestCredentialValidate(t *testing.T) {
+	assert.EqualError(t, Credential{BearerToken: "token"}.Validate(), "Please provide the host of every outbound http credential")
+	assert.EqualError(t, Credential{Host: "ft.com"}.Validate(), "Please provide a bearer token or a username and password for ft.com")
+	assert.EqualError(t, Credential{Host: "ft.com", BearerToken: "token", Username: "user"}.Validate(), "Please provide either a bearer token or a username and password for ft.com, not both")
+}
+
+func TestCredentialMatches(t *testing.T) {
+	req := httptest.NewRequest("GET", "http://notifier.upp.ft.com:8080/notify", nil)
+
+	assert.True(t, Credential{Host: "notifier.upp.ft.com"}.Matches(req))
+	assert.True(t, Credential{Host: "NOTIFIER.upp.ft.com"}.Matches(req))
+	assert.True(t, Credential{Host: "notifier.upp.ft.com:8080"}.Matches(req))
+	assert.True(t, Credential{Host: "*.ft.com"}.Matches(req))
+
+	assert.False(t, Credential{Host: "notifier.upp.ft.com:8443"}.Matches(req))
+	assert.False(t, Credential{Host: "upp.ft.com"}.Matches(req))
+	assert.False(t, Credential{Host: "*.example.com"}.Matches(req))
+}
+
+func TestCredentialsAreAddedToRequests(t *testing.T) {
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+	}))
+	defer server.Close()
+
+	host, _ := url.Parse(server.URL)
+	config := DefaultConfig
+	config.Credentials = []Credential{{Host: "other-host", Username: "user", Password: "pass"}, {Host: host.Hostname(), BearerToken: "token"}}
+
+	clients, err := New(config)
+	require.NoError(t, err)
+
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	resp, err := clients.Client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "Bearer token", auth)
+	assert.Empty(t, req.Header.Get("Authorization"), "the request of the caller is not modified")
+
+	req, _ = http.NewRequest("GET", server.URL, nil)
+	req.SetBasicAuth("read", "environment")
+	resp, err = clients.CheckClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "Basic cmVhZDplbnZpcm9ubWVudA==", auth, "requests which are already authenticated are left alone")
+}
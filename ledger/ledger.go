@@ -0,0 +1,333 @@
+package ledger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Financial-Times/publish-carousel/s3"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	ledgerSuffix      = "-ledger"
+	ledgerContentType = "application/vnd.ft-carousel-ledger.v1+gzip"
+)
+
+// Entry records the last successful carousel publish of a uuid. If the content was routed to several targets, and only reached some of them, the targets it did not reach are listed as failed.
+type Entry struct {
+	Collection    string    `json:"collection"`
+	UUID          string    `json:"uuid"`
+	LastPublished time.Time `json:"lastPublished"`
+	TransactionID string    `json:"transactionId"`
+	CycleID       string    `json:"cycleId"`
+	Attempts      int       `json:"attempts,omitempty"`
+	FailedTargets []string  `json:"failedTargets,omitempty"`
+}
+
+// Ledger remembers when each uuid was last successfully republished by the carousel
+type Ledger interface {
+	Record(entry Entry)
+	Get(collection string, uuid string) (Entry, bool)
+	OrderByStaleness(collection string, uuids []string)
+	Persist() error
+}
+
+type record struct {
+	published     int64
+	transactionID string
+	cycleID       string
+	attempts      int
+	failedTargets []string
+}
+
+// maxLedgerDeltas bounds the number of deltas which are chained onto a full snapshot, and so the number of reads needed to restore a collection
+const maxLedgerDeltas = 100
+
+// collectionLedger holds the records of a collection. The records are restored from S3 before loaded is closed.
+type collectionLedger struct {
+	loaded  chan struct{}
+	records map[string]record
+	changed map[string]bool
+	lastKey string
+	deltas  int
+	chained int
+}
+
+type s3Ledger struct {
+	lock        *sync.RWMutex
+	persistLock *sync.Mutex
+	rw          s3.ReadWriter
+	collections map[string]*collectionLedger
+}
+
+// NewS3Ledger returns a ledger which is held in memory, and persisted to S3 per collection. The ledger for a collection is restored from S3 the first time the collection is used.
+// Each persist only writes the entries which have changed, as a delta onto the previous write. Once the deltas hold as many entries as the ledger, or there are too many of them, a full snapshot is written instead.
+func NewS3Ledger(rw s3.ReadWriter) Ledger {
+	return &s3Ledger{lock: &sync.RWMutex{}, persistLock: &sync.Mutex{}, rw: rw, collections: make(map[string]*collectionLedger)}
+}
+
+// Record stores the entry as the last publish of its uuid
+func (l *s3Ledger) Record(entry Entry) {
+	c := l.collection(entry.Collection)
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	c.records[entry.UUID] = newRecord(entry)
+	c.changed[entry.UUID] = true
+}
+
+// Get returns the last publish of the uuid, or false if the carousel has not published it since the ledger was started
+func (l *s3Ledger) Get(collection string, uuid string) (Entry, bool) {
+	c := l.collection(collection)
+
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	r, ok := c.records[uuid]
+	if !ok {
+		return Entry{}, false
+	}
+	return r.entry(collection, uuid), true
+}
+
+// OrderByStaleness sorts the uuids so that those which have never been published, or which failed to reach some of their targets, come first, followed by the least recently published. The sort is stable, so uuids published at the same time keep their order.
+func (l *s3Ledger) OrderByStaleness(collection string, uuids []string) {
+	c := l.collection(collection)
+
+	l.lock.RLock()
+	published := make([]int64, len(uuids))
+	for i, uuid := range uuids {
+		if r := c.records[uuid]; len(r.failedTargets) == 0 {
+			published[i] = r.published
+		}
+	}
+	l.lock.RUnlock()
+
+	sort.Stable(&byStaleness{uuids: uuids, published: published})
+}
+
+// Persist writes the changes to the ledger of each collection since it was last persisted
+func (l *s3Ledger) Persist() error {
+	l.persistLock.Lock()
+	defer l.persistLock.Unlock()
+
+	l.lock.Lock()
+	var snapshots []ledgerSnapshot
+	for collection, c := range l.collections {
+		if c.records == nil || len(c.changed) == 0 {
+			continue
+		}
+
+		snapshots = append(snapshots, l.snapshot(collection, c))
+		c.changed = make(map[string]bool)
+	}
+	l.lock.Unlock()
+
+	var errs []string
+	for _, snapshot := range snapshots {
+		key, err := l.write(snapshot)
+
+		l.lock.Lock()
+		c := l.collections[snapshot.Collection]
+		if err != nil {
+			log.WithError(err).WithField("collection", snapshot.Collection).Warn("Failed to persist republish ledger to S3")
+			errs = append(errs, err.Error())
+
+			for _, e := range snapshot.Entries {
+				c.changed[e.UUID] = true
+			}
+		} else if snapshot.Previous == "" {
+			c.lastKey, c.deltas, c.chained = key, 0, 0
+		} else {
+			c.lastKey, c.deltas, c.chained = key, c.deltas+1, c.chained+len(snapshot.Entries)
+		}
+		l.lock.Unlock()
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// collection returns the ledger for the collection, restoring it from S3 if it has not been loaded yet. The restore does not hold the lock, so other collections can be used meanwhile.
+func (l *s3Ledger) collection(collection string) *collectionLedger {
+	l.lock.Lock()
+	c, ok := l.collections[collection]
+	if !ok {
+		c = &collectionLedger{loaded: make(chan struct{})}
+		l.collections[collection] = c
+	}
+	l.lock.Unlock()
+
+	if ok {
+		<-c.loaded
+		return c
+	}
+
+	restored, err := l.restore(collection)
+	if err != nil {
+		log.WithError(err).WithField("collection", collection).Warn("Failed to restore republish ledger from S3, starting with an empty ledger.")
+		restored = &collectionLedger{records: make(map[string]record)}
+	}
+
+	l.lock.Lock()
+	c.records, c.changed, c.lastKey, c.deltas, c.chained = restored.records, make(map[string]bool), restored.lastKey, restored.deltas, restored.chained
+	l.lock.Unlock()
+
+	close(c.loaded)
+	return c
+}
+
+// ledgerSnapshot holds every entry of a collection, or if Previous is set, only the entries which changed since the snapshot with the key Previous was written
+type ledgerSnapshot struct {
+	Collection string  `json:"collection"`
+	Previous   string  `json:"previous,omitempty"`
+	Entries    []Entry `json:"entries"`
+}
+
+// snapshot copies the changed entries for the collection as a delta, or every entry if a full snapshot is due. Must be called with the lock held.
+func (l *s3Ledger) snapshot(collection string, c *collectionLedger) ledgerSnapshot {
+	if c.lastKey == "" || c.deltas >= maxLedgerDeltas || c.chained+len(c.changed) >= len(c.records) {
+		snapshot := ledgerSnapshot{Collection: collection, Entries: make([]Entry, 0, len(c.records))}
+		for uuid, r := range c.records {
+			snapshot.Entries = append(snapshot.Entries, r.entry(collection, uuid))
+		}
+		return snapshot
+	}
+
+	snapshot := ledgerSnapshot{Collection: collection, Previous: c.lastKey, Entries: make([]Entry, 0, len(c.changed))}
+	for uuid := range c.changed {
+		snapshot.Entries = append(snapshot.Entries, c.records[uuid].entry(collection, uuid))
+	}
+	return snapshot
+}
+
+// write stores the snapshot in S3, and returns its key
+func (l *s3Ledger) write(snapshot ledgerSnapshot) (string, error) {
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	if err := json.NewEncoder(gz).Encode(snapshot); err != nil {
+		return "", err
+	}
+
+	if err := gz.Close(); err != nil {
+		return "", err
+	}
+
+	id := snapshot.Collection + ledgerSuffix
+	key := time.Now().UTC().Format(`20060102T150405.000000000`) + ".gz"
+	if err := l.rw.Write(id, key, buf.Bytes(), ledgerContentType); err != nil {
+		return "", err
+	}
+	return id + "/" + key, nil
+}
+
+// restore reads the latest snapshot of the collection, and every snapshot it was written onto, back to the last full snapshot
+func (l *s3Ledger) restore(collection string) (*collectionLedger, error) {
+	restored := &collectionLedger{records: make(map[string]record)}
+	if l.rw == nil {
+		return restored, nil
+	}
+
+	key, err := l.rw.GetLatestKeyForID(collection + ledgerSuffix)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(key) == "" {
+		return restored, nil
+	}
+
+	var chain []ledgerSnapshot
+	for next := key; next != ""; next = chain[len(chain)-1].Previous {
+		if len(chain) > maxLedgerDeltas {
+			return nil, fmt.Errorf("Ledger has more than %v deltas since its last full snapshot", maxLedgerDeltas)
+		}
+
+		snapshot, err := l.read(next)
+		if err != nil {
+			return nil, err
+		}
+
+		if snapshot.Collection != collection {
+			return nil, fmt.Errorf("Ledger is for collection %v, expected %v", snapshot.Collection, collection)
+		}
+		chain = append(chain, snapshot)
+	}
+
+	for i := len(chain) - 1; i >= 0; i-- {
+		for _, e := range chain[i].Entries {
+			restored.records[e.UUID] = newRecord(e)
+		}
+
+		if i < len(chain)-1 {
+			restored.deltas++
+			restored.chained += len(chain[i].Entries)
+		}
+	}
+	restored.lastKey = key
+
+	log.WithField("collection", collection).WithField("entries", len(restored.records)).WithField("deltas", restored.deltas).Info("Restored republish ledger from S3.")
+	return restored, nil
+}
+
+func (l *s3Ledger) read(key string) (ledgerSnapshot, error) {
+	snapshot := ledgerSnapshot{}
+	found, data, contentType, err := l.rw.Read(key)
+	if err != nil {
+		return snapshot, err
+	}
+
+	if !found {
+		return snapshot, errors.New("Key not found, has it recently been deleted?")
+	}
+	defer data.Close()
+
+	if contentType == nil || *contentType != ledgerContentType {
+		return snapshot, errors.New("Unexpected or nil content type")
+	}
+
+	gz, err := gzip.NewReader(data)
+	if err != nil {
+		return snapshot, err
+	}
+	defer gz.Close()
+
+	err = json.NewDecoder(gz).Decode(&snapshot)
+	return snapshot, err
+}
+
+func newRecord(entry Entry) record {
+	return record{published: entry.LastPublished.UnixNano(), transactionID: entry.TransactionID, cycleID: entry.CycleID, attempts: entry.Attempts, failedTargets: entry.FailedTargets}
+}
+
+func (r record) entry(collection string, uuid string) Entry {
+	return Entry{Collection: collection, UUID: uuid, LastPublished: time.Unix(0, r.published).UTC(), TransactionID: r.transactionID, CycleID: r.cycleID, Attempts: r.attempts, FailedTargets: r.failedTargets}
+}
+
+type byStaleness struct {
+	uuids     []string
+	published []int64
+}
+
+func (b *byStaleness) Len() int {
+	return len(b.uuids)
+}
+
+func (b *byStaleness) Less(i, j int) bool {
+	return b.published[i] < b.published[j]
+}
+
+func (b *byStaleness) Swap(i, j int) {
+	b.uuids[i], b.uuids[j] = b.uuids[j], b.uuids[i]
+	b.published[i], b.published[j] = b.published[j], b.published[i]
+}
@@ -0,0 +1,256 @@
+package ledger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/Financial-Times/publish-carousel/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func emptyS3() *s3.MockReadWriter {
+	rw := new(s3.MockReadWriter)
+	rw.On("GetLatestKeyForID", mock.AnythingOfType("string")).Return("", nil)
+	return rw
+}
+
+func TestRecordAndGet(t *testing.T) {
+	rw := emptyS3()
+	l := NewS3Ledger(rw)
+
+	_, ok := l.Get("methode", "uuid-1")
+	assert.False(t, ok)
+
+	published := time.Date(2017, 5, 1, 12, 0, 0, 0, time.UTC)
+	l.Record(Entry{Collection: "methode", UUID: "uuid-1", LastPublished: published, TransactionID: "tid_1234_carousel_1493640000", CycleID: "cycle-1"})
+
+	entry, ok := l.Get("methode", "uuid-1")
+	assert.True(t, ok)
+	assert.Equal(t, Entry{Collection: "methode", UUID: "uuid-1", LastPublished: published, TransactionID: "tid_1234_carousel_1493640000", CycleID: "cycle-1"}, entry)
+
+	_, ok = l.Get("wordpress", "uuid-1")
+	assert.False(t, ok, "ledgers are per collection")
+
+	rw.AssertNumberOfCalls(t, "GetLatestKeyForID", 2)
+}
+
+func TestOrderByStaleness(t *testing.T) {
+	l := NewS3Ledger(emptyS3())
+
+	now := time.Now()
+	l.Record(Entry{Collection: "methode", UUID: "recent", LastPublished: now})
+	l.Record(Entry{Collection: "methode", UUID: "old", LastPublished: now.Add(-48 * time.Hour)})
+	l.Record(Entry{Collection: "methode", UUID: "older", LastPublished: now.Add(-72 * time.Hour)})
+
+	uuids := []string{"recent", "never-1", "old", "older", "never-2"}
+	l.OrderByStaleness("methode", uuids)
+
+	assert.Equal(t, []string{"never-1", "never-2", "older", "old", "recent"}, uuids)
+}
+
+func TestOrderByStalenessPutsPartlyFailedPublishesFirst(t *testing.T) {
+	l := NewS3Ledger(emptyS3())
+
+	now := time.Now()
+	l.Record(Entry{Collection: "methode", UUID: "old", LastPublished: now.Add(-48 * time.Hour)})
+	l.Record(Entry{Collection: "methode", UUID: "partly-failed", LastPublished: now, FailedTargets: []string{"us"}})
+
+	uuids := []string{"old", "partly-failed"}
+	l.OrderByStaleness("methode", uuids)
+
+	assert.Equal(t, []string{"partly-failed", "old"}, uuids)
+}
+
+func TestPersistAndRestore(t *testing.T) {
+	var written []byte
+
+	rw := emptyS3()
+	rw.On("Write", "methode-ledger", mock.AnythingOfType("string"), mock.AnythingOfType("[]uint8"), ledgerContentType).Run(func(args mock.Arguments) {
+		written = args.Get(2).([]byte)
+	}).Return(nil).Once()
+
+	published := time.Date(2017, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	l := NewS3Ledger(rw)
+	l.Record(Entry{Collection: "methode", UUID: "uuid-1", LastPublished: published, TransactionID: "tid_1", CycleID: "cycle-1", FailedTargets: []string{"us"}})
+
+	require.NoError(t, l.Persist())
+	require.NoError(t, l.Persist(), "unchanged collections should not be written again")
+	rw.AssertExpectations(t)
+
+	contentType := ledgerContentType
+	restoring := new(s3.MockReadWriter)
+	restoring.On("GetLatestKeyForID", "methode-ledger").Return("methode-ledger/key.gz", nil)
+	restoring.On("Read", "methode-ledger/key.gz").Return(true, ioutil.NopCloser(bytes.NewReader(written)), &contentType, nil)
+
+	restored := NewS3Ledger(restoring)
+	entry, ok := restored.Get("methode", "uuid-1")
+	assert.True(t, ok)
+	assert.Equal(t, Entry{Collection: "methode", UUID: "uuid-1", LastPublished: published, TransactionID: "tid_1", CycleID: "cycle-1", FailedTargets: []string{"us"}}, entry)
+
+	restoring.AssertExpectations(t)
+}
+
+func TestPersistWritesDeltas(t *testing.T) {
+	written := make(map[string][]byte)
+
+	rw := emptyS3()
+	rw.On("Write", "methode-ledger", mock.AnythingOfType("string"), mock.AnythingOfType("[]uint8"), ledgerContentType).Run(func(args mock.Arguments) {
+		written["methode-ledger/"+args.String(1)] = args.Get(2).([]byte)
+	}).Return(nil)
+
+	published := time.Date(2017, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	l := NewS3Ledger(rw)
+	for _, uuid := range []string{"uuid-1", "uuid-2", "uuid-3"} {
+		l.Record(Entry{Collection: "methode", UUID: uuid, LastPublished: published})
+	}
+	require.NoError(t, l.Persist())
+
+	l.Record(Entry{Collection: "methode", UUID: "uuid-2", LastPublished: published.Add(time.Hour), TransactionID: "tid_2"})
+	require.NoError(t, l.Persist())
+	require.Len(t, written, 2)
+
+	var full, delta ledgerSnapshot
+	for key, data := range written {
+		snapshot, err := decode(data)
+		require.NoError(t, err)
+
+		if snapshot.Previous == "" {
+			full = snapshot
+			continue
+		}
+		delta = snapshot
+		assert.NotEqual(t, key, delta.Previous)
+	}
+
+	assert.Len(t, full.Entries, 3)
+	assert.Contains(t, written, delta.Previous, "the delta is written onto the full snapshot")
+	assert.Equal(t, []Entry{{Collection: "methode", UUID: "uuid-2", LastPublished: published.Add(time.Hour), TransactionID: "tid_2"}}, delta.Entries, "only the changed entry is written")
+
+	contentType := ledgerContentType
+	restoring := new(s3.MockReadWriter)
+	for key, data := range written {
+		restoring.On("Read", key).Return(true, ioutil.NopCloser(bytes.NewReader(data)), &contentType, nil).Once()
+		if key != delta.Previous {
+			restoring.On("GetLatestKeyForID", "methode-ledger").Return(key, nil).Once()
+		}
+	}
+
+	restored := NewS3Ledger(restoring)
+	entry, ok := restored.Get("methode", "uuid-2")
+	assert.True(t, ok)
+	assert.Equal(t, "tid_2", entry.TransactionID, "deltas are applied over the full snapshot")
+
+	_, ok = restored.Get("methode", "uuid-3")
+	assert.True(t, ok)
+	restoring.AssertExpectations(t)
+}
+
+func TestPersistCompactsDeltas(t *testing.T) {
+	var snapshots []ledgerSnapshot
+
+	rw := emptyS3()
+	rw.On("Write", "methode-ledger", mock.AnythingOfType("string"), mock.AnythingOfType("[]uint8"), ledgerContentType).Run(func(args mock.Arguments) {
+		snapshot, err := decode(args.Get(2).([]byte))
+		require.NoError(t, err)
+		snapshots = append(snapshots, snapshot)
+	}).Return(nil)
+
+	l := NewS3Ledger(rw)
+	l.Record(Entry{Collection: "methode", UUID: "uuid-1", LastPublished: time.Now()})
+	l.Record(Entry{Collection: "methode", UUID: "uuid-2", LastPublished: time.Now()})
+	require.NoError(t, l.Persist())
+
+	l.Record(Entry{Collection: "methode", UUID: "uuid-1", LastPublished: time.Now()})
+	require.NoError(t, l.Persist())
+
+	l.Record(Entry{Collection: "methode", UUID: "uuid-2", LastPublished: time.Now()})
+	require.NoError(t, l.Persist())
+
+	require.Len(t, snapshots, 3)
+	assert.Empty(t, snapshots[0].Previous)
+	assert.NotEmpty(t, snapshots[1].Previous)
+	assert.Empty(t, snapshots[2].Previous, "a full snapshot is written once the deltas hold as many entries as the ledger")
+	assert.Len(t, snapshots[2].Entries, 2)
+}
+
+func TestRestoreDoesNotBlockOtherCollections(t *testing.T) {
+	restoring := make(chan struct{})
+	released := make(chan struct{})
+
+	rw := new(s3.MockReadWriter)
+	rw.On("GetLatestKeyForID", "methode-ledger").Run(func(args mock.Arguments) {
+		close(restoring)
+		<-released
+	}).Return("", nil)
+	rw.On("GetLatestKeyForID", "wordpress-ledger").Return("", nil)
+
+	l := NewS3Ledger(rw)
+	go l.Get("methode", "uuid-1")
+	<-restoring
+
+	l.Record(Entry{Collection: "wordpress", UUID: "uuid-1", LastPublished: time.Now()})
+	_, ok := l.Get("wordpress", "uuid-1")
+	assert.True(t, ok, "other collections can be used while a collection is restored")
+
+	close(released)
+	l.Record(Entry{Collection: "methode", UUID: "uuid-1", LastPublished: time.Now()})
+	_, ok = l.Get("methode", "uuid-1")
+	assert.True(t, ok)
+	rw.AssertNumberOfCalls(t, "GetLatestKeyForID", 2)
+}
+
+func decode(data []byte) (ledgerSnapshot, error) {
+	snapshot := ledgerSnapshot{}
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return snapshot, err
+	}
+	err = json.NewDecoder(gz).Decode(&snapshot)
+	return snapshot, err
+}
+
+func TestPersistFailsIsRetried(t *testing.T) {
+	rw := emptyS3()
+	rw.On("Write", "methode-ledger", mock.AnythingOfType("string"), mock.AnythingOfType("[]uint8"), ledgerContentType).Return(errors.New("access denied")).Once()
+	rw.On("Write", "methode-ledger", mock.AnythingOfType("string"), mock.AnythingOfType("[]uint8"), ledgerContentType).Return(nil).Once()
+
+	l := NewS3Ledger(rw)
+	l.Record(Entry{Collection: "methode", UUID: "uuid-1", LastPublished: time.Now()})
+
+	assert.EqualError(t, l.Persist(), "access denied")
+	assert.NoError(t, l.Persist())
+	rw.AssertExpectations(t)
+}
+
+func TestRestoreFailsStartsEmpty(t *testing.T) {
+	rw := new(s3.MockReadWriter)
+	rw.On("GetLatestKeyForID", "methode-ledger").Return("", errors.New("access denied")).Once()
+
+	l := NewS3Ledger(rw)
+	_, ok := l.Get("methode", "uuid-1")
+	assert.False(t, ok)
+
+	l.Record(Entry{Collection: "methode", UUID: "uuid-1", LastPublished: time.Now()})
+	_, ok = l.Get("methode", "uuid-1")
+	assert.True(t, ok)
+	rw.AssertExpectations(t)
+}
+
+func TestRestoreUnexpectedContentType(t *testing.T) {
+	contentType := "application/json"
+	rw := new(s3.MockReadWriter)
+	rw.On("GetLatestKeyForID", "methode-ledger").Return("key", nil)
+	rw.On("Read", "key").Return(true, ioutil.NopCloser(bytes.NewReader([]byte(`{}`))), &contentType, nil)
+
+	_, err := NewS3Ledger(rw).(*s3Ledger).restore("methode")
+	assert.EqualError(t, err, "Unexpected or nil content type")
+}
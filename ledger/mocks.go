@@ -0,0 +1,24 @@
+package ledger
+
+import "github.com/stretchr/testify/mock"
+
+type MockLedger struct {
+	mock.Mock
+}
+
+func (m *MockLedger) Record(entry Entry) {
+	m.Called(entry)
+}
+
+func (m *MockLedger) Get(collection string, uuid string) (Entry, bool) {
+	args := m.Called(collection, uuid)
+	return args.Get(0).(Entry), args.Bool(1)
+}
+
+func (m *MockLedger) OrderByStaleness(collection string, uuids []string) {
+	m.Called(collection, uuids)
+}
+
+func (m *MockLedger) Persist() error {
+	return m.Called().Error(0)
+}
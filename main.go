@@ -18,15 +18,20 @@ import (
 	"github.com/Financial-Times/publish-carousel/cms"
 	"github.com/Financial-Times/publish-carousel/etcd"
 	"github.com/Financial-Times/publish-carousel/file"
-	"github.com/Financial-Times/publish-carousel/image"
+	"github.com/Financial-Times/publish-carousel/httpclient"
+	"github.com/Financial-Times/publish-carousel/ledger"
 	"github.com/Financial-Times/publish-carousel/native"
 	"github.com/Financial-Times/publish-carousel/resources"
 	"github.com/Financial-Times/publish-carousel/s3"
 	"github.com/Financial-Times/publish-carousel/scheduler"
+	"github.com/Financial-Times/publish-carousel/schema"
 	"github.com/Financial-Times/publish-carousel/tasks"
+	"github.com/Financial-Times/publish-carousel/tracing"
+	"github.com/Financial-Times/publish-carousel/verify"
 	"github.com/Financial-Times/service-status-go/httphandlers"
 	"github.com/husobee/vestigo"
 	log "github.com/sirupsen/logrus"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"gopkg.in/urfave/cli.v1"
 )
 
@@ -64,6 +69,18 @@ func main() {
 			EnvVar: "BLACKLIST_FILE",
 			Usage:  "Path to the plaintxt blacklist file, which contains blacklisted uuids.",
 		},
+		cli.StringFlag{
+			Name:   "native-collections",
+			Value:  "",
+			EnvVar: "NATIVE_COLLECTIONS_FILE",
+			Usage:  "Optional path to a yaml file which configures the database, uuid field and uuid encoding of each native collection. Collections which are not configured use the native-store database and a binary uuid field.",
+		},
+		cli.StringFlag{
+			Name:   "schemas-dir",
+			Value:  "",
+			EnvVar: "SCHEMAS_DIR",
+			Usage:  "Optional directory of JSON schemas, listed in its schemas.yml, which native content must match to be published. Content which does not match is skipped.",
+		},
 		cli.StringFlag{
 			Name:   "mongo-db",
 			Value:  "localhost:27017",
@@ -80,7 +97,49 @@ func main() {
 			Name:   "cms-notifier-url",
 			Value:  "http://localhost:8080/__cms-notifier",
 			EnvVar: "CMS_NOTIFIER_URL",
-			Usage:  "The CMS Notifier instance to POST publishes to.",
+			Usage:  "The CMS Notifier instance to POST publishes to, or a comma separated list of instances to balance publishes between.",
+		},
+		cli.StringFlag{
+			Name:   "cms-notifier-balancing",
+			Value:  cms.DefaultPoolPolicy.Balancing,
+			EnvVar: "CMS_NOTIFIER_BALANCING",
+			Usage:  "How publishes are balanced between several CMS Notifier instances, either round-robin or least-latency.",
+		},
+		cli.IntFlag{
+			Name:   "cms-notifier-eject-after",
+			Value:  cms.DefaultPoolPolicy.EjectAfter,
+			EnvVar: "CMS_NOTIFIER_EJECT_AFTER",
+			Usage:  "Number of consecutive failed publishes after which one of several CMS Notifier instances is ejected. Instances which fail their GTG are ejected immediately.",
+		},
+		cli.StringFlag{
+			Name:   "cms-notifier-eject-for",
+			Value:  cms.DefaultPoolPolicy.EjectFor.String(),
+			EnvVar: "CMS_NOTIFIER_EJECT_FOR",
+			Usage:  "How long an ejected CMS Notifier instance is ejected for, before it is re-admitted.",
+		},
+		cli.IntFlag{
+			Name:   "notifier-max-attempts",
+			Value:  cms.DefaultRetryPolicy.MaxAttempts,
+			EnvVar: "NOTIFIER_MAX_ATTEMPTS",
+			Usage:  "Maximum number of attempts to post each publish to the CMS Notifier. Timeouts, 429 and 5xx responses are retried, other failures are not. Set to 1 to disable retries",
+		},
+		cli.StringFlag{
+			Name:   "notifier-retry-backoff",
+			Value:  cms.DefaultRetryPolicy.InitialBackoff.String(),
+			EnvVar: "NOTIFIER_RETRY_BACKOFF",
+			Usage:  "Initial delay before a failed post to the CMS Notifier is retried, which doubles after each attempt, with jitter",
+		},
+		cli.StringFlag{
+			Name:   "notifier-retry-max-backoff",
+			Value:  cms.DefaultRetryPolicy.MaxBackoff.String(),
+			EnvVar: "NOTIFIER_RETRY_MAX_BACKOFF",
+			Usage:  "Maximum backoff before a failed post to the CMS Notifier is retried. A Retry-After from the response is always honoured, even if it is longer",
+		},
+		cli.StringFlag{
+			Name:   "notifier-routes",
+			Value:  "",
+			EnvVar: "NOTIFIER_ROUTES_FILE",
+			Usage:  "Optional path to a yaml file which routes content to notifier targets by collection, origin or content type, and can fan each publish out to several targets. Content which matches no route is posted to the cms-notifier-url.",
 		},
 		cli.StringFlag{
 			Name:   "pam-url",
@@ -118,6 +177,24 @@ func main() {
 			EnvVar: "MONGO_DB_TIMEOUT",
 			Usage:  "The timeout (in milliseconds) for Mongo DB connections.",
 		},
+		cli.StringFlag{
+			Name:   "mongo-read-preference",
+			Value:  "primary",
+			EnvVar: "MONGO_READ_PREFERENCE",
+			Usage:  "The default read preference for native-store queries, one of primary, primaryPreferred, secondary, secondaryPreferred or nearest. Can be overridden per cycle.",
+		},
+		cli.StringFlag{
+			Name:   "mongo-read-concern",
+			Value:  "",
+			EnvVar: "MONGO_READ_CONCERN",
+			Usage:  "The default read concern for native-store queries, one of local, available or majority. If empty, the server default is used. Can be overridden per cycle.",
+		},
+		cli.StringFlag{
+			Name:   "mongo-max-staleness",
+			Value:  "90s",
+			EnvVar: "MONGO_MAX_STALENESS",
+			Usage:  "If reading from secondaries, fall back to the primary when the secondaries lag further behind the primary than this duration. Set to 0 to disable the staleness guard. Can be overridden per cycle.",
+		},
 		cli.StringSliceFlag{
 			Name:   "etcd-peers",
 			Value:  &cli.StringSlice{"http://localhost:2379"},
@@ -142,6 +219,18 @@ func main() {
 			EnvVar: "ACTIVE_CLUSTER_ETCD_KEY",
 			Usage:  "The ETCD key that specifies if the cluster is active",
 		},
+		cli.IntFlag{
+			Name:   "native-read-batch-size",
+			Value:  10,
+			EnvVar: "NATIVE_READ_BATCH_SIZE",
+			Usage:  "Number of upcoming native documents which are read from Mongo in a single query. Set to 1 to read each document individually",
+		},
+		cli.StringFlag{
+			Name:   "native-read-max-age",
+			Value:  "1m",
+			EnvVar: "NATIVE_READ_MAX_AGE",
+			Usage:  "Maximum age of prefetched native content before it is published, after which it is read again from Mongo",
+		},
 		cli.StringFlag{
 			Name:   "default-throttle",
 			Value:  "1m",
@@ -154,6 +243,36 @@ func main() {
 			EnvVar: "CHECKPOINT_INTERVAL",
 			Usage:  "Interval for saving metadata checkpoints",
 		},
+		cli.IntFlag{
+			Name:   "cycle-restart-limit",
+			Value:  scheduler.DefaultRestartPolicy.MaxRestarts,
+			EnvVar: "CYCLE_RESTART_LIMIT",
+			Usage:  "Maximum number of consecutive automatic restarts for an unhealthy cycle, before it must be resumed manually. Set to 0 to disable automatic restarts",
+		},
+		cli.StringFlag{
+			Name:   "cycle-restart-backoff",
+			Value:  scheduler.DefaultRestartPolicy.InitialBackoff.String(),
+			EnvVar: "CYCLE_RESTART_BACKOFF",
+			Usage:  "Initial delay before an unhealthy cycle is restarted, which doubles after each consecutive restart",
+		},
+		cli.StringFlag{
+			Name:   "cycle-restart-max-backoff",
+			Value:  scheduler.DefaultRestartPolicy.MaxBackoff.String(),
+			EnvVar: "CYCLE_RESTART_MAX_BACKOFF",
+			Usage:  "Maximum delay before an unhealthy cycle is restarted",
+		},
+		cli.StringFlag{
+			Name:   "trace-exporter",
+			Value:  "none",
+			EnvVar: "TRACE_EXPORTER",
+			Usage:  "Where to export the trace spans of every publish, one of none, stdout or otlp. The traceparent header is sent to the cms notifier regardless.",
+		},
+		cli.StringFlag{
+			Name:   "otlp-endpoint",
+			Value:  tracing.DefaultOTLPEndpoint,
+			EnvVar: "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT",
+			Usage:  "The OTLP/HTTP traces endpoint of the OpenTelemetry collector, if the trace exporter is otlp.",
+		},
 		cli.StringFlag{
 			Name:   "configs-dir",
 			Value:  "/configs",
@@ -166,6 +285,96 @@ func main() {
 			EnvVar: "CREDENTIALS_DIR",
 			Usage:  "Directory containing the file with read environment credentials",
 		},
+		cli.StringFlag{
+			Name:   "http-timeout",
+			Value:  httpclient.DefaultConfig.Timeout.String(),
+			EnvVar: "HTTP_TIMEOUT",
+			Usage:  "Timeout of each outbound http request, such as publishes to the CMS Notifier and verification reads.",
+		},
+		cli.StringFlag{
+			Name:   "http-check-timeout",
+			Value:  httpclient.DefaultConfig.CheckTimeout.String(),
+			EnvVar: "HTTP_CHECK_TIMEOUT",
+			Usage:  "Timeout of the outbound GTG and healthcheck requests to cluster services and read environments.",
+		},
+		cli.StringFlag{
+			Name:   "http-dial-timeout",
+			Value:  httpclient.DefaultConfig.DialTimeout.String(),
+			EnvVar: "HTTP_DIAL_TIMEOUT",
+			Usage:  "Timeout for opening an outbound http connection.",
+		},
+		cli.StringFlag{
+			Name:   "http-keep-alive",
+			Value:  httpclient.DefaultConfig.KeepAlive.String(),
+			EnvVar: "HTTP_KEEP_ALIVE",
+			Usage:  "Interval between TCP keep-alive probes of outbound http connections.",
+		},
+		cli.StringFlag{
+			Name:   "http-tls-handshake-timeout",
+			Value:  httpclient.DefaultConfig.TLSHandshakeTimeout.String(),
+			EnvVar: "HTTP_TLS_HANDSHAKE_TIMEOUT",
+			Usage:  "Timeout for the TLS handshake of outbound https connections.",
+		},
+		cli.StringFlag{
+			Name:   "http-response-header-timeout",
+			Value:  httpclient.DefaultConfig.ResponseHeaderTimeout.String(),
+			EnvVar: "HTTP_RESPONSE_HEADER_TIMEOUT",
+			Usage:  "Timeout waiting for the response headers of an outbound http request, once it has been sent. 0s waits until the request times out.",
+		},
+		cli.StringFlag{
+			Name:   "http-idle-conn-timeout",
+			Value:  httpclient.DefaultConfig.IdleConnTimeout.String(),
+			EnvVar: "HTTP_IDLE_CONN_TIMEOUT",
+			Usage:  "How long an idle outbound http connection is kept open for reuse.",
+		},
+		cli.IntFlag{
+			Name:   "http-max-idle-conns",
+			Value:  httpclient.DefaultConfig.MaxIdleConns,
+			EnvVar: "HTTP_MAX_IDLE_CONNS",
+			Usage:  "Maximum number of idle outbound http connections, across all hosts.",
+		},
+		cli.IntFlag{
+			Name:   "http-max-idle-conns-per-host",
+			Value:  httpclient.DefaultConfig.MaxIdleConnsPerHost,
+			EnvVar: "HTTP_MAX_IDLE_CONNS_PER_HOST",
+			Usage:  "Maximum number of idle outbound http connections to each host.",
+		},
+		cli.IntFlag{
+			Name:   "http-max-conns-per-host",
+			Value:  httpclient.DefaultConfig.MaxConnsPerHost,
+			EnvVar: "HTTP_MAX_CONNS_PER_HOST",
+			Usage:  "Maximum number of outbound http connections to each host, including those in use. 0 is unlimited.",
+		},
+		cli.StringFlag{
+			Name:   "http-proxy",
+			Value:  "",
+			EnvVar: "HTTP_PROXY_URL",
+			Usage:  "Proxy url for outbound http requests, or direct to bypass any proxy. Defaults to the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.",
+		},
+		cli.StringFlag{
+			Name:   "http-ca-cert",
+			Value:  "",
+			EnvVar: "HTTP_CA_CERT",
+			Usage:  "Optional PEM file of CA certificates to trust for outbound https requests, in addition to the system certificates. Relative paths are read from the credentials-dir.",
+		},
+		cli.StringFlag{
+			Name:   "http-client-cert",
+			Value:  "",
+			EnvVar: "HTTP_CLIENT_CERT",
+			Usage:  "Optional PEM client certificate presented by outbound https requests, for mutual TLS. Relative paths are read from the credentials-dir.",
+		},
+		cli.StringFlag{
+			Name:   "http-client-key",
+			Value:  "",
+			EnvVar: "HTTP_CLIENT_KEY",
+			Usage:  "The PEM private key of the http-client-cert. Relative paths are read from the credentials-dir.",
+		},
+		cli.StringFlag{
+			Name:   "http-credentials",
+			Value:  "http.credentials.yml",
+			EnvVar: "HTTP_CREDENTIALS_FILE",
+			Usage:  "Optional yaml file of bearer or basic credentials for outbound http requests, per destination host. Relative paths are read from the credentials-dir.",
+		},
 	}
 
 	log.SetFormatter(&log.JSONFormatter{})
@@ -181,22 +390,94 @@ func main() {
 		s3rw := s3.NewReadWriter(ctx.String("aws-region"), ctx.String("s3-bucket"))
 		stateRw := scheduler.NewS3MetadataReadWriter(s3rw)
 
-		isImage := image.NewFilter()
 		blacklist, err := blacklist.NewFileBasedBlacklist(ctx.String("blacklist"))
 		if err != nil {
 			panic(err)
 		}
 
-		client := &http.Client{Timeout: time.Second * 30}
+		clientConfig, err := outboundHTTPConfig(ctx)
+		if err != nil {
+			panic(err)
+		}
 
-		mongo := native.NewMongoDatabase(ctx.String("mongo-db"), ctx.Int("mongo-timeout"))
+		clients, err := httpclient.New(clientConfig)
+		if err != nil {
+			panic(err)
+		}
+
+		client := clients.Client
+		cluster.SetClient(clients.CheckClient)
+
+		tracerProvider, err := tracing.NewTracerProvider(ctx.String("trace-exporter"), client, ctx.String("otlp-endpoint"), appSystemCode)
+		if err != nil {
+			panic(err)
+		}
+
+		collections, err := native.LoadCollectionConfigs(ctx.String("native-collections"))
+		if err != nil {
+			panic(err)
+		}
+
+		readOptions, err := native.ParseReadOptions(ctx.String("mongo-read-preference"), ctx.String("mongo-read-concern"), ctx.String("mongo-max-staleness"))
+		if err != nil {
+			panic(err)
+		}
+
+		mongo := native.NewMongoDatabase(ctx.String("mongo-db"), ctx.Int("mongo-timeout"), collections, readOptions)
+
+		reader := native.NewMongoNativeReader(mongo, tracerProvider)
+		if batchSize := ctx.Int("native-read-batch-size"); batchSize > 1 {
+			maxAge, err := time.ParseDuration(ctx.String("native-read-max-age"))
+			if err != nil {
+				log.WithError(err).Error("Invalid native read max age, defaulting to one minute.")
+				maxAge = time.Minute
+			}
+			reader = native.NewBatchingMongoNativeReader(mongo, batchSize, maxAge, tracerProvider)
+		}
+		var notifier cms.Notifier
+		if notifierURLs := cms.ParseURLs(ctx.String("cms-notifier-url")); len(notifierURLs) > 1 {
+			poolPolicy := cms.PoolPolicy{Balancing: ctx.String("cms-notifier-balancing"), EjectAfter: ctx.Int("cms-notifier-eject-after")}
+			if poolPolicy.EjectFor, err = time.ParseDuration(ctx.String("cms-notifier-eject-for")); err != nil {
+				log.WithError(err).Error("Invalid CMS Notifier ejection duration, using the default.")
+				poolPolicy.EjectFor = cms.DefaultPoolPolicy.EjectFor
+			}
+			notifier, err = cms.NewPooledNotifier(notifierURLs, client, poolPolicy, tracerProvider)
+		} else {
+			notifier, err = cms.NewNotifier(ctx.String("cms-notifier-url"), client, tracerProvider)
+		}
 
-		reader := native.NewMongoNativeReader(mongo)
-		notifier, err := cms.NewNotifier(ctx.String("cms-notifier-url"), client)
 		if err != nil {
 			log.WithError(err).Error("Error in CMS Notifier configuration")
 		}
 
+		retryPolicy := cms.DefaultRetryPolicy
+		retryPolicy.MaxAttempts = ctx.Int("notifier-max-attempts")
+
+		if retryPolicy.InitialBackoff, err = time.ParseDuration(ctx.String("notifier-retry-backoff")); err != nil {
+			log.WithError(err).Error("Invalid notifier retry backoff, using the default.")
+			retryPolicy.InitialBackoff = cms.DefaultRetryPolicy.InitialBackoff
+		}
+
+		if retryPolicy.MaxBackoff, err = time.ParseDuration(ctx.String("notifier-retry-max-backoff")); err != nil {
+			log.WithError(err).Error("Invalid notifier retry maximum backoff, using the default.")
+			retryPolicy.MaxBackoff = cms.DefaultRetryPolicy.MaxBackoff
+		}
+
+		if notifier != nil && retryPolicy.MaxAttempts > 1 {
+			notifier = cms.NewRetryingNotifier(notifier, retryPolicy)
+		}
+
+		routes, err := cms.LoadRoutes(ctx.String("notifier-routes"))
+		if err != nil {
+			panic(err)
+		}
+
+		if notifier != nil && routes != nil {
+			if notifier, err = cms.NewRoutingNotifier(*routes, notifier, client, retryPolicy, tracerProvider); err != nil {
+				panic(err)
+			}
+		}
+
 		pam, err := cluster.NewService("publish-availability-monitor", ctx.String("pam-url"), true) // true so that we check /__health
 		if err != nil {
 			log.WithError(err).Error("Error in Publish Availability Monitor configuration")
@@ -207,7 +488,17 @@ func main() {
 			panic(err)
 		}
 
-		task := tasks.NewNativeContentPublishTask(reader, notifier, isImage)
+		schemas, err := schema.LoadDir(ctx.String("schemas-dir"))
+		if err != nil {
+			panic(err)
+		}
+
+		task := tasks.NewNativeContentPublishTask(reader, notifier, schemas)
+
+		taskRegistry := tasks.NewRegistry()
+		taskRegistry.Register(tasks.NativeContentTaskName, tasks.NewNativeContentDefinition(task))
+		taskRegistry.Register(tasks.AnnotationsTaskName, tasks.NewAnnotationsDefinition(reader, notifier))
+		taskRegistry.Register(tasks.HTTPSinkTaskName, tasks.NewHTTPSinkDefinition(reader, client))
 
 		defaultThrottle, err := time.ParseDuration(ctx.String("default-throttle"))
 		if err != nil {
@@ -220,14 +511,36 @@ func main() {
 			checkpointInterval = time.Hour
 		}
 
+		restartPolicy := scheduler.DefaultRestartPolicy
+		restartPolicy.MaxRestarts = ctx.Int("cycle-restart-limit")
+
+		if restartPolicy.InitialBackoff, err = time.ParseDuration(ctx.String("cycle-restart-backoff")); err != nil {
+			log.WithError(err).Error("Invalid cycle restart backoff, using the default.")
+			restartPolicy.InitialBackoff = scheduler.DefaultRestartPolicy.InitialBackoff
+		}
+
+		if restartPolicy.MaxBackoff, err = time.ParseDuration(ctx.String("cycle-restart-max-backoff")); err != nil {
+			log.WithError(err).Error("Invalid cycle restart maximum backoff, using the default.")
+			restartPolicy.MaxBackoff = scheduler.DefaultRestartPolicy.MaxBackoff
+		}
+
 		uuidCollectionBuilder := native.NewNativeUUIDCollectionBuilder(mongo, s3rw, blacklist)
+		republishLedger := ledger.NewS3Ledger(s3rw)
 
-		sched, configError := scheduler.LoadSchedulerFromFile(ctx.String("cycles"), uuidCollectionBuilder, task, stateRw, defaultThrottle, checkpointInterval)
+		// the delivery lagcheck knows the read environments of the delivery clusters, which cycles can verify their publishes against
+		var deliveryLagcheck cluster.Service
+		verifier := verify.NewVerifier(client, func() []cluster.ReadEnvironment {
+			if envs, ok := deliveryLagcheck.(cluster.ReadEnvironments); ok {
+				return envs.ReadEnvironments()
+			}
+			return nil
+		})
+
+		sched, configError := scheduler.LoadSchedulerFromFile(ctx.String("cycles"), uuidCollectionBuilder, task, stateRw, defaultThrottle, checkpointInterval, restartPolicy, republishLedger, taskRegistry, verifier, tracerProvider)
 		if configError != nil {
 			log.WithError(configError).Error("Failed to load cycles configuration file")
 		}
 
-		var deliveryLagcheck cluster.Service
 		var manualToggle, autoToggle string
 
 		if ctx.StringSlice("etcd-peers")[0] == "NOT_AVAILABLE" {
@@ -236,7 +549,7 @@ func main() {
 			if err != nil {
 				panic(err)
 			}
-			deliveryLagcheck, err = cluster_file.NewExternalService("kafka-lagcheck-delivery", client, "kafka-lagcheck", fileWatcher, "read.environments", "read.credentials")
+			deliveryLagcheck, err = cluster_file.NewExternalService("kafka-lagcheck-delivery", clients.CheckClient, "kafka-lagcheck", fileWatcher, "read.environments", "read.credentials")
 			if err != nil {
 				panic(err)
 			}
@@ -254,7 +567,7 @@ func main() {
 				panic(err)
 			}
 
-			deliveryLagcheck, err = cluster_etcd.NewExternalService("kafka-lagcheck-delivery", client, "kafka-lagcheck", etcdWatcher, ctx.String("read-monitoring-etcd-key"))
+			deliveryLagcheck, err = cluster_etcd.NewExternalService("kafka-lagcheck-delivery", clients.CheckClient, "kafka-lagcheck", etcdWatcher, ctx.String("read-monitoring-etcd-key"))
 			if err != nil {
 				panic(err)
 			}
@@ -279,14 +592,50 @@ func main() {
 
 		api, _ := ioutil.ReadFile(ctx.String("api-yml"))
 
-		shutdown(sched)
-		serve(mongo, sched, s3rw, notifier, api, configError, pam, publishingLagcheck, deliveryLagcheck)
+		shutdown(sched, tracerProvider)
+		serve(mongo, collections, blacklist, task, republishLedger, sched, s3rw, notifier, api, configError, pam, publishingLagcheck, deliveryLagcheck)
 	}
 
 	app.Run(os.Args)
 }
 
-func shutdown(sched scheduler.Scheduler) {
+// outboundHTTPConfig reads the outbound http client configuration from the flags, and the certificates and credentials from the credentials directory
+func outboundHTTPConfig(ctx *cli.Context) (httpclient.Config, error) {
+	config := httpclient.DefaultConfig
+	config.MaxIdleConns = ctx.Int("http-max-idle-conns")
+	config.MaxIdleConnsPerHost = ctx.Int("http-max-idle-conns-per-host")
+	config.MaxConnsPerHost = ctx.Int("http-max-conns-per-host")
+	config.Proxy = ctx.String("http-proxy")
+
+	durations := map[string]*time.Duration{
+		"http-timeout":                 &config.Timeout,
+		"http-check-timeout":           &config.CheckTimeout,
+		"http-dial-timeout":            &config.DialTimeout,
+		"http-keep-alive":              &config.KeepAlive,
+		"http-tls-handshake-timeout":   &config.TLSHandshakeTimeout,
+		"http-response-header-timeout": &config.ResponseHeaderTimeout,
+		"http-idle-conn-timeout":       &config.IdleConnTimeout,
+	}
+	for flag, d := range durations {
+		parsed, err := time.ParseDuration(ctx.String(flag))
+		if err != nil {
+			log.WithError(err).WithField("flag", flag).Error("Invalid outbound http duration, using the default.")
+			continue
+		}
+		*d = parsed
+	}
+
+	credentialsDir := ctx.String("credentials-dir")
+	config.CACertFile = httpclient.InDir(credentialsDir, ctx.String("http-ca-cert"))
+	config.CertFile = httpclient.InDir(credentialsDir, ctx.String("http-client-cert"))
+	config.KeyFile = httpclient.InDir(credentialsDir, ctx.String("http-client-key"))
+
+	var err error
+	config.Credentials, err = httpclient.LoadCredentials(httpclient.InDir(credentialsDir, ctx.String("http-credentials")))
+	return config, err
+}
+
+func shutdown(sched scheduler.Scheduler, tracerProvider *sdktrace.TracerProvider) {
 	signals := make(chan os.Signal, 1)
 	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
 
@@ -297,11 +646,18 @@ func shutdown(sched scheduler.Scheduler) {
 		if err != nil {
 			log.WithError(err).Error("Error in stopping scheduler")
 		}
+
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err = tracerProvider.Shutdown(flushCtx)
+		cancel()
+		if err != nil {
+			log.WithError(err).Warn("Failed to export the remaining trace spans")
+		}
 		os.Exit(0)
 	}()
 }
 
-func serve(mongo native.DB, sched scheduler.Scheduler, s3rw s3.ReadWriter, notifier cms.Notifier, api []byte, configError error, upServices ...cluster.Service) {
+func serve(mongo native.DB, collections native.CollectionConfigs, isBlacklisted blacklist.IsBlacklisted, task tasks.Task, republishLedger ledger.Ledger, sched scheduler.Scheduler, s3rw s3.ReadWriter, notifier cms.Notifier, api []byte, configError error, upServices ...cluster.Service) {
 	r := vestigo.NewRouter()
 
 	healthService := resources.NewHealthService(appSystemCode, appName, description, mongo, s3rw, notifier, sched, configError, upServices...)
@@ -318,6 +674,10 @@ func serve(mongo native.DB, sched scheduler.Scheduler, s3rw s3.ReadWriter, notif
 	r.Get("/cycles", resources.GetCycles(sched))
 	r.Post("/cycles", resources.CreateCycle(sched))
 
+	r.Post("/cycles/stop", resources.StopCycles(sched))
+	r.Post("/cycles/resume", resources.ResumeCycles(sched))
+	r.Post("/cycles/reset", resources.ResetCycles(sched))
+
 	r.Get("/cycles/:id", resources.GetCycleForID(sched))
 	r.Delete("/cycles/:id", resources.DeleteCycle(sched))
 
@@ -334,6 +694,11 @@ func serve(mongo native.DB, sched scheduler.Scheduler, s3rw s3.ReadWriter, notif
 
 	r.Post("/scheduler/shutdown", resources.ShutdownScheduler(sched))
 
+	r.Get("/native/collections", resources.GetNativeCollections(mongo, sched))
+	r.Get("/native/:collection/timestamps", resources.GetTimestampReport(mongo))
+	r.Get("/native/:collection/:uuid/explain", resources.ExplainNativeContent(mongo, collections, isBlacklisted, task, sched))
+	r.Get("/native/:collection/:uuid/history", resources.GetRepublishHistory(republishLedger))
+
 	box := ui.UI()
 	dist := http.FileServer(box.HTTPBox())
 	r.Get("/*", dist.ServeHTTP)
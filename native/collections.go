@@ -0,0 +1,181 @@
+package native
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pborman/uuid"
+	"gopkg.in/mgo.v2/bson"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	DefaultDatabase  = "native-store"
+	DefaultUUIDField = "uuid"
+
+	// BinaryUUIDEncoding is for uuids stored as bson binary (subtype 4)
+	BinaryUUIDEncoding = "binary"
+	// StringUUIDEncoding is for uuids stored as strings
+	StringUUIDEncoding = "string"
+)
+
+// CollectionConfig configures the database for a native collection, and where its documents store their uuid and last modified timestamp.
+// Both fields can be a dot separated path to a nested field, i.e. "content.uuid".
+type CollectionConfig struct {
+	Database       string `yaml:"database" json:"database"`
+	UUIDField      string `yaml:"uuidField" json:"uuidField"`
+	UUIDEncoding   string `yaml:"uuidEncoding" json:"uuidEncoding"`
+	TimestampField string `yaml:"timestampField" json:"timestampField"`
+	TimestampType  string `yaml:"timestampType" json:"timestampType"`
+}
+
+// CollectionConfigs maps native collections to their configuration
+type CollectionConfigs map[string]CollectionConfig
+
+type collectionsSetupConfig struct {
+	Collections CollectionConfigs `yaml:"collections"`
+}
+
+// LoadCollectionConfigs reads the collection configuration from the provided yaml file. If no file is provided, every collection uses the default configuration.
+func LoadCollectionConfigs(configFile string) (CollectionConfigs, error) {
+	if strings.TrimSpace(configFile) == "" {
+		return CollectionConfigs{}, nil
+	}
+
+	fileData, err := ioutil.ReadFile(configFile)
+	if err != nil {
+		return nil, err
+	}
+
+	setup := collectionsSetupConfig{}
+	if err := yaml.Unmarshal(fileData, &setup); err != nil {
+		return nil, err
+	}
+
+	for collection, config := range setup.Collections {
+		if err := config.Validate(); err != nil {
+			return nil, fmt.Errorf("Invalid configuration for collection %v: %v", collection, err)
+		}
+	}
+
+	return setup.Collections, nil
+}
+
+// Get returns the configuration for the collection, using the defaults for anything which has not been configured
+func (c CollectionConfigs) Get(collection string) CollectionConfig {
+	config := c[collection]
+
+	if strings.TrimSpace(config.Database) == "" {
+		config.Database = DefaultDatabase
+	}
+
+	if strings.TrimSpace(config.UUIDField) == "" {
+		config.UUIDField = DefaultUUIDField
+	}
+
+	if strings.TrimSpace(config.UUIDEncoding) == "" {
+		config.UUIDEncoding = BinaryUUIDEncoding
+	}
+
+	if strings.TrimSpace(config.TimestampField) == "" {
+		config.TimestampField = DefaultTimestampField
+	}
+
+	if strings.TrimSpace(config.TimestampType) == "" {
+		config.TimestampType = StringTimestampType
+	}
+
+	return config
+}
+
+// Validate checks the uuid encoding and timestamp type are supported
+func (c CollectionConfig) Validate() error {
+	switch c.UUIDEncoding {
+	case "", BinaryUUIDEncoding, StringUUIDEncoding:
+	default:
+		return fmt.Errorf("Unsupported uuid encoding %v, please use either %v or %v", c.UUIDEncoding, BinaryUUIDEncoding, StringUUIDEncoding)
+	}
+
+	if !validTimestampType(c.TimestampType) {
+		return fmt.Errorf("Unsupported timestamp type %v, please use one of %v, %v, %v or %v", c.TimestampType, StringTimestampType, DateTimestampType, EpochTimestampType, EpochMillisTimestampType)
+	}
+	return nil
+}
+
+// uuidValue returns the uuid as it is stored in mongo
+func (c CollectionConfig) uuidValue(nativeUUID string) interface{} {
+	if c.UUIDEncoding == StringUUIDEncoding {
+		return nativeUUID
+	}
+	return bson.Binary{Kind: 0x04, Data: []byte(uuid.Parse(nativeUUID))}
+}
+
+func (c CollectionConfig) projection() bson.M {
+	return bson.M{c.UUIDField: 1}
+}
+
+const (
+	missingUUIDReason       = "missing uuid"
+	invalidBinaryUUIDReason = "invalid binary uuid"
+	invalidStringUUIDReason = "invalid uuid string"
+	// nonCanonicalUUIDReason is for uuid strings which are not in lower case hyphenated form. Their content is read by the stored string, so they cannot be published under the canonical uuid.
+	nonCanonicalUUIDReason = "non-canonical uuid string"
+)
+
+// skipReasons counts the documents which were skipped, by the reason they were skipped
+type skipReasons map[string]int
+
+func (r skipReasons) total() int {
+	total := 0
+	for _, n := range r {
+		total += n
+	}
+	return total
+}
+
+// SkippingUUIDCollection is implemented by uuid collections which skip documents with a missing or invalid uuid, rather than returning them from Next.
+// Skipped documents are counted in the Length of the collection, so cycles take them off their total once they are skipped.
+type SkippingUUIDCollection interface {
+	Skipped() map[string]int
+}
+
+// parseUUID converts a uuid stored as binary or as a string. If the value is not a valid uuid, or is a string which is not in canonical form, the reason the document should be skipped is returned instead.
+func parseUUID(val interface{}) (string, string) {
+	switch v := val.(type) {
+	case nil:
+		return "", missingUUIDReason
+	case bson.Binary:
+		if len(v.Data) != binaryUUIDLength {
+			return "", invalidBinaryUUIDReason
+		}
+		return uuid.UUID(v.Data).String(), ""
+	case string:
+		parsed := uuid.Parse(v)
+		if parsed == nil {
+			return "", invalidStringUUIDReason
+		}
+
+		if parsed.String() != v {
+			return "", nonCanonicalUUIDReason
+		}
+		return v, ""
+	}
+	return "", fmt.Sprintf("unexpected uuid type %T", val)
+}
+
+// lookupField returns the value of a dot separated field path in a document
+func lookupField(doc map[string]interface{}, path string) interface{} {
+	var val interface{} = doc
+	for _, field := range strings.Split(path, ".") {
+		switch m := val.(type) {
+		case map[string]interface{}:
+			val = m[field]
+		case bson.M:
+			val = m[field]
+		default:
+			return nil
+		}
+	}
+	return val
+}
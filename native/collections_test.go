@@ -0,0 +1,130 @@
+package native
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestCollectionConfigsDefaults(t *testing.T) {
+	configs := CollectionConfigs{"v2-content": {Database: "content-store", UUIDField: "content.uuid", UUIDEncoding: StringUUIDEncoding}}
+
+	assert.Equal(t, CollectionConfig{Database: DefaultDatabase, UUIDField: DefaultUUIDField, UUIDEncoding: BinaryUUIDEncoding, TimestampField: DefaultTimestampField, TimestampType: StringTimestampType}, configs.Get("methode"))
+	assert.Equal(t, CollectionConfig{Database: "content-store", UUIDField: "content.uuid", UUIDEncoding: StringUUIDEncoding, TimestampField: DefaultTimestampField, TimestampType: StringTimestampType}, configs.Get("v2-content"))
+}
+
+func TestCollectionConfigValidate(t *testing.T) {
+	assert.NoError(t, CollectionConfig{}.Validate())
+	assert.NoError(t, CollectionConfig{UUIDEncoding: BinaryUUIDEncoding}.Validate())
+	assert.NoError(t, CollectionConfig{UUIDEncoding: StringUUIDEncoding}.Validate())
+	assert.Error(t, CollectionConfig{UUIDEncoding: "base64"}.Validate())
+	assert.NoError(t, CollectionConfig{TimestampType: EpochMillisTimestampType}.Validate())
+	assert.Error(t, CollectionConfig{TimestampType: "unix"}.Validate())
+}
+
+func writeCollectionsFile(t *testing.T, data string) string {
+	f, err := ioutil.TempFile("", "collections")
+	require.NoError(t, err)
+	defer f.Close()
+
+	_, err = f.WriteString(data)
+	require.NoError(t, err)
+	return f.Name()
+}
+
+func TestLoadCollectionConfigs(t *testing.T) {
+	file := writeCollectionsFile(t, `collections:
+  v2-content:
+    database: content-store
+    uuidField: content.uuid
+    uuidEncoding: string
+    timestampField: publishedDate
+    timestampType: date
+`)
+	defer os.Remove(file)
+
+	configs, err := LoadCollectionConfigs(file)
+	assert.NoError(t, err)
+	assert.Equal(t, CollectionConfig{Database: "content-store", UUIDField: "content.uuid", UUIDEncoding: StringUUIDEncoding, TimestampField: "publishedDate", TimestampType: DateTimestampType}, configs.Get("v2-content"))
+	assert.Equal(t, DefaultDatabase, configs.Get("methode").Database)
+}
+
+func TestLoadCollectionConfigsInvalidEncoding(t *testing.T) {
+	file := writeCollectionsFile(t, `collections:
+  v2-content:
+    uuidEncoding: base64
+`)
+	defer os.Remove(file)
+
+	_, err := LoadCollectionConfigs(file)
+	assert.Error(t, err)
+}
+
+func TestLoadCollectionConfigsNoFile(t *testing.T) {
+	configs, err := LoadCollectionConfigs("")
+	assert.NoError(t, err)
+	assert.Len(t, configs, 0)
+
+	_, err = LoadCollectionConfigs("/does/not/exist.yml")
+	assert.Error(t, err)
+}
+
+func TestParseUUID(t *testing.T) {
+	expected := uuid.NewUUID()
+
+	val, reason := parseUUID(bson.Binary{Kind: 0x04, Data: []byte(expected)})
+	assert.Equal(t, expected.String(), val)
+	assert.Empty(t, reason)
+
+	val, reason = parseUUID(expected.String())
+	assert.Equal(t, expected.String(), val)
+	assert.Empty(t, reason)
+
+	_, reason = parseUUID(nil)
+	assert.Equal(t, missingUUIDReason, reason)
+
+	_, reason = parseUUID(bson.Binary{Kind: 0x04, Data: []byte("short")})
+	assert.Equal(t, invalidBinaryUUIDReason, reason)
+
+	_, reason = parseUUID("not-a-uuid")
+	assert.Equal(t, invalidStringUUIDReason, reason)
+
+	_, reason = parseUUID(strings.ToUpper(expected.String()))
+	assert.Equal(t, nonCanonicalUUIDReason, reason, "upper case uuids cannot be read back by their canonical form")
+
+	_, reason = parseUUID("urn:uuid:" + expected.String())
+	assert.Equal(t, nonCanonicalUUIDReason, reason)
+
+	_, reason = parseUUID(12345)
+	assert.Equal(t, "unexpected uuid type int", reason)
+}
+
+func TestLookupField(t *testing.T) {
+	doc := map[string]interface{}{
+		"uuid":    "top",
+		"content": bson.M{"uuid": "nested", "body": map[string]interface{}{"uuid": "deeper"}},
+	}
+
+	assert.Equal(t, "top", lookupField(doc, "uuid"))
+	assert.Equal(t, "nested", lookupField(doc, "content.uuid"))
+	assert.Equal(t, "deeper", lookupField(doc, "content.body.uuid"))
+	assert.Nil(t, lookupField(doc, "content.missing"))
+	assert.Nil(t, lookupField(doc, "uuid.nested"))
+}
+
+func TestCollectionConfigsDatabases(t *testing.T) {
+	assert.Equal(t, []string{DefaultDatabase}, CollectionConfigs{}.databases())
+
+	configs := CollectionConfigs{
+		"v2-content":  {Database: "content-store"},
+		"v1-metadata": {Database: "content-store"},
+		"methode":     {UUIDField: "content.uuid"},
+	}
+	assert.Equal(t, []string{"content-store", DefaultDatabase}, configs.databases())
+}
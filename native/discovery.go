@@ -0,0 +1,110 @@
+package native
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// CollectionSummary describes a native collection, its size, and the range of its last modified timestamps
+type CollectionSummary struct {
+	Database       string     `json:"database"`
+	Collection     string     `json:"collection"`
+	Count          int        `json:"count"`
+	TimestampField string     `json:"timestampField"`
+	Oldest         *time.Time `json:"oldest,omitempty"`
+	Newest         *time.Time `json:"newest,omitempty"`
+}
+
+// databases returns the default database and any databases configured for a collection
+func (c CollectionConfigs) databases() []string {
+	unique := map[string]struct{}{DefaultDatabase: {}}
+	for collection := range c {
+		unique[c.Get(collection).Database] = struct{}{}
+	}
+
+	databases := make([]string, 0, len(unique))
+	for db := range unique {
+		databases = append(databases, db)
+	}
+	sort.Strings(databases)
+	return databases
+}
+
+// ListCollections summarises every collection in the native databases, ordered by database and collection name
+func (tx *MongoTX) ListCollections() ([]CollectionSummary, error) {
+	summaries := make([]CollectionSummary, 0)
+
+	for _, database := range tx.collections.databases() {
+		names, err := tx.session.DB(database).CollectionNames()
+		if err != nil {
+			tx.sessionFailed(err)
+			return nil, err
+		}
+
+		sort.Strings(names)
+		for _, name := range names {
+			if strings.HasPrefix(name, "system.") {
+				continue
+			}
+
+			collection, config := tx.collection(name)
+			if config.Database != database {
+				continue
+			}
+
+			summary, err := tx.summarise(collection, config)
+			if err != nil {
+				return nil, err
+			}
+			summaries = append(summaries, summary)
+		}
+	}
+
+	return summaries, nil
+}
+
+func (tx *MongoTX) summarise(collection *mgo.Collection, config CollectionConfig) (CollectionSummary, error) {
+	summary := CollectionSummary{Database: config.Database, Collection: collection.Name, TimestampField: config.TimestampField}
+
+	count, err := tx.query(collection, findQuery{filter: bson.M{}}).Count()
+	if err != nil {
+		tx.sessionFailed(err)
+		return summary, err
+	}
+	summary.Count = count
+
+	if summary.Oldest, err = tx.boundaryTimestamp(collection, config, config.TimestampField); err != nil {
+		return summary, err
+	}
+
+	summary.Newest, err = tx.boundaryTimestamp(collection, config, config.sortByTimestamp())
+	return summary, err
+}
+
+// boundaryTimestamp returns the first timestamp in the collection for the given sort order, or nil if no document has a timestamp which can be parsed
+func (tx *MongoTX) boundaryTimestamp(collection *mgo.Collection, config CollectionConfig, sort string) (*time.Time, error) {
+	doc := map[string]interface{}{}
+	filter := bson.M{config.TimestampField: bson.M{"$exists": true}}
+
+	err := tx.query(collection, findQuery{filter: filter, projection: bson.M{config.TimestampField: 1}, sort: sort, limit: 1}).One(&doc)
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	}
+
+	if err != nil {
+		tx.sessionFailed(err)
+		return nil, err
+	}
+
+	t, ok := config.parseTimestamp(lookupField(doc, config.TimestampField))
+	if !ok {
+		return nil, nil
+	}
+
+	t = t.UTC()
+	return &t, nil
+}
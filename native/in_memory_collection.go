@@ -14,6 +14,8 @@ type InMemoryUUIDCollection struct {
 	uuids      []string
 	collection string
 	skip       int
+	skipped    skipReasons
+	ordering   string
 }
 
 type InMemoryCollectionBuilder struct {
@@ -27,21 +29,26 @@ func NewInMemoryCollectionBuilder(s3ReadWriter s3.ReadWriter) *InMemoryCollectio
 func (b *InMemoryCollectionBuilder) LoadIntoMemory(ctx context.Context, uuidCollection UUIDCollection, collection string, skip int, blist blacklist.IsBlacklisted) (UUIDCollection, error) {
 	defer uuidCollection.Close()
 
+	ordering := sortByDate
+	if ordered, ok := uuidCollection.(OrderedUUIDCollection); ok {
+		ordering = ordered.Ordering()
+	}
+
 	if skip > 0 && b.s3ReadWriter != nil {
 		log.WithField("collection", collection).Info("Attempting to retrieve uuids from S3")
-		uuids, err := readFromS3(b.s3ReadWriter, collection)
+		uuids, err := readFromS3(b.s3ReadWriter, collection, ordering, uuidCollection.Length())
 		if err != nil {
 			log.WithError(err).WithField("collection", collection).Warn("Failed to retrieve persisted file from S3")
 		} else if len(uuids) > 0 {
 			if skip < len(uuids) {
-				return &InMemoryUUIDCollection{collection: collection, skip: skip, uuids: uuids[skip:]}, nil
+				return &InMemoryUUIDCollection{collection: collection, skip: skip, uuids: uuids[skip:], ordering: ordering}, nil
 			}
 			log.WithField("skip", skip).WithField("uuids", len(uuids)).Info("Unexpected value for skip! It's greater than the total number of uuids to process. Restarting from zero.")
 			skip = 0
 		}
 	}
 
-	it := &InMemoryUUIDCollection{collection: collection, skip: skip, uuids: make([]string, 0)}
+	it := &InMemoryUUIDCollection{collection: collection, skip: skip, uuids: make([]string, 0), ordering: ordering}
 
 	if uuidCollection.Length() == 0 {
 		log.WithField("collection", collection).Warn("No data in mongo cursor for this collection.")
@@ -113,6 +120,11 @@ func (b *InMemoryCollectionBuilder) LoadIntoMemory(ctx context.Context, uuidColl
 	log.WithField("collection", collection).WithField("duration", diff.String()).Infof("Finished loading %v records from DB", len(it.uuids))
 	log.WithField("collection", collection).WithField("blacklisted", blacklisted).WithField("blank", blank).Info("Number of records blacklisted or blank.")
 
+	if reporter, ok := uuidCollection.(SkippingUUIDCollection); ok && len(reporter.Skipped()) > 0 {
+		log.WithField("collection", collection).WithField("skipped", reporter.Skipped()).Warn("Some records were skipped, as their uuids could not be read.")
+		it.skipped = reporter.Skipped()
+	}
+
 	return it, nil
 }
 
@@ -123,14 +135,35 @@ func (i *InMemoryUUIDCollection) Next() (bool, string, error) {
 	return false, i.shift(), nil
 }
 
+// Length includes the documents which were skipped while the collection was loaded
 func (i *InMemoryUUIDCollection) Length() int {
-	return len(i.uuids) + i.skip
+	return len(i.uuids) + i.skip + i.skipped.total()
+}
+
+// Skipped returns the number of documents which were skipped while the collection was loaded, by the reason they were skipped
+func (i *InMemoryUUIDCollection) Skipped() map[string]int {
+	return i.skipped
+}
+
+// Ordering returns the sort order the uuids were loaded in
+func (i *InMemoryUUIDCollection) Ordering() string {
+	return i.ordering
 }
 
 func (i *InMemoryUUIDCollection) Done() bool {
 	return len(i.uuids) == 0
 }
 
+// Position returns the number of uuids which will be published before the uuid, or false if the uuid is not waiting to be published
+func (i *InMemoryUUIDCollection) Position(uuid string) (int, bool) {
+	for pos, u := range i.uuids {
+		if u == uuid {
+			return pos, true
+		}
+	}
+	return -1, false
+}
+
 func (i *InMemoryUUIDCollection) Close() error {
 	return nil
 }
@@ -2,7 +2,6 @@ package native
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"io/ioutil"
 	"strings"
@@ -11,6 +10,7 @@ import (
 	"time"
 
 	"github.com/Financial-Times/publish-carousel/s3"
+	"github.com/pborman/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -52,6 +52,22 @@ func TestInMemoryIterator(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestInMemoryPosition(t *testing.T) {
+	it := &InMemoryUUIDCollection{collection: "collection", uuids: []string{"1", "2", "3"}}
+
+	pos, ok := it.Position("3")
+	assert.True(t, ok)
+	assert.Equal(t, 2, pos)
+
+	it.Next()
+	pos, ok = it.Position("3")
+	assert.True(t, ok)
+	assert.Equal(t, 1, pos)
+
+	_, ok = it.Position("1")
+	assert.False(t, ok, "published uuids are no longer positioned")
+}
+
 func TestLoadIntoMemory(t *testing.T) {
 	uuidCollection := &MockUUIDCollection{uuids: []string{"1", "2", "3"}}
 	uuidCollection.On("Close").Return(nil)
@@ -101,6 +117,23 @@ func TestLoadIntoMemoryIgnoresBlanks(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestLoadIntoMemoryKeepsSkippedReasons(t *testing.T) {
+	validUUID := uuid.NewUUID().String()
+	cursor := &NativeUUIDCollection{collection: "methode", iter: mockPageIter(testDoc(1, ""), testDoc(2, validUUID)), length: 2}
+
+	builder := &InMemoryCollectionBuilder{nil}
+
+	it, err := builder.LoadIntoMemory(context.Background(), cursor, "methode", 0, noopBlacklist)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{missingUUIDReason: 1}, it.(SkippingUUIDCollection).Skipped())
+	assert.Equal(t, 2, it.Length(), "skipped documents are counted in the length")
+
+	done, val, err := it.Next()
+	assert.False(t, done)
+	assert.Equal(t, validUUID, val)
+	assert.NoError(t, err)
+}
+
 func TestLoadIntoMemoryBlacklisted(t *testing.T) {
 	uuidCollection := &MockUUIDCollection{uuids: []string{"1", "2", "3"}}
 	uuidCollection.On("Close").Return(nil)
@@ -179,15 +212,14 @@ func TestLoadIntoMemoryEmptyCollection(t *testing.T) {
 }
 
 func TestPersistToS3DuringInMemoryStartup(t *testing.T) {
-	uuidCollection := &MockUUIDCollection{uuids: []string{"1", "2", "3"}}
+	uuids := []string{uuid.NewRandom().String(), uuid.NewRandom().String(), uuid.NewRandom().String()}
+	uuidCollection := &MockUUIDCollection{uuids: uuids}
 	uuidCollection.On("Close").Return(nil)
 	uuidCollection.On("Next").Return(nil)
 	uuidCollection.On("Length").Return(3)
 
-	expectedJSON, _ := json.Marshal(uuidCollection.uuids)
-
 	mockS3RW := new(s3.MockReadWriter)
-	mockS3RW.On("Write", "collection-uuids", mock.AnythingOfType("string"), expectedJSON, "application/json").Return(nil)
+	mockS3RW.On("Write", "collection-uuids", mock.AnythingOfType("string"), snapshotOf("collection", uuids...), uuidSnapshotContentType).Return(nil)
 
 	builder := &InMemoryCollectionBuilder{mockS3RW}
 
@@ -199,6 +231,7 @@ func TestPersistToS3DuringInMemoryStartup(t *testing.T) {
 func TestReadFromS3DuringInMemoryStartup(t *testing.T) {
 	uuidCollection := &MockUUIDCollection{uuids: []string{}}
 	uuidCollection.On("Close").Return(nil)
+	uuidCollection.On("Length").Return(4)
 
 	rw := new(s3.MockReadWriter)
 	rw.On("GetLatestKeyForID", "collection-uuids").Return("key", nil)
@@ -218,16 +251,15 @@ func TestReadFromS3DuringInMemoryStartup(t *testing.T) {
 }
 
 func TestReadFromS3DuringInMemoryStartupInvalidSkip(t *testing.T) {
-	uuidCollection := &MockUUIDCollection{uuids: []string{"1"}}
+	uuids := []string{uuid.NewRandom().String()}
+	uuidCollection := &MockUUIDCollection{uuids: uuids}
 	uuidCollection.On("Close").Return(nil)
 	uuidCollection.On("Next").Return(nil)
 	uuidCollection.On("Length").Return(1)
 
-	expectedJSON, _ := json.Marshal(uuidCollection.uuids)
-
 	rw := new(s3.MockReadWriter)
 	rw.On("GetLatestKeyForID", "collection-uuids").Return("key", nil)
-	rw.On("Write", "collection-uuids", mock.AnythingOfType("string"), expectedJSON, "application/json").Return(nil)
+	rw.On("Write", "collection-uuids", mock.AnythingOfType("string"), snapshotOf("collection", uuids...), uuidSnapshotContentType).Return(nil)
 
 	contentType := "application/json"
 
@@ -244,14 +276,15 @@ func TestReadFromS3DuringInMemoryStartupInvalidSkip(t *testing.T) {
 }
 
 func TestReadFromS3DuringInMemoryStartupFails(t *testing.T) {
-	uuidCollection := &MockUUIDCollection{uuids: []string{"1", "2", "3"}}
+	uuids := []string{uuid.NewRandom().String(), uuid.NewRandom().String(), uuid.NewRandom().String()}
+	uuidCollection := &MockUUIDCollection{uuids: uuids}
 	uuidCollection.On("Close").Return(nil)
 	uuidCollection.On("Next").Return(nil)
 	uuidCollection.On("Length").Return(3)
 
 	rw := new(s3.MockReadWriter)
 	rw.On("GetLatestKeyForID", "collection-uuids").Return("key", nil)
-	rw.On("Write", "collection-uuids", mock.AnythingOfType("string"), []byte(`["2","3"]`), "application/json").Return(nil)
+	rw.On("Write", "collection-uuids", mock.AnythingOfType("string"), snapshotOf("collection", uuids[1:]...), uuidSnapshotContentType).Return(nil)
 
 	contentType := "application/json"
 	rw.On("Read", "key").Return(false, ioutil.NopCloser(strings.NewReader("")), &contentType, errors.New("no s3 for you"))
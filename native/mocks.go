@@ -29,6 +29,11 @@ func (t *MockTX) ReadNativeContent(collectionID string, uuid string) (*Content,
 	return args.Get(0).(*Content), args.Error(1)
 }
 
+func (t *MockTX) ReadNativeContents(collectionID string, uuids []string) (map[string]*Content, error) {
+	args := t.Called(collectionID, uuids)
+	return args.Get(0).(map[string]*Content), args.Error(1)
+}
+
 func (t *MockTX) FindUUIDsInTimeWindow(collectionID string, start time.Time, end time.Time, batchsize int) (DBIter, int, error) {
 	args := t.Called(collectionID, start, end, batchsize)
 	return args.Get(0).(DBIter), args.Int(1), args.Error(2)
@@ -39,6 +44,21 @@ func (t *MockTX) FindUUIDs(collectionID string, skip int, batchsize int) (DBIter
 	return args.Get(0).(DBIter), args.Int(1), args.Error(2)
 }
 
+func (t *MockTX) FindUUIDsAfterID(collectionID string, afterID interface{}, skip int, limit int) (DBIter, int, error) {
+	args := t.Called(collectionID, afterID, skip, limit)
+	return args.Get(0).(DBIter), args.Int(1), args.Error(2)
+}
+
+func (t *MockTX) CountTimestamps(collectionID string) (*TimestampReport, error) {
+	args := t.Called(collectionID)
+	return args.Get(0).(*TimestampReport), args.Error(1)
+}
+
+func (t *MockTX) ListCollections() ([]CollectionSummary, error) {
+	args := t.Called()
+	return args.Get(0).([]CollectionSummary), args.Error(1)
+}
+
 func (t *MockTX) Ping(ctx context.Context) error {
 	args := t.Called(ctx)
 	return args.Error(0)
@@ -57,6 +77,19 @@ func (m *MockReader) Get(collection string, uuid string) (*Content, error) {
 	return args.Get(0).(*Content), args.Error(1)
 }
 
+type MockBatchReader struct {
+	MockReader
+}
+
+func (m *MockBatchReader) BatchSize() int {
+	return m.Called().Int(0)
+}
+
+func (m *MockBatchReader) Prefetch(collection string, uuids []string) error {
+	args := m.Called(collection, uuids)
+	return args.Error(0)
+}
+
 type MockDBIter struct {
 	mock.Mock
 }
@@ -92,6 +125,16 @@ type MockUUIDCollection struct {
 	count int
 }
 
+// NewMockUUIDCollection returns a mock collection of the given uuids, which expects any calls to Next, Length, Done and Close
+func NewMockUUIDCollection(uuids ...string) *MockUUIDCollection {
+	m := &MockUUIDCollection{uuids: uuids}
+	m.On("Next").Return(nil)
+	m.On("Length").Return()
+	m.On("Done").Return()
+	m.On("Close").Return(nil)
+	return m
+}
+
 func (m *MockUUIDCollection) Next() (bool, string, error) {
 	args := m.Called()
 
@@ -119,3 +162,21 @@ func (m *MockUUIDCollection) Done() bool {
 func (m *MockUUIDCollection) Close() error {
 	return m.Called().Error(0)
 }
+
+// MockPositionedUUIDCollection is a mock collection which can tell where a uuid sits among the uuids it has not yet returned
+type MockPositionedUUIDCollection struct {
+	*MockUUIDCollection
+}
+
+func NewMockPositionedUUIDCollection(uuids ...string) *MockPositionedUUIDCollection {
+	return &MockPositionedUUIDCollection{NewMockUUIDCollection(uuids...)}
+}
+
+func (m *MockPositionedUUIDCollection) Position(uuid string) (int, bool) {
+	for pos, u := range m.uuids[m.count:] {
+		if u == uuid {
+			return pos, true
+		}
+	}
+	return -1, false
+}
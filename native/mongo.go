@@ -3,6 +3,7 @@ package native
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"errors"
@@ -10,19 +11,18 @@ import (
 	"net"
 	"strings"
 
-	log "github.com/sirupsen/logrus"
 	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
 )
 
-var expectedConnections = 1
-var connections = 0
-
 const sortByDate = "-content.lastModified"
+const sortByID = "_id"
 
 type Content struct {
 	Body           map[string]interface{} `bson:"content"`
 	ContentType    string                 `bson:"content-type"`
 	OriginSystemID string                 `bson:"origin-system-id"`
+	Collection     string                 `bson:"-"`
 }
 
 // DB contains database functions
@@ -34,90 +34,209 @@ type DB interface {
 // TX contains database transaction functions
 type TX interface {
 	ReadNativeContent(collectionId string, uuid string) (*Content, error)
+	ReadNativeContents(collectionId string, uuids []string) (map[string]*Content, error)
 	FindUUIDsInTimeWindow(collectionId string, start time.Time, end time.Time, batchsize int) (DBIter, int, error)
 	FindUUIDs(collectionId string, skip int, batchsize int) (DBIter, int, error)
+	FindUUIDsAfterID(collectionId string, afterID interface{}, skip int, limit int) (DBIter, int, error)
+	CountTimestamps(collectionId string) (*TimestampReport, error)
+	ListCollections() ([]CollectionSummary, error)
 	Ping(ctx context.Context) error
 	Close()
 }
 
 // MongoTX wraps a mongo session
 type MongoTX struct {
-	session *mgo.Session
+	session     *mgo.Session
+	collections CollectionConfigs
+	db          *MongoDB
+	readConcern string
 }
 
 // MongoDB wraps a mango mongo session
 type MongoDB struct {
-	Urls    string
-	Timeout int
-	lock    *sync.Mutex
-	session *mgo.Session
+	Urls        string
+	Timeout     int
+	Collections CollectionConfigs
+	ReadOptions ReadOptions
+	lock        *sync.Mutex
+	session     *mgo.Session
+
+	dial           func(urls string, timeout time.Duration) (*mgo.Session, error)
+	ping           func(session *mgo.Session) error
+	replicationLag func(session *mgo.Session) (time.Duration, error)
+	now            func() time.Time
+	suspect        int32
+	openSessions   int64
+	lastChecked    time.Time
+	backoff        time.Duration
+	lagChecked     time.Time
+	lag            time.Duration
+	lagErr         error
+	fallingBack    map[ReadOptions]bool
+	stats          MongoStats
 }
 
-func NewMongoDatabase(connection string, timeout int) DB {
-	return &MongoDB{Urls: connection, Timeout: timeout, lock: &sync.Mutex{}}
+func NewMongoDatabase(connection string, timeout int, collections CollectionConfigs, readOptions ReadOptions) DB {
+	mgo.SetStats(true)
+	return &MongoDB{
+		Urls:           connection,
+		Timeout:        timeout,
+		Collections:    collections,
+		ReadOptions:    readOptions,
+		lock:           &sync.Mutex{},
+		dial:           dialMongo,
+		ping:           pingSession,
+		replicationLag: replicationLag,
+		now:            time.Now,
+	}
 }
 
+// Open copies the mongo session for a new transaction, dialling mongo if there is no healthy session
 func (db *MongoDB) Open() (TX, error) {
+	return db.open(db.ReadOptions)
+}
+
+func (db *MongoDB) open(opts ReadOptions) (TX, error) {
+	db.checkSessionIfDue()
+
 	db.lock.Lock()
-	defer db.lock.Unlock()
+	session, err := db.ensureSession()
+	if err != nil {
+		db.lock.Unlock()
+		return nil, err
+	}
+	copy := session.Copy()
+	db.lock.Unlock()
 
-	if db.session == nil {
-		session, err := mgo.DialWithTimeout(db.Urls, time.Duration(db.Timeout)*time.Millisecond)
-		if err != nil {
-			log.WithError(err).Error("Session error")
-			return nil, err
-		}
+	db.checkLagIfDue(session, opts)
 
-		db.session = session
-		connections++
+	db.lock.Lock()
+	mode := db.readMode(opts)
+	db.lock.Unlock()
 
-		if connections > expectedConnections {
-			log.Warnf("There are more MongoDB connections opened than expected! Are you sure this is what you want? Open connections: %v, expected %v.", connections, expectedConnections)
-		}
-	}
+	copy.SetMode(mode, true)
 
-	return &MongoTX{db.session.Copy()}, nil
+	atomic.AddInt64(&db.openSessions, 1)
+	return &MongoTX{session: copy, collections: db.Collections, db: db, readConcern: opts.ReadConcern}, nil
 }
 
-// FindUUIDsInTimeWindow queries mongo for a list of uuids and returns an iterator
+func (tx *MongoTX) collection(collectionID string) (*mgo.Collection, CollectionConfig) {
+	config := tx.collections.Get(collectionID)
+	return tx.session.DB(config.Database).C(collectionID), config
+}
+
+// FindUUIDsInTimeWindow queries mongo for a list of uuids and returns an iterator, with the number of documents in the time window
 func (tx *MongoTX) FindUUIDsInTimeWindow(collectionID string, start time.Time, end time.Time, batchsize int) (DBIter, int, error) {
-	collection := tx.session.DB("native-store").C(collectionID)
+	collection, config := tx.collection(collectionID)
 
-	query, projection := findUUIDsForTimeWindowQueryElements(start, end)
-	find := collection.Find(query).Select(projection).Batch(batchsize)
+	query, projection := findUUIDsForTimeWindowQueryElements(config, start, end)
+	find := tx.query(collection, findQuery{filter: query, projection: projection, batchSize: batchsize})
 
-	count, err := find.Count()
-	return find.Iter(), count, err
+	count, err := tx.countInTimeWindow(collection, config, query, start, end)
+	tx.sessionFailed(err)
+	return newTimeWindowIter(newUUIDFieldIter(find.Iter(), config), config, start, end), count, err
+}
+
+// countInTimeWindow counts the documents in the time window. The query for string timestamps matches a wider window, so their timestamps are read and only those inside the time window are counted.
+func (tx *MongoTX) countInTimeWindow(collection *mgo.Collection, config CollectionConfig, query bson.M, start time.Time, end time.Time) (int, error) {
+	if config.TimestampType != StringTimestampType {
+		return tx.query(collection, findQuery{filter: query}).Count()
+	}
+
+	iter := tx.query(collection, findQuery{filter: query, projection: bson.M{config.TimestampField: 1}, batchSize: 1000}).Iter()
+	defer iter.Close()
+
+	return countInTimeWindow(newTimeWindowIter(iter, config, start, end))
+}
+
+// CountTimestamps reads the timestamp of every document in the collection, and reports how many are missing or cannot be parsed
+func (tx *MongoTX) CountTimestamps(collectionID string) (*TimestampReport, error) {
+	collection, config := tx.collection(collectionID)
+
+	iter := tx.query(collection, findQuery{filter: bson.M{}, projection: bson.M{config.TimestampField: 1}, batchSize: 1000}).Iter()
+	defer iter.Close()
+
+	report, err := countTimestamps(collectionID, config, iter)
+	tx.sessionFailed(err)
+	return report, err
 }
 
 // FindUUIDs returns all uuids for a collection sorted by lastodified date, if no lastmodified exists records are returned at the end of the list
 func (tx *MongoTX) FindUUIDs(collectionID string, skip int, batchsize int) (DBIter, int, error) {
-	collection := tx.session.DB("native-store").C(collectionID)
+	collection, config := tx.collection(collectionID)
 
-	query, projection := findUUIDsQueryElements()
-	find := collection.Find(query).Select(projection).Sort(sortByDate).Batch(batchsize)
-
-	if skip > 0 {
-		find.Skip(skip)
-	}
+	query, projection := findUUIDsQueryElements(config)
+	find := tx.query(collection, findQuery{filter: query, projection: projection, sort: config.sortByTimestamp(), skip: skip, batchSize: batchsize})
 
 	count, err := find.Count()
-	return find.Iter(), count + skip, err // add count to skip as this correctly computes the total size of the cursor
+	tx.sessionFailed(err)
+	return &sortedIter{DBIter: newUUIDFieldIter(find.Iter(), config), sort: config.sortByTimestamp()}, count + skip, err // add count to skip as this correctly computes the total size of the cursor
+}
+
+// FindUUIDsAfterID returns a page of at most limit uuids for a collection, sorted by _id and starting after the provided _id, and the total number of documents in the collection
+func (tx *MongoTX) FindUUIDsAfterID(collectionID string, afterID interface{}, skip int, limit int) (DBIter, int, error) {
+	collection, config := tx.collection(collectionID)
+
+	query, projection := findUUIDsAfterIDQueryElements(config, afterID)
+	find := tx.query(collection, findQuery{filter: query, projection: projection, sort: sortByID, skip: skip, limit: limit, batchSize: limit})
+
+	count, err := tx.query(collection, findQuery{filter: bson.M{}}).Count()
+	tx.sessionFailed(err)
+	return newUUIDFieldIter(find.Iter(), config), count, err
 }
 
 // ReadNativeContent queries mongo for a uuid and returns the native document
 func (tx *MongoTX) ReadNativeContent(collectionID string, uuid string) (*Content, error) {
-	collection := tx.session.DB("native-store").C(collectionID)
+	collection, config := tx.collection(collectionID)
 
-	query := readNativeContentQuery(uuid)
-	find := collection.Find(query)
+	query := readNativeContentQuery(config, uuid)
+	find := tx.query(collection, findQuery{filter: query})
 
 	result := &Content{}
 	err := find.One(result)
+	tx.sessionFailed(err)
 
+	result.Collection = collectionID
 	return result, err
 }
 
+// ReadNativeContents queries mongo for all the given uuids at once, and returns the native documents which were found by uuid
+func (tx *MongoTX) ReadNativeContents(collectionID string, uuids []string) (map[string]*Content, error) {
+	collection, config := tx.collection(collectionID)
+
+	query := readNativeContentsQuery(config, uuids)
+	iter := tx.query(collection, findQuery{filter: query, batchSize: len(uuids)}).Iter()
+
+	contents := make(map[string]*Content)
+
+	var unmarshalErr error
+	var raw bson.Raw
+	for iter.Next(&raw) {
+		doc := map[string]interface{}{}
+		content := &Content{}
+		if unmarshalErr = raw.Unmarshal(&doc); unmarshalErr != nil {
+			break
+		}
+
+		if unmarshalErr = raw.Unmarshal(content); unmarshalErr != nil {
+			break
+		}
+		content.Collection = collectionID
+
+		if uuid, reason := parseUUID(lookupField(doc, config.UUIDField)); reason == "" {
+			contents[uuid] = content
+		}
+	}
+
+	err := iter.Close() // always close the cursor, so that its socket is released even if a document could not be read
+	tx.sessionFailed(err)
+
+	if unmarshalErr != nil {
+		return nil, unmarshalErr
+	}
+	return contents, err
+}
+
 func CheckMongoURLs(providedMongoUrls string, expectedMongoNodeCount int) error {
 	if providedMongoUrls == "" {
 		return errors.New("MongoDB urls are missing")
@@ -153,6 +272,7 @@ func (tx *MongoTX) Ping(ctx context.Context) error {
 	case <-ctx.Done():
 		return ctx.Err()
 	case err := <-ping:
+		tx.sessionFailed(err)
 		return err
 	}
 }
@@ -160,11 +280,20 @@ func (tx *MongoTX) Ping(ctx context.Context) error {
 // Close closes the transaction
 func (tx *MongoTX) Close() {
 	tx.session.Close()
+	if tx.db != nil {
+		atomic.AddInt64(&tx.db.openSessions, -1)
+	}
 }
 
 // Close closes the entire database connection
 func (db *MongoDB) Close() {
-	db.session.Close()
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if db.session != nil {
+		db.session.Close()
+		db.session = nil
+	}
 }
 
 type DBIter interface {
@@ -174,3 +303,41 @@ type DBIter interface {
 	Timeout() bool
 	Close() error
 }
+
+// sortedIter records the sort order of the query, so that it can be checked against uuids which were persisted by a previous query
+type sortedIter struct {
+	DBIter
+	sort string
+}
+
+// orderingOf returns the sort order of the iterator, which is by the default timestamp unless the query recorded its own
+func orderingOf(iter DBIter) string {
+	if sorted, ok := iter.(*sortedIter); ok {
+		return sorted.sort
+	}
+	return sortByDate
+}
+
+// uuidFieldIter moves the configured uuid field of each document to the "uuid" key, so that uuids can be read from every collection in the same way
+type uuidFieldIter struct {
+	*mgo.Iter
+	field string
+}
+
+func newUUIDFieldIter(iter *mgo.Iter, config CollectionConfig) DBIter {
+	if config.UUIDField == DefaultUUIDField {
+		return iter
+	}
+	return &uuidFieldIter{Iter: iter, field: config.UUIDField}
+}
+
+func (i *uuidFieldIter) Next(result interface{}) bool {
+	if !i.Iter.Next(result) {
+		return false
+	}
+
+	if doc, ok := result.(*map[string]interface{}); ok {
+		(*doc)[DefaultUUIDField] = lookupField(*doc, i.field)
+	}
+	return true
+}
@@ -22,11 +22,11 @@ func startMongo(t *testing.T) DB {
 		t.Fatal("Please set the environment variable MONGO_TEST_URL to run mongo integration tests (e.g. MONGO_TEST_URL=localhost:27017). Alternatively, run `go test -short` to skip them.")
 	}
 
-	return NewMongoDatabase(mongoURL, 30000)
+	return NewMongoDatabase(mongoURL, 30000, CollectionConfigs{}, ReadOptions{})
 }
 
 func TestCreateDB(t *testing.T) {
-	db := NewMongoDatabase("test-url", 30000)
+	db := NewMongoDatabase("test-url", 30000, CollectionConfigs{}, ReadOptions{})
 	mongo := db.(*MongoDB)
 	assert.Equal(t, "test-url", mongo.Urls)
 	assert.Equal(t, 30000, mongo.Timeout)
@@ -87,7 +87,7 @@ func TestFindByUUID(t *testing.T) {
 			continue
 		}
 
-		if parseBinaryUUID(val) == testUUID {
+		if uuidOf(val) == testUUID {
 			found = true
 		}
 	}
@@ -121,13 +121,51 @@ func TestFindUUIDsDateSort(t *testing.T) {
 		result := map[string]interface{}{}
 		iter.Next(&result)
 		val := result["uuid"]
-		actualUUIDs = append(actualUUIDs, parseBinaryUUID(val))
+		actualUUIDs = append(actualUUIDs, uuidOf(val))
 	}
 	assert.Equal(t, testUUIDs, actualUUIDs, "uuids do not match therefore they are not in expected descending date order")
 
 	cleanupTestContent(t, db, testUUIDs...)
 }
 
+func TestFindUUIDsAfterID(t *testing.T) {
+	db := startMongo(t)
+	defer db.Close()
+
+	tx, err := db.Open()
+	assert.NoError(t, err)
+	defer tx.Close()
+
+	testUUID1 := uuid.NewUUID().String()
+	testUUID2 := uuid.NewUUID().String()
+	insertTestContent(t, db, testUUID1, time.Now())
+	insertTestContent(t, db, testUUID2, time.Now().Add(-10*time.Second))
+
+	var lastID interface{}
+	var actualUUIDs []string
+	for {
+		iter, count, err := tx.FindUUIDsAfterID("methode", lastID, 0, 1)
+		assert.NoError(t, err)
+		assert.NotEqual(t, 0, count)
+
+		result := map[string]interface{}{}
+		if !iter.Next(&result) {
+			iter.Close()
+			break
+		}
+		iter.Close()
+
+		lastID = result["_id"]
+		actualUUIDs = append(actualUUIDs, uuidOf(result["uuid"]))
+	}
+
+	assert.Contains(t, actualUUIDs, testUUID1)
+	assert.Contains(t, actualUUIDs, testUUID2)
+	assert.Equal(t, testUUID2, actualUUIDs[len(actualUUIDs)-1], "uuids should be in insertion (_id) order")
+
+	cleanupTestContent(t, db, testUUID1, testUUID2)
+}
+
 func TestFindByTimeWindow(t *testing.T) {
 	db := startMongo(t)
 	defer db.Close()
@@ -156,11 +194,11 @@ func TestFindByTimeWindow(t *testing.T) {
 		iter.Next(&result)
 
 		t.Log(result)
-		if parseBinaryUUID(result["uuid"]) == testUUID {
+		if uuidOf(result["uuid"]) == testUUID {
 			found = true
 		}
 
-		if parseBinaryUUID(result["uuid"]) == testUUID2 {
+		if uuidOf(result["uuid"]) == testUUID2 {
 			t.Log("Should not find this uuid as it is outside the window.")
 			t.Fail()
 		}
@@ -191,6 +229,40 @@ func TestReadNativeContent(t *testing.T) {
 	cleanupTestContent(t, db, testUUID)
 }
 
+func TestListCollections(t *testing.T) {
+	db := startMongo(t)
+	defer db.Close()
+
+	tx, err := db.Open()
+	assert.NoError(t, err)
+	defer tx.Close()
+
+	testUUID := uuid.NewUUID().String()
+	lastModified := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	insertTestContent(t, db, testUUID, lastModified)
+
+	summaries, err := tx.ListCollections()
+	assert.NoError(t, err)
+
+	found := false
+	for _, summary := range summaries {
+		assert.False(t, strings.HasPrefix(summary.Collection, "system."))
+		if summary.Collection != "methode" {
+			continue
+		}
+
+		found = true
+		assert.Equal(t, "native-store", summary.Database)
+		assert.NotEqual(t, 0, summary.Count)
+		assert.NotNil(t, summary.Oldest)
+		assert.NotNil(t, summary.Newest)
+		assert.False(t, summary.Newest.Before(lastModified))
+	}
+
+	assert.True(t, found)
+	cleanupTestContent(t, db, testUUID)
+}
+
 func TestPing(t *testing.T) {
 	db := startMongo(t)
 	defer db.Close()
@@ -257,3 +329,8 @@ func TestCheckMongoURLsMissingHost(t *testing.T) {
 	err := CheckMongoURLs(":1234", 1)
 	assert.Error(t, err)
 }
+
+func uuidOf(val interface{}) string {
+	uuid, _ := parseUUID(val)
+	return uuid
+}
@@ -1,18 +1,56 @@
 package native
 
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Financial-Times/publish-carousel/tracing"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
 type Reader interface {
 	Get(collection string, uuid string) (*Content, error)
 }
 
+// ContextReader is implemented by readers which record each read as a span of the trace of the context
+type ContextReader interface {
+	GetContext(ctx context.Context, collection string, uuid string) (*Content, error)
+}
+
+// GetContext reads the native content with the reader, within the trace of the context if the reader supports it
+func GetContext(ctx context.Context, reader Reader, collection string, uuid string) (*Content, error) {
+	if r, ok := reader.(ContextReader); ok {
+		return r.GetContext(ctx, collection, uuid)
+	}
+	return reader.Get(collection, uuid)
+}
+
 type MongoReader struct {
-	mongo DB
+	mongo  DB
+	tracer trace.Tracer
 }
 
-func NewMongoNativeReader(mongo DB) Reader {
-	return &MongoReader{mongo}
+// NewMongoNativeReader returns a reader which records a span of the tracer provider for every read from mongo
+func NewMongoNativeReader(mongo DB, tracerProvider trace.TracerProvider) Reader {
+	return &MongoReader{mongo: mongo, tracer: tracing.Tracer(tracerProvider)}
 }
 
 func (m *MongoReader) Get(collection string, uuid string) (*Content, error) {
+	return m.GetContext(context.Background(), collection, uuid)
+}
+
+// GetContext reads the native content from mongo, within a span of the trace of the context
+func (m *MongoReader) GetContext(ctx context.Context, collection string, uuid string) (*Content, error) {
+	_, span := m.tracer.Start(ctx, tracing.ReadSpan, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attribute.String("collection", collection), attribute.String("uuid", uuid)))
+	content, err := m.read(collection, uuid)
+	tracing.End(span, err)
+	return content, err
+}
+
+func (m *MongoReader) read(collection string, uuid string) (*Content, error) {
 	tx, err := m.mongo.Open()
 
 	if err != nil {
@@ -28,3 +66,129 @@ func (m *MongoReader) Get(collection string, uuid string) (*Content, error) {
 
 	return content, nil
 }
+
+// WithReadOptions returns a reader which reads native content with the given read options, if the database supports them
+func (m *MongoReader) WithReadOptions(opts ReadOptions) Reader {
+	return &MongoReader{mongo: withReadOptions(m.mongo, opts), tracer: m.tracer}
+}
+
+func withReadOptions(mongo DB, opts ReadOptions) DB {
+	if db, ok := mongo.(ReadOptionsDB); ok && !opts.IsZero() {
+		return db.WithReadOptions(opts)
+	}
+	return mongo
+}
+
+// BatchReader is a Reader which can prefetch the native content for several uuids at once
+type BatchReader interface {
+	Reader
+	BatchSize() int
+	Prefetch(collection string, uuids []string) error
+}
+
+const maxPrefetchedContent = 10000
+
+type prefetchedContent struct {
+	content *Content
+	fetched time.Time
+}
+
+// BatchingMongoReader serves native content from documents which have been prefetched in batches, using a single query per batch.
+// Prefetched content is served at most once, and only if it was fetched within maxAge; otherwise the content is read individually.
+type BatchingMongoReader struct {
+	*MongoReader
+	batchSize  int
+	maxAge     time.Duration
+	lock       *sync.Mutex
+	prefetched map[string]prefetchedContent
+}
+
+// NewBatchingMongoNativeReader returns a batching reader which records a span of the tracer provider for every uuid it reads individually
+func NewBatchingMongoNativeReader(mongo DB, batchSize int, maxAge time.Duration, tracerProvider trace.TracerProvider) BatchReader {
+	return newBatchingMongoReader(&MongoReader{mongo: mongo, tracer: tracing.Tracer(tracerProvider)}, batchSize, maxAge)
+}
+
+func newBatchingMongoReader(reader *MongoReader, batchSize int, maxAge time.Duration) *BatchingMongoReader {
+	return &BatchingMongoReader{MongoReader: reader, batchSize: batchSize, maxAge: maxAge, lock: &sync.Mutex{}, prefetched: make(map[string]prefetchedContent)}
+}
+
+func (b *BatchingMongoReader) BatchSize() int {
+	return b.batchSize
+}
+
+// Prefetch reads the native content for the given uuids, in batches of at most BatchSize uuids
+func (b *BatchingMongoReader) Prefetch(collection string, uuids []string) error {
+	if len(uuids) == 0 {
+		return nil
+	}
+
+	tx, err := b.mongo.Open()
+	if err != nil {
+		return err
+	}
+	defer tx.Close()
+
+	for start := 0; start < len(uuids); start += b.batchSize {
+		end := start + b.batchSize
+		if end > len(uuids) {
+			end = len(uuids)
+		}
+
+		contents, err := tx.ReadNativeContents(collection, uuids[start:end])
+		if err != nil {
+			return err
+		}
+
+		b.store(collection, contents, time.Now())
+	}
+
+	return nil
+}
+
+func (b *BatchingMongoReader) store(collection string, contents map[string]*Content, fetched time.Time) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	for key, entry := range b.prefetched {
+		if fetched.Sub(entry.fetched) > b.maxAge {
+			delete(b.prefetched, key)
+		}
+	}
+
+	for uuid, content := range contents {
+		if len(b.prefetched) >= maxPrefetchedContent {
+			log.WithField("collection", collection).Warn("Too much prefetched native content is waiting to be published, content will be read individually.")
+			return
+		}
+		b.prefetched[prefetchKey(collection, uuid)] = prefetchedContent{content: content, fetched: fetched}
+	}
+}
+
+func (b *BatchingMongoReader) Get(collection string, uuid string) (*Content, error) {
+	return b.GetContext(context.Background(), collection, uuid)
+}
+
+// GetContext returns the prefetched content for the uuid if available, or otherwise reads it from mongo, within a span of the trace of the context
+func (b *BatchingMongoReader) GetContext(ctx context.Context, collection string, uuid string) (*Content, error) {
+	key := prefetchKey(collection, uuid)
+
+	b.lock.Lock()
+	entry, ok := b.prefetched[key]
+	delete(b.prefetched, key)
+	b.lock.Unlock()
+
+	if ok && time.Since(entry.fetched) <= b.maxAge {
+		return entry.content, nil
+	}
+
+	return b.MongoReader.GetContext(ctx, collection, uuid)
+}
+
+// WithReadOptions returns a batching reader which reads native content with the given read options. Its prefetched content is kept separately.
+func (b *BatchingMongoReader) WithReadOptions(opts ReadOptions) Reader {
+	return newBatchingMongoReader(&MongoReader{mongo: withReadOptions(b.mongo, opts), tracer: b.tracer}, b.batchSize, b.maxAge)
+}
+
+func prefetchKey(collection string, uuid string) string {
+	return collection + "/" + uuid
+}
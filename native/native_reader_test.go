@@ -1,10 +1,18 @@
 package native
 
 import (
+	"context"
 	"errors"
 	"testing"
+	"time"
 
+	"github.com/Financial-Times/publish-carousel/tracing"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 func TestNativeReaderGet(t *testing.T) {
@@ -16,7 +24,7 @@ func TestNativeReaderGet(t *testing.T) {
 
 	testContent := Content{Body: make(map[string]interface{}), ContentType: "application/vnd.expect-this"}
 
-	reader := NewMongoNativeReader(mockDb)
+	reader := NewMongoNativeReader(mockDb, nil)
 
 	mockDb.On("Open").Return(mockTx, nil)
 	mockTx.On("Close")
@@ -32,6 +40,39 @@ func TestNativeReaderGet(t *testing.T) {
 	assert.Equal(t, "", actual.OriginSystemID)
 }
 
+func TestNativeReaderRecordsReadSpans(t *testing.T) {
+	mockDb := new(MockDB)
+	mockTx := new(MockTX)
+
+	mockDb.On("Open").Return(mockTx, nil)
+	mockTx.On("Close")
+	mockTx.On("ReadNativeContent", "methode", "uuid-1").Return(&Content{Body: make(map[string]interface{})}, nil)
+	mockTx.On("ReadNativeContent", "methode", "uuid-2").Return(&Content{}, errors.New("computer says no"))
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	reader := NewMongoNativeReader(mockDb, provider)
+
+	ctx, publish := provider.Tracer("test").Start(context.Background(), tracing.PublishSpan)
+	_, err := GetContext(ctx, reader, "methode", "uuid-1")
+	assert.NoError(t, err)
+
+	_, err = GetContext(ctx, reader, "methode", "uuid-2")
+	assert.Error(t, err)
+	publish.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+
+	assert.Equal(t, tracing.ReadSpan, spans[0].Name())
+	assert.Equal(t, publish.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+
+	mockDb.AssertExpectations(t)
+	mockTx.AssertExpectations(t)
+}
+
 func TestNativeReaderGetWithOrigin(t *testing.T) {
 	mockDb := new(MockDB)
 	mockTx := new(MockTX)
@@ -41,7 +82,7 @@ func TestNativeReaderGetWithOrigin(t *testing.T) {
 
 	testContent := Content{Body: make(map[string]interface{}), ContentType: "application/vnd.expect-this", OriginSystemID: "http://cmdb.ft.com/systems/cct"}
 
-	reader := NewMongoNativeReader(mockDb)
+	reader := NewMongoNativeReader(mockDb, nil)
 
 	mockDb.On("Open").Return(mockTx, nil)
 	mockTx.On("Close")
@@ -64,7 +105,7 @@ func TestNativeReaderMongoOpenFails(t *testing.T) {
 	testCollection := "testing-123"
 	testUUID := "fake-uuid"
 
-	reader := NewMongoNativeReader(mockDb)
+	reader := NewMongoNativeReader(mockDb, nil)
 
 	mockDb.On("Open").Return(mockTx, errors.New("mongo broke mate"))
 
@@ -82,7 +123,7 @@ func TestNativeReaderMongoReadFails(t *testing.T) {
 	testCollection := "testing-123"
 	testUUID := "fake-uuid"
 
-	reader := NewMongoNativeReader(mockDb)
+	reader := NewMongoNativeReader(mockDb, nil)
 
 	mockDb.On("Open").Return(mockTx, nil)
 	mockTx.On("Close")
@@ -94,3 +135,119 @@ func TestNativeReaderMongoReadFails(t *testing.T) {
 	mockDb.AssertExpectations(t)
 	mockTx.AssertExpectations(t)
 }
+
+func TestBatchingReaderServesPrefetchedContent(t *testing.T) {
+	mockDb := new(MockDB)
+	mockTx := new(MockTX)
+
+	uuids := []string{"uuid-1", "uuid-2", "uuid-3"}
+	content1 := &Content{Body: map[string]interface{}{"uuid": "uuid-1"}}
+	content2 := &Content{Body: map[string]interface{}{"uuid": "uuid-2"}}
+	content3 := &Content{Body: map[string]interface{}{"uuid": "uuid-3"}}
+
+	mockDb.On("Open").Return(mockTx, nil)
+	mockTx.On("Close")
+	mockTx.On("ReadNativeContents", "methode", []string{"uuid-1", "uuid-2"}).Return(map[string]*Content{"uuid-1": content1, "uuid-2": content2}, nil)
+	mockTx.On("ReadNativeContents", "methode", []string{"uuid-3"}).Return(map[string]*Content{"uuid-3": content3}, nil)
+
+	reader := NewBatchingMongoNativeReader(mockDb, 2, time.Minute, nil)
+	assert.Equal(t, 2, reader.BatchSize())
+
+	err := reader.Prefetch("methode", uuids)
+	assert.NoError(t, err)
+
+	for i, expected := range []*Content{content1, content2, content3} {
+		actual, err := reader.Get("methode", uuids[i])
+		assert.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	}
+
+	mockDb.AssertNumberOfCalls(t, "Open", 1)
+	mockTx.AssertNotCalled(t, "ReadNativeContent", mock.Anything, mock.Anything)
+	mock.AssertExpectationsForObjects(t, mockDb, mockTx)
+}
+
+func TestBatchingReaderReadsMissingContentIndividually(t *testing.T) {
+	mockDb := new(MockDB)
+	mockTx := new(MockTX)
+
+	content := &Content{Body: map[string]interface{}{"uuid": "uuid-2"}}
+
+	mockDb.On("Open").Return(mockTx, nil)
+	mockTx.On("Close")
+	mockTx.On("ReadNativeContents", "methode", []string{"uuid-1", "uuid-2"}).Return(map[string]*Content{"uuid-1": {}}, nil)
+	mockTx.On("ReadNativeContent", "methode", "uuid-2").Return(content, nil)
+
+	reader := NewBatchingMongoNativeReader(mockDb, 10, time.Minute, nil)
+	assert.NoError(t, reader.Prefetch("methode", []string{"uuid-1", "uuid-2"}))
+
+	actual, err := reader.Get("methode", "uuid-2")
+	assert.NoError(t, err)
+	assert.Equal(t, content, actual)
+
+	mock.AssertExpectationsForObjects(t, mockDb, mockTx)
+}
+
+func TestBatchingReaderDoesNotServeStaleContent(t *testing.T) {
+	mockDb := new(MockDB)
+	mockTx := new(MockTX)
+
+	stale := &Content{Body: map[string]interface{}{"title": "stale"}}
+	fresh := &Content{Body: map[string]interface{}{"title": "fresh"}}
+
+	mockDb.On("Open").Return(mockTx, nil)
+	mockTx.On("Close")
+	mockTx.On("ReadNativeContents", "methode", []string{"uuid-1"}).Return(map[string]*Content{"uuid-1": stale}, nil)
+	mockTx.On("ReadNativeContent", "methode", "uuid-1").Return(fresh, nil)
+
+	reader := NewBatchingMongoNativeReader(mockDb, 10, time.Millisecond, nil)
+	assert.NoError(t, reader.Prefetch("methode", []string{"uuid-1"}))
+
+	time.Sleep(5 * time.Millisecond)
+
+	actual, err := reader.Get("methode", "uuid-1")
+	assert.NoError(t, err)
+	assert.Equal(t, fresh, actual)
+
+	mock.AssertExpectationsForObjects(t, mockDb, mockTx)
+}
+
+func TestBatchingReaderServesPrefetchedContentOnce(t *testing.T) {
+	mockDb := new(MockDB)
+	mockTx := new(MockTX)
+
+	prefetched := &Content{Body: map[string]interface{}{"title": "prefetched"}}
+	reread := &Content{Body: map[string]interface{}{"title": "reread"}}
+
+	mockDb.On("Open").Return(mockTx, nil)
+	mockTx.On("Close")
+	mockTx.On("ReadNativeContents", "methode", []string{"uuid-1"}).Return(map[string]*Content{"uuid-1": prefetched}, nil)
+	mockTx.On("ReadNativeContent", "methode", "uuid-1").Return(reread, nil)
+
+	reader := NewBatchingMongoNativeReader(mockDb, 10, time.Minute, nil)
+	assert.NoError(t, reader.Prefetch("methode", []string{"uuid-1"}))
+
+	actual, err := reader.Get("methode", "uuid-1")
+	assert.NoError(t, err)
+	assert.Equal(t, prefetched, actual)
+
+	actual, err = reader.Get("methode", "uuid-1")
+	assert.NoError(t, err)
+	assert.Equal(t, reread, actual)
+
+	mock.AssertExpectationsForObjects(t, mockDb, mockTx)
+}
+
+func TestBatchingReaderPrefetchFails(t *testing.T) {
+	mockDb := new(MockDB)
+	mockTx := new(MockTX)
+
+	mockDb.On("Open").Return(mockTx, nil)
+	mockTx.On("Close")
+	mockTx.On("ReadNativeContents", "methode", []string{"uuid-1"}).Return(map[string]*Content{}, errors.New("computer says no"))
+
+	reader := NewBatchingMongoNativeReader(mockDb, 10, time.Minute, nil)
+	assert.EqualError(t, reader.Prefetch("methode", []string{"uuid-1"}), "computer says no")
+
+	mock.AssertExpectationsForObjects(t, mockDb, mockTx)
+}
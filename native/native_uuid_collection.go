@@ -9,10 +9,7 @@ import (
 
 	"github.com/Financial-Times/publish-carousel/blacklist"
 	"github.com/Financial-Times/publish-carousel/s3"
-	"github.com/pborman/uuid"
 	log "github.com/sirupsen/logrus"
-
-	"gopkg.in/mgo.v2/bson"
 )
 
 const mongoCursorTimeout = 10 * time.Minute
@@ -25,10 +22,34 @@ type UUIDCollection interface {
 	Done() bool
 }
 
+// PositionedUUIDCollection is implemented by collections which know where a uuid sits among the uuids still to be published
+type PositionedUUIDCollection interface {
+	Position(uuid string) (int, bool)
+}
+
+// OrderedUUIDCollection is implemented by collections which know the sort order of their uuids
+type OrderedUUIDCollection interface {
+	Ordering() string
+}
+
+// ResumableUUIDCollection is implemented by collections which can be reopened after the last uuid returned by Next, using the token it returns
+type ResumableUUIDCollection interface {
+	ResumeToken() string
+}
+
+const (
+	// InMemoryUUIDCollectionType loads every uuid of the collection into memory before publishing, sorted by last modified date
+	InMemoryUUIDCollectionType = "inMemory"
+	// StreamingUUIDCollectionType streams the uuids of the collection in pages, sorted by _id
+	StreamingUUIDCollectionType = "streaming"
+)
+
 type NativeUUIDCollection struct {
 	collection string
 	iter       DBIter
 	length     int
+	skipped    skipReasons
+	ordering   string
 }
 
 type NativeUUIDCollectionBuilder struct {
@@ -41,6 +62,13 @@ func NewNativeUUIDCollectionBuilder(mongo DB, rw s3.ReadWriter, isBlacklisted bl
 	return &NativeUUIDCollectionBuilder{db: mongo, isBlacklisted: isBlacklisted, inMemory: NewInMemoryCollectionBuilder(rw)}
 }
 
+// WithReadOptions returns a builder which reads uuids with the given read options, if the database supports them
+func (b *NativeUUIDCollectionBuilder) WithReadOptions(opts ReadOptions) *NativeUUIDCollectionBuilder {
+	builder := *b
+	builder.db = withReadOptions(b.db, opts)
+	return &builder
+}
+
 func (b *NativeUUIDCollectionBuilder) NewNativeUUIDCollectionForTimeWindow(collection string, start time.Time, end time.Time, maximumThrottle time.Duration) (UUIDCollection, error) {
 	tx, err := b.db.Open()
 	if err != nil {
@@ -90,39 +118,100 @@ func (b *NativeUUIDCollectionBuilder) NewNativeUUIDCollection(ctx context.Contex
 		return nil, err
 	}
 
-	cursor := &NativeUUIDCollection{collection: collection, iter: iter, length: length}
+	cursor := &NativeUUIDCollection{collection: collection, iter: iter, length: length, ordering: orderingOf(iter)}
 
 	inMemory, err := b.inMemory.LoadIntoMemory(ctx, cursor, collection, skip, b.isBlacklisted)
 	return inMemory, err
 }
 
-func (n *NativeUUIDCollection) Next() (bool, string, error) {
-	result := map[string]interface{}{}
+// NewPrioritisedUUIDCollection loads every uuid of the collection into memory, and then reorders them in place with the prioritise function. The uuids are not persisted to S3, as their order changes every iteration.
+func (b *NativeUUIDCollectionBuilder) NewPrioritisedUUIDCollection(ctx context.Context, collection string, prioritise func(uuids []string)) (UUIDCollection, error) {
+	tx, err := b.db.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	iter, length, err := tx.FindUUIDs(collection, 0, 100)
+	if err != nil {
+		return nil, err
+	}
 
-	success := n.iter.Next(&result)
+	cursor := &NativeUUIDCollection{collection: collection, iter: iter, length: length, ordering: orderingOf(iter)}
 
-	if !success || n.iter.Err() != nil {
-		return true, "", n.iter.Err()
+	inMemory, err := NewInMemoryCollectionBuilder(nil).LoadIntoMemory(ctx, cursor, collection, 0, b.isBlacklisted)
+	if err != nil {
+		return inMemory, err
 	}
 
-	if n.iter.Timeout() {
-		return true, "", errors.New("Mongo timeout detected")
+	prioritise(inMemory.(*InMemoryUUIDCollection).uuids)
+	return inMemory, nil
+}
+
+// NewStreamingUUIDCollection returns a collection which streams uuids from mongo one page at a time. It resumes after the _id of the resume token if one is given, otherwise it skips the first skip documents.
+func (b *NativeUUIDCollectionBuilder) NewStreamingUUIDCollection(collection string, skip int, resumeToken string) (UUIDCollection, error) {
+	var afterID interface{}
+	if resumeToken != "" {
+		id, err := decodeResumeToken(resumeToken)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid resume token for collection %v: %v", collection, err)
+		}
+		afterID, skip = id, 0
 	}
 
-	if !success {
-		return true, "", nil
+	tx, err := b.db.Open()
+	if err != nil {
+		return nil, err
 	}
 
-	val, ok := result["uuid"]
-	if !ok {
-		return false, "", nil // this document has no uuid
+	streaming := newStreamingUUIDCollection(tx, collection, skip, afterID, streamingPageSize, b.isBlacklisted)
+	if err := streaming.nextPage(); err != nil {
+		tx.Close()
+		return nil, err
 	}
 
-	return false, parseBinaryUUID(val), nil
+	return streaming, nil
+}
+
+// Next returns the next valid uuid, skipping any documents whose uuid is missing or invalid
+func (n *NativeUUIDCollection) Next() (bool, string, error) {
+	for {
+		result := map[string]interface{}{}
+
+		success := n.iter.Next(&result)
+
+		if !success || n.iter.Err() != nil {
+			return true, "", n.iter.Err()
+		}
+
+		if n.iter.Timeout() {
+			return true, "", errors.New("Mongo timeout detected")
+		}
+
+		uuid, reason := parseUUID(result["uuid"])
+		if reason != "" {
+			n.skip(reason)
+			continue
+		}
+
+		return false, uuid, nil
+	}
+}
+
+func (n *NativeUUIDCollection) skip(reason string) {
+	if n.skipped == nil {
+		n.skipped = make(skipReasons)
+	}
+	n.skipped[reason]++
+}
+
+// Skipped returns the number of documents which were skipped, by the reason they were skipped
+func (n *NativeUUIDCollection) Skipped() map[string]int {
+	return n.skipped
 }
 
-func parseBinaryUUID(bin interface{}) string {
-	return uuid.UUID(bin.(bson.Binary).Data).String()
+// Ordering returns the sort order of the uuids, or an empty string if they are not sorted
+func (n *NativeUUIDCollection) Ordering() string {
+	return n.ordering
 }
 
 func (n *NativeUUIDCollection) Close() error {
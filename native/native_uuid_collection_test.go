@@ -1,11 +1,13 @@
 package native
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"testing"
 	"time"
 
+	"github.com/Financial-Times/publish-carousel/s3"
 	"github.com/pborman/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -122,6 +124,80 @@ func TestNewNativeUUIDCollectionFindFails(t *testing.T) {
 	mockTx.AssertExpectations(t)
 }
 
+func TestNewNativeUUIDCollectionPersistsTheSortOrderOfTheQuery(t *testing.T) {
+	testUUID := uuid.NewUUID().String()
+
+	mockDb := new(MockDB)
+	mockTx := new(MockTX)
+	rw := new(s3.MockReadWriter)
+
+	mockDb.On("Open").Return(mockTx, nil)
+	mockTx.On("FindUUIDs", "methode", 0, 100).Return(&sortedIter{DBIter: mockPageIter(testDoc(1, testUUID)), sort: "-publishedDate"}, 1, nil)
+
+	var persisted []byte
+	rw.On("Write", "methode-uuids", mock.AnythingOfType("string"), mock.AnythingOfType("[]uint8"), uuidSnapshotContentType).Run(func(args mock.Arguments) {
+		persisted = args.Get(2).([]byte)
+	}).Return(nil)
+
+	builder := NewNativeUUIDCollectionBuilder(mockDb, rw, noopBlacklist)
+
+	actual, err := builder.NewNativeUUIDCollection(context.Background(), "methode", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "-publishedDate", actual.(OrderedUUIDCollection).Ordering())
+
+	snapshot, err := decodeUUIDSnapshot(bytes.NewReader(persisted), maxSnapshotUUIDs)
+	assert.NoError(t, err)
+	assert.Equal(t, "-publishedDate", snapshot.ordering)
+	assert.Equal(t, []string{testUUID}, snapshot.uuids)
+
+	mockDb.AssertExpectations(t)
+	mockTx.AssertExpectations(t)
+	rw.AssertExpectations(t)
+}
+
+func TestNewPrioritisedUUIDCollection(t *testing.T) {
+	uuid1 := uuid.NewUUID().String()
+	uuid2 := uuid.NewUUID().String()
+	blacklisted := uuid.NewUUID().String()
+
+	mockDb := new(MockDB)
+	mockTx := new(MockTX)
+
+	mockDb.On("Open").Return(mockTx, nil)
+	mockTx.On("FindUUIDs", "methode", 0, 100).Return(mockPageIter(testDoc(1, uuid1), testDoc(2, blacklisted), testDoc(3, uuid2)), 3, nil)
+
+	isBlacklisted := func(uuid string) (bool, error) { return uuid == blacklisted, nil }
+	builder := NewNativeUUIDCollectionBuilder(mockDb, nil, isBlacklisted)
+
+	var prioritised []string
+	actual, err := builder.NewPrioritisedUUIDCollection(context.Background(), "methode", func(uuids []string) {
+		prioritised = append(prioritised, uuids...)
+		uuids[0], uuids[1] = uuids[1], uuids[0]
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{uuid1, uuid2}, prioritised, "blacklisted uuids should be removed before prioritising")
+
+	_, first, _ := actual.Next()
+	_, second, _ := actual.Next()
+	assert.Equal(t, []string{uuid2, uuid1}, []string{first, second})
+
+	mockDb.AssertExpectations(t)
+	mockTx.AssertExpectations(t)
+}
+
+func TestNewPrioritisedUUIDCollectionOpenFails(t *testing.T) {
+	mockDb := new(MockDB)
+	mockDb.On("Open").Return(new(MockTX), errors.New("fail"))
+
+	builder := NewNativeUUIDCollectionBuilder(mockDb, nil, noopBlacklist)
+
+	_, err := builder.NewPrioritisedUUIDCollection(context.Background(), "methode", func(uuids []string) {
+		t.Fatal("should not prioritise when mongo is unavailable")
+	})
+	assert.Error(t, err)
+	mockDb.AssertExpectations(t)
+}
+
 func TestNewNativeUUIDCollectionForTimeWindow(t *testing.T) {
 	mockDb := new(MockDB)
 	mockTx := new(MockTX)
@@ -221,3 +297,23 @@ func TestNativeUUIDCollection(t *testing.T) {
 
 	cleanupTestContent(t, db, testUUID)
 }
+
+func TestNativeUUIDCollectionSkipsInvalidUUIDs(t *testing.T) {
+	validUUID := uuid.NewUUID().String()
+
+	iter := mockPageIter(map[string]interface{}{"uuid": 12345}, testDoc(1, ""), map[string]interface{}{"uuid": "not-a-uuid"}, testDoc(2, validUUID))
+
+	c := &NativeUUIDCollection{collection: "methode", iter: iter, length: 4}
+
+	finished, val, err := c.Next()
+	assert.NoError(t, err)
+	assert.False(t, finished)
+	assert.Equal(t, validUUID, val, "documents with invalid uuids are skipped by the collection")
+
+	finished, _, err = c.Next()
+	assert.NoError(t, err)
+	assert.True(t, finished)
+
+	assert.Equal(t, map[string]int{"unexpected uuid type int": 1, missingUUIDReason: 1, invalidStringUUIDReason: 1}, c.Skipped())
+	assert.Equal(t, 4, c.Length(), "skipped documents are still counted in the length")
+}
@@ -3,38 +3,34 @@ package native
 import (
 	"time"
 
-	"github.com/pborman/uuid"
-
 	"gopkg.in/mgo.v2/bson"
 )
 
-func readNativeContentQuery(nativeUUID string) bson.M {
-	return bson.M{"uuid": bson.Binary{Kind: 0x04, Data: []byte(uuid.Parse(nativeUUID))}}
+func readNativeContentQuery(config CollectionConfig, nativeUUID string) bson.M {
+	return bson.M{config.UUIDField: config.uuidValue(nativeUUID)}
 }
 
-var uuidProjection = bson.M{
-	"uuid": 1,
+func readNativeContentsQuery(config CollectionConfig, nativeUUIDs []string) bson.M {
+	values := make([]interface{}, 0, len(nativeUUIDs))
+	for _, nativeUUID := range nativeUUIDs {
+		values = append(values, config.uuidValue(nativeUUID))
+	}
+	return bson.M{config.UUIDField: bson.M{"$in": values}}
 }
 
-func findUUIDsForTimeWindowQueryElements(start time.Time, end time.Time) (bson.M, bson.M) {
-	query := bson.M{
-		"$and": []bson.M{
-			{
-				"content.lastModified": bson.M{
-					"$gte": start.UTC().Format(time.RFC3339),
-				},
-			},
-			{
-				"content.lastModified": bson.M{
-					"$lt": end.UTC().Format(time.RFC3339),
-				},
-			},
-		},
-	}
+func findUUIDsForTimeWindowQueryElements(config CollectionConfig, start time.Time, end time.Time) (bson.M, bson.M) {
+	projection := config.projection()
+	projection[config.TimestampField] = 1
+	return config.timeWindowQuery(start, end), projection
+}
 
-	return query, uuidProjection
+func findUUIDsQueryElements(config CollectionConfig) (bson.M, bson.M) {
+	return bson.M{}, config.projection()
 }
 
-func findUUIDsQueryElements() (bson.M, bson.M) {
-	return bson.M{}, uuidProjection
+func findUUIDsAfterIDQueryElements(config CollectionConfig, afterID interface{}) (bson.M, bson.M) {
+	if afterID == nil {
+		return bson.M{}, config.projection()
+	}
+	return bson.M{"_id": bson.M{"$gt": afterID}}, config.projection()
 }
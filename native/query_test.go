@@ -12,7 +12,7 @@ import (
 
 func TestReadNativeContentQuery(t *testing.T) {
 	testUUID := `e7743707-b4c4-4ab3-8043-bcb5f7fdd56b`
-	query := readNativeContentQuery(testUUID)
+	query := readNativeContentQuery(CollectionConfigs{}.Get("methode"), testUUID)
 
 	data, err := bson.MarshalJSON(query)
 	assert.NoError(t, err)
@@ -20,19 +20,53 @@ func TestReadNativeContentQuery(t *testing.T) {
 }
 
 func TestFindUUIDsQueryElements(t *testing.T) {
-	query, projection := findUUIDsQueryElements()
+	query, projection := findUUIDsQueryElements(CollectionConfigs{}.Get("methode"))
 	assert.Equal(t, bson.M{}, query)
-	assert.Equal(t, uuidProjection, projection)
+	assert.Equal(t, bson.M{"uuid": 1}, projection)
 }
 
 func TestFindUUIDsForTimeWindowQueryElements(t *testing.T) {
 	end := time.Date(2017, 03, 16, 0, 0, 0, 0, time.UTC)
 	start := end.Add(time.Minute * -1)
 
-	query, projection := findUUIDsForTimeWindowQueryElements(start, end)
+	query, projection := findUUIDsForTimeWindowQueryElements(CollectionConfigs{}.Get("methode"), start, end)
 
 	data, err := bson.MarshalJSON(query)
 	assert.NoError(t, err)
-	assert.Equal(t, `{"$and":[{"content.lastModified":{"$gte":"2017-03-15T23:59:00Z"}},{"content.lastModified":{"$lt":"2017-03-16T00:00:00Z"}}]}`, strings.TrimSpace(string(data)))
-	assert.Equal(t, uuidProjection, projection)
+	assert.Equal(t, `{"$and":[{"content.lastModified":{"$gte":"2017-03-15T09:59:00Z"}},{"content.lastModified":{"$lt":"2017-03-16T14:00:00Z"}}]}`, strings.TrimSpace(string(data)))
+	assert.Equal(t, bson.M{"uuid": 1, "content.lastModified": 1}, projection)
+}
+
+func TestFindUUIDsAfterIDQueryElements(t *testing.T) {
+	query, projection := findUUIDsAfterIDQueryElements(CollectionConfigs{}.Get("methode"), nil)
+	assert.Equal(t, bson.M{}, query)
+	assert.Equal(t, bson.M{"uuid": 1}, projection)
+
+	id := bson.ObjectIdHex("58d2a5e0dbd8c4b2a67c8b0a")
+	query, projection = findUUIDsAfterIDQueryElements(CollectionConfigs{}.Get("methode"), id)
+	assert.Equal(t, bson.M{"_id": bson.M{"$gt": id}}, query)
+	assert.Equal(t, bson.M{"uuid": 1}, projection)
+}
+
+func TestReadNativeContentsQuery(t *testing.T) {
+	query := readNativeContentsQuery(CollectionConfigs{}.Get("methode"), []string{`e7743707-b4c4-4ab3-8043-bcb5f7fdd56b`})
+
+	data, err := bson.MarshalJSON(query)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"uuid":{"$in":[{"$binary":"53Q3B7TESrOAQ7y19/3Vaw==","$type":"0x4"}]}}`, strings.TrimSpace(string(data)))
+}
+
+func TestStringUUIDQueries(t *testing.T) {
+	config := CollectionConfigs{"v2-content": {UUIDField: "content.uuid", UUIDEncoding: StringUUIDEncoding}}.Get("v2-content")
+
+	data, err := bson.MarshalJSON(readNativeContentQuery(config, `e7743707-b4c4-4ab3-8043-bcb5f7fdd56b`))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"content.uuid":"e7743707-b4c4-4ab3-8043-bcb5f7fdd56b"}`, strings.TrimSpace(string(data)))
+
+	data, err = bson.MarshalJSON(readNativeContentsQuery(config, []string{`e7743707-b4c4-4ab3-8043-bcb5f7fdd56b`}))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"content.uuid":{"$in":["e7743707-b4c4-4ab3-8043-bcb5f7fdd56b"]}}`, strings.TrimSpace(string(data)))
+
+	_, projection := findUUIDsQueryElements(config)
+	assert.Equal(t, bson.M{"content.uuid": 1}, projection)
 }
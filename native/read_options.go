@@ -0,0 +1,363 @@
+package native
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+var readPreferences = map[string]mgo.Mode{
+	"primary":            mgo.Primary,
+	"primaryPreferred":   mgo.PrimaryPreferred,
+	"secondary":          mgo.Secondary,
+	"secondaryPreferred": mgo.SecondaryPreferred,
+	"nearest":            mgo.Nearest,
+}
+
+var readConcerns = map[string]bool{
+	"local":     true,
+	"available": true,
+	"majority":  true,
+}
+
+// ReadOptions configures which replica set members native content is read from, and the read concern of the queries.
+// If MaxStaleness is set, reads fall back to the primary whenever the secondaries lag further behind the primary than MaxStaleness.
+type ReadOptions struct {
+	ReadPreference string        `json:"readPreference,omitempty"`
+	ReadConcern    string        `json:"readConcern,omitempty"`
+	MaxStaleness   time.Duration `json:"maxStaleness,omitempty"`
+}
+
+// ReadOptionsDB is implemented by databases which can open transactions with different read options
+type ReadOptionsDB interface {
+	WithReadOptions(opts ReadOptions) DB
+}
+
+// ReadOptionsReader is implemented by readers which can read native content with different read options
+type ReadOptionsReader interface {
+	WithReadOptions(opts ReadOptions) Reader
+}
+
+// ParseReadOptions validates the read preference and read concern, and parses the max staleness duration
+func ParseReadOptions(readPreference string, readConcern string, maxStaleness string) (ReadOptions, error) {
+	opts := ReadOptions{ReadPreference: strings.TrimSpace(readPreference), ReadConcern: strings.TrimSpace(readConcern)}
+
+	if _, ok := readPreferences[opts.ReadPreference]; opts.ReadPreference != "" && !ok {
+		return opts, fmt.Errorf("Unsupported read preference %v, please use one of primary, primaryPreferred, secondary, secondaryPreferred or nearest", opts.ReadPreference)
+	}
+
+	if opts.ReadConcern != "" && !readConcerns[opts.ReadConcern] {
+		return opts, fmt.Errorf("Unsupported read concern %v, please use one of local, available or majority", opts.ReadConcern)
+	}
+
+	if strings.TrimSpace(maxStaleness) == "" {
+		return opts, nil
+	}
+
+	staleness, err := time.ParseDuration(maxStaleness)
+	if err != nil {
+		return opts, fmt.Errorf("Invalid max staleness %v: %v", maxStaleness, err)
+	}
+
+	if staleness < 0 {
+		return opts, errors.New("Please provide a positive max staleness")
+	}
+
+	opts.MaxStaleness = staleness
+	return opts, nil
+}
+
+// IsZero returns true if no read options have been set
+func (o ReadOptions) IsZero() bool {
+	return o == ReadOptions{}
+}
+
+// withDefaults returns the options, using the defaults for anything which has not been set
+func (o ReadOptions) withDefaults(defaults ReadOptions) ReadOptions {
+	if o.ReadPreference == "" {
+		o.ReadPreference = defaults.ReadPreference
+	}
+
+	if o.ReadConcern == "" {
+		o.ReadConcern = defaults.ReadConcern
+	}
+
+	if o.MaxStaleness == 0 {
+		o.MaxStaleness = defaults.MaxStaleness
+	}
+	return o
+}
+
+func (o ReadOptions) mode() mgo.Mode {
+	if mode, ok := readPreferences[o.ReadPreference]; ok {
+		return mode
+	}
+	return mgo.Primary
+}
+
+// guarded returns true if reads can fall back to the primary when the secondaries are too stale
+func (o ReadOptions) guarded() bool {
+	return o.mode() != mgo.Primary && o.MaxStaleness > 0
+}
+
+// checkLagIfDue checks the replication lag of the secondaries if the read options are guarded, and the lag has not been checked recently. Must be called without the lock held.
+// The replica set status is read without the lock held, so that a slow or hung status call does not hold up transactions which are opened in the meantime.
+func (db *MongoDB) checkLagIfDue(session *mgo.Session, opts ReadOptions) {
+	if !opts.guarded() {
+		return
+	}
+
+	db.lock.Lock()
+	now := db.now()
+	due := now.Sub(db.lagChecked) > sessionCheckInterval
+	if due {
+		db.lagChecked = now
+	}
+	db.lock.Unlock()
+
+	if !due {
+		return
+	}
+
+	lag, err := db.replicationLag(session)
+
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	db.lag, db.lagErr = lag, err
+	db.stats.ReplicationLag = lag.String()
+}
+
+// readMode returns the session mode for the read options, falling back to the primary if the secondaries are too stale. A fallback is only logged and counted when the read options start falling back, not for every transaction. Must be called with the lock held.
+func (db *MongoDB) readMode(opts ReadOptions) mgo.Mode {
+	mode := opts.mode()
+	if !opts.guarded() {
+		return mode
+	}
+
+	if db.fallingBack == nil {
+		db.fallingBack = make(map[ReadOptions]bool)
+	}
+
+	fallBack := db.lagErr != nil || db.lag > opts.MaxStaleness
+	if fallBack != db.fallingBack[opts] {
+		db.fallingBack[opts] = fallBack
+		if fallBack {
+			db.stats.PrimaryFallbacks++
+			log.WithError(db.lagErr).WithField("lag", db.lag.String()).WithField("maxStaleness", opts.MaxStaleness.String()).WithField("readPreference", opts.ReadPreference).Warn("Secondaries are too stale, or their lag is unknown. Reading from the primary instead.")
+		} else {
+			log.WithField("lag", db.lag.String()).WithField("maxStaleness", opts.MaxStaleness.String()).WithField("readPreference", opts.ReadPreference).Info("Secondaries have caught up. Reading from the secondaries again.")
+		}
+	}
+
+	if fallBack {
+		return mgo.Primary
+	}
+	return mode
+}
+
+type replicaSetMember struct {
+	StateStr   string    `bson:"stateStr"`
+	Health     float64   `bson:"health"`
+	OptimeDate time.Time `bson:"optimeDate"`
+}
+
+func replicationLag(session *mgo.Session) (time.Duration, error) {
+	s := session.Copy()
+	defer s.Close()
+
+	status := struct {
+		Members []replicaSetMember `bson:"members"`
+	}{}
+
+	if err := s.Run("replSetGetStatus", &status); err != nil {
+		return 0, err
+	}
+
+	return maxSecondaryLag(status.Members)
+}
+
+// maxSecondaryLag returns how far the most stale healthy secondary is behind the primary
+func maxSecondaryLag(members []replicaSetMember) (time.Duration, error) {
+	var primary *replicaSetMember
+	for i, m := range members {
+		if m.StateStr == "PRIMARY" {
+			primary = &members[i]
+		}
+	}
+
+	if primary == nil {
+		return 0, errors.New("No primary found in the replica set status")
+	}
+
+	var lag time.Duration
+	for _, m := range members {
+		if m.StateStr != "SECONDARY" || m.Health != 1 {
+			continue
+		}
+
+		if l := primary.OptimeDate.Sub(m.OptimeDate); l > lag {
+			lag = l
+		}
+	}
+	return lag, nil
+}
+
+// mongoReadView opens transactions on the underlying database with its own read options
+type mongoReadView struct {
+	db   *MongoDB
+	opts ReadOptions
+}
+
+// WithReadOptions returns a view of the database whose transactions use the given read options, using the database defaults for any which are not set
+func (db *MongoDB) WithReadOptions(opts ReadOptions) DB {
+	return &mongoReadView{db: db, opts: opts.withDefaults(db.ReadOptions)}
+}
+
+func (v *mongoReadView) Open() (TX, error) {
+	return v.db.open(v.opts)
+}
+
+// Close does nothing, as the underlying database is closed by its owner
+func (v *mongoReadView) Close() {}
+
+// findQuery describes a query, so that it can be run as either an mgo query or a find command
+type findQuery struct {
+	filter     bson.M
+	projection bson.M
+	sort       string
+	skip       int
+	limit      int
+	batchSize  int
+}
+
+// nativeQuery is implemented by both *mgo.Query and readConcernQuery
+type nativeQuery interface {
+	Iter() *mgo.Iter
+	Count() (int, error)
+	One(result interface{}) error
+}
+
+func (tx *MongoTX) query(collection *mgo.Collection, q findQuery) nativeQuery {
+	if tx.readConcern != "" {
+		return &readConcernQuery{collection: collection, q: q, level: tx.readConcern}
+	}
+
+	query := collection.Find(q.filter)
+	if q.projection != nil {
+		query.Select(q.projection)
+	}
+
+	if q.sort != "" {
+		query.Sort(q.sort)
+	}
+
+	if q.skip > 0 {
+		query.Skip(q.skip)
+	}
+
+	if q.limit > 0 {
+		query.Limit(q.limit)
+	}
+
+	if q.batchSize > 0 {
+		query.Batch(q.batchSize)
+	}
+	return query
+}
+
+// readConcernQuery runs queries as find and count commands, as mgo queries do not support read concerns
+type readConcernQuery struct {
+	collection *mgo.Collection
+	q          findQuery
+	level      string
+}
+
+func (r *readConcernQuery) findCommand() bson.D {
+	cmd := bson.D{{Name: "find", Value: r.collection.Name}, {Name: "filter", Value: r.q.filter}}
+
+	if r.q.projection != nil {
+		cmd = append(cmd, bson.DocElem{Name: "projection", Value: r.q.projection})
+	}
+
+	if r.q.sort != "" {
+		cmd = append(cmd, bson.DocElem{Name: "sort", Value: sortDocument(r.q.sort)})
+	}
+
+	if r.q.skip > 0 {
+		cmd = append(cmd, bson.DocElem{Name: "skip", Value: r.q.skip})
+	}
+
+	if r.q.limit > 0 {
+		cmd = append(cmd, bson.DocElem{Name: "limit", Value: r.q.limit})
+	}
+
+	if r.q.batchSize > 0 {
+		cmd = append(cmd, bson.DocElem{Name: "batchSize", Value: r.q.batchSize})
+	}
+
+	return append(cmd, bson.DocElem{Name: "readConcern", Value: bson.M{"level": r.level}})
+}
+
+func (r *readConcernQuery) countCommand() bson.D {
+	cmd := bson.D{{Name: "count", Value: r.collection.Name}, {Name: "query", Value: r.q.filter}}
+
+	if r.q.skip > 0 {
+		cmd = append(cmd, bson.DocElem{Name: "skip", Value: r.q.skip})
+	}
+
+	if r.q.limit > 0 {
+		cmd = append(cmd, bson.DocElem{Name: "limit", Value: r.q.limit})
+	}
+
+	return append(cmd, bson.DocElem{Name: "readConcern", Value: bson.M{"level": r.level}})
+}
+
+func (r *readConcernQuery) Iter() *mgo.Iter {
+	result := struct {
+		Cursor struct {
+			FirstBatch []bson.Raw `bson:"firstBatch"`
+			ID         int64      `bson:"id"`
+		} `bson:"cursor"`
+	}{}
+
+	err := r.collection.Database.Run(r.findCommand(), &result)
+	return r.collection.NewIter(nil, result.Cursor.FirstBatch, result.Cursor.ID, err)
+}
+
+func (r *readConcernQuery) Count() (int, error) {
+	result := struct {
+		N int `bson:"n"`
+	}{}
+
+	err := r.collection.Database.Run(r.countCommand(), &result)
+	return result.N, err
+}
+
+func (r *readConcernQuery) One(result interface{}) error {
+	q := *r
+	q.q.limit = 1
+
+	iter := q.Iter()
+	if iter.Next(result) {
+		return iter.Close()
+	}
+
+	if err := iter.Close(); err != nil {
+		return err
+	}
+	return mgo.ErrNotFound
+}
+
+// sortDocument converts an mgo sort string, i.e. "-content.lastModified", into a sort document
+func sortDocument(sort string) bson.D {
+	if strings.HasPrefix(sort, "-") {
+		return bson.D{{Name: strings.TrimPrefix(sort, "-"), Value: -1}}
+	}
+	return bson.D{{Name: sort, Value: 1}}
+}
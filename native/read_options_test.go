@@ -0,0 +1,217 @@
+package native
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestParseReadOptions(t *testing.T) {
+	opts, err := ParseReadOptions("secondaryPreferred", "majority", "90s")
+	assert.NoError(t, err)
+	assert.Equal(t, ReadOptions{ReadPreference: "secondaryPreferred", ReadConcern: "majority", MaxStaleness: 90 * time.Second}, opts)
+
+	opts, err = ParseReadOptions("", "", "")
+	assert.NoError(t, err)
+	assert.True(t, opts.IsZero())
+
+	_, err = ParseReadOptions("secondaryOnly", "", "")
+	assert.Error(t, err)
+
+	_, err = ParseReadOptions("", "linearizable", "")
+	assert.Error(t, err)
+
+	_, err = ParseReadOptions("nearest", "", "a while")
+	assert.Error(t, err)
+
+	_, err = ParseReadOptions("nearest", "", "-1m")
+	assert.Error(t, err)
+}
+
+func TestReadOptionsWithDefaults(t *testing.T) {
+	defaults := ReadOptions{ReadPreference: "primary", ReadConcern: "local", MaxStaleness: time.Minute}
+
+	assert.Equal(t, defaults, ReadOptions{}.withDefaults(defaults))
+	assert.Equal(t, ReadOptions{ReadPreference: "nearest", ReadConcern: "local", MaxStaleness: time.Minute}, ReadOptions{ReadPreference: "nearest"}.withDefaults(defaults))
+}
+
+func TestReadOptionsMode(t *testing.T) {
+	assert.Equal(t, mgo.Primary, ReadOptions{}.mode())
+	assert.Equal(t, mgo.SecondaryPreferred, ReadOptions{ReadPreference: "secondaryPreferred"}.mode())
+	assert.Equal(t, mgo.Nearest, ReadOptions{ReadPreference: "nearest"}.mode())
+}
+
+func TestMaxSecondaryLag(t *testing.T) {
+	now := time.Now()
+	members := []replicaSetMember{
+		{StateStr: "SECONDARY", Health: 1, OptimeDate: now.Add(-5 * time.Second)},
+		{StateStr: "PRIMARY", Health: 1, OptimeDate: now},
+		{StateStr: "SECONDARY", Health: 1, OptimeDate: now.Add(-2 * time.Second)},
+		{StateStr: "SECONDARY", Health: 0, OptimeDate: now.Add(-time.Hour)},
+		{StateStr: "ARBITER", Health: 1},
+	}
+
+	lag, err := maxSecondaryLag(members)
+	assert.NoError(t, err)
+	assert.Equal(t, 5*time.Second, lag)
+
+	_, err = maxSecondaryLag(members[2:])
+	assert.Error(t, err)
+}
+
+func TestReadModeStalenessGuard(t *testing.T) {
+	f := &fakeMongo{now: time.Now()}
+	db := f.db()
+
+	lags := 0
+	lag := 10 * time.Second
+	var lagErr error
+	db.replicationLag = func(session *mgo.Session) (time.Duration, error) {
+		lags++
+		return lag, lagErr
+	}
+
+	opts := ReadOptions{ReadPreference: "secondaryPreferred", MaxStaleness: time.Minute}
+	readMode := func(opts ReadOptions) mgo.Mode {
+		db.checkLagIfDue(nil, opts)
+		return db.readMode(opts)
+	}
+
+	assert.Equal(t, mgo.SecondaryPreferred, readMode(opts))
+	assert.Equal(t, 1, lags)
+
+	lag = 2 * time.Minute
+	assert.Equal(t, mgo.SecondaryPreferred, readMode(opts), "the lag should be cached")
+	assert.Equal(t, 1, lags)
+
+	f.now = f.now.Add(sessionCheckInterval + time.Second)
+	assert.Equal(t, mgo.Primary, readMode(opts))
+	assert.Equal(t, 2, lags)
+	assert.Equal(t, 1, db.stats.PrimaryFallbacks)
+	assert.Equal(t, "2m0s", db.stats.ReplicationLag)
+
+	assert.Equal(t, mgo.Primary, readMode(opts))
+	assert.Equal(t, 1, db.stats.PrimaryFallbacks, "a fallback is only counted when the mode changes")
+
+	lag, lagErr = 0, errors.New("not authorized on admin")
+	f.now = f.now.Add(sessionCheckInterval + time.Second)
+	assert.Equal(t, mgo.Primary, readMode(opts), "should fall back to primary if the lag is unknown")
+	assert.Equal(t, 1, db.stats.PrimaryFallbacks)
+
+	lag, lagErr = 0, nil
+	f.now = f.now.Add(sessionCheckInterval + time.Second)
+	assert.Equal(t, mgo.SecondaryPreferred, readMode(opts), "should read from the secondaries once they catch up")
+
+	lag = 2 * time.Minute
+	f.now = f.now.Add(sessionCheckInterval + time.Second)
+	assert.Equal(t, mgo.Primary, readMode(opts))
+	assert.Equal(t, 2, db.stats.PrimaryFallbacks)
+
+	assert.Equal(t, mgo.Nearest, readMode(ReadOptions{ReadPreference: "nearest"}), "no guard without a max staleness")
+	assert.Equal(t, mgo.Primary, readMode(ReadOptions{MaxStaleness: time.Second}))
+	assert.Equal(t, 5, lags, "the lag is only checked for guarded read options")
+}
+
+func TestCheckLagWithoutTheLock(t *testing.T) {
+	db := (&fakeMongo{now: time.Now()}).db()
+
+	lockHeld := false
+	db.replicationLag = func(session *mgo.Session) (time.Duration, error) {
+		locked := make(chan struct{})
+		go func() {
+			db.lock.Lock()
+			db.lock.Unlock()
+			close(locked)
+		}()
+
+		select {
+		case <-locked:
+		case <-time.After(time.Second):
+			lockHeld = true
+		}
+		return 5 * time.Second, nil
+	}
+
+	db.checkLagIfDue(nil, ReadOptions{ReadPreference: "secondary", MaxStaleness: time.Minute})
+	assert.False(t, lockHeld, "the lock should not be held while reading the replica set status")
+	assert.Equal(t, 5*time.Second, db.lag)
+	assert.Equal(t, "5s", db.stats.ReplicationLag)
+}
+
+func TestWithReadOptionsUsesDefaults(t *testing.T) {
+	db := (&fakeMongo{}).db()
+	db.ReadOptions = ReadOptions{ReadPreference: "primary", ReadConcern: "local", MaxStaleness: time.Minute}
+
+	view := db.WithReadOptions(ReadOptions{ReadPreference: "nearest"}).(*mongoReadView)
+	assert.Equal(t, db, view.db)
+	assert.Equal(t, ReadOptions{ReadPreference: "nearest", ReadConcern: "local", MaxStaleness: time.Minute}, view.opts)
+}
+
+func testCollection() *mgo.Collection {
+	return &mgo.Collection{Database: &mgo.Database{Session: &mgo.Session{}, Name: "native-store"}, Name: "methode", FullName: "native-store.methode"}
+}
+
+func TestQueryWithoutReadConcern(t *testing.T) {
+	tx := &MongoTX{}
+	_, ok := tx.query(testCollection(), findQuery{filter: bson.M{}}).(*mgo.Query)
+	assert.True(t, ok)
+}
+
+func TestReadConcernQueryCommands(t *testing.T) {
+	tx := &MongoTX{readConcern: "majority"}
+	q := tx.query(testCollection(), findQuery{filter: bson.M{"a": 1}, projection: bson.M{"uuid": 1}, sort: "-content.lastModified", skip: 5, limit: 10, batchSize: 100}).(*readConcernQuery)
+
+	assert.Equal(t, bson.D{
+		{Name: "find", Value: "methode"},
+		{Name: "filter", Value: bson.M{"a": 1}},
+		{Name: "projection", Value: bson.M{"uuid": 1}},
+		{Name: "sort", Value: bson.D{{Name: "content.lastModified", Value: -1}}},
+		{Name: "skip", Value: 5},
+		{Name: "limit", Value: 10},
+		{Name: "batchSize", Value: 100},
+		{Name: "readConcern", Value: bson.M{"level": "majority"}},
+	}, q.findCommand())
+
+	assert.Equal(t, bson.D{
+		{Name: "count", Value: "methode"},
+		{Name: "query", Value: bson.M{"a": 1}},
+		{Name: "skip", Value: 5},
+		{Name: "limit", Value: 10},
+		{Name: "readConcern", Value: bson.M{"level": "majority"}},
+	}, q.countCommand())
+}
+
+func TestSortDocument(t *testing.T) {
+	assert.Equal(t, bson.D{{Name: "_id", Value: 1}}, sortDocument("_id"))
+	assert.Equal(t, bson.D{{Name: "content.lastModified", Value: -1}}, sortDocument("-content.lastModified"))
+}
+
+func TestReadersWithReadOptions(t *testing.T) {
+	db := (&fakeMongo{}).db()
+	opts := ReadOptions{ReadPreference: "secondaryPreferred"}
+
+	reader := NewMongoNativeReader(db, nil).(*MongoReader).WithReadOptions(opts).(*MongoReader)
+	assert.Equal(t, opts.ReadPreference, reader.mongo.(*mongoReadView).opts.ReadPreference)
+
+	batching := NewBatchingMongoNativeReader(db, 10, time.Minute, nil).(*BatchingMongoReader).WithReadOptions(opts).(*BatchingMongoReader)
+	assert.Equal(t, 10, batching.batchSize)
+	assert.Equal(t, opts.ReadPreference, batching.mongo.(*mongoReadView).opts.ReadPreference)
+
+	mockDB := new(MockDB)
+	assert.Equal(t, mockDB, NewMongoNativeReader(mockDB, nil).(*MongoReader).WithReadOptions(opts).(*MongoReader).mongo, "databases without read options are used as they are")
+	assert.Equal(t, db, NewMongoNativeReader(db, nil).(*MongoReader).WithReadOptions(ReadOptions{}).(*MongoReader).mongo)
+}
+
+func TestBuilderWithReadOptions(t *testing.T) {
+	db := (&fakeMongo{}).db()
+	builder := NewNativeUUIDCollectionBuilder(db, nil, noopBlacklist)
+
+	withOpts := builder.WithReadOptions(ReadOptions{ReadPreference: "nearest"})
+	assert.Equal(t, db, builder.db)
+	assert.Equal(t, "nearest", withOpts.db.(*mongoReadView).opts.ReadPreference)
+	assert.Equal(t, builder.inMemory, withOpts.inMemory)
+}
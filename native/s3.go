@@ -3,6 +3,8 @@ package native
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"time"
 
 	"github.com/Financial-Times/publish-carousel/s3"
@@ -11,17 +13,18 @@ import (
 const persistedUUIDsSuffix = "-uuids"
 
 func persistInS3(rw s3.ReadWriter, collection *InMemoryUUIDCollection) error {
-	key := time.Now().UTC().Format(`20060102T15040599`) + ".json"
+	key := time.Now().UTC().Format(`20060102T15040599`) + ".gz"
 
-	b, err := json.Marshal(collection.uuids)
+	b, err := encodeUUIDSnapshot(uuidSnapshot{collection: collection.collection, ordering: collection.ordering, uuids: collection.uuids})
 	if err != nil {
 		return err
 	}
 
-	return rw.Write(collection.collection+persistedUUIDsSuffix, key, b, "application/json")
+	return rw.Write(collection.collection+persistedUUIDsSuffix, key, b, uuidSnapshotContentType)
 }
 
-func readFromS3(rw s3.ReadWriter, collection string) ([]string, error) {
+// readFromS3 reads the latest uuids persisted for the collection, which must have been sorted by the given ordering, and cannot hold more uuids than the collection has documents
+func readFromS3(rw s3.ReadWriter, collection string, ordering string, maxUUIDs int) ([]string, error) {
 	key, err := rw.GetLatestKeyForID(collection + persistedUUIDsSuffix)
 	if err != nil {
 		return nil, err
@@ -36,15 +39,43 @@ func readFromS3(rw s3.ReadWriter, collection string) ([]string, error) {
 		return nil, errors.New("Key not found, has it recently been deleted?")
 	}
 
-	if contentType == nil || *contentType != "application/json" {
+	defer data.Close()
+
+	if contentType == nil {
 		return nil, errors.New("Unexpected or nil content type")
 	}
 
-	defer data.Close()
+	switch *contentType {
+	case uuidSnapshotContentType:
+		return readUUIDSnapshot(data, collection, ordering, maxUUIDs)
+	case "application/json": // snapshots persisted before the binary format was introduced
+		return readJSONUUIDs(data)
+	}
+
+	return nil, errors.New("Unexpected or nil content type")
+}
+
+func readUUIDSnapshot(data io.Reader, collection string, ordering string, maxUUIDs int) ([]string, error) {
+	snapshot, err := decodeUUIDSnapshot(data, maxUUIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	if snapshot.collection != collection {
+		return nil, fmt.Errorf("Snapshot is for collection %v, expected %v", snapshot.collection, collection)
+	}
+
+	if snapshot.ordering != ordering {
+		return nil, fmt.Errorf("Snapshot uuids are ordered by %v, expected %v", snapshot.ordering, ordering)
+	}
+
+	return snapshot.uuids, nil
+}
 
+func readJSONUUIDs(data io.Reader) ([]string, error) {
 	dec := json.NewDecoder(data)
 	var uuids []string
-	err = dec.Decode(&uuids)
+	err := dec.Decode(&uuids)
 
 	if err != nil {
 		return nil, err
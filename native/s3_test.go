@@ -1,6 +1,7 @@
 package native
 
 import (
+	"bytes"
 	"errors"
 	"io/ioutil"
 	"strings"
@@ -8,26 +9,61 @@ import (
 
 	"github.com/Financial-Times/publish-carousel/cluster"
 	"github.com/Financial-Times/publish-carousel/s3"
+	"github.com/pborman/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
+func snapshotOf(collection string, uuids ...string) interface{} {
+	return mock.MatchedBy(func(b []byte) bool {
+		snapshot, err := decodeUUIDSnapshot(bytes.NewReader(b), maxSnapshotUUIDs)
+		if err != nil || snapshot.collection != collection || len(snapshot.uuids) != len(uuids) {
+			return false
+		}
+
+		for i, val := range uuids {
+			if snapshot.uuids[i] != val {
+				return false
+			}
+		}
+		return true
+	})
+}
+
 func TestPersistToS3(t *testing.T) {
 	rw := new(s3.MockReadWriter)
-	cursor := &InMemoryUUIDCollection{collection: "collection", uuids: make([]string, 0)}
+	testUUID := uuid.NewRandom().String()
+	cursor := &InMemoryUUIDCollection{collection: "collection", uuids: []string{testUUID}}
 
-	rw.On("Write", "collection-uuids", mock.AnythingOfType("string"), []byte("[]"), "application/json").Return(nil)
+	rw.On("Write", "collection-uuids", mock.AnythingOfType("string"), snapshotOf("collection", testUUID), uuidSnapshotContentType).Return(nil)
 
 	err := persistInS3(rw, cursor)
 	assert.NoError(t, err)
 	mock.AssertExpectationsForObjects(t, rw)
 }
 
+func TestPersistToS3RecordsOrdering(t *testing.T) {
+	rw := new(s3.MockReadWriter)
+	cursor := &InMemoryUUIDCollection{collection: "collection", uuids: []string{uuid.NewRandom().String()}, ordering: "-publishedDate"}
+
+	var persisted []byte
+	rw.On("Write", "collection-uuids", mock.AnythingOfType("string"), mock.AnythingOfType("[]uint8"), uuidSnapshotContentType).Run(func(args mock.Arguments) {
+		persisted = args.Get(2).([]byte)
+	}).Return(nil)
+
+	err := persistInS3(rw, cursor)
+	assert.NoError(t, err)
+
+	snapshot, err := decodeUUIDSnapshot(bytes.NewReader(persisted), maxSnapshotUUIDs)
+	assert.NoError(t, err)
+	assert.Equal(t, "-publishedDate", snapshot.ordering)
+}
+
 func TestPersistToS3Fails(t *testing.T) {
 	rw := new(s3.MockReadWriter)
 	cursor := &InMemoryUUIDCollection{collection: "collection", uuids: make([]string, 0)}
 
-	rw.On("Write", "collection-uuids", mock.AnythingOfType("string"), []byte("[]"), "application/json").Return(errors.New("oh no"))
+	rw.On("Write", "collection-uuids", mock.AnythingOfType("string"), snapshotOf("collection"), uuidSnapshotContentType).Return(errors.New("oh no"))
 
 	err := persistInS3(rw, cursor)
 	assert.Error(t, err)
@@ -42,7 +78,7 @@ func TestReadFromS3(t *testing.T) {
 
 	rw.On("Read", "key").Return(true, ioutil.NopCloser(strings.NewReader(`["a-uuid"]`)), &contentType, nil)
 
-	uuids, err := readFromS3(rw, "collection")
+	uuids, err := readFromS3(rw, "collection", sortByDate, 10)
 	assert.NotNil(t, uuids)
 	assert.NoError(t, err)
 
@@ -51,11 +87,69 @@ func TestReadFromS3(t *testing.T) {
 	mock.AssertExpectationsForObjects(t, rw)
 }
 
+func TestPersistToS3InvalidUUID(t *testing.T) {
+	rw := new(s3.MockReadWriter)
+	cursor := &InMemoryUUIDCollection{collection: "collection", uuids: []string{"not-a-uuid"}}
+
+	err := persistInS3(rw, cursor)
+	assert.EqualError(t, err, "Cannot encode invalid uuid not-a-uuid in snapshot")
+	rw.AssertNotCalled(t, "Write", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestReadSnapshotFromS3(t *testing.T) {
+	testUUIDs := []string{uuid.NewRandom().String(), uuid.NewRandom().String()}
+	snapshot, err := encodeUUIDSnapshot(uuidSnapshot{collection: "collection", ordering: sortByDate, uuids: testUUIDs})
+	assert.NoError(t, err)
+
+	rw := new(s3.MockReadWriter)
+	rw.On("GetLatestKeyForID", "collection-uuids").Return("key", nil)
+
+	contentType := uuidSnapshotContentType
+	rw.On("Read", "key").Return(true, ioutil.NopCloser(bytes.NewReader(snapshot)), &contentType, nil)
+
+	uuids, err := readFromS3(rw, "collection", sortByDate, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, testUUIDs, uuids)
+	mock.AssertExpectationsForObjects(t, rw)
+}
+
+func TestReadSnapshotFromS3ForDifferentCollection(t *testing.T) {
+	snapshot, err := encodeUUIDSnapshot(uuidSnapshot{collection: "wordpress", ordering: sortByDate, uuids: []string{uuid.NewRandom().String()}})
+	assert.NoError(t, err)
+
+	rw := new(s3.MockReadWriter)
+	rw.On("GetLatestKeyForID", "collection-uuids").Return("key", nil)
+
+	contentType := uuidSnapshotContentType
+	rw.On("Read", "key").Return(true, ioutil.NopCloser(bytes.NewReader(snapshot)), &contentType, nil)
+
+	uuids, err := readFromS3(rw, "collection", sortByDate, 10)
+	assert.Nil(t, uuids)
+	assert.EqualError(t, err, "Snapshot is for collection wordpress, expected collection")
+	mock.AssertExpectationsForObjects(t, rw)
+}
+
+func TestReadSnapshotFromS3InDifferentOrder(t *testing.T) {
+	snapshot, err := encodeUUIDSnapshot(uuidSnapshot{collection: "collection", ordering: sortByDate, uuids: []string{uuid.NewRandom().String()}})
+	assert.NoError(t, err)
+
+	rw := new(s3.MockReadWriter)
+	rw.On("GetLatestKeyForID", "collection-uuids").Return("key", nil)
+
+	contentType := uuidSnapshotContentType
+	rw.On("Read", "key").Return(true, ioutil.NopCloser(bytes.NewReader(snapshot)), &contentType, nil)
+
+	uuids, err := readFromS3(rw, "collection", "-publishedDate", 10)
+	assert.Nil(t, uuids)
+	assert.EqualError(t, err, "Snapshot uuids are ordered by -content.lastModified, expected -publishedDate")
+	mock.AssertExpectationsForObjects(t, rw)
+}
+
 func TestReadFromS3NoPreviousSave(t *testing.T) {
 	rw := new(s3.MockReadWriter)
 	rw.On("GetLatestKeyForID", "collection-uuids").Return("", errors.New("nooo"))
 
-	uuids, err := readFromS3(rw, "collection")
+	uuids, err := readFromS3(rw, "collection", sortByDate, 10)
 	assert.Nil(t, uuids)
 	assert.EqualError(t, err, "nooo")
 	mock.AssertExpectationsForObjects(t, rw)
@@ -68,7 +162,7 @@ func TestReadFromS3ReadFails(t *testing.T) {
 	contentType := "application/json"
 	rw.On("Read", "key").Return(false, ioutil.NopCloser(strings.NewReader("[]]")), &contentType, errors.New("something failed"))
 
-	uuids, err := readFromS3(rw, "collection")
+	uuids, err := readFromS3(rw, "collection", sortByDate, 10)
 	assert.Nil(t, uuids)
 	assert.EqualError(t, err, "something failed")
 	mock.AssertExpectationsForObjects(t, rw)
@@ -81,7 +175,7 @@ func TestReadFromS3NotFound(t *testing.T) {
 	contentType := "application/json"
 	rw.On("Read", "key").Return(false, ioutil.NopCloser(strings.NewReader("[]]")), &contentType, nil)
 
-	uuids, err := readFromS3(rw, "collection")
+	uuids, err := readFromS3(rw, "collection", sortByDate, 10)
 	assert.Nil(t, uuids)
 	assert.EqualError(t, err, "Key not found, has it recently been deleted?")
 	mock.AssertExpectationsForObjects(t, rw)
@@ -94,7 +188,7 @@ func TestReadFromS3UnsupportMediaType(t *testing.T) {
 	contentType := "application/something-else"
 	rw.On("Read", "key").Return(true, ioutil.NopCloser(strings.NewReader("[]]")), &contentType, nil)
 
-	uuids, err := readFromS3(rw, "collection")
+	uuids, err := readFromS3(rw, "collection", sortByDate, 10)
 	assert.Nil(t, uuids)
 	assert.EqualError(t, err, "Unexpected or nil content type")
 	mock.AssertExpectationsForObjects(t, rw)
@@ -108,7 +202,7 @@ func TestReadFromS3NilMediaType(t *testing.T) {
 
 	rw.On("Read", "key").Return(true, ioutil.NopCloser(strings.NewReader("[]]")), contentType, nil)
 
-	uuids, err := readFromS3(rw, "collection")
+	uuids, err := readFromS3(rw, "collection", sortByDate, 10)
 	assert.Nil(t, uuids)
 	assert.EqualError(t, err, "Unexpected or nil content type")
 	mock.AssertExpectationsForObjects(t, rw)
@@ -122,7 +216,7 @@ func TestReadFromS3InvalidDataType(t *testing.T) {
 
 	rw.On("Read", "key").Return(true, ioutil.NopCloser(strings.NewReader("{}")), &contentType, nil)
 
-	uuids, err := readFromS3(rw, "collection")
+	uuids, err := readFromS3(rw, "collection", sortByDate, 10)
 	assert.Nil(t, uuids)
 	assert.EqualError(t, err, "json: cannot unmarshal object into Go value of type []string")
 	mock.AssertExpectationsForObjects(t, rw)
@@ -141,7 +235,7 @@ func TestReadFromS3ClosesResponseBody(t *testing.T) {
 
 	rw.On("Read", "key").Return(true, body, &contentType, nil)
 
-	uuids, err := readFromS3(rw, "collection")
+	uuids, err := readFromS3(rw, "collection", sortByDate, 10)
 	assert.Nil(t, uuids)
 	assert.EqualError(t, err, "json: cannot unmarshal object into Go value of type []string")
 
@@ -0,0 +1,181 @@
+package native
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	mgo "gopkg.in/mgo.v2"
+)
+
+const (
+	sessionCheckInterval = 10 * time.Second
+	minRedialBackoff     = time.Second
+	maxRedialBackoff     = time.Minute
+)
+
+// MongoStats describes the health of the mongo session, the sockets in its pool, and how often it has been refreshed or redialled
+type MongoStats struct {
+	Connected        bool      `json:"connected"`
+	LiveServers      []string  `json:"liveServers"`
+	OpenSessions     int64     `json:"openSessions"`
+	SocketsAlive     int       `json:"socketsAlive"`
+	SocketsInUse     int       `json:"socketsInUse"`
+	Dials            int       `json:"dials"`
+	FailedDials      int       `json:"failedDials"`
+	Reconnects       int       `json:"reconnects"`
+	Refreshes        int       `json:"refreshes"`
+	ReplicationLag   string    `json:"replicationLag,omitempty"`
+	PrimaryFallbacks int       `json:"primaryFallbacks"`
+	LastError        string    `json:"lastError,omitempty"`
+	LastReconnect    time.Time `json:"lastReconnect,omitempty"`
+	NextDialAttempt  time.Time `json:"nextDialAttempt,omitempty"`
+}
+
+func (s MongoStats) String() string {
+	return fmt.Sprintf("live servers: %v, open sessions: %v, sockets alive: %v, sockets in use: %v, reconnects: %v, refreshes: %v, failed dials: %v, primary fallbacks: %v", s.LiveServers, s.OpenSessions, s.SocketsAlive, s.SocketsInUse, s.Reconnects, s.Refreshes, s.FailedDials, s.PrimaryFallbacks)
+}
+
+// StatsReporter is implemented by databases which report the health of their session
+type StatsReporter interface {
+	Stats() MongoStats
+}
+
+func dialMongo(urls string, timeout time.Duration) (*mgo.Session, error) {
+	return mgo.DialWithTimeout(urls, timeout)
+}
+
+func pingSession(session *mgo.Session) error {
+	s := session.Copy()
+	defer s.Close()
+	return s.Ping()
+}
+
+// ensureSession returns the session, redialling mongo if there is no session, or the last one was found to be dead. Redials are backed off exponentially after each failure. Must be called with the lock held.
+func (db *MongoDB) ensureSession() (*mgo.Session, error) {
+	now := db.now()
+
+	if db.session != nil {
+		return db.session, nil
+	}
+
+	if now.Before(db.stats.NextDialAttempt) {
+		return nil, fmt.Errorf("Mongo is unavailable, the next attempt to connect is at %v: %v", db.stats.NextDialAttempt.Format(time.RFC3339), db.stats.LastError)
+	}
+
+	session, err := db.dial(db.Urls, time.Duration(db.Timeout)*time.Millisecond)
+	if err != nil {
+		db.failedDial(now, err)
+		return nil, err
+	}
+
+	if db.stats.Dials > 0 {
+		db.stats.Reconnects++
+		db.stats.LastReconnect = now
+		log.WithField("reconnects", db.stats.Reconnects).Info("Reconnected to mongo.")
+	}
+
+	db.stats.Dials++
+	db.stats.NextDialAttempt = time.Time{}
+	db.backoff = 0
+	db.lastChecked = now
+	db.session = session
+	return session, nil
+}
+
+// checkSessionIfDue checks the session if it is suspect, or has not been checked recently. Must be called without the lock held.
+func (db *MongoDB) checkSessionIfDue() {
+	db.lock.Lock()
+	now := db.now()
+	session := db.session
+	due := session != nil && (atomic.SwapInt32(&db.suspect, 0) == 1 || now.Sub(db.lastChecked) > sessionCheckInterval)
+	if due {
+		db.lastChecked = now
+	}
+	db.lock.Unlock()
+
+	if due {
+		db.checkSession(session)
+	}
+}
+
+// checkSession pings the session, refreshing it to discard its sockets and rediscover the primary if the ping fails. If the refreshed session still cannot be pinged, it is closed so that mongo is redialled.
+// The session is pinged without the lock held, so that a slow ping does not hold up transactions which are opened in the meantime.
+func (db *MongoDB) checkSession(session *mgo.Session) {
+	if err := db.ping(session); err == nil {
+		return
+	}
+
+	db.lock.Lock()
+	if db.session != session {
+		db.lock.Unlock()
+		return
+	}
+	session.Refresh()
+	db.stats.Refreshes++
+	refreshes := db.stats.Refreshes
+	db.lock.Unlock()
+
+	err := db.ping(session)
+	if err == nil {
+		log.WithField("refreshes", refreshes).Info("Refreshed mongo session after a failed ping.")
+		return
+	}
+
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	log.WithError(err).Warn("Mongo session is dead, closing the session and redialling.")
+	db.stats.LastError = err.Error()
+	if db.session == session {
+		session.Close()
+		db.session = nil
+	}
+}
+
+func (db *MongoDB) failedDial(now time.Time, err error) {
+	if db.backoff == 0 {
+		db.backoff = minRedialBackoff
+	} else if db.backoff *= 2; db.backoff > maxRedialBackoff {
+		db.backoff = maxRedialBackoff
+	}
+
+	db.stats.FailedDials++
+	db.stats.LastError = err.Error()
+	db.stats.NextDialAttempt = now.Add(db.backoff)
+	log.WithError(err).WithField("backoff", db.backoff.String()).Error("Failed to connect to mongo")
+}
+
+// Stats returns the health of the mongo session
+func (db *MongoDB) Stats() MongoStats {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	stats := db.stats
+	stats.OpenSessions = atomic.LoadInt64(&db.openSessions)
+
+	mgoStats := mgo.GetStats()
+	stats.SocketsAlive = mgoStats.SocketsAlive
+	stats.SocketsInUse = mgoStats.SocketsInUse
+
+	if db.session != nil {
+		stats.Connected = true
+		stats.LiveServers = db.session.LiveServers()
+	}
+	return stats
+}
+
+// sessionFailed marks the session as suspect if the error could be caused by a dead socket or a replica set election, so that it is checked when the next transaction is opened
+func (tx *MongoTX) sessionFailed(err error) {
+	if tx.db == nil || err == nil || err == mgo.ErrNotFound {
+		return
+	}
+
+	switch err.(type) {
+	case *mgo.QueryError, *mgo.LastError:
+		return
+	}
+
+	atomic.StoreInt32(&tx.db.suspect, 1)
+}
@@ -0,0 +1,201 @@
+package native
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	mgo "gopkg.in/mgo.v2"
+)
+
+type fakeMongo struct {
+	dials    int
+	dialErr  error
+	pings    int
+	pingErrs []error
+	now      time.Time
+}
+
+func (f *fakeMongo) db() *MongoDB {
+	return &MongoDB{
+		Urls:    "localhost:27017",
+		Timeout: 100,
+		lock:    &sync.Mutex{},
+		dial: func(urls string, timeout time.Duration) (*mgo.Session, error) {
+			f.dials++
+			if f.dialErr != nil {
+				return nil, f.dialErr
+			}
+			return &mgo.Session{}, nil
+		},
+		ping: func(session *mgo.Session) error {
+			f.pings++
+			if len(f.pingErrs) == 0 {
+				return nil
+			}
+
+			err := f.pingErrs[0]
+			f.pingErrs = f.pingErrs[1:]
+			return err
+		},
+		now: func() time.Time { return f.now },
+	}
+}
+
+func TestEnsureSessionBacksOffFailedDials(t *testing.T) {
+	f := &fakeMongo{dialErr: errors.New("no reachable servers"), now: time.Now()}
+	db := f.db()
+
+	_, err := db.ensureSession()
+	assert.EqualError(t, err, "no reachable servers")
+	assert.Equal(t, 1, f.dials)
+
+	_, err = db.ensureSession()
+	assert.Error(t, err)
+	assert.Equal(t, 1, f.dials, "should not redial until the backoff has passed")
+
+	f.now = f.now.Add(minRedialBackoff)
+	_, err = db.ensureSession()
+	assert.Error(t, err)
+	assert.Equal(t, 2, f.dials)
+	assert.Equal(t, 2*minRedialBackoff, db.backoff)
+
+	f.dialErr = nil
+	f.now = f.now.Add(2 * minRedialBackoff)
+	session, err := db.ensureSession()
+	assert.NoError(t, err)
+	assert.NotNil(t, session)
+	assert.Equal(t, 3, f.dials)
+	assert.Equal(t, time.Duration(0), db.backoff)
+
+	stats := db.stats
+	assert.Equal(t, 2, stats.FailedDials)
+	assert.Equal(t, 1, stats.Dials)
+	assert.Equal(t, 0, stats.Reconnects)
+	assert.Equal(t, "no reachable servers", stats.LastError)
+}
+
+func TestFailedDialBackoffIsLimited(t *testing.T) {
+	db := (&fakeMongo{}).db()
+	for i := 0; i < 20; i++ {
+		db.failedDial(time.Now(), errors.New("no reachable servers"))
+	}
+	assert.Equal(t, maxRedialBackoff, db.backoff)
+}
+
+func TestEnsureSessionOnlyChecksPeriodically(t *testing.T) {
+	f := &fakeMongo{now: time.Now()}
+	db := f.db()
+
+	first, err := db.ensureSession()
+	assert.NoError(t, err)
+
+	db.checkSessionIfDue()
+	second, err := db.ensureSession()
+	assert.NoError(t, err)
+	assert.Equal(t, first, second)
+	assert.Equal(t, 0, f.pings)
+
+	f.now = f.now.Add(sessionCheckInterval + time.Second)
+	db.checkSessionIfDue()
+	_, err = db.ensureSession()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, f.pings)
+	assert.Equal(t, 1, f.dials)
+}
+
+func TestEnsureSessionRefreshesAfterFailedPing(t *testing.T) {
+	f := &fakeMongo{now: time.Now(), pingErrs: []error{errors.New("EOF")}}
+	db := f.db()
+
+	first, err := db.ensureSession()
+	assert.NoError(t, err)
+
+	db.suspect = 1
+	db.checkSessionIfDue()
+	second, err := db.ensureSession()
+	assert.NoError(t, err)
+	assert.Equal(t, first, second, "refreshed session should be reused")
+	assert.Equal(t, 2, f.pings)
+	assert.Equal(t, 1, f.dials)
+	assert.Equal(t, 1, db.stats.Refreshes)
+	assert.Equal(t, int32(0), db.suspect)
+}
+
+func TestEnsureSessionRedialsDeadSession(t *testing.T) {
+	f := &fakeMongo{now: time.Now(), pingErrs: []error{errors.New("EOF"), errors.New("EOF")}}
+	db := f.db()
+
+	first, err := db.ensureSession()
+	assert.NoError(t, err)
+
+	db.suspect = 1
+	db.checkSessionIfDue()
+	second, err := db.ensureSession()
+	assert.NoError(t, err)
+	assert.False(t, first == second, "dead session should be replaced")
+	assert.Equal(t, 2, f.dials)
+	assert.Equal(t, 1, db.stats.Reconnects)
+	assert.Equal(t, f.now, db.stats.LastReconnect)
+	assert.Equal(t, "EOF", db.stats.LastError)
+}
+
+func TestCheckSessionPingsWithoutTheLock(t *testing.T) {
+	f := &fakeMongo{now: time.Now()}
+	db := f.db()
+
+	_, err := db.ensureSession()
+	assert.NoError(t, err)
+
+	lockHeld := false
+	db.ping = func(session *mgo.Session) error {
+		locked := make(chan struct{})
+		go func() {
+			db.lock.Lock()
+			db.lock.Unlock()
+			close(locked)
+		}()
+
+		select {
+		case <-locked:
+		case <-time.After(time.Second):
+			lockHeld = true
+		}
+		return nil
+	}
+
+	db.suspect = 1
+	db.checkSessionIfDue()
+	assert.False(t, lockHeld, "the lock should not be held while pinging")
+}
+
+func TestSessionFailedMarksSuspect(t *testing.T) {
+	db := (&fakeMongo{}).db()
+	tx := &MongoTX{db: db}
+
+	tx.sessionFailed(nil)
+	tx.sessionFailed(mgo.ErrNotFound)
+	tx.sessionFailed(&mgo.QueryError{Message: "bad query"})
+	assert.Equal(t, int32(0), db.suspect)
+
+	tx.sessionFailed(errors.New("EOF"))
+	assert.Equal(t, int32(1), db.suspect)
+}
+
+func TestStatsWhenDisconnected(t *testing.T) {
+	mgo.SetStats(true)
+	defer mgo.SetStats(false)
+
+	f := &fakeMongo{dialErr: errors.New("no reachable servers"), now: time.Now()}
+	db := f.db()
+	db.ensureSession()
+	db.openSessions = 2
+
+	stats := db.Stats()
+	assert.False(t, stats.Connected)
+	assert.Equal(t, int64(2), stats.OpenSessions)
+	assert.Equal(t, 1, stats.FailedDials)
+	assert.Equal(t, f.now.Add(minRedialBackoff), stats.NextDialAttempt)
+}
@@ -0,0 +1,178 @@
+package native
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+
+	"github.com/pborman/uuid"
+)
+
+const (
+	uuidSnapshotContentType = "application/vnd.ft-carousel-uuids.v1+gzip"
+	uuidSnapshotMagic       = "FTCU"
+	uuidSnapshotVersion     = 1
+	binaryUUIDLength        = 16
+	maxSnapshotUUIDs        = 1 << 28
+	maxSnapshotStringLength = 1 << 10
+	snapshotChunkUUIDs      = 1 << 12
+)
+
+// uuidSnapshot is a compact, versioned copy of the uuids for a collection. It is encoded as a gzipped header followed by each uuid as 16 binary bytes.
+// The header contains the magic bytes "FTCU", the format version, the collection, the ordering of the uuids, the count of uuids, and a CRC32 checksum of the uuid bytes.
+type uuidSnapshot struct {
+	collection string
+	ordering   string
+	uuids      []string
+}
+
+func encodeUUIDSnapshot(snapshot uuidSnapshot) ([]byte, error) {
+	data := make([]byte, 0, len(snapshot.uuids)*binaryUUIDLength)
+	for _, val := range snapshot.uuids {
+		bin := uuid.Parse(val)
+		if bin == nil {
+			return nil, fmt.Errorf("Cannot encode invalid uuid %v in snapshot", val)
+		}
+		data = append(data, bin...)
+	}
+
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	w := &errWriter{w: gz}
+
+	w.write([]byte(uuidSnapshotMagic))
+	w.write([]byte{uuidSnapshotVersion})
+	w.writeString(snapshot.collection)
+	w.writeString(snapshot.ordering)
+	w.writeUint(uint64(len(snapshot.uuids)))
+	w.writeUint(uint64(crc32.ChecksumIEEE(data)))
+	w.write(data)
+
+	if w.err != nil {
+		return nil, w.err
+	}
+
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeUUIDSnapshot reads a snapshot of at most maxUUIDs uuids. The count in the header cannot be trusted until the checksum has been verified, so the uuids are read in chunks, and memory is only allocated for the uuids which are actually in the snapshot.
+func decodeUUIDSnapshot(r io.Reader, maxUUIDs int) (*uuidSnapshot, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	br := bufio.NewReader(gz)
+
+	header := make([]byte, len(uuidSnapshotMagic)+1)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, err
+	}
+
+	if string(header[:len(uuidSnapshotMagic)]) != uuidSnapshotMagic {
+		return nil, errors.New("Invalid uuid snapshot, unexpected magic bytes")
+	}
+
+	if version := header[len(uuidSnapshotMagic)]; version != uuidSnapshotVersion {
+		return nil, fmt.Errorf("Unsupported uuid snapshot version %v", version)
+	}
+
+	snapshot := &uuidSnapshot{}
+	if snapshot.collection, err = readString(br); err != nil {
+		return nil, err
+	}
+
+	if snapshot.ordering, err = readString(br); err != nil {
+		return nil, err
+	}
+
+	count, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+
+	if count > maxSnapshotUUIDs || count > uint64(maxUUIDs) {
+		return nil, fmt.Errorf("Invalid uuid snapshot, unexpected count of %v uuids", count)
+	}
+
+	checksum, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := crc32.NewIEEE()
+	chunk := make([]byte, snapshotChunkUUIDs*binaryUUIDLength)
+
+	for remaining := count; remaining > 0; {
+		n := remaining
+		if n > snapshotChunkUUIDs {
+			n = snapshotChunkUUIDs
+		}
+
+		data := chunk[:n*binaryUUIDLength]
+		if _, err := io.ReadFull(br, data); err != nil {
+			return nil, fmt.Errorf("Truncated uuid snapshot, expected %v uuids: %v", count, err)
+		}
+		hash.Write(data)
+
+		for i := 0; i < len(data); i += binaryUUIDLength {
+			snapshot.uuids = append(snapshot.uuids, uuid.UUID(data[i:i+binaryUUIDLength]).String())
+		}
+		remaining -= n
+	}
+
+	if uint64(hash.Sum32()) != checksum {
+		return nil, errors.New("Invalid uuid snapshot, checksum does not match")
+	}
+
+	return snapshot, nil
+}
+
+func readString(r *bufio.Reader) (string, error) {
+	length, err := binary.ReadUvarint(r)
+	if err != nil {
+		return "", err
+	}
+
+	if length > maxSnapshotStringLength {
+		return "", fmt.Errorf("Invalid uuid snapshot, unexpected string length of %v bytes", length)
+	}
+
+	b := make([]byte, length)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// errWriter keeps the first error encountered while writing, so that errors only need to be checked once all writes are complete
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (e *errWriter) write(b []byte) {
+	if e.err != nil {
+		return
+	}
+	_, e.err = e.w.Write(b)
+}
+
+func (e *errWriter) writeUint(v uint64) {
+	buf := make([]byte, binary.MaxVarintLen64)
+	e.write(buf[:binary.PutUvarint(buf, v)])
+}
+
+func (e *errWriter) writeString(s string) {
+	e.writeUint(uint64(len(s)))
+	e.write([]byte(s))
+}
@@ -0,0 +1,120 @@
+package native
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeDecodeUUIDSnapshot(t *testing.T) {
+	var uuids []string
+	for i := 0; i < 1000; i++ {
+		uuids = append(uuids, uuid.NewRandom().String())
+	}
+
+	b, err := encodeUUIDSnapshot(uuidSnapshot{collection: "methode", ordering: sortByDate, uuids: uuids})
+	assert.NoError(t, err)
+	assert.True(t, len(b) < len(uuids)*20, "snapshot should be close to 16 bytes per uuid")
+
+	snapshot, err := decodeUUIDSnapshot(bytes.NewReader(b), maxSnapshotUUIDs)
+	assert.NoError(t, err)
+	assert.Equal(t, "methode", snapshot.collection)
+	assert.Equal(t, sortByDate, snapshot.ordering)
+	assert.Equal(t, uuids, snapshot.uuids)
+}
+
+func TestEncodeDecodeEmptyUUIDSnapshot(t *testing.T) {
+	b, err := encodeUUIDSnapshot(uuidSnapshot{collection: "methode", ordering: sortByDate})
+	assert.NoError(t, err)
+
+	snapshot, err := decodeUUIDSnapshot(bytes.NewReader(b), maxSnapshotUUIDs)
+	assert.NoError(t, err)
+	assert.Empty(t, snapshot.uuids)
+}
+
+func gunzip(t *testing.T, b []byte) []byte {
+	gz, err := gzip.NewReader(bytes.NewReader(b))
+	assert.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	_, err = buf.ReadFrom(gz)
+	assert.NoError(t, err)
+	return buf.Bytes()
+}
+
+func regzip(t *testing.T, b []byte) []byte {
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	_, err := gz.Write(b)
+	assert.NoError(t, err)
+	assert.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func TestDecodeUUIDSnapshotChecksumMismatch(t *testing.T) {
+	b, err := encodeUUIDSnapshot(uuidSnapshot{collection: "methode", ordering: sortByDate, uuids: []string{uuid.NewRandom().String()}})
+	assert.NoError(t, err)
+
+	raw := gunzip(t, b)
+	raw[len(raw)-1] ^= 0xff
+
+	_, err = decodeUUIDSnapshot(bytes.NewReader(regzip(t, raw)), maxSnapshotUUIDs)
+	assert.EqualError(t, err, "Invalid uuid snapshot, checksum does not match")
+}
+
+func TestDecodeUUIDSnapshotTruncated(t *testing.T) {
+	b, err := encodeUUIDSnapshot(uuidSnapshot{collection: "methode", ordering: sortByDate, uuids: []string{uuid.NewRandom().String(), uuid.NewRandom().String()}})
+	assert.NoError(t, err)
+
+	raw := gunzip(t, b)
+
+	_, err = decodeUUIDSnapshot(bytes.NewReader(regzip(t, raw[:len(raw)-8])), maxSnapshotUUIDs)
+	assert.Error(t, err)
+}
+
+func TestDecodeUUIDSnapshotInvalidMagic(t *testing.T) {
+	_, err := decodeUUIDSnapshot(bytes.NewReader(regzip(t, []byte("JSON["))), maxSnapshotUUIDs)
+	assert.EqualError(t, err, "Invalid uuid snapshot, unexpected magic bytes")
+}
+
+func TestDecodeUUIDSnapshotUnsupportedVersion(t *testing.T) {
+	_, err := decodeUUIDSnapshot(bytes.NewReader(regzip(t, []byte("FTCU\x02"))), maxSnapshotUUIDs)
+	assert.EqualError(t, err, "Unsupported uuid snapshot version 2")
+}
+
+func TestDecodeUUIDSnapshotStringTooLong(t *testing.T) {
+	_, err := decodeUUIDSnapshot(bytes.NewReader(regzip(t, []byte("FTCU\x01\xff\xff\xff\xff\x0f"))), maxSnapshotUUIDs)
+	assert.EqualError(t, err, "Invalid uuid snapshot, unexpected string length of 4294967295 bytes")
+}
+
+func TestDecodeUUIDSnapshotNotGzipped(t *testing.T) {
+	_, err := decodeUUIDSnapshot(bytes.NewReader([]byte(`["a-uuid"]`)), maxSnapshotUUIDs)
+	assert.Error(t, err)
+}
+
+func TestDecodeUUIDSnapshotMoreUUIDsThanTheCollection(t *testing.T) {
+	b, err := encodeUUIDSnapshot(uuidSnapshot{collection: "methode", ordering: sortByDate, uuids: []string{uuid.NewRandom().String(), uuid.NewRandom().String()}})
+	assert.NoError(t, err)
+
+	_, err = decodeUUIDSnapshot(bytes.NewReader(b), 1)
+	assert.EqualError(t, err, "Invalid uuid snapshot, unexpected count of 2 uuids")
+}
+
+func TestDecodeUUIDSnapshotTruncatedWithLargeCount(t *testing.T) {
+	header := &bytes.Buffer{}
+	w := &errWriter{w: header}
+	w.write([]byte(uuidSnapshotMagic))
+	w.write([]byte{uuidSnapshotVersion})
+	w.writeString("methode")
+	w.writeString(sortByDate)
+	w.writeUint(maxSnapshotUUIDs)
+	w.writeUint(0)
+	w.write([]byte(uuid.NewRandom()))
+	assert.NoError(t, w.err)
+
+	_, err := decodeUUIDSnapshot(bytes.NewReader(regzip(t, header.Bytes())), maxSnapshotUUIDs)
+	assert.EqualError(t, err, "Truncated uuid snapshot, expected 268435456 uuids: unexpected EOF")
+}
@@ -0,0 +1,171 @@
+package native
+
+import (
+	"encoding/base64"
+	"errors"
+
+	"github.com/Financial-Times/publish-carousel/blacklist"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const streamingPageSize = 1000
+
+// StreamingUUIDCollection reads the uuids of a collection in _id ordered pages, so only a single page of uuids is ever held in memory.
+// Each page is read fully and its cursor closed before publishing, which avoids cursor timeouts for slowly throttled cycles.
+type StreamingUUIDCollection struct {
+	tx            TX
+	collection    string
+	pageSize      int
+	isBlacklisted blacklist.IsBlacklisted
+
+	skip      int
+	length    int
+	started   bool
+	lastID    interface{}
+	resumeID  interface{}
+	uuids     []string
+	ids       []interface{}
+	exhausted bool
+
+	skipped     skipReasons
+	blacklisted int
+}
+
+func newStreamingUUIDCollection(tx TX, collection string, skip int, afterID interface{}, pageSize int, isBlacklisted blacklist.IsBlacklisted) *StreamingUUIDCollection {
+	return &StreamingUUIDCollection{tx: tx, collection: collection, skip: skip, lastID: afterID, resumeID: afterID, pageSize: pageSize, isBlacklisted: isBlacklisted, skipped: make(skipReasons)}
+}
+
+func (s *StreamingUUIDCollection) Next() (bool, string, error) {
+	for len(s.uuids) == 0 {
+		if s.exhausted {
+			return true, "", nil
+		}
+
+		if err := s.nextPage(); err != nil {
+			return true, "", err
+		}
+	}
+
+	var uuid string
+	uuid, s.uuids = s.uuids[0], s.uuids[1:]
+	s.resumeID, s.ids = s.ids[0], s.ids[1:]
+	return false, uuid, nil
+}
+
+// ResumeToken returns a token for the _id of the last uuid returned by Next, which the collection can be reopened after. Documents after it which were skipped or blacklisted are read again.
+func (s *StreamingUUIDCollection) ResumeToken() string {
+	if s.resumeID == nil {
+		return ""
+	}
+
+	token, err := encodeResumeToken(s.resumeID)
+	if err != nil {
+		log.WithError(err).WithField("collection", s.collection).Warn("Failed to encode the _id to resume the collection from.")
+		return ""
+	}
+	return token
+}
+
+// nextPage reads the next page of uuids from mongo, filtering out invalid and blacklisted uuids
+func (s *StreamingUUIDCollection) nextPage() error {
+	iter, length, err := s.tx.FindUUIDsAfterID(s.collection, s.lastID, s.skip, s.pageSize)
+	if err != nil {
+		return err
+	}
+	defer iter.Close()
+
+	if !s.started {
+		log.WithField("collection", s.collection).WithField("skip", s.skip).WithField("resuming", s.lastID != nil).WithField("length", length).Info("Streaming uuids for collection.")
+		s.started = true
+	}
+
+	s.length = length
+	s.skip = 0
+
+	read := 0
+	for {
+		result := map[string]interface{}{}
+		if !iter.Next(&result) {
+			break
+		}
+
+		read++
+		s.lastID = result["_id"]
+
+		uuid, reason := parseUUID(result["uuid"])
+		if reason != "" {
+			s.skipped[reason]++
+			continue
+		}
+
+		if ok, err := s.isBlacklisted(uuid); err != nil || ok {
+			s.blacklisted++
+			continue
+		}
+
+		s.uuids = append(s.uuids, uuid)
+		s.ids = append(s.ids, result["_id"])
+	}
+
+	if err := iter.Err(); err != nil {
+		return err
+	}
+
+	if iter.Timeout() {
+		return errors.New("Mongo timeout detected")
+	}
+
+	if read < s.pageSize {
+		s.exhausted = true
+		log.WithField("collection", s.collection).WithField("blacklisted", s.blacklisted).WithField("skipped", s.skipped).Info("Finished streaming uuids. Number of records blacklisted or skipped.")
+	}
+
+	return nil
+}
+
+// Length returns the total number of documents in the collection, including any which are skipped or blacklisted
+func (s *StreamingUUIDCollection) Length() int {
+	return s.length
+}
+
+// Skipped returns the number of documents which were skipped, by the reason they were skipped
+func (s *StreamingUUIDCollection) Skipped() map[string]int {
+	return s.skipped
+}
+
+func (s *StreamingUUIDCollection) Done() bool {
+	return s.exhausted && len(s.uuids) == 0
+}
+
+func (s *StreamingUUIDCollection) Close() error {
+	s.tx.Close()
+	return nil
+}
+
+// encodeResumeToken encodes the _id as bson, so it keeps its type, i.e. an ObjectId, when it is stored as a string
+func encodeResumeToken(id interface{}) (string, error) {
+	data, err := bson.Marshal(bson.M{"_id": id})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeResumeToken(token string) (interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := bson.M{}
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	id, ok := doc["_id"]
+	if !ok {
+		return nil, errors.New("Resume token has no _id")
+	}
+	return id, nil
+}
@@ -0,0 +1,203 @@
+package native
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func mockPageIter(docs ...map[string]interface{}) *MockDBIter {
+	iter := new(MockDBIter)
+	if len(docs) > 0 {
+		i := 0
+		iter.On("Next", mock.AnythingOfType("*map[string]interface {}")).Return(true).Run(func(args mock.Arguments) {
+			result := args.Get(0).(*map[string]interface{})
+			for k, v := range docs[i] {
+				(*result)[k] = v
+			}
+			i++
+		}).Times(len(docs))
+	}
+	iter.On("Next", mock.AnythingOfType("*map[string]interface {}")).Return(false)
+	iter.On("Err").Return(nil)
+	iter.On("Timeout").Return(false)
+	iter.On("Close").Return(nil)
+	return iter
+}
+
+func testDoc(id int, docUUID string) map[string]interface{} {
+	doc := map[string]interface{}{"_id": id}
+	if docUUID != "" {
+		doc["uuid"] = bson.Binary{Kind: 0x04, Data: []byte(uuid.Parse(docUUID))}
+	}
+	return doc
+}
+
+func TestStreamingUUIDCollection(t *testing.T) {
+	uuid1 := uuid.NewUUID().String()
+	uuid2 := uuid.NewUUID().String()
+	uuid3 := uuid.NewUUID().String()
+
+	tx := new(MockTX)
+	tx.On("FindUUIDsAfterID", "collection", nil, 0, 2).Return(mockPageIter(testDoc(1, uuid1), testDoc(2, uuid2)), 3, nil)
+	tx.On("FindUUIDsAfterID", "collection", 2, 0, 2).Return(mockPageIter(testDoc(3, uuid3)), 3, nil)
+	tx.On("Close").Return()
+
+	c := newStreamingUUIDCollection(tx, "collection", 0, nil, 2, noopBlacklist)
+	assert.NoError(t, c.nextPage())
+	assert.Equal(t, 3, c.Length())
+
+	var actual []string
+	for !c.Done() {
+		finished, val, err := c.Next()
+		assert.NoError(t, err)
+		if finished {
+			break
+		}
+		actual = append(actual, val)
+	}
+
+	assert.Equal(t, []string{uuid1, uuid2, uuid3}, actual)
+
+	finished, _, err := c.Next()
+	assert.NoError(t, err)
+	assert.True(t, finished)
+
+	assert.NoError(t, c.Close())
+	tx.AssertExpectations(t)
+}
+
+func TestStreamingUUIDCollectionWithSkip(t *testing.T) {
+	uuid3 := uuid.NewUUID().String()
+
+	tx := new(MockTX)
+	tx.On("FindUUIDsAfterID", "collection", nil, 2, 10).Return(mockPageIter(testDoc(3, uuid3)), 3, nil)
+
+	c := newStreamingUUIDCollection(tx, "collection", 2, nil, 10, noopBlacklist)
+	assert.NoError(t, c.nextPage())
+	assert.Equal(t, 3, c.Length())
+
+	finished, val, err := c.Next()
+	assert.NoError(t, err)
+	assert.False(t, finished)
+	assert.Equal(t, uuid3, val)
+
+	finished, _, err = c.Next()
+	assert.NoError(t, err)
+	assert.True(t, finished)
+	assert.True(t, c.Done())
+
+	tx.AssertExpectations(t)
+}
+
+func TestStreamingUUIDCollectionSkipsInvalidAndBlacklisted(t *testing.T) {
+	uuid1 := uuid.NewUUID().String()
+	blacklistedUUID := uuid.NewUUID().String()
+
+	tx := new(MockTX)
+	tx.On("FindUUIDsAfterID", "collection", nil, 0, 3).Return(mockPageIter(testDoc(1, ""), testDoc(2, blacklistedUUID), testDoc(3, uuid1)), 3, nil)
+	tx.On("FindUUIDsAfterID", "collection", 3, 0, 3).Return(mockPageIter(), 3, nil)
+
+	isBlacklisted := func(val string) (bool, error) {
+		return val == blacklistedUUID, nil
+	}
+
+	c := newStreamingUUIDCollection(tx, "collection", 0, nil, 3, isBlacklisted)
+	assert.NoError(t, c.nextPage())
+	assert.Empty(t, c.ResumeToken())
+
+	finished, val, err := c.Next()
+	assert.NoError(t, err)
+	assert.False(t, finished)
+	assert.Equal(t, uuid1, val)
+
+	afterID, err := decodeResumeToken(c.ResumeToken())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, afterID, "the collection resumes after the last uuid it returned")
+
+	finished, _, err = c.Next()
+	assert.NoError(t, err)
+	assert.True(t, finished)
+
+	assert.Equal(t, 1, c.skipped[missingUUIDReason])
+	assert.Equal(t, 1, c.blacklisted)
+	tx.AssertExpectations(t)
+}
+
+func TestStreamingUUIDCollectionFindFails(t *testing.T) {
+	uuid1 := uuid.NewUUID().String()
+
+	tx := new(MockTX)
+	tx.On("FindUUIDsAfterID", "collection", nil, 0, 1).Return(mockPageIter(testDoc(1, uuid1)), 2, nil)
+	tx.On("FindUUIDsAfterID", "collection", 1, 0, 1).Return(new(MockDBIter), 0, errors.New("computer says no"))
+
+	c := newStreamingUUIDCollection(tx, "collection", 0, nil, 1, noopBlacklist)
+	assert.NoError(t, c.nextPage())
+
+	_, val, err := c.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, uuid1, val)
+
+	finished, _, err := c.Next()
+	assert.EqualError(t, err, "computer says no")
+	assert.True(t, finished)
+
+	tx.AssertExpectations(t)
+}
+
+func TestNewStreamingUUIDCollection(t *testing.T) {
+	tx := new(MockTX)
+	tx.On("FindUUIDsAfterID", "collection", nil, 5, streamingPageSize).Return(mockPageIter(), 5, nil)
+
+	db := new(MockDB)
+	db.On("Open").Return(tx, nil)
+
+	builder := NewNativeUUIDCollectionBuilder(db, nil, noopBlacklist)
+	c, err := builder.NewStreamingUUIDCollection("collection", 5, "")
+	assert.NoError(t, err)
+	assert.Equal(t, 5, c.Length())
+	assert.True(t, c.Done())
+
+	mock.AssertExpectationsForObjects(t, db, tx)
+}
+
+func TestNewStreamingUUIDCollectionResumesAfterToken(t *testing.T) {
+	lastID := bson.NewObjectId()
+	token, err := encodeResumeToken(lastID)
+	assert.NoError(t, err)
+
+	tx := new(MockTX)
+	tx.On("FindUUIDsAfterID", "collection", lastID, 0, streamingPageSize).Return(mockPageIter(), 5, nil)
+
+	db := new(MockDB)
+	db.On("Open").Return(tx, nil)
+
+	builder := NewNativeUUIDCollectionBuilder(db, nil, noopBlacklist)
+	c, err := builder.NewStreamingUUIDCollection("collection", 2, token)
+	assert.NoError(t, err)
+	assert.Equal(t, token, c.(ResumableUUIDCollection).ResumeToken(), "the _id keeps its type, and the skip is ignored")
+
+	_, err = builder.NewStreamingUUIDCollection("collection", 2, "not a token")
+	assert.Error(t, err)
+
+	mock.AssertExpectationsForObjects(t, db, tx)
+}
+
+func TestNewStreamingUUIDCollectionFindFails(t *testing.T) {
+	tx := new(MockTX)
+	tx.On("FindUUIDsAfterID", "collection", nil, 0, streamingPageSize).Return(new(MockDBIter), 0, errors.New("computer says no"))
+	tx.On("Close").Return()
+
+	db := new(MockDB)
+	db.On("Open").Return(tx, nil)
+
+	builder := NewNativeUUIDCollectionBuilder(db, nil, noopBlacklist)
+	_, err := builder.NewStreamingUUIDCollection("collection", 0, "")
+	assert.Error(t, err)
+
+	mock.AssertExpectationsForObjects(t, db, tx)
+}
@@ -0,0 +1,218 @@
+package native
+
+import (
+	"errors"
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	DefaultTimestampField = "content.lastModified"
+
+	// StringTimestampType is for RFC3339 timestamps stored as strings, with or without fractional seconds and with any offset
+	StringTimestampType = "string"
+	// DateTimestampType is for timestamps stored as bson dates
+	DateTimestampType = "date"
+	// EpochTimestampType is for timestamps stored as the number of seconds since the unix epoch
+	EpochTimestampType = "epoch"
+	// EpochMillisTimestampType is for timestamps stored as the number of milliseconds since the unix epoch
+	EpochMillisTimestampType = "epochMillis"
+
+	// maxUTCOffset is the largest offset from UTC a timestamp string can have
+	maxUTCOffset = 14 * time.Hour
+
+	maxTimestampExamples = 10
+)
+
+// TimestampReport counts the documents in a collection which are missing a timestamp, or whose timestamp cannot be parsed as the configured type. These documents are never found by time windowed cycles.
+type TimestampReport struct {
+	Collection     string        `json:"collection"`
+	TimestampField string        `json:"timestampField"`
+	TimestampType  string        `json:"timestampType"`
+	Total          int           `json:"total"`
+	Missing        int           `json:"missing"`
+	Unparseable    int           `json:"unparseable"`
+	Examples       []interface{} `json:"examples,omitempty"`
+}
+
+func validTimestampType(timestampType string) bool {
+	switch timestampType {
+	case "", StringTimestampType, DateTimestampType, EpochTimestampType, EpochMillisTimestampType:
+		return true
+	}
+	return false
+}
+
+// timeWindowQuery finds documents with a timestamp in the window [start, end). Strings are compared lexicographically by mongo, so for string timestamps the window is widened to include any offset or fractional seconds, and documents outside the window are filtered by the iterator, and are not counted.
+func (c CollectionConfig) timeWindowQuery(start time.Time, end time.Time) bson.M {
+	var lower, upper interface{}
+
+	switch c.TimestampType {
+	case DateTimestampType:
+		lower, upper = start.UTC(), end.UTC()
+	case EpochTimestampType:
+		lower, upper = start.Unix(), end.Unix()
+	case EpochMillisTimestampType:
+		lower, upper = toMillis(start), toMillis(end)
+	default:
+		lower = start.Add(-maxUTCOffset).UTC().Format(time.RFC3339)
+		upper = end.Add(maxUTCOffset).UTC().Format(time.RFC3339)
+	}
+
+	return bson.M{
+		"$and": []bson.M{
+			{c.TimestampField: bson.M{"$gte": lower}},
+			{c.TimestampField: bson.M{"$lt": upper}},
+		},
+	}
+}
+
+func (c CollectionConfig) sortByTimestamp() string {
+	return "-" + c.TimestampField
+}
+
+// parseTimestamp reads the timestamp of a document, which must be stored as the configured type
+func (c CollectionConfig) parseTimestamp(val interface{}) (time.Time, bool) {
+	switch c.TimestampType {
+	case DateTimestampType:
+		t, ok := val.(time.Time)
+		return t, ok
+	case EpochTimestampType, EpochMillisTimestampType:
+		n, ok := toInt64(val)
+		if !ok {
+			return time.Time{}, false
+		}
+
+		if c.TimestampType == EpochMillisTimestampType {
+			return time.Unix(0, n*int64(time.Millisecond)), true
+		}
+		return time.Unix(n, 0), true
+	}
+
+	s, ok := val.(string)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, s)
+	return t, err == nil
+}
+
+func toMillis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+func toInt64(val interface{}) (int64, bool) {
+	switch n := val.(type) {
+	case int:
+		return int64(n), true
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	case float64:
+		return int64(n), true
+	}
+	return 0, false
+}
+
+// Timestamp reads the configured timestamp of the native content. Only the fields of the document which are read into the content can be found, so timestamps stored elsewhere in the document are reported as missing.
+func (c CollectionConfig) Timestamp(content *Content) (time.Time, bool) {
+	doc := map[string]interface{}{"content": content.Body, "content-type": content.ContentType, "origin-system-id": content.OriginSystemID}
+	return c.parseTimestamp(lookupField(doc, c.TimestampField))
+}
+
+// timeWindowIter skips any documents whose timestamp is outside the time window
+type timeWindowIter struct {
+	DBIter
+	config CollectionConfig
+	start  time.Time
+	end    time.Time
+}
+
+func newTimeWindowIter(iter DBIter, config CollectionConfig, start time.Time, end time.Time) DBIter {
+	if config.TimestampType != StringTimestampType {
+		return iter
+	}
+	return &timeWindowIter{DBIter: iter, config: config, start: start, end: end}
+}
+
+func (i *timeWindowIter) Next(result interface{}) bool {
+	for i.DBIter.Next(result) {
+		doc, ok := result.(*map[string]interface{})
+		if !ok {
+			return true
+		}
+
+		t, ok := i.config.parseTimestamp(lookupField(*doc, i.config.TimestampField))
+		if ok && !t.Before(i.start) && t.Before(i.end) {
+			return true
+		}
+	}
+	return false
+}
+
+// countInTimeWindow reads every document from the iterator, which must already skip any documents outside the time window
+func countInTimeWindow(iter DBIter) (int, error) {
+	count := 0
+	for {
+		doc := map[string]interface{}{}
+		if !iter.Next(&doc) {
+			break
+		}
+		count++
+	}
+
+	if err := iter.Err(); err != nil {
+		return 0, err
+	}
+
+	if iter.Timeout() {
+		return 0, errors.New("Mongo timeout detected")
+	}
+
+	return count, nil
+}
+
+// countTimestamps reads every document from the iterator, counting those with a missing or unparseable timestamp
+func countTimestamps(collection string, config CollectionConfig, iter DBIter) (*TimestampReport, error) {
+	report := &TimestampReport{Collection: collection, TimestampField: config.TimestampField, TimestampType: config.TimestampType}
+
+	for {
+		doc := map[string]interface{}{}
+		if !iter.Next(&doc) {
+			break
+		}
+
+		report.Total++
+
+		val := lookupField(doc, config.TimestampField)
+		if val == nil {
+			report.Missing++
+			report.example(doc["_id"])
+			continue
+		}
+
+		if _, ok := config.parseTimestamp(val); !ok {
+			report.Unparseable++
+			report.example(doc["_id"])
+		}
+	}
+
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+
+	if iter.Timeout() {
+		return nil, errors.New("Mongo timeout detected")
+	}
+
+	return report, nil
+}
+
+func (r *TimestampReport) example(id interface{}) {
+	if len(r.Examples) < maxTimestampExamples {
+		r.Examples = append(r.Examples, id)
+	}
+}
@@ -0,0 +1,157 @@
+package native
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestTimeWindowQuery(t *testing.T) {
+	end := time.Date(2017, 03, 16, 0, 0, 0, 0, time.UTC)
+	start := end.Add(time.Minute * -1)
+
+	var tests = []struct {
+		timestampType string
+		expected      string
+	}{
+		{DateTimestampType, `{"$and":[{"lastModified":{"$gte":{"$date":"2017-03-15T23:59:00Z"}}},{"lastModified":{"$lt":{"$date":"2017-03-16T00:00:00Z"}}}]}`},
+		{EpochTimestampType, `{"$and":[{"lastModified":{"$gte":{"$numberLong":1489622340}}},{"lastModified":{"$lt":{"$numberLong":1489622400}}}]}`},
+		{EpochMillisTimestampType, `{"$and":[{"lastModified":{"$gte":{"$numberLong":1489622340000}}},{"lastModified":{"$lt":{"$numberLong":1489622400000}}}]}`},
+		{StringTimestampType, `{"$and":[{"lastModified":{"$gte":"2017-03-15T09:59:00Z"}},{"lastModified":{"$lt":"2017-03-16T14:00:00Z"}}]}`},
+	}
+
+	for _, test := range tests {
+		config := CollectionConfigs{"c": {TimestampField: "lastModified", TimestampType: test.timestampType}}.Get("c")
+
+		data, err := bson.MarshalJSON(config.timeWindowQuery(start, end))
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, strings.TrimSpace(string(data)), test.timestampType)
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	expected := time.Date(2017, 03, 16, 0, 0, 0, 0, time.UTC)
+
+	var tests = []struct {
+		timestampType string
+		val           interface{}
+		ok            bool
+	}{
+		{StringTimestampType, "2017-03-16T00:00:00Z", true},
+		{StringTimestampType, "2017-03-16T00:00:00.000Z", true},
+		{StringTimestampType, "2017-03-16T01:00:00+01:00", true},
+		{StringTimestampType, "16/03/2017", false},
+		{StringTimestampType, expected, false},
+		{DateTimestampType, expected, true},
+		{DateTimestampType, "2017-03-16T00:00:00Z", false},
+		{EpochTimestampType, int64(1489622400), true},
+		{EpochTimestampType, 1489622400, true},
+		{EpochTimestampType, float64(1489622400), true},
+		{EpochMillisTimestampType, int64(1489622400000), true},
+		{EpochMillisTimestampType, "1489622400000", false},
+	}
+
+	for _, test := range tests {
+		config := CollectionConfig{TimestampType: test.timestampType}
+
+		actual, ok := config.parseTimestamp(test.val)
+		assert.Equal(t, test.ok, ok, "%v %v", test.timestampType, test.val)
+		if test.ok {
+			assert.True(t, expected.Equal(actual), "%v %v", test.timestampType, test.val)
+		}
+	}
+}
+
+func TestTimeWindowIterFiltersStringTimestamps(t *testing.T) {
+	end := time.Date(2017, 03, 16, 0, 0, 0, 0, time.UTC)
+	start := end.Add(time.Minute * -1)
+
+	doc := func(id int, lastModified string) map[string]interface{} {
+		return map[string]interface{}{"_id": id, "content": map[string]interface{}{"lastModified": lastModified}}
+	}
+
+	iter := mockPageIter(
+		doc(1, "2017-03-15T23:58:59.999Z"),
+		doc(2, "2017-03-15T23:59:00.5Z"),
+		doc(3, "2017-03-16T00:59:30+01:00"),
+		doc(4, "2017-03-16T00:00:00Z"),
+		doc(5, "not a timestamp"),
+	)
+
+	filtered := newTimeWindowIter(iter, CollectionConfigs{}.Get("methode"), start, end)
+
+	var ids []int
+	for {
+		result := map[string]interface{}{}
+		if !filtered.Next(&result) {
+			break
+		}
+		ids = append(ids, result["_id"].(int))
+	}
+
+	assert.Equal(t, []int{2, 3}, ids)
+}
+
+func TestCountInTimeWindowOnlyCountsDocumentsInTheWindow(t *testing.T) {
+	end := time.Date(2017, 03, 16, 0, 0, 0, 0, time.UTC)
+	start := end.Add(time.Minute * -1)
+
+	doc := func(lastModified string) map[string]interface{} {
+		return map[string]interface{}{"content": map[string]interface{}{"lastModified": lastModified}}
+	}
+
+	iter := mockPageIter(
+		doc("2017-03-15T10:00:00Z"),
+		doc("2017-03-15T23:59:30Z"),
+		doc("2017-03-16T00:59:30+01:00"),
+		doc("2017-03-16T12:00:00Z"),
+	)
+
+	count, err := countInTimeWindow(newTimeWindowIter(iter, CollectionConfigs{}.Get("methode"), start, end))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count, "documents matched by the widened query, but outside the window, should not be counted")
+}
+
+func TestTimeWindowIterOnlyFiltersStrings(t *testing.T) {
+	iter := mockPageIter()
+	config := CollectionConfigs{"c": {TimestampType: DateTimestampType}}.Get("c")
+	assert.Equal(t, iter, newTimeWindowIter(iter, config, time.Now(), time.Now()))
+}
+
+func TestCountTimestamps(t *testing.T) {
+	iter := mockPageIter(
+		map[string]interface{}{"_id": 1, "content": map[string]interface{}{"lastModified": "2017-03-16T00:00:00Z"}},
+		map[string]interface{}{"_id": 2, "content": map[string]interface{}{}},
+		map[string]interface{}{"_id": 3, "content": map[string]interface{}{"lastModified": "yesterday"}},
+		map[string]interface{}{"_id": 4, "content": map[string]interface{}{"lastModified": time.Now()}},
+	)
+
+	report, err := countTimestamps("methode", CollectionConfigs{}.Get("methode"), iter)
+	assert.NoError(t, err)
+	assert.Equal(t, &TimestampReport{
+		Collection:     "methode",
+		TimestampField: DefaultTimestampField,
+		TimestampType:  StringTimestampType,
+		Total:          4,
+		Missing:        1,
+		Unparseable:    2,
+		Examples:       []interface{}{2, 3, 4},
+	}, report)
+}
+
+func TestContentTimestamp(t *testing.T) {
+	content := &Content{Body: map[string]interface{}{"lastModified": "2017-05-01T12:00:00.000Z"}}
+
+	ts, ok := CollectionConfigs{}.Get("methode").Timestamp(content)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2017, 5, 1, 12, 0, 0, 0, time.UTC), ts.UTC())
+
+	_, ok = CollectionConfig{TimestampField: "lastModified", TimestampType: StringTimestampType}.Timestamp(content)
+	assert.False(t, ok, "fields outside of the content are not available")
+
+	_, ok = CollectionConfig{TimestampField: DefaultTimestampField, TimestampType: DateTimestampType}.Timestamp(content)
+	assert.False(t, ok)
+}
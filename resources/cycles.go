@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sort"
 	"strings"
 
 	"github.com/Financial-Times/publish-carousel/scheduler"
@@ -11,15 +12,22 @@ import (
 	"github.com/husobee/vestigo"
 )
 
-// GetCycles returns all cycles as an array
+// GetCycles returns all cycles as an array, optionally filtered by the selector query parameter
 func GetCycles(sched scheduler.Scheduler) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Add("Content-Type", "application/json")
-		cycles := sched.Cycles()
+
+		selector, err := scheduler.ParseSelector(r.URL.Query().Get("selector"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 
 		arr := make([]scheduler.Cycle, 0)
-		for _, c := range cycles {
-			arr = append(arr, c)
+		for _, c := range sched.Cycles() {
+			if selector.Matches(c) {
+				arr = append(arr, c)
+			}
 		}
 
 		data, err := json.Marshal(arr)
@@ -149,6 +157,90 @@ func StopCycle(sched scheduler.Scheduler) func(w http.ResponseWriter, r *http.Re
 	}
 }
 
+type cycleGroupResult struct {
+	ID    string   `json:"id"`
+	Name  string   `json:"name"`
+	State []string `json:"state"`
+	Error string   `json:"error,omitempty"`
+}
+
+// StopCycles stops every cycle which matches the selector query parameter
+func StopCycles(sched scheduler.Scheduler) func(w http.ResponseWriter, r *http.Request) {
+	return cycleGroupAction(sched, func(cycle scheduler.Cycle) error {
+		cycle.Stop()
+		return nil
+	})
+}
+
+// ResumeCycles resumes every stopped cycle which matches the selector query parameter
+func ResumeCycles(sched scheduler.Scheduler) func(w http.ResponseWriter, r *http.Request) {
+	return cycleGroupAction(sched, func(cycle scheduler.Cycle) error {
+		if states := cycle.State(); !scheduler.IsStopped(states) {
+			return fmt.Errorf("Cycle is not stopped: %v", strings.Join(states, ","))
+		}
+
+		cycle.Start()
+		return nil
+	})
+}
+
+// ResetCycles stops and completely resets every cycle which matches the selector query parameter
+func ResetCycles(sched scheduler.Scheduler) func(w http.ResponseWriter, r *http.Request) {
+	return cycleGroupAction(sched, func(cycle scheduler.Cycle) error {
+		cycle.Reset()
+		return nil
+	})
+}
+
+func cycleGroupAction(sched scheduler.Scheduler, action func(cycle scheduler.Cycle) error) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Content-Type", "application/json")
+
+		selector, err := scheduler.ParseSelector(r.URL.Query().Get("selector"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if selector.Empty() {
+			http.Error(w, "Please provide a selector for the cycles, i.e. ?selector=origin=methode-web-pub", http.StatusBadRequest)
+			return
+		}
+
+		var cycles []scheduler.Cycle
+		for _, c := range sched.Cycles() {
+			if selector.Matches(c) {
+				cycles = append(cycles, c)
+			}
+		}
+
+		sort.Slice(cycles, func(i, j int) bool {
+			return cycles[i].ID() < cycles[j].ID()
+		})
+
+		results := make([]cycleGroupResult, 0)
+		for _, cycle := range cycles {
+			result := cycleGroupResult{ID: cycle.ID(), Name: cycle.Name()}
+			if err := action(cycle); err != nil {
+				log.WithError(err).WithField("cycleID", cycle.ID()).Warn("Failed to apply action to cycle.")
+				result.Error = err.Error()
+			}
+
+			result.State = cycle.State()
+			results = append(results, result)
+		}
+
+		data, err := json.Marshal(results)
+		if err != nil {
+			log.WithError(err).Warn("Error in marshalling cycle results")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Write(data)
+	}
+}
+
 // Get a cycle throttle
 func GetCycleThrottle(sched scheduler.Scheduler) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
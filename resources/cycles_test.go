@@ -363,3 +363,111 @@ func TestSetCycleThrottleRedirectUsesOriginalRequestURL(t *testing.T) {
 	assert.Equal(t, "https://www.example.com/__test/"+fmt.Sprintf("/cycles/%s", cycleID), w.Header().Get("Location"), "Location header")
 	assert.Equal(t, newCycle.Metadata(), metadata)
 }
+
+func mockLabelledCycle(id string, origin string, state []string) *scheduler.MockCycle {
+	cycle := new(scheduler.MockCycle)
+	cycle.On("ID").Return(id)
+	cycle.On("Name").Return(id + "-name")
+	cycle.On("TransformToConfig").Return(scheduler.CycleConfig{Name: id + "-name", Labels: map[string]string{"origin": origin}})
+	cycle.On("State").Return(state)
+	return cycle
+}
+
+func TestGetCyclesWithSelector(t *testing.T) {
+	sched := new(scheduler.MockScheduler)
+	cycles := make(map[string]scheduler.Cycle)
+
+	uuidCollectionBuilder := native.NewNativeUUIDCollectionBuilder(nil, nil, blacklist.NoOpBlacklist)
+	wordpress, err := scheduler.NewScheduler(uuidCollectionBuilder, nil, nil, time.Minute, time.Minute, scheduler.DefaultRestartPolicy, nil, nil, nil, nil).NewCycle(scheduler.CycleConfig{Name: "wordpress-one-hour", Type: "ScalingWindow", Origin: "wordpress", Collection: "wordpress", CoolDown: "5m", TimeWindow: "1h", MinimumThrottle: "1s", MaximumThrottle: "1m", Labels: map[string]string{"origin": "wordpress"}})
+	assert.NoError(t, err)
+
+	cycles[wordpress.ID()] = wordpress
+	cycles["methode"] = mockLabelledCycle("methode", "methode", []string{"running"})
+
+	sched.On("Cycles").Return(cycles)
+
+	r := httptest.NewRequest("GET", "/cycles?selector=origin%3Dwordpress", nil)
+	w := setupRouter(sched, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"labels":{"origin":"wordpress"}`)
+	assert.NotContains(t, w.Body.String(), `methode`)
+	sched.AssertExpectations(t)
+}
+
+func TestGetCyclesWithInvalidSelector(t *testing.T) {
+	sched := new(scheduler.MockScheduler)
+
+	r := httptest.NewRequest("GET", "/cycles?selector=origin", nil)
+	w := setupRouter(sched, r)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	sched.AssertExpectations(t)
+}
+
+func TestStopCyclesWithSelector(t *testing.T) {
+	sched := new(scheduler.MockScheduler)
+
+	methode1 := mockLabelledCycle("methode1", "methode", []string{"stopped"})
+	methode1.On("Stop").Return()
+	methode2 := mockLabelledCycle("methode2", "methode", []string{"stopped"})
+	methode2.On("Stop").Return()
+	wordpress := mockLabelledCycle("wordpress", "wordpress", []string{"running"})
+
+	sched.On("Cycles").Return(map[string]scheduler.Cycle{"methode1": methode1, "methode2": methode2, "wordpress": wordpress})
+
+	req := httptest.NewRequest("POST", "/cycles/stop?selector=origin%3Dmethode", nil)
+	w := setupRouter(sched, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[{"id":"methode1","name":"methode1-name","state":["stopped"]},{"id":"methode2","name":"methode2-name","state":["stopped"]}]`, w.Body.String())
+
+	sched.AssertExpectations(t)
+	methode1.AssertExpectations(t)
+	methode2.AssertExpectations(t)
+	wordpress.AssertNotCalled(t, "Stop")
+}
+
+func TestStopCyclesRequiresSelector(t *testing.T) {
+	sched := new(scheduler.MockScheduler)
+
+	req := httptest.NewRequest("POST", "/cycles/stop", nil)
+	w := setupRouter(sched, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	sched.AssertNotCalled(t, "Cycles")
+}
+
+func TestResumeCyclesSkipsRunningCycles(t *testing.T) {
+	sched := new(scheduler.MockScheduler)
+
+	stopped := mockLabelledCycle("stopped", "methode", []string{"stopped", "unhealthy"})
+	stopped.On("Start").Return()
+	running := mockLabelledCycle("running", "methode", []string{"running"})
+
+	sched.On("Cycles").Return(map[string]scheduler.Cycle{"stopped": stopped, "running": running})
+
+	req := httptest.NewRequest("POST", "/cycles/resume?selector=origin%3Dmethode", nil)
+	w := setupRouter(sched, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[{"id":"running","name":"running-name","state":["running"],"error":"Cycle is not stopped: running"},{"id":"stopped","name":"stopped-name","state":["stopped","unhealthy"]}]`, w.Body.String())
+
+	stopped.AssertExpectations(t)
+	running.AssertNotCalled(t, "Start")
+}
+
+func TestResetCyclesWithSelector(t *testing.T) {
+	sched := new(scheduler.MockScheduler)
+
+	methode := mockLabelledCycle("methode", "methode", []string{})
+	methode.On("Reset").Return()
+
+	sched.On("Cycles").Return(map[string]scheduler.Cycle{"methode": methode})
+
+	req := httptest.NewRequest("POST", "/cycles/reset?selector=origin%3Dmethode", nil)
+	w := setupRouter(sched, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	methode.AssertExpectations(t)
+}
@@ -130,7 +130,7 @@ func pingMongo(db native.DB) func() (string, error) {
 	return func() (string, error) {
 		tx, err := db.Open()
 		if err != nil {
-			return "", err
+			return "", mongoStatsError(db, err)
 		}
 
 		defer func() { go tx.Close() }()
@@ -140,13 +140,23 @@ func pingMongo(db native.DB) func() (string, error) {
 
 		err = tx.Ping(ctx)
 		if err != nil {
-			return "", err
+			return "", mongoStatsError(db, err)
 		}
 
+		if reporter, ok := db.(native.StatsReporter); ok {
+			return fmt.Sprintf("OK, %v", reporter.Stats()), nil
+		}
 		return "OK", nil
 	}
 }
 
+func mongoStatsError(db native.DB, err error) error {
+	if reporter, ok := db.(native.StatsReporter); ok {
+		return fmt.Errorf("%v (%v)", err, reporter.Stats())
+	}
+	return err
+}
+
 func pingS3(svc s3.ReadWriter) func() (string, error) {
 	return func() (string, error) {
 		err := svc.Ping()
@@ -184,6 +194,15 @@ func cmsNotifierGTG(notifier cms.Notifier) func() (string, error) {
 			return "", err
 		}
 
+		if router, ok := notifier.(cms.TargetsNotifier); ok {
+			return toJSON(router.Targets()), nil
+		}
+
+		if pool, ok := notifier.(cms.EndpointsNotifier); ok {
+			if endpoints := pool.Endpoints(); len(endpoints) > 0 {
+				return toJSON(endpoints), nil
+			}
+		}
 		return "OK", nil
 	}
 }
@@ -241,6 +241,34 @@ func TestUnhappyCMSNotifierHealthcheck(t *testing.T) {
 	}
 }
 
+func TestCMSNotifierHealthcheckListsEndpoints(t *testing.T) {
+	pool := new(cms.MockPooledNotifier)
+	pool.On("Check").Return(nil)
+	pool.On("Endpoints").Return([]cms.EndpointStatus{
+		{URL: "http://notifier-1:8080", Available: true, Latency: "120ms"},
+		{URL: "http://notifier-2:8080", Available: false, ConsecutiveFailures: 2, LastError: "GTG for cms-notifier returned a non-200 code: 503"},
+	})
+
+	output, err := cmsNotifierGTG(pool)()
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[{"url":"http://notifier-1:8080","available":true,"consecutiveFailures":0,"latency":"120ms"},{"url":"http://notifier-2:8080","available":false,"consecutiveFailures":2,"lastError":"GTG for cms-notifier returned a non-200 code: 503"}]`, output)
+	pool.AssertExpectations(t)
+}
+
+func TestCMSNotifierHealthcheckListsTargets(t *testing.T) {
+	router := new(cms.MockRoutingNotifier)
+	router.On("Check").Return(nil)
+	router.On("Targets").Return([]cms.TargetStatus{
+		{Name: cms.DefaultTarget, Succeeded: 12},
+		{Name: "eu", URL: "http://eu-notifier:8080", Succeeded: 3, Failed: 1, LastError: "A non 2xx error code was received by the CMS Notifier! Status: 400"},
+	})
+
+	output, err := cmsNotifierGTG(router)()
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[{"name":"default","succeeded":12,"failed":0},{"name":"eu","url":"http://eu-notifier:8080","succeeded":3,"failed":1,"lastError":"A non 2xx error code was received by the CMS Notifier! Status: 400"}]`, output)
+	router.AssertExpectations(t)
+}
+
 func TestUnhappyCyclesHealthcheck(t *testing.T) {
 	endpoint, mocks := setupTestHealthcheckEndpoint(nil)
 	req := httptest.NewRequest("GET", "http://example.com/__health", nil)
@@ -565,3 +593,36 @@ func TestUnhappyGTG(t *testing.T) {
 	endpoint(w, req)
 	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
 }
+
+type mockStatsDB struct {
+	*native.MockDB
+	stats native.MongoStats
+}
+
+func (m *mockStatsDB) Stats() native.MongoStats {
+	return m.stats
+}
+
+func TestPingMongoReportsStats(t *testing.T) {
+	tx := new(native.MockTX)
+	tx.On("Ping", mock.AnythingOfType("*context.timerCtx")).Return(nil)
+	tx.On("Close").Return()
+
+	db := &mockStatsDB{MockDB: new(native.MockDB), stats: native.MongoStats{Connected: true, LiveServers: []string{"mongo-1:27017"}, Reconnects: 2}}
+	db.On("Open").Return(tx, nil)
+
+	output, err := pingMongo(db)()
+	assert.NoError(t, err)
+	assert.Contains(t, output, "live servers: [mongo-1:27017]")
+	assert.Contains(t, output, "reconnects: 2")
+}
+
+func TestPingMongoFailureReportsStats(t *testing.T) {
+	db := &mockStatsDB{MockDB: new(native.MockDB), stats: native.MongoStats{FailedDials: 3}}
+	db.On("Open").Return(new(native.MockTX), errors.New("no reachable servers"))
+
+	_, err := pingMongo(db)()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no reachable servers")
+	assert.Contains(t, err.Error(), "failed dials: 3")
+}
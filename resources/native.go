@@ -0,0 +1,325 @@
+package resources
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/Financial-Times/publish-carousel/blacklist"
+	"github.com/Financial-Times/publish-carousel/cms"
+	"github.com/Financial-Times/publish-carousel/ledger"
+	"github.com/Financial-Times/publish-carousel/native"
+	"github.com/Financial-Times/publish-carousel/scheduler"
+	"github.com/Financial-Times/publish-carousel/tasks"
+	"github.com/husobee/vestigo"
+	log "github.com/sirupsen/logrus"
+	mgo "gopkg.in/mgo.v2"
+)
+
+// GetTimestampReport reports how many documents in the native collection have a missing or unparseable timestamp, and so are never republished by time windowed cycles
+func GetTimestampReport(db native.DB) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Content-Type", "application/json")
+		collection := vestigo.Param(r, "collection")
+
+		tx, err := db.Open()
+		if err != nil {
+			log.WithError(err).Error("Failed to connect to mongo")
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		defer tx.Close()
+
+		report, err := tx.CountTimestamps(collection)
+		if err != nil {
+			log.WithError(err).WithField("collection", collection).Error("Failed to count timestamps for collection")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		data, err := json.Marshal(report)
+		if err != nil {
+			log.WithError(err).Warn("Error in marshalling timestamp report")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Write(data)
+	}
+}
+
+type coveringCycle struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type nativeCollection struct {
+	native.CollectionSummary
+	Covered bool            `json:"covered"`
+	Cycles  []coveringCycle `json:"cycles"`
+}
+
+// GetNativeCollections lists the native collections with their sizes and timestamp ranges, and the cycles which cover them. Collections which no cycle covers are flagged as not covered.
+func GetNativeCollections(db native.DB, sched scheduler.Scheduler) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Content-Type", "application/json")
+
+		tx, err := db.Open()
+		if err != nil {
+			log.WithError(err).Error("Failed to connect to mongo")
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		defer tx.Close()
+
+		summaries, err := tx.ListCollections()
+		if err != nil {
+			log.WithError(err).Error("Failed to list native collections")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		covering := make(map[string][]coveringCycle)
+		for _, c := range sched.Cycles() {
+			collection := c.TransformToConfig().Collection
+			covering[collection] = append(covering[collection], coveringCycle{ID: c.ID(), Name: c.Name()})
+		}
+
+		collections := make([]nativeCollection, 0, len(summaries))
+		for _, summary := range summaries {
+			cycles := covering[summary.Collection]
+			if cycles == nil {
+				cycles = make([]coveringCycle, 0)
+			}
+
+			sort.Slice(cycles, func(i, j int) bool { return cycles[i].Name < cycles[j].Name })
+			collections = append(collections, nativeCollection{CollectionSummary: summary, Covered: len(cycles) > 0, Cycles: cycles})
+		}
+
+		data, err := json.Marshal(collections)
+		if err != nil {
+			log.WithError(err).Warn("Error in marshalling native collections")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Write(data)
+	}
+}
+
+// GetRepublishHistory returns when the uuid was last successfully republished by the carousel, and with which transaction id
+func GetRepublishHistory(republishLedger ledger.Ledger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Content-Type", "application/json")
+		collection := vestigo.Param(r, "collection")
+		uuid := vestigo.Param(r, "uuid")
+
+		entry, ok := republishLedger.Get(collection, uuid)
+		if !ok {
+			http.Error(w, "The carousel has not republished this uuid", http.StatusNotFound)
+			return
+		}
+
+		data, err := json.Marshal(entry)
+		if err != nil {
+			log.WithError(err).Warn("Error in marshalling republish history")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Write(data)
+	}
+}
+
+type explainedCycle struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Type      string     `json:"type"`
+	Task      string     `json:"task,omitempty"`
+	State     []string   `json:"state"`
+	Origin    string     `json:"origin"`
+	Included  bool       `json:"included"`
+	Reason    string     `json:"reason"`
+	Filter    string     `json:"filter,omitempty"`
+	Iteration int        `json:"iteration"`
+	Position  *int       `json:"position,omitempty"`
+	Pending   *bool      `json:"pending,omitempty"`
+	Start     *time.Time `json:"windowStart,omitempty"`
+	End       *time.Time `json:"windowEnd,omitempty"`
+
+	Transformations []string               `json:"transformations,omitempty"`
+	NativeHash      string                 `json:"nativeHash,omitempty"`
+	Transformed     map[string]interface{} `json:"transformed,omitempty"`
+	TransactionID   string                 `json:"transactionId,omitempty"`
+	SchemaError     string                 `json:"schemaError,omitempty"`
+}
+
+type nativeExplanation struct {
+	UUID           string `json:"uuid"`
+	Collection     string `json:"collection"`
+	Blacklisted    bool   `json:"blacklisted"`
+	BlacklistError string `json:"blacklistError,omitempty"`
+	tasks.Explanation
+	Timestamp *time.Time             `json:"timestamp,omitempty"`
+	Content   map[string]interface{} `json:"content"`
+	Cycles    []explainedCycle       `json:"cycles"`
+}
+
+// ExplainNativeContent runs the checks the publish pipeline would make on the native content for a uuid, without publishing it, and reports which cycles would republish it and where it sits in their current iteration
+func ExplainNativeContent(db native.DB, collections native.CollectionConfigs, isBlacklisted blacklist.IsBlacklisted, task tasks.Task, sched scheduler.Scheduler) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Content-Type", "application/json")
+		collection := vestigo.Param(r, "collection")
+		uuid := vestigo.Param(r, "uuid")
+
+		tx, err := db.Open()
+		if err != nil {
+			log.WithError(err).Error("Failed to connect to mongo")
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		defer tx.Close()
+
+		content, err := tx.ReadNativeContent(collection, uuid)
+		if err == mgo.ErrNotFound {
+			http.Error(w, "No native content found for uuid", http.StatusNotFound)
+			return
+		}
+
+		if err != nil {
+			log.WithError(err).WithField("collection", collection).WithField("uuid", uuid).Error("Failed to read native content")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		explanation := nativeExplanation{UUID: uuid, Collection: collection, Content: content.Body}
+
+		blacklisted, err := isBlacklisted(uuid)
+		if err != nil {
+			explanation.BlacklistError = err.Error()
+		}
+		explanation.Blacklisted = blacklisted || err != nil
+
+		explainer, _ := task.(tasks.Explainer)
+		if explainer != nil {
+			explanation.Explanation = explainer.Explain(uuid, content)
+		}
+
+		if ts, ok := collections.Get(collection).Timestamp(content); ok {
+			explanation.Timestamp = &ts
+		}
+
+		explanation.Cycles = make([]explainedCycle, 0)
+		for _, c := range sched.Cycles() {
+			config := c.TransformToConfig()
+			if config.Collection == collection {
+				explanation.Cycles = append(explanation.Cycles, explainCycle(c, config, uuid, content, explanation, explainer))
+			}
+		}
+
+		sort.Slice(explanation.Cycles, func(i, j int) bool { return explanation.Cycles[i].Name < explanation.Cycles[j].Name })
+
+		data, err := json.Marshal(explanation)
+		if err != nil {
+			log.WithError(err).Warn("Error in marshalling native content explanation")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Write(data)
+	}
+}
+
+// explainCycle reports whether the cycle would republish the content, and previews any transformations it would make to the content. Whole collection cycles skip blacklisted uuids when they load the collection, whereas time windowed cycles only find content whose timestamp is within their current window.
+func explainCycle(c scheduler.Cycle, config scheduler.CycleConfig, uuid string, content *native.Content, explanation nativeExplanation, explainer tasks.Explainer) explainedCycle {
+	metadata := c.Metadata()
+	explained := explainedCycle{ID: c.ID(), Name: c.Name(), Type: config.Type, Task: config.Task, State: c.State(), Origin: cms.Origin(config.Origin, content), Iteration: metadata.Iteration}
+
+	// cycles which publish with their own task are explained with that task, rather than the default publish task
+	explainable, _ := c.(scheduler.ExplainableCycle)
+	if explainable != nil {
+		explainer, _ = explainable.PublishTask().(tasks.Explainer)
+		explanation.Explanation = tasks.Explanation{}
+		if explainer != nil {
+			explanation.Explanation = explainer.Explain(uuid, content)
+		}
+
+		if config.Task != "" {
+			explained.NativeHash = explanation.NativeHash
+			explained.TransactionID = explanation.TransactionID
+		}
+	}
+
+	if config.TimeWindow != "" {
+		explained.Start, explained.End = metadata.Start, metadata.End
+		switch {
+		case explanation.Timestamp == nil:
+			explained.Reason = "The content has no timestamp, so is never found by time windowed cycles"
+		case metadata.Start == nil || metadata.End == nil:
+			explained.Reason = "The cycle has no current time window"
+		case explanation.Timestamp.Before(*metadata.Start) || !explanation.Timestamp.Before(*metadata.End):
+			explained.Reason = "The content timestamp is outside the current time window"
+		default:
+			explained.Included = true
+			explained.Reason = "The content timestamp is within the current time window"
+		}
+	} else if explanation.Blacklisted {
+		explained.Reason = "The uuid is blacklisted"
+	} else {
+		explained.Included = true
+		explained.Reason = "Whole collection cycles include every uuid in the collection"
+	}
+
+	if explained.Included && explanation.MissingBody {
+		explained.Reason += ", but the publish task will skip the content"
+	}
+
+	publishes := explained.Included && !explanation.MissingBody
+	if explainable != nil && publishes {
+		rule, skipped := explainable.Filter(content)
+		explained.Filter = rule
+		if skipped {
+			explained.Reason += ", but the cycle's filters skip the content"
+			publishes = false
+		}
+	}
+
+	transformed, schemaError := content, explanation.SchemaError
+	if explainable != nil && publishes {
+		var applied []string
+		if transformed, applied = explainable.Transform(content); len(applied) > 0 {
+			explained.Transformations = applied
+			explained.Transformed = transformed.Body
+			if explainer != nil {
+				transformedExplanation := explainer.Explain(uuid, transformed)
+				explained.NativeHash = transformedExplanation.NativeHash
+				explained.TransactionID = transformedExplanation.TransactionID
+				schemaError = transformedExplanation.SchemaError
+			}
+		}
+	}
+
+	if publishes && schemaError != "" {
+		explained.SchemaError = schemaError
+		explained.Reason += ", but the content the cycle would publish does not match its schema"
+		publishes = false
+	}
+
+	if explainable != nil && publishes {
+		if txID, ok := explainable.TransactionID(uuid, transformed); ok {
+			explained.TransactionID = txID
+		}
+	}
+
+	if explainable != nil {
+		if position, pending, ok := explainable.Position(uuid); ok {
+			explained.Pending = &pending
+			if pending {
+				explained.Position = &position
+			}
+		}
+	}
+
+	return explained
+}
@@ -0,0 +1,539 @@
+package resources
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Financial-Times/publish-carousel/blacklist"
+	"github.com/Financial-Times/publish-carousel/cms"
+	"github.com/Financial-Times/publish-carousel/ledger"
+	"github.com/Financial-Times/publish-carousel/native"
+	"github.com/Financial-Times/publish-carousel/scheduler"
+	"github.com/Financial-Times/publish-carousel/tasks"
+	"github.com/husobee/vestigo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	mgo "gopkg.in/mgo.v2"
+)
+
+func setupNativeRouter(db native.DB, req *http.Request) *httptest.ResponseRecorder {
+	return setupNativeRouterWithScheduler(db, new(scheduler.MockScheduler), req)
+}
+
+func setupNativeRouterWithScheduler(db native.DB, sched scheduler.Scheduler, req *http.Request) *httptest.ResponseRecorder {
+	r := vestigo.NewRouter()
+	r.Get("/native/collections", GetNativeCollections(db, sched))
+	r.Get("/native/:collection/timestamps", GetTimestampReport(db))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestGetTimestampReport(t *testing.T) {
+	tx := new(native.MockTX)
+	tx.On("CountTimestamps", "methode").Return(&native.TimestampReport{Collection: "methode", TimestampField: "content.lastModified", TimestampType: "string", Total: 10, Missing: 1, Unparseable: 2, Examples: []interface{}{"a", "b", "c"}}, nil)
+	tx.On("Close").Return()
+
+	db := new(native.MockDB)
+	db.On("Open").Return(tx, nil)
+
+	w := setupNativeRouter(db, httptest.NewRequest("GET", "/native/methode/timestamps", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"collection":"methode","timestampField":"content.lastModified","timestampType":"string","total":10,"missing":1,"unparseable":2,"examples":["a","b","c"]}`, w.Body.String())
+	mock.AssertExpectationsForObjects(t, db, tx)
+}
+
+func TestGetTimestampReportFails(t *testing.T) {
+	tx := new(native.MockTX)
+	tx.On("CountTimestamps", "methode").Return((*native.TimestampReport)(nil), errors.New("computer says no"))
+	tx.On("Close").Return()
+
+	db := new(native.MockDB)
+	db.On("Open").Return(tx, nil)
+
+	w := setupNativeRouter(db, httptest.NewRequest("GET", "/native/methode/timestamps", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	mock.AssertExpectationsForObjects(t, db, tx)
+}
+
+func TestGetTimestampReportMongoUnavailable(t *testing.T) {
+	db := new(native.MockDB)
+	db.On("Open").Return(new(native.MockTX), errors.New("no reachable servers"))
+
+	w := setupNativeRouter(db, httptest.NewRequest("GET", "/native/methode/timestamps", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	db.AssertExpectations(t)
+}
+
+func mockCycleFor(id string, name string, collection string) *scheduler.MockCycle {
+	c := new(scheduler.MockCycle)
+	c.On("ID").Return(id)
+	c.On("Name").Return(name)
+	c.On("TransformToConfig").Return(scheduler.CycleConfig{Name: name, Collection: collection})
+	return c
+}
+
+func TestGetNativeCollections(t *testing.T) {
+	oldest := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	newest := time.Date(2017, 3, 16, 0, 0, 0, 0, time.UTC)
+
+	tx := new(native.MockTX)
+	tx.On("ListCollections").Return([]native.CollectionSummary{
+		{Database: "native-store", Collection: "methode", Count: 100, TimestampField: "content.lastModified", Oldest: &oldest, Newest: &newest},
+		{Database: "native-store", Collection: "video", Count: 5, TimestampField: "content.lastModified"},
+	}, nil)
+	tx.On("Close").Return()
+
+	db := new(native.MockDB)
+	db.On("Open").Return(tx, nil)
+
+	sched := new(scheduler.MockScheduler)
+	sched.On("Cycles").Return(map[string]scheduler.Cycle{
+		"1": mockCycleFor("1", "methode-whole-archive", "methode"),
+		"2": mockCycleFor("2", "methode-1hr", "methode"),
+	})
+
+	w := setupNativeRouterWithScheduler(db, sched, httptest.NewRequest("GET", "/native/collections", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[
+		{"database":"native-store","collection":"methode","count":100,"timestampField":"content.lastModified","oldest":"2016-01-01T00:00:00Z","newest":"2017-03-16T00:00:00Z","covered":true,
+			"cycles":[{"id":"2","name":"methode-1hr"},{"id":"1","name":"methode-whole-archive"}]},
+		{"database":"native-store","collection":"video","count":5,"timestampField":"content.lastModified","covered":false,"cycles":[]}
+	]`, w.Body.String())
+	mock.AssertExpectationsForObjects(t, db, tx, sched)
+}
+
+func TestGetNativeCollectionsFails(t *testing.T) {
+	tx := new(native.MockTX)
+	tx.On("ListCollections").Return([]native.CollectionSummary{}, errors.New("computer says no"))
+	tx.On("Close").Return()
+
+	db := new(native.MockDB)
+	db.On("Open").Return(tx, nil)
+
+	w := setupNativeRouter(db, httptest.NewRequest("GET", "/native/collections", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	mock.AssertExpectationsForObjects(t, db, tx)
+}
+
+func explainingTask() tasks.Task {
+	return tasks.NewNativeContentPublishTask(new(native.MockReader), new(cms.MockNotifier), nil)
+}
+
+func setupExplainRouter(db native.DB, isBlacklisted blacklist.IsBlacklisted, sched scheduler.Scheduler, req *http.Request) *httptest.ResponseRecorder {
+	task := explainingTask()
+
+	r := vestigo.NewRouter()
+	r.Get("/native/:collection/timestamps", GetTimestampReport(db))
+	r.Get("/native/:collection/:uuid/explain", ExplainNativeContent(db, native.CollectionConfigs{}, isBlacklisted, task, sched))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func mockExplainedCycle(c *scheduler.MockCycle, id string, name string, config scheduler.CycleConfig, metadata scheduler.CycleMetadata) {
+	config.Name = name
+	c.On("ID").Return(id)
+	c.On("Name").Return(name)
+	c.On("State").Return([]string{"running"})
+	c.On("Metadata").Return(metadata)
+	c.On("TransformToConfig").Return(config)
+}
+
+func explainedContent() *native.Content {
+	return &native.Content{
+		Body:           map[string]interface{}{"uuid": "a-uuid", "publishReference": "tid_1234", "lastModified": "2017-05-01T12:00:00.000Z"},
+		ContentType:    "application/json",
+		OriginSystemID: "methode-web-pub",
+	}
+}
+
+func TestExplainNativeContent(t *testing.T) {
+	content := explainedContent()
+	tx := new(native.MockTX)
+	tx.On("ReadNativeContent", "methode", "a-uuid").Return(content, nil)
+	tx.On("Close").Return()
+
+	db := new(native.MockDB)
+	db.On("Open").Return(tx, nil)
+
+	start := time.Date(2017, 5, 1, 11, 0, 0, 0, time.UTC)
+	end := time.Date(2017, 5, 1, 13, 0, 0, 0, time.UTC)
+
+	wholeArchive := new(scheduler.MockExplainableCycle)
+	mockExplainedCycle(&wholeArchive.MockCycle, "1", "methode-whole-archive", scheduler.CycleConfig{Type: "ThrottledWholeCollection", Collection: "methode", Origin: "methode-origin"}, scheduler.CycleMetadata{Iteration: 3})
+	wholeArchive.On("PublishTask").Return(explainingTask())
+	wholeArchive.On("Filter", content).Return("", false)
+	wholeArchive.On("Transform", content).Return(content, []string{})
+	wholeArchive.On("TransactionID", "a-uuid", content).Return("", false)
+	wholeArchive.On("Position", "a-uuid").Return(5, true, true)
+
+	timeWindowed := new(scheduler.MockCycle)
+	mockExplainedCycle(timeWindowed, "2", "methode-2hr", scheduler.CycleConfig{Type: "ScalingWindow", Collection: "methode", Origin: "methode-origin", TimeWindow: "2h"}, scheduler.CycleMetadata{Iteration: 7, Start: &start, End: &end})
+
+	other := new(scheduler.MockCycle)
+	other.On("TransformToConfig").Return(scheduler.CycleConfig{Name: "wordpress", Collection: "wordpress"})
+
+	sched := new(scheduler.MockScheduler)
+	sched.On("Cycles").Return(map[string]scheduler.Cycle{"1": wholeArchive, "2": timeWindowed, "3": other})
+
+	w := setupExplainRouter(db, blacklist.NoOpBlacklist, sched, httptest.NewRequest("GET", "/native/methode/a-uuid/explain", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	explanation := nativeExplanation{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &explanation))
+
+	assert.Equal(t, "a-uuid", explanation.UUID)
+	assert.False(t, explanation.Blacklisted)
+	assert.False(t, explanation.Skipped)
+	assert.Equal(t, "methode-web-pub", explanation.OriginSystemID)
+	assert.Regexp(t, `^tid_1234_carousel_\d{10}$`, explanation.TransactionID)
+	assert.NotEmpty(t, explanation.NativeHash)
+	assert.Equal(t, "a-uuid", explanation.Content["uuid"])
+	require.NotNil(t, explanation.Timestamp)
+	assert.Equal(t, time.Date(2017, 5, 1, 12, 0, 0, 0, time.UTC), explanation.Timestamp.UTC())
+
+	require.Len(t, explanation.Cycles, 2)
+
+	windowed := explanation.Cycles[0]
+	assert.Equal(t, "methode-2hr", windowed.Name)
+	assert.True(t, windowed.Included)
+	assert.Equal(t, "methode-web-pub", windowed.Origin, "the content origin overrides the cycle origin")
+	assert.Equal(t, 7, windowed.Iteration)
+	assert.Nil(t, windowed.Position)
+
+	whole := explanation.Cycles[1]
+	assert.Equal(t, "methode-whole-archive", whole.Name)
+	assert.True(t, whole.Included)
+	require.NotNil(t, whole.Position)
+	assert.Equal(t, 5, *whole.Position)
+	assert.True(t, *whole.Pending)
+
+	mock.AssertExpectationsForObjects(t, db, tx, sched, wholeArchive, timeWindowed)
+}
+
+func TestExplainBlacklistedNativeContent(t *testing.T) {
+	content := explainedContent()
+	content.OriginSystemID = ""
+
+	tx := new(native.MockTX)
+	tx.On("ReadNativeContent", "methode", "a-uuid").Return(content, nil)
+	tx.On("Close").Return()
+
+	db := new(native.MockDB)
+	db.On("Open").Return(tx, nil)
+
+	start := time.Date(2017, 5, 2, 11, 0, 0, 0, time.UTC)
+	end := time.Date(2017, 5, 2, 13, 0, 0, 0, time.UTC)
+
+	wholeArchive := new(scheduler.MockCycle)
+	mockExplainedCycle(wholeArchive, "1", "methode-whole-archive", scheduler.CycleConfig{Type: "ThrottledWholeCollection", Collection: "methode", Origin: "methode-origin"}, scheduler.CycleMetadata{})
+
+	timeWindowed := new(scheduler.MockCycle)
+	mockExplainedCycle(timeWindowed, "2", "methode-2hr", scheduler.CycleConfig{Type: "ScalingWindow", Collection: "methode", Origin: "methode-origin", TimeWindow: "2h"}, scheduler.CycleMetadata{Start: &start, End: &end})
+
+	sched := new(scheduler.MockScheduler)
+	sched.On("Cycles").Return(map[string]scheduler.Cycle{"1": wholeArchive, "2": timeWindowed})
+
+	isBlacklisted := func(uuid string) (bool, error) { return uuid == "a-uuid", nil }
+	w := setupExplainRouter(db, isBlacklisted, sched, httptest.NewRequest("GET", "/native/methode/a-uuid/explain", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	explanation := nativeExplanation{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &explanation))
+
+	assert.True(t, explanation.Blacklisted)
+	require.Len(t, explanation.Cycles, 2)
+
+	assert.False(t, explanation.Cycles[0].Included)
+	assert.Equal(t, "The content timestamp is outside the current time window", explanation.Cycles[0].Reason)
+	assert.Equal(t, "methode-origin", explanation.Cycles[0].Origin)
+
+	assert.False(t, explanation.Cycles[1].Included)
+	assert.Equal(t, "The uuid is blacklisted", explanation.Cycles[1].Reason)
+	assert.Nil(t, explanation.Cycles[1].Pending)
+
+	mock.AssertExpectationsForObjects(t, db, tx, sched, wholeArchive, timeWindowed)
+}
+
+func TestExplainFilteredNativeContent(t *testing.T) {
+	content := explainedContent()
+
+	tx := new(native.MockTX)
+	tx.On("ReadNativeContent", "methode", "a-uuid").Return(content, nil)
+	tx.On("Close").Return()
+
+	db := new(native.MockDB)
+	db.On("Open").Return(tx, nil)
+
+	images := new(scheduler.MockExplainableCycle)
+	mockExplainedCycle(&images.MockCycle, "1", "methode-whole-archive", scheduler.CycleConfig{Type: "ThrottledWholeCollection", Collection: "methode", Origin: "methode-origin"}, scheduler.CycleMetadata{})
+	images.On("PublishTask").Return(explainingTask())
+	images.On("Filter", content).Return("images", true)
+	images.On("Position", "a-uuid").Return(-1, false, false)
+
+	articles := new(scheduler.MockExplainableCycle)
+	mockExplainedCycle(&articles.MockCycle, "2", "methode-articles", scheduler.CycleConfig{Type: "ThrottledWholeCollection", Collection: "methode", Origin: "methode-origin"}, scheduler.CycleMetadata{})
+	articles.On("PublishTask").Return(explainingTask())
+	articles.On("Filter", content).Return("articles", false)
+	articles.On("Transform", content).Return(content, []string{})
+	articles.On("TransactionID", "a-uuid", content).Return("", false)
+	articles.On("Position", "a-uuid").Return(-1, false, false)
+
+	sched := new(scheduler.MockScheduler)
+	sched.On("Cycles").Return(map[string]scheduler.Cycle{"1": images, "2": articles})
+
+	w := setupExplainRouter(db, blacklist.NoOpBlacklist, sched, httptest.NewRequest("GET", "/native/methode/a-uuid/explain", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	explanation := nativeExplanation{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &explanation))
+	require.Len(t, explanation.Cycles, 2)
+
+	assert.Equal(t, "methode-articles", explanation.Cycles[0].Name)
+	assert.Equal(t, "articles", explanation.Cycles[0].Filter)
+	assert.Equal(t, "Whole collection cycles include every uuid in the collection", explanation.Cycles[0].Reason)
+
+	assert.Equal(t, "methode-whole-archive", explanation.Cycles[1].Name)
+	assert.Equal(t, "images", explanation.Cycles[1].Filter)
+	assert.Equal(t, "Whole collection cycles include every uuid in the collection, but the cycle's filters skip the content", explanation.Cycles[1].Reason)
+
+	mock.AssertExpectationsForObjects(t, db, tx, sched, images, articles)
+}
+
+func TestExplainTransformedNativeContent(t *testing.T) {
+	content := explainedContent()
+
+	tx := new(native.MockTX)
+	tx.On("ReadNativeContent", "methode", "a-uuid").Return(content, nil)
+	tx.On("Close").Return()
+
+	db := new(native.MockDB)
+	db.On("Open").Return(tx, nil)
+
+	transformed := explainedContent()
+	delete(transformed.Body, "lastModified")
+
+	transforming := new(scheduler.MockExplainableCycle)
+	mockExplainedCycle(&transforming.MockCycle, "1", "methode-whole-archive", scheduler.CycleConfig{Type: "ThrottledWholeCollection", Collection: "methode", Origin: "methode-origin"}, scheduler.CycleMetadata{})
+	transforming.On("PublishTask").Return(explainingTask())
+	transforming.On("Filter", content).Return("", false)
+	transforming.On("Transform", content).Return(transformed, []string{"lastModified"})
+	transforming.On("TransactionID", "a-uuid", transformed).Return("", false)
+	transforming.On("Position", "a-uuid").Return(-1, false, false)
+
+	sched := new(scheduler.MockScheduler)
+	sched.On("Cycles").Return(map[string]scheduler.Cycle{"1": transforming})
+
+	w := setupExplainRouter(db, blacklist.NoOpBlacklist, sched, httptest.NewRequest("GET", "/native/methode/a-uuid/explain", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	explanation := nativeExplanation{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &explanation))
+	require.Len(t, explanation.Cycles, 1)
+
+	cycle := explanation.Cycles[0]
+	assert.Equal(t, []string{"lastModified"}, cycle.Transformations)
+	assert.Equal(t, map[string]interface{}{"uuid": "a-uuid", "publishReference": "tid_1234"}, cycle.Transformed)
+	assert.NotEmpty(t, cycle.NativeHash)
+	assert.NotEqual(t, explanation.NativeHash, cycle.NativeHash, "the cycle publishes the transformed content with its own hash")
+
+	mock.AssertExpectationsForObjects(t, db, tx, sched, transforming)
+}
+
+type titleExplainer struct{}
+
+func (titleExplainer) Explain(uuid string, content *native.Content) tasks.Explanation {
+	if _, ok := content.Body["title"]; !ok {
+		return tasks.Explanation{SchemaError: "Content does not match the schema content.json: $: missing properties: 'title'", Skipped: true}
+	}
+	return tasks.Explanation{NativeHash: "hash"}
+}
+
+func TestExplainCycleValidatesTransformedContent(t *testing.T) {
+	content := explainedContent()
+	untitled := titleExplainer{}.Explain("a-uuid", content)
+	explanation := nativeExplanation{UUID: "a-uuid", Collection: "methode", Explanation: untitled}
+
+	titled := explainedContent()
+	titled.Body["title"] = "Untitled"
+
+	fixing := new(scheduler.MockExplainableCycle)
+	mockExplainedCycle(&fixing.MockCycle, "1", "methode-whole-archive", scheduler.CycleConfig{Type: "ThrottledWholeCollection", Collection: "methode"}, scheduler.CycleMetadata{})
+	fixing.On("PublishTask").Return(&titleTask{})
+	fixing.On("Filter", content).Return("", false)
+	fixing.On("Transform", content).Return(titled, []string{"title"})
+	fixing.On("TransactionID", "a-uuid", titled).Return("", false)
+	fixing.On("Position", "a-uuid").Return(-1, false, false)
+
+	explained := explainCycle(fixing, fixing.TransformToConfig(), "a-uuid", content, explanation, titleExplainer{})
+	assert.True(t, explained.Included)
+	assert.Empty(t, explained.SchemaError, "the transformations fix the content before it is validated")
+	assert.Equal(t, "hash", explained.NativeHash)
+
+	plain := new(scheduler.MockCycle)
+	mockExplainedCycle(plain, "2", "methode-plain", scheduler.CycleConfig{Type: "ThrottledWholeCollection", Collection: "methode"}, scheduler.CycleMetadata{})
+
+	explained = explainCycle(plain, plain.TransformToConfig(), "a-uuid", content, explanation, titleExplainer{})
+	assert.Equal(t, "Content does not match the schema content.json: $: missing properties: 'title'", explained.SchemaError)
+	assert.Equal(t, "Whole collection cycles include every uuid in the collection, but the content the cycle would publish does not match its schema", explained.Reason)
+}
+
+type titleTask struct {
+	tasks.MockTask
+	titleExplainer
+}
+
+func TestExplainCycleWithItsOwnTask(t *testing.T) {
+	content := explainedContent()
+	explanation := nativeExplanation{UUID: "a-uuid", Collection: "methode", Explanation: tasks.Explanation{NativeHash: "default-hash"}}
+
+	titled := new(scheduler.MockExplainableCycle)
+	mockExplainedCycle(&titled.MockCycle, "1", "methode-titles", scheduler.CycleConfig{Type: "ThrottledWholeCollection", Collection: "methode", Task: "titles"}, scheduler.CycleMetadata{})
+	titled.On("PublishTask").Return(&titleTask{})
+	titled.On("Filter", content).Return("", false)
+	titled.On("Transform", content).Return(content, []string{})
+	titled.On("TransactionID", "a-uuid", content).Return("", false)
+	titled.On("Position", "a-uuid").Return(-1, false, false)
+
+	explained := explainCycle(titled, titled.TransformToConfig(), "a-uuid", content, explanation, nil)
+	assert.Equal(t, "titles", explained.Task)
+	assert.Equal(t, "Content does not match the schema content.json: $: missing properties: 'title'", explained.SchemaError, "the cycle's own task validates the content")
+	assert.Equal(t, "Whole collection cycles include every uuid in the collection, but the content the cycle would publish does not match its schema", explained.Reason)
+
+	content.Body["title"] = "Untitled"
+	explained = explainCycle(titled, titled.TransformToConfig(), "a-uuid", content, explanation, nil)
+	assert.Empty(t, explained.SchemaError)
+	assert.Equal(t, "hash", explained.NativeHash, "the cycle publishes with the native hash of its own task")
+
+	unexplained := new(scheduler.MockExplainableCycle)
+	mockExplainedCycle(&unexplained.MockCycle, "2", "methode-sink", scheduler.CycleConfig{Type: "ThrottledWholeCollection", Collection: "methode", Task: "sink"}, scheduler.CycleMetadata{})
+	unexplained.On("PublishTask").Return(new(tasks.MockTask))
+	unexplained.On("Filter", content).Return("", false)
+	unexplained.On("Transform", content).Return(content, []string{})
+	unexplained.On("TransactionID", "a-uuid", content).Return("", false)
+	unexplained.On("Position", "a-uuid").Return(-1, false, false)
+
+	explanation.SchemaError = "Content does not match the default schema"
+	explained = explainCycle(unexplained, unexplained.TransformToConfig(), "a-uuid", content, explanation, titleExplainer{})
+	assert.Empty(t, explained.SchemaError, "the default task's checks do not apply to a cycle with its own task")
+	assert.Empty(t, explained.NativeHash)
+}
+
+func TestExplainNativeContentWithCycleTransactionID(t *testing.T) {
+	content := explainedContent()
+
+	tx := new(native.MockTX)
+	tx.On("ReadNativeContent", "methode", "a-uuid").Return(content, nil)
+	tx.On("Close").Return()
+
+	db := new(native.MockDB)
+	db.On("Open").Return(tx, nil)
+
+	templated := new(scheduler.MockExplainableCycle)
+	mockExplainedCycle(&templated.MockCycle, "1", "methode-whole-archive", scheduler.CycleConfig{Type: "ThrottledWholeCollection", Collection: "methode", Origin: "methode-origin"}, scheduler.CycleMetadata{})
+	templated.On("PublishTask").Return(explainingTask())
+	templated.On("Filter", content).Return("", false)
+	templated.On("Transform", content).Return(content, []string{})
+	templated.On("TransactionID", "a-uuid", content).Return("tid_1234_methode-whole-archive_3", true)
+	templated.On("Position", "a-uuid").Return(-1, false, false)
+
+	sched := new(scheduler.MockScheduler)
+	sched.On("Cycles").Return(map[string]scheduler.Cycle{"1": templated})
+
+	w := setupExplainRouter(db, blacklist.NoOpBlacklist, sched, httptest.NewRequest("GET", "/native/methode/a-uuid/explain", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	explanation := nativeExplanation{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &explanation))
+	require.Len(t, explanation.Cycles, 1)
+
+	assert.Regexp(t, `^tid_1234_carousel_\d{10}$`, explanation.TransactionID)
+	assert.Equal(t, "tid_1234_methode-whole-archive_3", explanation.Cycles[0].TransactionID)
+
+	mock.AssertExpectationsForObjects(t, db, tx, sched, templated)
+}
+
+func TestExplainNativeContentNotFound(t *testing.T) {
+	tx := new(native.MockTX)
+	tx.On("ReadNativeContent", "methode", "a-uuid").Return(&native.Content{}, mgo.ErrNotFound)
+	tx.On("Close").Return()
+
+	db := new(native.MockDB)
+	db.On("Open").Return(tx, nil)
+
+	w := setupExplainRouter(db, blacklist.NoOpBlacklist, new(scheduler.MockScheduler), httptest.NewRequest("GET", "/native/methode/a-uuid/explain", nil))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mock.AssertExpectationsForObjects(t, db, tx)
+}
+
+func TestExplainNativeContentReadFails(t *testing.T) {
+	tx := new(native.MockTX)
+	tx.On("ReadNativeContent", "methode", "a-uuid").Return(&native.Content{}, errors.New("computer says no"))
+	tx.On("Close").Return()
+
+	db := new(native.MockDB)
+	db.On("Open").Return(tx, nil)
+
+	w := setupExplainRouter(db, blacklist.NoOpBlacklist, new(scheduler.MockScheduler), httptest.NewRequest("GET", "/native/methode/a-uuid/explain", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	mock.AssertExpectationsForObjects(t, db, tx)
+}
+
+func TestExplainNativeContentMongoUnavailable(t *testing.T) {
+	db := new(native.MockDB)
+	db.On("Open").Return(new(native.MockTX), errors.New("no reachable servers"))
+
+	w := setupExplainRouter(db, blacklist.NoOpBlacklist, new(scheduler.MockScheduler), httptest.NewRequest("GET", "/native/methode/a-uuid/explain", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	db.AssertExpectations(t)
+}
+
+func TestGetRepublishHistory(t *testing.T) {
+	published := time.Date(2017, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	republishLedger := new(ledger.MockLedger)
+	republishLedger.On("Get", "methode", "a-uuid").Return(ledger.Entry{Collection: "methode", UUID: "a-uuid", LastPublished: published, TransactionID: "tid_1234_carousel_1493640000", CycleID: "5118842b62670d2b"}, true)
+
+	r := vestigo.NewRouter()
+	r.Get("/native/:collection/:uuid/history", GetRepublishHistory(republishLedger))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/native/methode/a-uuid/history", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"collection":"methode","uuid":"a-uuid","lastPublished":"2017-05-01T12:00:00Z","transactionId":"tid_1234_carousel_1493640000","cycleId":"5118842b62670d2b"}`, w.Body.String())
+	republishLedger.AssertExpectations(t)
+}
+
+func TestGetRepublishHistoryNotPublished(t *testing.T) {
+	republishLedger := new(ledger.MockLedger)
+	republishLedger.On("Get", "methode", "a-uuid").Return(ledger.Entry{}, false)
+
+	r := vestigo.NewRouter()
+	r.Get("/native/:collection/:uuid/history", GetRepublishHistory(republishLedger))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/native/methode/a-uuid/history", nil))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	republishLedger.AssertExpectations(t)
+}
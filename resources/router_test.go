@@ -13,6 +13,10 @@ func setupRouter(sched scheduler.Scheduler, req *http.Request) *httptest.Respons
 	r.Get("/cycles", GetCycles(sched))
 	r.Post("/cycles", CreateCycle(sched))
 
+	r.Post("/cycles/stop", StopCycles(sched))
+	r.Post("/cycles/resume", ResumeCycles(sched))
+	r.Post("/cycles/reset", ResetCycles(sched))
+
 	r.Get("/cycles/:id", GetCycleForID(sched))
 	r.Delete("/cycles/:id", DeleteCycle(sched))
 
@@ -49,7 +49,7 @@ func TestCheckpointSaveCycleMetadata(t *testing.T) {
 	rw := MockMetadataRW{}
 	rw.On("WriteMetadata", id2, c2.TransformToConfig(), mock.AnythingOfType("CycleMetadata")).Return(nil).Times(12)
 
-	s := NewScheduler(uuidCollectionBuilder, &tasks.MockTask{}, &rw, 1*time.Second, 1*time.Second)
+	s := NewScheduler(uuidCollectionBuilder, &tasks.MockTask{}, &rw, 1*time.Second, 1*time.Second, DefaultRestartPolicy, nil, nil, nil, nil)
 
 	s.AddCycle(c1)
 	s.AddCycle(c2)
@@ -9,9 +9,15 @@ import (
 
 	yaml "gopkg.in/yaml.v2"
 
+	"github.com/Financial-Times/publish-carousel/filter"
+	"github.com/Financial-Times/publish-carousel/ledger"
 	"github.com/Financial-Times/publish-carousel/native"
 	"github.com/Financial-Times/publish-carousel/tasks"
+	"github.com/Financial-Times/publish-carousel/transform"
+	"github.com/Financial-Times/publish-carousel/txid"
+	"github.com/Financial-Times/publish-carousel/verify"
 	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type cycleSetupConfig struct {
@@ -28,6 +34,28 @@ type CycleConfig struct {
 	TimeWindow      string `yaml:"timeWindow" json:"timeWindow,omitempty"`
 	MinimumThrottle string `yaml:"minimumThrottle" json:"minimumThrottle,omitempty"`
 	MaximumThrottle string `yaml:"maximumThrottle" json:"maximumThrottle,omitempty"`
+	UUIDCollection  string `yaml:"uuidCollection" json:"uuidCollection,omitempty"`
+
+	MaxIterations      int    `yaml:"maxIterations" json:"maxIterations,omitempty"`
+	ExpiresAt          string `yaml:"expiresAt" json:"expiresAt,omitempty"`
+	RemoveOnCompletion bool   `yaml:"removeOnCompletion" json:"removeOnCompletion,omitempty"`
+
+	Labels map[string]string `yaml:"labels" json:"labels,omitempty"`
+
+	Task        string        `yaml:"task" json:"task,omitempty"`
+	TaskOptions tasks.Options `yaml:"taskOptions" json:"taskOptions,omitempty"`
+
+	Filters         []filter.Rule    `yaml:"filters" json:"filters,omitempty"`
+	PublishImages   bool             `yaml:"publishImages" json:"publishImages,omitempty"`
+	Transformations []transform.Rule `yaml:"transformations" json:"transformations,omitempty"`
+
+	Verification *verify.Config `yaml:"verification" json:"verification,omitempty"`
+
+	TransactionIDTemplate string `yaml:"transactionIdTemplate" json:"transactionIdTemplate,omitempty"`
+
+	ReadPreference string `yaml:"readPreference" json:"readPreference,omitempty"`
+	ReadConcern    string `yaml:"readConcern" json:"readConcern,omitempty"`
+	MaxStaleness   string `yaml:"maxStaleness" json:"maxStaleness,omitempty"`
 }
 
 // Validate checks the provided config for errors
@@ -48,11 +76,61 @@ func (c CycleConfig) Validate() error {
 		return err
 	}
 
+	if c.MaxIterations < 0 {
+		return fmt.Errorf("Please provide a positive number of maximum iterations for cycle %v", c.Name)
+	}
+
+	if err := validateLabels(c.Name, c.Labels); err != nil {
+		return err
+	}
+
+	if len(c.TaskOptions) > 0 && strings.TrimSpace(c.Task) == "" {
+		return fmt.Errorf("Please provide the task for the task options of cycle %v", c.Name)
+	}
+
+	if _, err := c.filterChain(); err != nil {
+		return fmt.Errorf("Invalid filters for cycle %v: %v", c.Name, err)
+	}
+
+	if _, err := transform.NewChain(c.Transformations); err != nil {
+		return fmt.Errorf("Invalid transformations for cycle %v: %v", c.Name, err)
+	}
+
+	if c.Verification != nil {
+		if err := c.Verification.Validate(); err != nil {
+			return fmt.Errorf("Invalid verification for cycle %v: %v", c.Name, err)
+		}
+	}
+
+	if c.TransactionIDTemplate != "" {
+		if _, err := txid.NewTemplate(c.TransactionIDTemplate); err != nil {
+			return fmt.Errorf("Invalid transaction id template for cycle %v: %v", c.Name, err)
+		}
+	}
+
+	if _, err := c.readOptions(); err != nil {
+		return fmt.Errorf("Invalid read options for cycle %v: %v", c.Name, err)
+	}
+
+	if _, err := c.expiryTime(); err != nil {
+		return fmt.Errorf("Error in parsing expiry time for cycle %v: ExpiresAt=%v err=%v.", c.Name, c.ExpiresAt, err)
+	}
+
 	switch strings.ToLower(c.Type) {
+	case "stalestfirst":
+		if err := checkDurations(c.Name, c.Throttle); c.Throttle != "" && err != nil {
+			return err
+		}
 	case "throttledwholecollection":
 		if err := checkDurations(c.Name, c.Throttle); c.Throttle != "" && err != nil {
 			return err
 		}
+
+		switch c.UUIDCollection {
+		case "", native.InMemoryUUIDCollectionType, native.StreamingUUIDCollectionType:
+		default:
+			return fmt.Errorf("Please provide a valid uuid collection type for cycle %v, either %v or %v", c.Name, native.InMemoryUUIDCollectionType, native.StreamingUUIDCollectionType)
+		}
 	case "scalingwindow":
 		if err := checkDurations(c.Name, c.TimeWindow, c.MinimumThrottle, c.MaximumThrottle); err != nil {
 			return err
@@ -64,6 +142,39 @@ func (c CycleConfig) Validate() error {
 	return nil
 }
 
+// filterChain compiles the filters of the cycle. Images are skipped first by the default rules, unless the cycle publishes images or has its own images rule.
+func (c CycleConfig) filterChain() (*filter.Chain, error) {
+	if c.PublishImages {
+		return filter.NewChain(c.Filters)
+	}
+
+	for _, rule := range c.Filters {
+		if strings.EqualFold(rule.Name, filter.ImagesRule) {
+			return filter.NewChain(c.Filters)
+		}
+	}
+
+	return filter.NewChain(append(filter.DefaultRules(), c.Filters...))
+}
+
+// readOptions parses the read options for the cycle, which override the defaults for native-store queries
+func (c CycleConfig) readOptions() (native.ReadOptions, error) {
+	return native.ParseReadOptions(c.ReadPreference, c.ReadConcern, c.MaxStaleness)
+}
+
+// expiryTime parses the RFC3339 expiry time of the cycle, which is nil if the cycle does not expire
+func (c CycleConfig) expiryTime() (*time.Time, error) {
+	if strings.TrimSpace(c.ExpiresAt) == "" {
+		return nil, nil
+	}
+
+	expiresAt, err := time.Parse(time.RFC3339, c.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return &expiresAt, nil
+}
+
 func checkDurations(name string, durations ...string) error {
 	for _, duration := range durations {
 		if _, err := time.ParseDuration(duration); err != nil {
@@ -73,26 +184,20 @@ func checkDurations(name string, durations ...string) error {
 	return nil
 }
 
-// LoadSchedulerFromFile loads cycles and throttles from the provided yaml config file
-func LoadSchedulerFromFile(configFile string, uuidCollectionBuilder *native.NativeUUIDCollectionBuilder, publishTask tasks.Task, rw MetadataReadWriter, defaultThrottle time.Duration, checkpointInterval time.Duration) (Scheduler, error) {
-	scheduler := NewScheduler(uuidCollectionBuilder, publishTask, rw, defaultThrottle, checkpointInterval)
-	fileData, err := ioutil.ReadFile(configFile)
-	if err != nil {
-		return scheduler, err
-	}
+// LoadSchedulerFromFile loads cycles and throttles from the provided yaml config file, and then replays any cycles which were created, modified or deleted through the API
+func LoadSchedulerFromFile(configFile string, uuidCollectionBuilder *native.NativeUUIDCollectionBuilder, publishTask tasks.Task, rw MetadataReadWriter, defaultThrottle time.Duration, checkpointInterval time.Duration, restartPolicy RestartPolicy, republishLedger ledger.Ledger, taskRegistry *tasks.Registry, verifier verify.Verifier, tracerProvider trace.TracerProvider) (Scheduler, error) {
+	scheduler := newDefaultScheduler(uuidCollectionBuilder, publishTask, rw, defaultThrottle, checkpointInterval, restartPolicy, republishLedger, taskRegistry, verifier, tracerProvider)
 
-	setup := cycleSetupConfig{}
-	err = yaml.Unmarshal(fileData, &setup)
+	cycleConfigs, err := loadCycleConfigsFromFile(configFile)
 	if err != nil {
+		if journalErrs := scheduler.replayCycleJournal(); len(journalErrs) > 0 {
+			return scheduler, combineConfigErrors(append(journalErrs, err))
+		}
 		return scheduler, err
 	}
 
-	if len(setup.Cycles) == 0 {
-		return scheduler, errors.New("No configured cycles")
-	}
-
 	var errs []error
-	for _, cycleConfig := range setup.Cycles {
+	for _, cycleConfig := range cycleConfigs {
 		cycle, err := scheduler.NewCycle(cycleConfig)
 		if err != nil {
 			log.WithError(err).WithField("cycleName", cycleConfig.Name).Warn("Skipping cycle")
@@ -107,9 +212,29 @@ func LoadSchedulerFromFile(configFile string, uuidCollectionBuilder *native.Nati
 		}
 	}
 
+	errs = append(errs, scheduler.replayCycleJournal()...)
 	return scheduler, combineConfigErrors(errs)
 }
 
+func loadCycleConfigsFromFile(configFile string) ([]CycleConfig, error) {
+	fileData, err := ioutil.ReadFile(configFile)
+	if err != nil {
+		return nil, err
+	}
+
+	setup := cycleSetupConfig{}
+	err = yaml.Unmarshal(fileData, &setup)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(setup.Cycles) == 0 {
+		return nil, errors.New("No configured cycles")
+	}
+
+	return setup.Cycles, nil
+}
+
 func combineConfigErrors(errs []error) error {
 	if len(errs) == 0 {
 		return nil
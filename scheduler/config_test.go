@@ -0,0 +1,173 @@
+package scheduler
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/Financial-Times/publish-carousel/filter"
+	"github.com/Financial-Times/publish-carousel/native"
+	"github.com/Financial-Times/publish-carousel/tasks"
+	"github.com/Financial-Times/publish-carousel/transform"
+	"github.com/Financial-Times/publish-carousel/verify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateCycleLimits(t *testing.T) {
+	config := CycleConfig{Name: "wordpress-once", Type: "ThrottledWholeCollection", Origin: "wordpress", Collection: "wordpress", CoolDown: "5m", Throttle: "30s", MaxIterations: 1, ExpiresAt: "2017-03-06T09:00:00Z"}
+	assert.NoError(t, config.Validate())
+
+	expiresAt, err := config.expiryTime()
+	assert.NoError(t, err)
+	assert.Equal(t, "2017-03-06T09:00:00Z", expiresAt.Format("2006-01-02T15:04:05Z07:00"))
+
+	config.MaxIterations = -1
+	assert.EqualError(t, config.Validate(), "Please provide a positive number of maximum iterations for cycle wordpress-once")
+
+	config.MaxIterations = 1
+	config.ExpiresAt = "next monday"
+	assert.Error(t, config.Validate())
+}
+
+func TestValidateCycleWithoutExpiry(t *testing.T) {
+	config := CycleConfig{Name: "wordpress", Type: "ThrottledWholeCollection", Origin: "wordpress", Collection: "wordpress", CoolDown: "5m"}
+	assert.NoError(t, config.Validate())
+
+	expiresAt, err := config.expiryTime()
+	assert.NoError(t, err)
+	assert.Nil(t, expiresAt)
+}
+
+func TestValidateCycleUUIDCollection(t *testing.T) {
+	config := CycleConfig{Name: "methode-whole-archive", Type: "ThrottledWholeCollection", Origin: "methode-web-pub", Collection: "methode", CoolDown: "5m", UUIDCollection: "streaming"}
+	assert.NoError(t, config.Validate())
+
+	config.UUIDCollection = "inMemory"
+	assert.NoError(t, config.Validate())
+
+	config.UUIDCollection = "onDisk"
+	assert.EqualError(t, config.Validate(), "Please provide a valid uuid collection type for cycle methode-whole-archive, either inMemory or streaming")
+}
+
+func TestValidateCycleReadOptions(t *testing.T) {
+	config := CycleConfig{Name: "methode-whole-archive", Type: "ThrottledWholeCollection", Origin: "methode-web-pub", Collection: "methode", CoolDown: "5m", ReadPreference: "secondaryPreferred", ReadConcern: "majority", MaxStaleness: "2m"}
+	assert.NoError(t, config.Validate())
+
+	config.ReadPreference = "secondaryOnly"
+	assert.Error(t, config.Validate())
+
+	config.ReadPreference = "nearest"
+	config.MaxStaleness = "a while"
+	assert.Error(t, config.Validate())
+}
+
+func TestValidateStalestFirstCycle(t *testing.T) {
+	config := CycleConfig{Name: "methode-stalest-first", Type: "StalestFirst", Origin: "methode-web-pub", Collection: "methode", CoolDown: "5m"}
+	assert.NoError(t, config.Validate())
+
+	config.Throttle = "30s"
+	assert.NoError(t, config.Validate())
+
+	config.Throttle = "fast"
+	assert.Error(t, config.Validate())
+}
+
+func TestValidateCycleTaskOptions(t *testing.T) {
+	config := CycleConfig{Name: "methode-sink", Type: "ThrottledWholeCollection", Origin: "methode-web-pub", Collection: "methode", CoolDown: "5m", TaskOptions: tasks.Options{"url": "http://localhost:8080/sink"}}
+	assert.EqualError(t, config.Validate(), "Please provide the task for the task options of cycle methode-sink")
+
+	config.Task = "http"
+	assert.NoError(t, config.Validate())
+}
+
+func TestLoadCycleConfigsWithTaskOptions(t *testing.T) {
+	f, err := ioutil.TempFile(os.TempDir(), "cycles")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+
+	_, err = f.WriteString(`cycles:
+  - name: methode-sink
+    type: ThrottledWholeCollection
+    origin: methode-web-pub
+    collection: methode
+    coolDown: 5m
+    task: http
+    taskOptions:
+      url: http://localhost:8080/sink/{uuid}
+      headers:
+        Authorization: Basic dXNlcjpwYXNz
+`)
+	require.NoError(t, err)
+	f.Close()
+
+	configs, err := loadCycleConfigsFromFile(f.Name())
+	require.NoError(t, err)
+	require.Len(t, configs, 1)
+
+	assert.Equal(t, "http", configs[0].Task)
+	assert.Equal(t, tasks.Options{"url": "http://localhost:8080/sink/{uuid}", "headers": map[string]interface{}{"Authorization": "Basic dXNlcjpwYXNz"}}, configs[0].TaskOptions)
+}
+
+func TestValidateCycleFilters(t *testing.T) {
+	config := CycleConfig{Name: "methode-articles", Type: "ThrottledWholeCollection", Origin: "methode-web-pub", Collection: "methode", CoolDown: "5m", Filters: []filter.Rule{{Name: "articles", Action: "include", Condition: filter.Condition{Field: "type", Values: []string{"Article"}}}}}
+	assert.NoError(t, config.Validate())
+
+	config.Filters = append(config.Filters, filter.Rule{Name: "videos", Action: "skip", Condition: filter.Condition{Field: "type"}})
+	assert.EqualError(t, config.Validate(), "Invalid filters for cycle methode-articles: Please provide an action for filter rule videos, either include or exclude")
+}
+
+func TestCycleFilterChainSkipsImages(t *testing.T) {
+	image := &native.Content{Body: map[string]interface{}{"type": "image"}}
+	videos := filter.Rule{Name: "videos", Action: "exclude", Condition: filter.Condition{Field: "type", Values: []string{"Video"}}}
+
+	config := CycleConfig{Filters: []filter.Rule{videos}}
+	chain, err := config.filterChain()
+	require.NoError(t, err)
+
+	rule, skipped := chain.Skip(image)
+	assert.True(t, skipped, "the default images rule is kept alongside the cycle's filters")
+	assert.Equal(t, filter.ImagesRule, rule)
+
+	config.PublishImages = true
+	chain, err = config.filterChain()
+	require.NoError(t, err)
+
+	_, skipped = chain.Skip(image)
+	assert.False(t, skipped)
+
+	config = CycleConfig{Filters: []filter.Rule{videos, {Name: "Images", Action: "exclude", Condition: filter.Condition{Field: "type", Values: []string{"image", "graphic"}}}}}
+	chain, err = config.filterChain()
+	require.NoError(t, err)
+
+	rule, skipped = chain.Skip(&native.Content{Body: map[string]interface{}{"type": "graphic"}})
+	assert.True(t, skipped, "a cycle's own images rule replaces the default")
+	assert.Equal(t, "Images", rule)
+}
+
+func TestValidateCycleTransformations(t *testing.T) {
+	config := CycleConfig{Name: "methode-articles", Type: "ThrottledWholeCollection", Origin: "methode-web-pub", Collection: "methode", CoolDown: "5m", Transformations: []transform.Rule{{Name: "internal", Action: "remove", Field: "internalNotes"}}}
+	assert.NoError(t, config.Validate())
+
+	config.Transformations = append(config.Transformations, transform.Rule{Name: "byline", Action: "rename", Field: "byline"})
+	assert.EqualError(t, config.Validate(), "Invalid transformations for cycle methode-articles: Transformation byline: Please provide a field path")
+}
+
+func TestValidateCycleVerification(t *testing.T) {
+	config := CycleConfig{Name: "methode-articles", Type: "ThrottledWholeCollection", Origin: "methode-web-pub", Collection: "methode", CoolDown: "5m", Verification: &verify.Config{URL: "{readURL}/__document-store-api/content/{uuid}"}}
+	assert.NoError(t, config.Validate())
+
+	config.Verification.Delay = "later"
+	assert.EqualError(t, config.Validate(), "Invalid verification for cycle methode-articles: Please provide a valid verification duration, not later")
+}
+
+func TestValidateCycleTransactionIDTemplate(t *testing.T) {
+	config := CycleConfig{Name: "methode-articles", Type: "ThrottledWholeCollection", Origin: "methode-web-pub", Collection: "methode", CoolDown: "5m", TransactionIDTemplate: "{{.TID}}_carousel_{{.Cycle}}_{{.Iteration}}_{{.Unix}}"}
+	assert.NoError(t, config.Validate())
+
+	config.TransactionIDTemplate = "{{.Cycle}}_{{.Iteration}}"
+	assert.EqualError(t, config.Validate(), "Invalid transaction id template for cycle methode-articles: Please include {{.TID}} in the transaction id template, so every publish has a unique transaction id")
+
+	config.TransactionIDTemplate = "{{.TID}}_{{.Missing}}"
+	assert.Error(t, config.Validate())
+}
@@ -10,9 +10,18 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Financial-Times/publish-carousel/cms"
+	"github.com/Financial-Times/publish-carousel/filter"
+	"github.com/Financial-Times/publish-carousel/ledger"
 	"github.com/Financial-Times/publish-carousel/native"
 	"github.com/Financial-Times/publish-carousel/tasks"
+	"github.com/Financial-Times/publish-carousel/tracing"
+	"github.com/Financial-Times/publish-carousel/transform"
+	"github.com/Financial-Times/publish-carousel/txid"
+	"github.com/Financial-Times/publish-carousel/verify"
 	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Cycle interface {
@@ -25,22 +34,73 @@ type Cycle interface {
 	Metadata() CycleMetadata
 	SetMetadata(state CycleMetadata)
 	TransformToConfig() CycleConfig
+	Source() string
+	SetSource(source string)
 	State() []string
 }
 
 type CycleMetadata struct {
-	CurrentPublishUUID  string     `json:"currentPublishUuid"`
-	CurrentPublishRef   string     `json:"currentPublishReference"`
-	CurrentPublishError string     `json:"currentPublishError,omitempty"`
-	Errors              int        `json:"errors"`
-	Progress            float64    `json:"progress"`
-	State               []string   `json:"state"`
-	Completed           int        `json:"completed"`
-	Total               int        `json:"total"`
-	Iteration           int        `json:"iteration"`
-	Attempts            int        `json:"attempts"`
-	Start               *time.Time `json:"windowStart,omitempty"`
-	End                 *time.Time `json:"windowEnd,omitempty"`
+	CurrentPublishUUID  string           `json:"currentPublishUuid"`
+	CurrentPublishRef   string           `json:"currentPublishReference"`
+	CurrentPublishError string           `json:"currentPublishError,omitempty"`
+	Errors              int              `json:"errors"`
+	Retries             int              `json:"retries,omitempty"`
+	Failures            []PublishFailure `json:"failures,omitempty"`
+	Skipped             map[string]int   `json:"skipped,omitempty"`
+	Progress            float64          `json:"progress"`
+	State               []string         `json:"state"`
+	Completed           int              `json:"completed"`
+	Total               int              `json:"total"`
+	Iteration           int              `json:"iteration"`
+	Attempts            int              `json:"attempts"`
+	Start               *time.Time       `json:"windowStart,omitempty"`
+	End                 *time.Time       `json:"windowEnd,omitempty"`
+	IterationStart      *time.Time       `json:"iterationStart,omitempty"`
+	IterationEnd        *time.Time       `json:"iterationEnd,omitempty"`
+	IterationElapsed    string           `json:"iterationElapsed,omitempty"`
+	ResumeToken         string           `json:"resumeToken,omitempty"`
+	PublishRate         float64          `json:"publishRate"`
+	EstimatedCompletion *time.Time       `json:"estimatedCompletion,omitempty"`
+	WindowLag           string           `json:"windowLag,omitempty"`
+	Restarts            int              `json:"restarts"`
+	NextRestart         *time.Time       `json:"nextRestart,omitempty"`
+	Panics              int              `json:"panics"`
+	LastPanic           string           `json:"lastPanic,omitempty"`
+
+	Verification *VerificationMetadata `json:"verification,omitempty"`
+}
+
+// VerificationMetadata counts the publishes which were, or were not, read back from the verification url of the cycle, and how long it took for them to be verified
+type VerificationMetadata struct {
+	Verified       int    `json:"verified"`
+	Unverified     int    `json:"unverified"`
+	Dropped        int    `json:"dropped,omitempty"`
+	LastLatency    string `json:"lastLatency,omitempty"`
+	AverageLatency string `json:"averageLatency,omitempty"`
+}
+
+// maxFailures bounds the number of recent failures kept in the metadata of each cycle
+const maxFailures = 20
+
+// PublishFailure records a uuid which the cycle failed to publish, and why
+type PublishFailure struct {
+	UUID          string    `json:"uuid,omitempty"`
+	TransactionID string    `json:"transactionId,omitempty"`
+	Attempts      int       `json:"attempts,omitempty"`
+	Error         string    `json:"error"`
+	Time          time.Time `json:"time"`
+}
+
+// recordFailure appends the failure to a copy of the failures, dropping the oldest failures beyond maxFailures
+func recordFailure(failures []PublishFailure, failure PublishFailure) []PublishFailure {
+	start := 0
+	if len(failures) >= maxFailures {
+		start = len(failures) - maxFailures + 1
+	}
+
+	recent := make([]PublishFailure, 0, len(failures)-start+1)
+	recent = append(recent, failures[start:]...)
+	return append(recent, failure)
 }
 
 func newCycleID(name string, dbcollection string) string {
@@ -57,12 +117,17 @@ func newAbstractCycle(name string, cycleType string, uuidCollectionBuilder *nati
 		CycleType:             cycleType,
 		CycleMetadata:         CycleMetadata{},
 		metadataLock:          &sync.RWMutex{},
+		collectionLock:        &sync.Mutex{},
 		DBCollection:          dbCollection,
 		Origin:                origin,
 		CoolDown:              coolDown.String(),
+		CycleSource:           FileCycleSource,
 		coolDown:              coolDown,
 		publishTask:           task,
 		uuidCollectionBuilder: uuidCollectionBuilder,
+		throughput:            newThroughput(throughputWindow),
+		verificationLock:      &sync.Mutex{},
+		verifications:         &sync.WaitGroup{},
 	}
 	cycle.UpdateState(stoppedState)
 
@@ -77,15 +142,30 @@ type abstractCycle struct {
 	DBCollection  string        `json:"collection"`
 	Origin        string        `json:"origin"`
 	CoolDown      string        `json:"coolDown"`
+	CycleSource   string        `json:"source"`
+
+	cycleOptions
 
 	coolDown              time.Duration
 	metadataLock          *sync.RWMutex
+	collectionLock        *sync.Mutex
+	collection            native.UUIDCollection
 	cancel                context.CancelFunc
 	uuidCollectionBuilder *native.NativeUUIDCollectionBuilder
 	publishTask           tasks.Task
+	verificationLock      *sync.Mutex
+	verificationQueue     chan pendingVerification
+	verifications         *sync.WaitGroup
+	throughput            *throughput
+	lastPublish           time.Time
 }
 
 func (a *abstractCycle) publishCollection(ctx context.Context, collection native.UUIDCollection, t Throttle) (bool, error) {
+	collection = withPrefetching(collection, a.DBCollection, a.publishTask)
+	a.setCollection(collection)
+	defer a.setCollection(nil)
+
+	counted := make(map[string]int)
 	for {
 		t.Queue()
 
@@ -93,46 +173,304 @@ func (a *abstractCycle) publishCollection(ctx context.Context, collection native
 			return true, err
 		}
 
-		finished, uuid, err := collection.Next()
+		finished, uuid, err := a.next()
+		a.updateCollectionSkipped(counted)
 		if finished {
 			log.WithField("id", a.CycleID).WithField("name", a.Name()).WithField("collection", a.DBCollection).Info("Finished publishing collection.")
 			a.updateProgress("", "", err)
+			a.finishIteration(time.Now())
 			return false, err
 		}
 
-		if strings.TrimSpace(uuid) == "" { // N.B. UUID cannot be empty for the in memory collection
+		if strings.TrimSpace(uuid) == "" { // N.B. collections skip documents whose uuid is missing or invalid, so this should not happen
 			log.WithField("id", a.CycleID).WithField("name", a.Name()).WithField("collection", a.DBCollection).Warn("Next UUID is empty! Skipping.")
 			a.updateProgress(uuid, "", errors.New("Empty uuid"))
 			continue
 		}
 
+		token := a.resumeToken()
+
 		log.WithField("id", a.CycleID).WithField("name", a.Name()).WithField("collection", a.DBCollection).WithField("uuid", uuid).Info("Running publish task.")
-		content, txID, err := a.publishTask.Prepare(a.DBCollection, uuid)
+		a.publish(ctx, uuid)
+		a.updateResumeToken(token)
+	}
+}
+
+// publishWholeCollection publishes one iteration of a whole collection cycle, which starts with the given metadata, and returns true if the cycle should start another iteration
+func (a *abstractCycle) publishWholeCollection(ctx context.Context, collection native.UUIDCollection, t Throttle, metadata CycleMetadata) bool {
+	a.SetMetadata(metadata)
+
+	if collection.Length() == 0 {
+		a.UpdateState(stoppedState, unhealthyState) // assume unhealthy, as the whole archive should *always* have content
+		return false
+	}
 
-		if err == nil {
-			err = a.publishTask.Execute(uuid, content, a.Origin, txID)
-			if err != nil {
-				log.WithField("id", a.CycleID).WithField("name", a.Name()).WithField("collection", a.DBCollection).WithField("uuid", uuid).WithError(err).Warn("Failed to publish!")
-			}
+	stopped, err := a.publishCollection(ctx, collection, t)
+	if stopped {
+		if ctx.Err() == context.DeadlineExceeded {
+			a.complete()
 		} else {
-			log.WithField("id", a.CycleID).WithField("name", a.Name()).WithField("collection", a.DBCollection).WithField("uuid", uuid).WithError(err).Warn("Failed to prepare content!")
+			a.UpdateState(stoppedState)
 		}
+		return false
+	}
+
+	if err != nil {
+		log.WithField("id", a.CycleID).WithField("name", a.CycleName).WithField("collection", a.DBCollection).WithError(err).Error("Unexpected error occurred while publishing collection.")
+		a.UpdateState(stoppedState, unhealthyState)
+		return false
+	}
+
+	if a.reachedMaxIterations() {
+		a.complete()
+		return false
+	}
+
+	return true
+}
+
+// publish prepares, filters and transforms the content of the uuid, then validates and publishes the transformed content with its transaction id
+func (a *abstractCycle) publish(ctx context.Context, uuid string) {
+	ctx, span := a.startSpan(ctx, tracing.PublishSpan, attribute.String("uuid", uuid), attribute.String("collection", a.DBCollection), attribute.String("cycle", a.Name()))
+	content, txID, err := tasks.Prepare(ctx, a.publishTask, a.DBCollection, uuid)
+
+	if err != nil {
+		log.WithField("id", a.CycleID).WithField("name", a.Name()).WithField("collection", a.DBCollection).WithField("uuid", uuid).WithError(err).Warn("Failed to prepare content!")
+		span.SetAttributes(attribute.String("transaction_id", txID))
+		tracing.End(span, err)
+		a.updatePublished(uuid, txID, 0, err)
+		return
+	}
+
+	_, filtered := a.startSpan(ctx, tracing.FilterSpan)
+	rule, skip := a.filters.Skip(content)
+	filtered.SetAttributes(attribute.String("rule", rule))
+	filtered.End()
+
+	if skip {
+		log.WithField("id", a.CycleID).WithField("name", a.Name()).WithField("collection", a.DBCollection).WithField("uuid", uuid).WithField("rule", rule).Info("Skipping content excluded by filter rule.")
+		span.SetAttributes(attribute.String("transaction_id", txID), attribute.String("skipped", rule))
+		span.End()
+		a.updateSkipped(uuid, rule)
+		return
+	}
+
+	_, transformed := a.startSpan(ctx, tracing.TransformSpan)
+	content, applied := a.transformations.Apply(content)
+	if len(applied) > 0 {
+		log.WithField("id", a.CycleID).WithField("name", a.Name()).WithField("collection", a.DBCollection).WithField("uuid", uuid).WithField("transformations", applied).Debug("Transformed content.")
+	}
+	transformed.SetAttributes(attribute.StringSlice("applied", applied))
+	transformed.End()
+
+	txID = a.transactionID(uuid, content, txID)
+	span.SetAttributes(attribute.String("transaction_id", txID))
+
+	if err := tasks.Validate(a.publishTask, uuid, content); err != nil {
+		tracing.End(span, err)
+		a.updatePublished(uuid, txID, 0, err)
+		return
+	}
+
+	attempts, err := tasks.ExecuteAttempts(ctx, a.publishTask, uuid, content, a.Origin, txID)
+	span.SetAttributes(attribute.Int("attempts", attempts))
+
+	if err != nil {
+		log.WithField("id", a.CycleID).WithField("name", a.Name()).WithField("collection", a.DBCollection).WithField("uuid", uuid).WithField("attempts", attempts).WithError(err).Warn("Failed to publish!")
+		if fanOut, ok := err.(*cms.FanOutError); ok && len(fanOut.Succeeded) > 0 && a.ledger != nil {
+			a.ledger.Record(ledger.Entry{Collection: a.DBCollection, UUID: uuid, LastPublished: time.Now(), TransactionID: txID, CycleID: a.CycleID, Attempts: attempts, FailedTargets: fanOut.FailedTargets()})
+		}
+	} else {
+		if a.ledger != nil {
+			a.ledger.Record(ledger.Entry{Collection: a.DBCollection, UUID: uuid, LastPublished: time.Now(), TransactionID: txID, CycleID: a.CycleID, Attempts: attempts})
+		}
+
+		if a.verifier != nil {
+			a.enqueueVerification(uuid, txID, time.Now())
+		}
+	}
+
+	tracing.End(span, err)
+	a.updatePublished(uuid, txID, attempts, err)
+}
+
+// startSpan starts a span within the trace of the context. The cycle's own context has no span, so every publish starts a trace of its own.
+func (a *abstractCycle) startSpan(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	tracer := a.tracer
+	if tracer == nil {
+		tracer = tracing.Tracer(nil)
+	}
+	return tracer.Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// transactionID works out the transaction id of the transformed content, with the cycle's own strategy if it has one, otherwise with the publish task's
+func (a *abstractCycle) transactionID(uuid string, content *native.Content, txID string) string {
+	if id, ok := a.TransactionID(uuid, content); ok {
+		return id
+	}
+	return tasks.TransactionID(a.publishTask, content, txID)
+}
+
+func (a *abstractCycle) setCollection(collection native.UUIDCollection) {
+	a.collectionLock.Lock()
+	defer a.collectionLock.Unlock()
+	a.collection = collection
+}
+
+func (a *abstractCycle) next() (bool, string, error) {
+	a.collectionLock.Lock()
+	defer a.collectionLock.Unlock()
+	return a.collection.Next()
+}
 
-		a.updateProgress(uuid, txID, err)
+// resumeToken returns the token which the collection can be reopened with after the uuid it last returned, if the collection can be resumed
+func (a *abstractCycle) resumeToken() string {
+	a.collectionLock.Lock()
+	defer a.collectionLock.Unlock()
+
+	if resumable, ok := a.collection.(native.ResumableUUIDCollection); ok {
+		return resumable.ResumeToken()
 	}
+	return ""
+}
+
+// updateResumeToken records the token to resume the iteration with, once the uuid it was returned with has been published
+func (a *abstractCycle) updateResumeToken(token string) {
+	if token == "" {
+		return
+	}
+
+	a.metadataLock.Lock()
+	defer a.metadataLock.Unlock()
+	a.CycleMetadata.ResumeToken = token
+}
+
+// ExplainableCycle is implemented by cycles which can explain how they would publish a piece of content
+type ExplainableCycle interface {
+	PublishTask() tasks.Task
+	Filter(content *native.Content) (rule string, skipped bool)
+	Transform(content *native.Content) (transformed *native.Content, applied []string)
+	TransactionID(uuid string, content *native.Content) (txID string, ok bool)
+	Position(uuid string) (position int, pending bool, ok bool)
+}
+
+// Filter returns the name of the filter rule which decides whether the cycle publishes the content, and true if the cycle would skip it
+func (a *abstractCycle) Filter(content *native.Content) (string, bool) {
+	return a.filters.Skip(content)
+}
+
+// Transform returns the content as the cycle would publish it, and the names of the transformations which changed it
+func (a *abstractCycle) Transform(content *native.Content) (*native.Content, []string) {
+	return a.transformations.Apply(content)
+}
+
+// TransactionID returns the transaction id which the cycle would publish the content with, if the cycle has its own transaction id strategy. Otherwise the publish task decides the transaction id, and ok is false.
+func (a *abstractCycle) TransactionID(uuid string, content *native.Content) (string, bool) {
+	if a.txIDs == nil {
+		return "", false
+	}
+	return a.txIDs.TransactionID(txid.Context{UUID: uuid, Collection: a.DBCollection, Cycle: a.Name(), Iteration: a.Metadata().Iteration, PublishReference: tasks.PublishReference(content), Time: time.Now()}), true
+}
+
+// PublishTask returns the task the cycle publishes content with, which is either the cycle's own task or the default publish task
+func (a *abstractCycle) PublishTask() tasks.Task {
+	return a.publishTask
+}
+
+// Position returns the number of uuids which will be published before the uuid in the current iteration, and whether the uuid is still waiting to be published. If the cycle is not publishing, or its uuid collection cannot tell, ok is false.
+func (a *abstractCycle) Position(uuid string) (int, bool, bool) {
+	a.collectionLock.Lock()
+	defer a.collectionLock.Unlock()
+
+	switch c := a.collection.(type) {
+	case *prefetchingCollection:
+		return c.position(uuid)
+	case native.PositionedUUIDCollection:
+		position, pending := c.Position(uuid)
+		return position, pending, true
+	}
+	return -1, false, false
 }
 
 func (a *abstractCycle) updateProgress(uuid string, txId string, err error) {
+	a.updatePublished(uuid, txId, 0, err)
+}
+
+// updatePublished records the outcome of a publish which took the given number of attempts. Attempts beyond the first are counted as retries.
+func (a *abstractCycle) updatePublished(uuid string, txId string, attempts int, err error) {
 	a.metadataLock.Lock()
 	defer a.metadataLock.Unlock()
 
+	if attempts > 1 {
+		a.CycleMetadata.Retries += attempts - 1
+	}
+
+	now := time.Now()
 	if err == nil {
 		a.CycleMetadata.CurrentPublishError = ""
+		if uuid != "" {
+			a.lastPublish = now
+		}
 	} else {
 		a.CycleMetadata.Errors++
 		a.CycleMetadata.CurrentPublishError = err.Error()
+		a.CycleMetadata.Failures = recordFailure(a.CycleMetadata.Failures, PublishFailure{UUID: uuid, TransactionID: txId, Attempts: attempts, Error: err.Error(), Time: now})
+	}
+
+	a.advance(now, uuid, txId)
+}
+
+// updateCollectionSkipped counts the documents which the collection has skipped since they were last counted, because their uuid is missing or invalid. They are counted by reason, and taken off the total, as they are included in the length of the collection.
+func (a *abstractCycle) updateCollectionSkipped(counted map[string]int) {
+	a.collectionLock.Lock()
+	skipping, ok := a.collection.(native.SkippingUUIDCollection)
+	skipped := make(map[string]int)
+	if ok {
+		for reason, n := range skipping.Skipped() {
+			skipped[reason] = n
+		}
+	}
+	a.collectionLock.Unlock()
+
+	a.metadataLock.Lock()
+	defer a.metadataLock.Unlock()
+
+	for reason, n := range skipped {
+		uncounted := n - counted[reason]
+		if uncounted <= 0 {
+			continue
+		}
+
+		if a.CycleMetadata.Skipped == nil {
+			a.CycleMetadata.Skipped = make(map[string]int)
+		}
+
+		a.CycleMetadata.Skipped[reason] += uncounted
+		a.CycleMetadata.Total -= uncounted
+		counted[reason] = n
+	}
+
+	if a.CycleMetadata.Total > 0 {
+		a.CycleMetadata.Progress = float64(a.CycleMetadata.Completed) / float64(a.CycleMetadata.Total)
 	}
+}
+
+// updateSkipped counts the uuid as completed, and as skipped by the filter rule
+func (a *abstractCycle) updateSkipped(uuid string, rule string) {
+	a.metadataLock.Lock()
+	defer a.metadataLock.Unlock()
 
+	if a.CycleMetadata.Skipped == nil {
+		a.CycleMetadata.Skipped = make(map[string]int)
+	}
+
+	a.CycleMetadata.Skipped[rule]++
+	a.CycleMetadata.CurrentPublishError = ""
+	a.advance(time.Now(), uuid, "")
+}
+
+// advance moves the progress of the current iteration on by one uuid. The metadata lock must be held by the caller.
+func (a *abstractCycle) advance(now time.Time, uuid string, txId string) {
 	a.CycleMetadata.Completed++
 	a.CycleMetadata.CurrentPublishUUID = uuid
 	a.CycleMetadata.CurrentPublishRef = txId
@@ -142,6 +480,189 @@ func (a *abstractCycle) updateProgress(uuid string, txId string, err error) {
 	} else {
 		a.CycleMetadata.Progress = float64(a.CycleMetadata.Completed) / float64(a.CycleMetadata.Total)
 	}
+
+	a.throughput.record(now)
+	a.CycleMetadata.updateEstimates(a.throughput.rate(now), now)
+}
+
+// finishIteration records the end of the current iteration, which stops its elapsed time from growing
+func (a *abstractCycle) finishIteration(end time.Time) {
+	a.metadataLock.Lock()
+	defer a.metadataLock.Unlock()
+
+	a.CycleMetadata.IterationEnd = &end
+	a.CycleMetadata.updateEstimates(a.throughput.rate(end), end)
+}
+
+// updateEstimates sets the publish rate, and computes the elapsed time (up to the end of the iteration, once it has finished), projected completion time and window lag for the current iteration
+func (m *CycleMetadata) updateEstimates(rate float64, now time.Time) {
+	m.PublishRate = rate
+
+	if m.IterationStart != nil {
+		end := now
+		if m.IterationEnd != nil {
+			end = *m.IterationEnd
+		}
+		m.IterationElapsed = end.Sub(*m.IterationStart).Truncate(time.Second).String()
+	}
+
+	if m.End != nil {
+		m.WindowLag = now.Sub(*m.End).Truncate(time.Second).String()
+	}
+
+	remaining := m.Total - m.Completed
+	if remaining <= 0 || m.PublishRate <= 0 {
+		m.EstimatedCompletion = nil
+		return
+	}
+
+	estimate := now.Add(time.Duration(float64(remaining) / m.PublishRate * float64(time.Minute))).Truncate(time.Second)
+	m.EstimatedCompletion = &estimate
+}
+
+// clone returns a copy of the metadata which shares none of its maps, slices or mutable pointers, so it can be read while the cycle carries on
+func (m CycleMetadata) clone() CycleMetadata {
+	if m.Failures != nil {
+		m.Failures = append([]PublishFailure(nil), m.Failures...)
+	}
+
+	if m.Skipped != nil {
+		skipped := make(map[string]int, len(m.Skipped))
+		for rule, n := range m.Skipped {
+			skipped[rule] = n
+		}
+		m.Skipped = skipped
+	}
+
+	if m.State != nil {
+		m.State = append([]string(nil), m.State...)
+	}
+
+	if m.Verification != nil {
+		verification := *m.Verification
+		m.Verification = &verification
+	}
+	return m
+}
+
+// cycleOptions are the options which every type of cycle can be configured with, whatever collection it publishes and however it iterates over it
+type cycleOptions struct {
+	MaxIterations      int    `json:"maxIterations,omitempty"`
+	ExpiresAt          string `json:"expiresAt,omitempty"`
+	RemoveOnCompletion bool   `json:"removeOnCompletion,omitempty"`
+
+	Labels map[string]string `json:"labels,omitempty"`
+
+	Task        string        `json:"task,omitempty"`
+	TaskOptions tasks.Options `json:"taskOptions,omitempty"`
+
+	Filters         []filter.Rule    `json:"filters,omitempty"`
+	PublishImages   bool             `json:"publishImages,omitempty"`
+	Transformations []transform.Rule `json:"transformations,omitempty"`
+
+	Verification *verify.Config `json:"verification,omitempty"`
+
+	TransactionIDTemplate string `json:"transactionIdTemplate,omitempty"`
+
+	ReadPreference string `json:"readPreference,omitempty"`
+	ReadConcern    string `json:"readConcern,omitempty"`
+	MaxStaleness   string `json:"maxStaleness,omitempty"`
+
+	expiresAt       *time.Time
+	onCompleted     func()
+	ledger          ledger.Ledger
+	filters         *filter.Chain
+	transformations *transform.Chain
+	txIDs           txid.Strategy
+	verifier        verify.Verifier
+	restartPolicy   RestartPolicy
+	readOptions     native.ReadOptions
+	tracer          trace.Tracer
+}
+
+// configurableCycle is implemented by cycles which accept the options common to every type of cycle
+type configurableCycle interface {
+	setOptions(opts cycleOptions)
+}
+
+// setOptions replaces the cycle's options. The cycle reads its uuid collection, and publishes with its task, using the read options, if there are any.
+func (a *abstractCycle) setOptions(opts cycleOptions) {
+	a.cycleOptions = opts
+	if opts.readOptions.IsZero() {
+		return
+	}
+
+	if a.uuidCollectionBuilder != nil {
+		a.uuidCollectionBuilder = a.uuidCollectionBuilder.WithReadOptions(opts.readOptions)
+	}
+	if task, ok := a.publishTask.(tasks.ReadOptionsTask); ok {
+		a.publishTask = task.WithReadOptions(opts.readOptions)
+	}
+}
+
+// baseConfig returns the config fields which are common to every type of cycle. Each type of cycle adds its own fields in TransformToConfig.
+func (a *abstractCycle) baseConfig() CycleConfig {
+	return CycleConfig{
+		Name:                  a.CycleName,
+		Type:                  a.CycleType,
+		Collection:            a.DBCollection,
+		Origin:                a.Origin,
+		CoolDown:              a.CoolDown,
+		MaxIterations:         a.MaxIterations,
+		ExpiresAt:             a.ExpiresAt,
+		RemoveOnCompletion:    a.RemoveOnCompletion,
+		Labels:                a.Labels,
+		Task:                  a.Task,
+		TaskOptions:           a.TaskOptions,
+		Filters:               a.Filters,
+		PublishImages:         a.PublishImages,
+		Transformations:       a.Transformations,
+		Verification:          a.Verification,
+		TransactionIDTemplate: a.TransactionIDTemplate,
+		ReadPreference:        a.ReadPreference,
+		ReadConcern:           a.ReadConcern,
+		MaxStaleness:          a.MaxStaleness,
+	}
+}
+
+// newContext returns the context for a run of the cycle, which is cancelled when the cycle is stopped, or when it expires
+func (a *abstractCycle) newContext() (context.Context, context.CancelFunc) {
+	if a.expiresAt != nil {
+		return context.WithDeadline(context.Background(), *a.expiresAt)
+	}
+	return context.WithCancel(context.Background())
+}
+
+func (a *abstractCycle) hasExpired() bool {
+	return a.expiresAt != nil && !time.Now().Before(*a.expiresAt)
+}
+
+func (a *abstractCycle) reachedMaxIterations() bool {
+	return a.MaxIterations > 0 && a.Metadata().Iteration >= a.MaxIterations
+}
+
+// isCompleted returns true if the cycle has expired, or has already completed all of its iterations
+func (a *abstractCycle) isCompleted() bool {
+	if a.hasExpired() {
+		return true
+	}
+
+	for _, state := range a.State() {
+		if state == completedState {
+			return a.MaxIterations > 0
+		}
+	}
+	return false
+}
+
+// complete marks the cycle as completed, and removes it from the scheduler if configured to do so
+func (a *abstractCycle) complete() {
+	log.WithField("id", a.CycleID).WithField("name", a.CycleName).WithField("collection", a.DBCollection).WithField("iteration", a.Metadata().Iteration).Info("Cycle completed.")
+	a.UpdateState(completedState)
+
+	if a.RemoveOnCompletion && a.onCompleted != nil {
+		go a.onCompleted()
+	}
 }
 
 func (a *abstractCycle) ID() string {
@@ -156,10 +677,23 @@ func (a *abstractCycle) Type() string {
 	return a.CycleType
 }
 
+func (a *abstractCycle) Source() string {
+	a.metadataLock.RLock()
+	defer a.metadataLock.RUnlock()
+	return a.CycleSource
+}
+
+func (a *abstractCycle) SetSource(source string) {
+	a.metadataLock.Lock()
+	defer a.metadataLock.Unlock()
+	a.CycleSource = source
+}
+
 func (a *abstractCycle) Stop() {
 	if a.cancel != nil {
 		a.cancel()
 	}
+	a.stopVerifiers()
 	log.WithField("id", a.CycleID).WithField("name", a.CycleName).WithField("collection", a.DBCollection).Info("Cycle stopped.")
 	a.UpdateState(stoppedState)
 }
@@ -168,13 +702,18 @@ func (a *abstractCycle) Reset() {
 	a.Stop()
 	metadata := CycleMetadata{}
 	a.SetMetadata(metadata)
+	a.throughput.reset()
 }
 
+// Metadata returns a copy of the cycle's metadata, with the estimates for the current iteration brought up to date
 func (a *abstractCycle) Metadata() CycleMetadata {
-	a.metadataLock.Lock()
-	defer a.metadataLock.Unlock()
+	a.metadataLock.RLock()
+	metadata := a.CycleMetadata.clone()
+	a.metadataLock.RUnlock()
 
-	return a.CycleMetadata
+	now := time.Now()
+	metadata.updateEstimates(a.throughput.rate(now), now)
+	return metadata
 }
 
 func (a *abstractCycle) SetMetadata(metadata CycleMetadata) {
@@ -201,5 +740,5 @@ func (a *abstractCycle) PublishedItems() int {
 func (a *abstractCycle) State() []string {
 	a.metadataLock.RLock()
 	defer a.metadataLock.RUnlock()
-	return a.CycleMetadata.State
+	return append([]string(nil), a.CycleMetadata.State...)
 }
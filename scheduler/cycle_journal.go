@@ -0,0 +1,121 @@
+package scheduler
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Cycle sources, which describe where the current configuration of a cycle came from.
+const (
+	FileCycleSource = "file"
+	APICycleSource  = "api"
+)
+
+const (
+	upsertCycleAction = "upsert"
+	deleteCycleAction = "delete"
+	// completedCycleAction records a cycle which removed itself on completion. Unlike a delete, it does not stop the cycle being loaded from file again.
+	completedCycleAction = "completed"
+)
+
+// CycleJournalEntry records the latest change made to a cycle through the API
+type CycleJournalEntry struct {
+	ID     string       `json:"id"`
+	Action string       `json:"action"`
+	Config *CycleConfig `json:"config,omitempty"`
+	Time   time.Time    `json:"time"`
+}
+
+// cycleJournal keeps the latest API change for each cycle, and persists the full journal through the state backend on every change.
+// Journalling is disabled until the scheduler has been loaded, so that cycles from the configuration file are not recorded.
+type cycleJournal struct {
+	sync.Mutex
+	rw      MetadataReadWriter
+	entries map[string]CycleJournalEntry
+	enabled bool
+}
+
+func newCycleJournal(rw MetadataReadWriter) *cycleJournal {
+	return &cycleJournal{rw: rw, entries: make(map[string]CycleJournalEntry)}
+}
+
+// load reads the persisted journal, and returns its entries in the order in which they were recorded
+func (j *cycleJournal) load() ([]CycleJournalEntry, error) {
+	j.Lock()
+	defer j.Unlock()
+
+	entries, err := j.rw.LoadCycleJournal()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if existing, ok := j.entries[entry.ID]; ok && existing.Time.After(entry.Time) {
+			continue
+		}
+		j.entries[entry.ID] = entry
+	}
+
+	return j.sortedEntries(), nil
+}
+
+func (j *cycleJournal) enable() {
+	j.Lock()
+	defer j.Unlock()
+	j.enabled = true
+}
+
+func (j *cycleJournal) isEnabled() bool {
+	j.Lock()
+	defer j.Unlock()
+	return j.enabled
+}
+
+func (j *cycleJournal) recordUpsert(id string, config CycleConfig) {
+	j.record(CycleJournalEntry{ID: id, Action: upsertCycleAction, Config: &config, Time: time.Now().UTC()})
+}
+
+func (j *cycleJournal) recordDelete(id string) {
+	j.record(CycleJournalEntry{ID: id, Action: deleteCycleAction, Time: time.Now().UTC()})
+}
+
+func (j *cycleJournal) recordCompleted(id string) {
+	j.record(CycleJournalEntry{ID: id, Action: completedCycleAction, Time: time.Now().UTC()})
+}
+
+func (j *cycleJournal) forget(id string) {
+	j.Lock()
+	defer j.Unlock()
+	delete(j.entries, id)
+}
+
+func (j *cycleJournal) record(entry CycleJournalEntry) {
+	j.Lock()
+	defer j.Unlock()
+
+	if !j.enabled {
+		return
+	}
+
+	j.entries[entry.ID] = entry
+
+	err := j.rw.WriteCycleJournal(j.sortedEntries())
+	if err != nil {
+		log.WithError(err).WithField("cycle", entry.ID).WithField("action", entry.Action).Error("Failed to persist cycle journal.")
+	}
+}
+
+func (j *cycleJournal) sortedEntries() []CycleJournalEntry {
+	entries := make([]CycleJournalEntry, 0, len(j.entries))
+	for _, entry := range j.entries {
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, k int) bool {
+		return entries[i].Time.Before(entries[k].Time)
+	})
+	return entries
+}
@@ -0,0 +1,210 @@
+package scheduler
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Financial-Times/publish-carousel/blacklist"
+	"github.com/Financial-Times/publish-carousel/native"
+	"github.com/Financial-Times/publish-carousel/tasks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const testCyclesFile = `cycles:
+-  name: methode-whole-archive
+   type: ThrottledWholeCollection
+   origin: methode-web-pub
+   collection: methode
+   coolDown: 5m
+   throttle: 3s
+
+-  name: wordpress-whole-archive
+   type: ThrottledWholeCollection
+   origin: wordpress
+   collection: wordpress
+   coolDown: 5m
+   throttle: 6s
+`
+
+func writeTestCyclesFile(t *testing.T) string {
+	f, err := ioutil.TempFile(os.TempDir(), "cycles")
+	assert.NoError(t, err)
+
+	_, err = f.WriteString(testCyclesFile)
+	assert.NoError(t, err)
+	f.Close()
+
+	return f.Name()
+}
+
+func TestLoadSchedulerReplaysCycleJournal(t *testing.T) {
+	configFile := writeTestCyclesFile(t)
+	defer os.Remove(configFile)
+
+	methodeID := newCycleID("methode-whole-archive", "methode")
+	wordpressID := newCycleID("wordpress-whole-archive", "wordpress")
+	videoID := newCycleID("video-whole-archive", "video")
+
+	now := time.Now()
+	entries := []CycleJournalEntry{
+		{ID: methodeID, Action: upsertCycleAction, Time: now.Add(-3 * time.Minute), Config: &CycleConfig{Name: "methode-whole-archive", Type: "ThrottledWholeCollection", Origin: "methode-web-pub", Collection: "methode", CoolDown: "5m", Throttle: "10s"}},
+		{ID: wordpressID, Action: deleteCycleAction, Time: now.Add(-2 * time.Minute)},
+		{ID: videoID, Action: upsertCycleAction, Time: now.Add(-1 * time.Minute), Config: &CycleConfig{Name: "video-whole-archive", Type: "ThrottledWholeCollection", Origin: "next-video-editor", Collection: "video", CoolDown: "5m", Throttle: "1s"}},
+	}
+
+	rw := new(MockMetadataRW)
+	rw.On("LoadCycleJournal").Return(entries, nil)
+
+	uuidCollectionBuilder := native.NewNativeUUIDCollectionBuilder(new(native.MockDB), nil, blacklist.NoOpBlacklist)
+	s, err := LoadSchedulerFromFile(configFile, uuidCollectionBuilder, &tasks.MockTask{}, rw, time.Minute, time.Minute, DefaultRestartPolicy, nil, nil, nil, nil)
+	assert.NoError(t, err)
+
+	cycles := s.Cycles()
+	assert.Len(t, cycles, 2)
+
+	methode, ok := cycles[methodeID]
+	assert.True(t, ok, "methode cycle should be loaded")
+	assert.Equal(t, APICycleSource, methode.Source(), "journal entries take precedence over file configuration")
+	assert.Equal(t, "10s", methode.TransformToConfig().Throttle)
+
+	_, ok = cycles[wordpressID]
+	assert.False(t, ok, "wordpress cycle was deleted through the API")
+
+	video, ok := cycles[videoID]
+	assert.True(t, ok, "video cycle was created through the API")
+	assert.Equal(t, APICycleSource, video.Source())
+
+	rw.AssertExpectations(t)
+}
+
+func TestLoadSchedulerWithoutCycleJournal(t *testing.T) {
+	configFile := writeTestCyclesFile(t)
+	defer os.Remove(configFile)
+
+	rw := new(MockMetadataRW)
+	rw.On("LoadCycleJournal").Return([]CycleJournalEntry{}, nil)
+
+	uuidCollectionBuilder := native.NewNativeUUIDCollectionBuilder(new(native.MockDB), nil, blacklist.NoOpBlacklist)
+	s, err := LoadSchedulerFromFile(configFile, uuidCollectionBuilder, &tasks.MockTask{}, rw, time.Minute, time.Minute, DefaultRestartPolicy, nil, nil, nil, nil)
+	assert.NoError(t, err)
+
+	assert.Len(t, s.Cycles(), 2)
+	for _, cycle := range s.Cycles() {
+		assert.Equal(t, FileCycleSource, cycle.Source())
+	}
+
+	rw.AssertExpectations(t)
+	rw.AssertNotCalled(t, "WriteCycleJournal", mock.Anything)
+}
+
+func TestAPICycleChangesAreJournaled(t *testing.T) {
+	configFile := writeTestCyclesFile(t)
+	defer os.Remove(configFile)
+
+	wordpressID := newCycleID("wordpress-whole-archive", "wordpress")
+	videoID := newCycleID("video-whole-archive", "video")
+
+	rw := new(MockMetadataRW)
+	rw.On("LoadCycleJournal").Return([]CycleJournalEntry{}, nil)
+	rw.On("WriteCycleJournal", mock.MatchedBy(func(entries []CycleJournalEntry) bool {
+		return len(entries) == 1 && entries[0].ID == videoID && entries[0].Action == upsertCycleAction && entries[0].Config.Collection == "video"
+	})).Return(nil).Once()
+	rw.On("WriteCycleJournal", mock.MatchedBy(func(entries []CycleJournalEntry) bool {
+		return len(entries) == 2 && entries[1].ID == wordpressID && entries[1].Action == deleteCycleAction && entries[1].Config == nil
+	})).Return(nil).Once()
+
+	uuidCollectionBuilder := native.NewNativeUUIDCollectionBuilder(new(native.MockDB), nil, blacklist.NoOpBlacklist)
+	s, err := LoadSchedulerFromFile(configFile, uuidCollectionBuilder, &tasks.MockTask{}, rw, time.Minute, time.Minute, DefaultRestartPolicy, nil, nil, nil, nil)
+	assert.NoError(t, err)
+
+	cycle, err := s.NewCycle(CycleConfig{Name: "video-whole-archive", Type: "ThrottledWholeCollection", Origin: "next-video-editor", Collection: "video", CoolDown: "5m", Throttle: "1s"})
+	assert.NoError(t, err)
+
+	err = s.AddCycle(cycle)
+	assert.NoError(t, err)
+	assert.Equal(t, APICycleSource, cycle.Source())
+
+	err = s.DeleteCycle(wordpressID)
+	assert.NoError(t, err)
+
+	rw.AssertExpectations(t)
+}
+
+func TestLoadSchedulerCycleJournalFailure(t *testing.T) {
+	configFile := writeTestCyclesFile(t)
+	defer os.Remove(configFile)
+
+	rw := new(MockMetadataRW)
+	rw.On("LoadCycleJournal").Return([]CycleJournalEntry{}, errors.New("computer says no"))
+
+	uuidCollectionBuilder := native.NewNativeUUIDCollectionBuilder(new(native.MockDB), nil, blacklist.NoOpBlacklist)
+	s, err := LoadSchedulerFromFile(configFile, uuidCollectionBuilder, &tasks.MockTask{}, rw, time.Minute, time.Minute, DefaultRestartPolicy, nil, nil, nil, nil)
+	assert.EqualError(t, err, `"Failed to load the cycle journal: computer says no" `)
+	assert.Len(t, s.Cycles(), 2, "the cycles from file are still loaded")
+
+	cycle, err := s.NewCycle(CycleConfig{Name: "video-whole-archive", Type: "ThrottledWholeCollection", Origin: "next-video-editor", Collection: "video", CoolDown: "5m", Throttle: "1s"})
+	assert.NoError(t, err)
+	assert.NoError(t, s.AddCycle(cycle))
+
+	rw.AssertExpectations(t)
+	rw.AssertNotCalled(t, "WriteCycleJournal", mock.Anything)
+}
+
+func TestCompletedCyclesAreLoadedFromFileOnRestart(t *testing.T) {
+	configFile := writeTestCyclesFile(t)
+	defer os.Remove(configFile)
+
+	wordpressID := newCycleID("wordpress-whole-archive", "wordpress")
+	videoID := newCycleID("video-whole-archive", "video")
+
+	now := time.Now()
+	entries := []CycleJournalEntry{
+		{ID: wordpressID, Action: completedCycleAction, Time: now.Add(-2 * time.Minute)},
+		{ID: videoID, Action: completedCycleAction, Time: now.Add(-1 * time.Minute)},
+	}
+
+	rw := new(MockMetadataRW)
+	rw.On("LoadCycleJournal").Return(entries, nil)
+
+	uuidCollectionBuilder := native.NewNativeUUIDCollectionBuilder(new(native.MockDB), nil, blacklist.NoOpBlacklist)
+	s, err := LoadSchedulerFromFile(configFile, uuidCollectionBuilder, &tasks.MockTask{}, rw, time.Minute, time.Minute, DefaultRestartPolicy, nil, nil, nil, nil)
+	assert.NoError(t, err)
+
+	cycles := s.Cycles()
+	assert.Len(t, cycles, 2)
+
+	wordpress, ok := cycles[wordpressID]
+	assert.True(t, ok, "a cycle from file which completed should be loaded again")
+	assert.Equal(t, FileCycleSource, wordpress.Source())
+
+	_, ok = cycles[videoID]
+	assert.False(t, ok, "a cycle created through the API which completed should not be restored")
+
+	rw.AssertExpectations(t)
+}
+
+func TestRemovedCompletedCyclesAreJournaledAsCompleted(t *testing.T) {
+	configFile := writeTestCyclesFile(t)
+	defer os.Remove(configFile)
+
+	wordpressID := newCycleID("wordpress-whole-archive", "wordpress")
+
+	rw := new(MockMetadataRW)
+	rw.On("LoadCycleJournal").Return([]CycleJournalEntry{}, nil)
+	rw.On("WriteCycleJournal", mock.MatchedBy(func(entries []CycleJournalEntry) bool {
+		return len(entries) == 1 && entries[0].ID == wordpressID && entries[0].Action == completedCycleAction
+	})).Return(nil).Once()
+
+	uuidCollectionBuilder := native.NewNativeUUIDCollectionBuilder(new(native.MockDB), nil, blacklist.NoOpBlacklist)
+	s, err := LoadSchedulerFromFile(configFile, uuidCollectionBuilder, &tasks.MockTask{}, rw, time.Minute, time.Minute, DefaultRestartPolicy, nil, nil, nil, nil)
+	assert.NoError(t, err)
+
+	s.(*defaultScheduler).removeCompletedCycle(s.Cycles()[wordpressID])
+	assert.Len(t, s.Cycles(), 1)
+
+	rw.AssertExpectations(t)
+}
@@ -11,6 +11,7 @@ const runningState = "running"
 const stoppedState = "stopped"
 const unhealthyState = "unhealthy"
 const coolDownState = "cooldown"
+const completedState = "completed"
 
 type State struct {
 	states []string
@@ -57,3 +58,13 @@ func (s *State) Update(states ...string) {
 	sort.Strings(arr)
 	s.states = arr
 }
+
+// IsStopped returns true if the states are those of a cycle which is not running, and can be started again
+func IsStopped(states []string) bool {
+	for _, state := range states {
+		if state != stoppedState && state != unhealthyState {
+			return false
+		}
+	}
+	return true
+}
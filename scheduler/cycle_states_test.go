@@ -23,3 +23,13 @@ func TestStatesUnmarshalJSON(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, s.states, []string{"hey", "you"})
 }
+
+func TestIsStopped(t *testing.T) {
+	assert.True(t, IsStopped([]string{stoppedState}))
+	assert.True(t, IsStopped([]string{stoppedState, unhealthyState}))
+	assert.True(t, IsStopped(nil), "a cycle which was never started can be started")
+
+	assert.False(t, IsStopped([]string{runningState}))
+	assert.False(t, IsStopped([]string{coolDownState}))
+	assert.False(t, IsStopped([]string{completedState}))
+}
@@ -0,0 +1,503 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Financial-Times/publish-carousel/cms"
+	"github.com/Financial-Times/publish-carousel/filter"
+	"github.com/Financial-Times/publish-carousel/ledger"
+	"github.com/Financial-Times/publish-carousel/native"
+	"github.com/Financial-Times/publish-carousel/tasks"
+	"github.com/Financial-Times/publish-carousel/tracing"
+	"github.com/Financial-Times/publish-carousel/transform"
+	"github.com/Financial-Times/publish-carousel/txid"
+	"github.com/Financial-Times/publish-carousel/verify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestCycleMetadataEstimates(t *testing.T) {
+	c := newAbstractCycle("name", "test", nil, "collection", "origin", time.Minute, nil)
+
+	now := time.Now()
+	iterationStart := now.Add(-5 * time.Minute)
+	c.SetMetadata(CycleMetadata{Total: 110, Completed: 0, IterationStart: &iterationStart})
+
+	for i := 0; i < 10; i++ {
+		c.throughput.record(now.Add(time.Duration(i-10) * 30 * time.Second))
+		c.CycleMetadata.Completed++
+	}
+
+	c.CycleMetadata.updateEstimates(c.throughput.rate(now), now)
+	metadata := c.CycleMetadata
+
+	assert.InDelta(t, 2.0, metadata.PublishRate, 0.001)
+	assert.Equal(t, "5m0s", metadata.IterationElapsed)
+	assert.NotNil(t, metadata.EstimatedCompletion)
+	assert.WithinDuration(t, now.Add(50*time.Minute), *metadata.EstimatedCompletion, time.Second, "100 remaining at 2 per minute")
+	assert.Empty(t, metadata.WindowLag, "only time windowed cycles have a window lag")
+}
+
+func TestCycleMetadataElapsedStopsWhenIterationFinishes(t *testing.T) {
+	c := newAbstractCycle("name", "test", nil, "collection", "origin", time.Minute, nil)
+
+	now := time.Now()
+	iterationStart := now.Add(-5 * time.Minute)
+	c.SetMetadata(CycleMetadata{Total: 1, IterationStart: &iterationStart})
+
+	c.finishIteration(now)
+	c.CycleMetadata.updateEstimates(c.throughput.rate(now.Add(time.Hour)), now.Add(time.Hour))
+
+	require.NotNil(t, c.CycleMetadata.IterationEnd)
+	assert.Equal(t, "5m0s", c.CycleMetadata.IterationElapsed)
+}
+
+func TestCycleMetadataWindowLag(t *testing.T) {
+	c := newAbstractCycle("name", "test", nil, "collection", "origin", time.Minute, nil)
+
+	now := time.Now()
+	end := now.Add(-90 * time.Second)
+	c.SetMetadata(CycleMetadata{End: &end})
+
+	c.CycleMetadata.updateEstimates(c.throughput.rate(now), now)
+
+	assert.Equal(t, "1m30s", c.CycleMetadata.WindowLag)
+	assert.Nil(t, c.CycleMetadata.EstimatedCompletion, "no estimate without any publishes")
+}
+
+func TestCycleMetadataIsACopy(t *testing.T) {
+	c := newAbstractCycle("name", "test", nil, "collection", "origin", time.Minute, nil)
+	c.updateSkipped("uuid-1", "images")
+	c.updateProgress("uuid-2", "tid_2", errors.New("cms notifier is down"))
+
+	metadata := c.Metadata()
+	states := c.State()
+
+	c.updateSkipped("uuid-3", "images")
+	c.updateProgress("uuid-4", "tid_4", errors.New("cms notifier is still down"))
+	c.UpdateState(runningState)
+
+	assert.Equal(t, 1, metadata.Skipped["images"], "the skipped counts should not change once they have been read")
+	assert.Len(t, metadata.Failures, 1)
+	assert.Equal(t, []string{stoppedState}, metadata.State)
+	assert.Equal(t, []string{stoppedState}, states)
+
+	metadata.Skipped["images"] = 10
+	assert.Equal(t, 2, c.Metadata().Skipped["images"], "changing the copy should not change the cycle")
+}
+
+func TestCycleResetClearsThroughput(t *testing.T) {
+	c := newAbstractCycle("name", "test", nil, "collection", "origin", time.Minute, nil)
+	c.updateProgress("uuid", "tid", nil)
+	assert.True(t, c.Metadata().PublishRate > 0)
+
+	c.Reset()
+	assert.Equal(t, 0.0, c.Metadata().PublishRate)
+}
+
+func TestCyclePosition(t *testing.T) {
+	c := newAbstractCycle("name", "test", nil, "collection", "origin", time.Minute, nil)
+
+	_, _, ok := c.Position("uuid-1")
+	assert.False(t, ok, "position is unknown while the cycle is not publishing")
+
+	c.setCollection(native.NewMockPositionedUUIDCollection("uuid-1", "uuid-2"))
+	c.next()
+
+	pos, pending, ok := c.Position("uuid-2")
+	assert.True(t, ok)
+	assert.True(t, pending)
+	assert.Equal(t, 0, pos)
+
+	_, pending, ok = c.Position("uuid-1")
+	assert.True(t, ok)
+	assert.False(t, pending)
+
+	c.setCollection(native.NewMockUUIDCollection("uuid-1"))
+	_, _, ok = c.Position("uuid-1")
+	assert.False(t, ok)
+}
+
+func TestPublishCollectionRecordsSuccessfulPublishes(t *testing.T) {
+	task := new(tasks.MockTask)
+	task.On("Prepare", "collection", "uuid-1").Return(&native.Content{}, "tid_1", nil)
+	task.On("Execute", "uuid-1", mock.AnythingOfType("*native.Content"), "origin", "tid_1").Return(nil)
+	task.On("Prepare", "collection", "uuid-2").Return(&native.Content{}, "tid_2", nil)
+	task.On("Execute", "uuid-2", mock.AnythingOfType("*native.Content"), "origin", "tid_2").Return(errors.New("cms notifier is down"))
+	task.On("Prepare", "collection", "uuid-3").Return(&native.Content{}, "", errors.New("no content"))
+
+	throttle := new(MockThrottle)
+	throttle.On("Queue").Return(nil)
+
+	c := newAbstractCycle("name", "test", nil, "collection", "origin", time.Minute, task)
+
+	republishLedger := new(ledger.MockLedger)
+	republishLedger.On("Record", mock.MatchedBy(func(entry ledger.Entry) bool {
+		return entry.Collection == "collection" && entry.UUID == "uuid-1" && entry.TransactionID == "tid_1" && entry.CycleID == c.CycleID && !entry.LastPublished.IsZero()
+	})).Return()
+	c.setOptions(cycleOptions{ledger: republishLedger})
+
+	stopped, err := c.publishCollection(context.Background(), native.NewMockUUIDCollection("uuid-1", "uuid-2", "uuid-3"), throttle)
+	assert.False(t, stopped)
+	assert.NoError(t, err)
+
+	republishLedger.AssertNumberOfCalls(t, "Record", 1)
+	mock.AssertExpectationsForObjects(t, task, republishLedger)
+}
+
+type skippingCollection struct {
+	*native.MockUUIDCollection
+	skipped map[string]int
+}
+
+func (s *skippingCollection) Skipped() map[string]int {
+	return s.skipped
+}
+
+func TestPublishCollectionCountsDocumentsSkippedByTheCollection(t *testing.T) {
+	task := new(tasks.MockTask)
+	task.On("Prepare", "collection", "uuid-1").Return(&native.Content{}, "tid_1", nil)
+	task.On("Execute", "uuid-1", mock.AnythingOfType("*native.Content"), "origin", "tid_1").Return(nil)
+
+	throttle := new(MockThrottle)
+	throttle.On("Queue").Return(nil)
+
+	c := newAbstractCycle("name", "test", nil, "collection", "origin", time.Minute, task)
+	c.SetMetadata(CycleMetadata{Total: 3})
+
+	collection := &skippingCollection{MockUUIDCollection: native.NewMockUUIDCollection("uuid-1"), skipped: map[string]int{"missing uuid": 2}}
+	stopped, err := c.publishCollection(context.Background(), collection, throttle)
+	assert.False(t, stopped)
+	assert.NoError(t, err)
+
+	metadata := c.Metadata()
+	assert.Equal(t, map[string]int{"missing uuid": 2}, metadata.Skipped)
+	assert.Equal(t, 0, metadata.Errors, "skipped documents are not errors")
+	assert.Equal(t, 1, metadata.Total, "skipped documents are taken off the total")
+	task.AssertExpectations(t)
+}
+
+func TestPublishCollectionRecordsAttempts(t *testing.T) {
+	task := new(tasks.MockAttemptsTask)
+	task.On("Prepare", "collection", "uuid-1").Return(&native.Content{}, "tid_1", nil)
+	task.On("ExecuteAttempts", "uuid-1", mock.AnythingOfType("*native.Content"), "origin", "tid_1").Return(2, nil)
+	task.On("Prepare", "collection", "uuid-2").Return(&native.Content{}, "tid_2", nil)
+	task.On("ExecuteAttempts", "uuid-2", mock.AnythingOfType("*native.Content"), "origin", "tid_2").Return(3, errors.New("cms notifier is down"))
+
+	throttle := new(MockThrottle)
+	throttle.On("Queue").Return(nil)
+
+	c := newAbstractCycle("name", "test", nil, "collection", "origin", time.Minute, task)
+
+	republishLedger := new(ledger.MockLedger)
+	republishLedger.On("Record", mock.MatchedBy(func(entry ledger.Entry) bool {
+		return entry.UUID == "uuid-1" && entry.Attempts == 2
+	})).Return()
+	c.setOptions(cycleOptions{ledger: republishLedger})
+
+	stopped, err := c.publishCollection(context.Background(), native.NewMockUUIDCollection("uuid-1", "uuid-2"), throttle)
+	assert.False(t, stopped)
+	assert.NoError(t, err)
+
+	metadata := c.Metadata()
+	assert.Equal(t, 3, metadata.Retries)
+	require.Len(t, metadata.Failures, 1)
+	assert.Equal(t, "uuid-2", metadata.Failures[0].UUID)
+	assert.Equal(t, 3, metadata.Failures[0].Attempts)
+	mock.AssertExpectationsForObjects(t, task, republishLedger)
+}
+
+func TestPublishCollectionRecordsPartlyFailedFanOut(t *testing.T) {
+	fanOut := &cms.FanOutError{Targets: 2, Succeeded: []string{"eu"}, Failed: map[string]error{"us": errors.New("us is down")}}
+
+	task := new(tasks.MockAttemptsTask)
+	task.On("Prepare", "collection", "uuid-1").Return(&native.Content{}, "tid_1", nil)
+	task.On("ExecuteAttempts", "uuid-1", mock.AnythingOfType("*native.Content"), "origin", "tid_1").Return(1, fanOut)
+
+	throttle := new(MockThrottle)
+	throttle.On("Queue").Return(nil)
+
+	c := newAbstractCycle("name", "test", nil, "collection", "origin", time.Minute, task)
+
+	republishLedger := new(ledger.MockLedger)
+	republishLedger.On("Record", mock.MatchedBy(func(entry ledger.Entry) bool {
+		return entry.UUID == "uuid-1" && entry.TransactionID == "tid_1" && len(entry.FailedTargets) == 1 && entry.FailedTargets[0] == "us"
+	})).Return()
+	c.setOptions(cycleOptions{ledger: republishLedger})
+
+	_, err := c.publishCollection(context.Background(), native.NewMockUUIDCollection("uuid-1"), throttle)
+	assert.NoError(t, err)
+
+	metadata := c.Metadata()
+	assert.Equal(t, 1, metadata.Errors, "the publish still fails")
+	mock.AssertExpectationsForObjects(t, task, republishLedger)
+}
+
+func TestPublishCollectionCountsFilteredContent(t *testing.T) {
+	image := &native.Content{Body: map[string]interface{}{"type": "Image"}}
+	video := &native.Content{Body: map[string]interface{}{"type": "Video"}, ContentType: "application/vnd.ft-upp-video+json"}
+	article := &native.Content{Body: map[string]interface{}{"type": "Article"}}
+
+	task := new(tasks.MockTask)
+	task.On("Prepare", "collection", "uuid-1").Return(image, "tid_1", nil)
+	task.On("Prepare", "collection", "uuid-2").Return(video, "tid_2", nil)
+	task.On("Prepare", "collection", "uuid-3").Return(article, "tid_3", nil)
+	task.On("Prepare", "collection", "uuid-4").Return(image, "tid_4", nil)
+	task.On("Execute", "uuid-3", article, "origin", "tid_3").Return(nil)
+
+	throttle := new(MockThrottle)
+	throttle.On("Queue").Return(nil)
+
+	rules := append(filter.DefaultRules(), filter.Rule{Name: "videos", Action: "exclude", Condition: filter.Condition{ContentTypes: []string{"application/vnd.ft-upp-video+json"}}})
+	chain, err := filter.NewChain(rules)
+	assert.NoError(t, err)
+
+	c := newAbstractCycle("name", "test", nil, "collection", "origin", time.Minute, task)
+	c.setOptions(cycleOptions{Filters: rules, filters: chain})
+	c.SetMetadata(CycleMetadata{Total: 4})
+
+	stopped, err := c.publishCollection(context.Background(), native.NewMockUUIDCollection("uuid-1", "uuid-2", "uuid-3", "uuid-4"), throttle)
+	assert.False(t, stopped)
+	assert.NoError(t, err)
+
+	metadata := c.Metadata()
+	assert.Equal(t, map[string]int{"images": 2, "videos": 1}, metadata.Skipped)
+	assert.Equal(t, 0, metadata.Errors)
+	assert.Equal(t, 5, metadata.Completed, "skipped content counts towards the progress of the iteration")
+
+	task.AssertNumberOfCalls(t, "Execute", 1)
+	mock.AssertExpectationsForObjects(t, task)
+}
+
+type tracedTask struct {
+	tasks.MockTask
+	executed []trace.SpanContext
+}
+
+func (m *tracedTask) ExecuteAttempts(ctx context.Context, uuid string, content *native.Content, origin string, txId string) (int, error) {
+	m.executed = append(m.executed, trace.SpanContextFromContext(ctx))
+	return 1, nil
+}
+
+func TestPublishRecordsATraceOfEachPublish(t *testing.T) {
+	task := new(tracedTask)
+	task.On("Prepare", "collection", "uuid-1").Return(&native.Content{Body: map[string]interface{}{"type": "Article"}}, "tid_1", nil)
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	c := newAbstractCycle("name", "test", nil, "collection", "origin", time.Minute, task)
+	c.setOptions(cycleOptions{tracer: tracing.Tracer(provider)})
+
+	c.publish(context.Background(), "uuid-1")
+	c.publish(context.Background(), "uuid-1")
+
+	var publishes []sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.Name() == tracing.PublishSpan {
+			publishes = append(publishes, span)
+			continue
+		}
+		assert.Contains(t, []string{tracing.FilterSpan, tracing.TransformSpan}, span.Name())
+	}
+
+	require.Len(t, publishes, 2)
+	require.Len(t, task.executed, 2)
+	for i, publish := range publishes {
+		assert.False(t, publish.Parent().IsValid(), "each publish starts a trace of its own")
+		assert.Contains(t, publish.Attributes(), attribute.String("transaction_id", "tid_1"))
+		assert.Equal(t, publish.SpanContext(), task.executed[i], "the task executes within the span of the publish")
+	}
+	assert.NotEqual(t, publishes[0].SpanContext().TraceID(), publishes[1].SpanContext().TraceID(), "republishing the same transaction id starts a new trace")
+}
+
+func TestPublishCollectionExecutesTransformedContent(t *testing.T) {
+	article := &native.Content{Body: map[string]interface{}{"type": "Article", "internalNotes": "notes"}}
+
+	task := new(tasks.MockTask)
+	task.On("Prepare", "collection", "uuid-1").Return(article, "tid_1", nil)
+	task.On("Execute", "uuid-1", &native.Content{Body: map[string]interface{}{"type": "Article"}}, "origin", "tid_1").Return(nil)
+
+	throttle := new(MockThrottle)
+	throttle.On("Queue").Return(nil)
+
+	rules := []transform.Rule{{Name: "internal", Action: "remove", Field: "internalNotes"}}
+	chain, err := transform.NewChain(rules)
+	assert.NoError(t, err)
+
+	c := newAbstractCycle("name", "test", nil, "collection", "origin", time.Minute, task)
+	c.setOptions(cycleOptions{Transformations: rules, transformations: chain})
+
+	stopped, err := c.publishCollection(context.Background(), native.NewMockUUIDCollection("uuid-1"), throttle)
+	assert.False(t, stopped)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "notes", article.Body["internalNotes"], "the prepared content is not changed")
+	mock.AssertExpectationsForObjects(t, task)
+}
+
+func TestPublishCollectionWorksOutTransactionIDAfterTransforming(t *testing.T) {
+	article := &native.Content{Body: map[string]interface{}{"type": "Article"}}
+	transformed := &native.Content{Body: map[string]interface{}{"type": "Article", "publishReference": "tid_legacy"}}
+
+	task := new(tasks.MockTransactionIDTask)
+	task.On("Prepare", "collection", "uuid-1").Return(article, "tid_generated_carousel_1493640000_gentx", nil)
+	task.On("TransactionID", transformed).Return("tid_legacy_carousel_1493640000")
+	task.On("Execute", "uuid-1", transformed, "origin", "tid_legacy_carousel_1493640000").Return(nil)
+
+	throttle := new(MockThrottle)
+	throttle.On("Queue").Return(nil)
+
+	rules := []transform.Rule{{Name: "reference", Action: "default", Field: "publishReference", Value: "tid_legacy"}}
+	chain, err := transform.NewChain(rules)
+	require.NoError(t, err)
+
+	c := newAbstractCycle("name", "test", nil, "collection", "origin", time.Minute, task)
+	c.setOptions(cycleOptions{Transformations: rules, transformations: chain})
+
+	stopped, err := c.publishCollection(context.Background(), native.NewMockUUIDCollection("uuid-1"), throttle)
+	assert.False(t, stopped)
+	assert.NoError(t, err)
+
+	mock.AssertExpectationsForObjects(t, task)
+}
+
+func TestPublishCollectionValidatesTransformedContent(t *testing.T) {
+	legacy := &native.Content{Body: map[string]interface{}{"type": "Article"}}
+	fixed := &native.Content{Body: map[string]interface{}{"type": "Article", "title": "Untitled"}}
+	other := &native.Content{Body: map[string]interface{}{"type": "Article", "title": 12}}
+
+	task := new(tasks.MockValidatingTask)
+	task.On("Prepare", "collection", "uuid-1").Return(legacy, "tid_1", nil)
+	task.On("Validate", "uuid-1", fixed).Return(nil)
+	task.On("Execute", "uuid-1", fixed, "origin", "tid_1").Return(nil)
+	task.On("Prepare", "collection", "uuid-2").Return(other, "tid_2", nil)
+	task.On("Validate", "uuid-2", other).Return(errors.New(`Skipping uuid "uuid-2" as it is invalid: $.title must be string, not integer`))
+
+	throttle := new(MockThrottle)
+	throttle.On("Queue").Return(nil)
+
+	rules := []transform.Rule{{Name: "title", Action: "default", Field: "title", Value: "Untitled"}}
+	chain, err := transform.NewChain(rules)
+	require.NoError(t, err)
+
+	c := newAbstractCycle("name", "test", nil, "collection", "origin", time.Minute, task)
+	c.setOptions(cycleOptions{Transformations: rules, transformations: chain})
+
+	stopped, err := c.publishCollection(context.Background(), native.NewMockUUIDCollection("uuid-1", "uuid-2"), throttle)
+	assert.False(t, stopped)
+	assert.NoError(t, err)
+
+	mock.AssertExpectationsForObjects(t, task)
+	task.AssertNotCalled(t, "Execute", "uuid-2", other, "origin", "tid_2")
+
+	metadata := c.Metadata()
+	assert.Equal(t, 1, metadata.Errors)
+	require.Len(t, metadata.Failures, 1)
+	assert.Equal(t, "uuid-2", metadata.Failures[0].UUID)
+}
+
+func TestPublishCollectionUsesTransactionIDTemplate(t *testing.T) {
+	article := &native.Content{Body: map[string]interface{}{"type": "Article", "publishReference": "tid_1234"}}
+
+	task := new(tasks.MockTask)
+	task.On("Prepare", "collection", "uuid-1").Return(article, "tid_1234_carousel_1493640000", nil)
+	task.On("Execute", "uuid-1", article, "origin", "tid_1234_my-cycle_0").Return(nil)
+
+	throttle := new(MockThrottle)
+	throttle.On("Queue").Return(nil)
+
+	strategy, err := txid.NewTemplate("{{.TID}}_{{.Cycle}}_{{.Iteration}}")
+	require.NoError(t, err)
+
+	c := newAbstractCycle("name", "test", nil, "collection", "origin", time.Minute, task)
+	c.CycleName = "my cycle"
+	c.setOptions(cycleOptions{TransactionIDTemplate: "{{.TID}}_{{.Cycle}}_{{.Iteration}}", txIDs: strategy})
+
+	stopped, err := c.publishCollection(context.Background(), native.NewMockUUIDCollection("uuid-1"), throttle)
+	assert.False(t, stopped)
+	assert.NoError(t, err)
+
+	mock.AssertExpectationsForObjects(t, task)
+}
+
+func TestCycleRecordsRecentFailures(t *testing.T) {
+	c := newAbstractCycle("name", "test", nil, "collection", "origin", time.Minute, nil)
+
+	for i := 0; i < maxFailures+5; i++ {
+		c.updateProgress(fmt.Sprintf("uuid-%v", i), "tid", errors.New("invalid content"))
+	}
+	c.updateProgress("uuid-ok", "tid", nil)
+
+	failures := c.Metadata().Failures
+	require.Len(t, failures, maxFailures)
+	assert.Equal(t, "uuid-5", failures[0].UUID, "the oldest failures are dropped")
+	assert.Equal(t, fmt.Sprintf("uuid-%v", maxFailures+4), failures[maxFailures-1].UUID)
+	assert.Equal(t, "invalid content", failures[0].Error)
+	assert.Equal(t, "tid", failures[0].TransactionID)
+	assert.Equal(t, maxFailures+5, c.Metadata().Errors)
+}
+
+func TestPublishCollectionVerifiesPublishes(t *testing.T) {
+	task := new(tasks.MockTask)
+	task.On("Prepare", "collection", "uuid-1").Return(&native.Content{}, "tid_1", nil)
+	task.On("Execute", "uuid-1", mock.AnythingOfType("*native.Content"), "origin", "tid_1").Return(nil)
+	task.On("Prepare", "collection", "uuid-2").Return(&native.Content{}, "tid_2", nil)
+	task.On("Execute", "uuid-2", mock.AnythingOfType("*native.Content"), "origin", "tid_2").Return(nil)
+	task.On("Prepare", "collection", "uuid-3").Return(&native.Content{}, "tid_3", nil)
+	task.On("Execute", "uuid-3", mock.AnythingOfType("*native.Content"), "origin", "tid_3").Return(errors.New("cms notifier is down"))
+
+	throttle := new(MockThrottle)
+	throttle.On("Queue").Return(nil)
+
+	config := &verify.Config{URL: "http://document-store-api:8080/content/{uuid}"}
+	verifier := new(verify.MockVerifier)
+	verifier.On("Verify", mock.Anything, *config, "uuid-1", "tid_1", mock.AnythingOfType("time.Time")).Return(nil)
+	verifier.On("Verify", mock.Anything, *config, "uuid-2", "tid_2", mock.AnythingOfType("time.Time")).Return(errors.New("Not verified within 5m0s"))
+
+	c := newAbstractCycle("name", "test", nil, "collection", "origin", time.Minute, task)
+	c.setOptions(cycleOptions{Verification: config, verifier: verifier})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	c.startVerifiers(ctx)
+
+	stopped, err := c.publishCollection(ctx, native.NewMockUUIDCollection("uuid-1", "uuid-2", "uuid-3"), throttle)
+	assert.False(t, stopped)
+	assert.NoError(t, err)
+	c.verifications.Wait()
+	c.Stop()
+
+	metadata := c.Metadata()
+	require.NotNil(t, metadata.Verification)
+	assert.Equal(t, 1, metadata.Verification.Verified)
+	assert.Equal(t, 1, metadata.Verification.Unverified)
+	assert.NotEmpty(t, metadata.Verification.LastLatency)
+	assert.Equal(t, metadata.Verification.LastLatency, metadata.Verification.AverageLatency)
+
+	require.Len(t, metadata.Failures, 2)
+	assert.Equal(t, "uuid-3", metadata.Failures[0].UUID, "failed publishes are not verified")
+	assert.Equal(t, "uuid-2", metadata.Failures[1].UUID)
+	assert.Equal(t, "Not verified within 5m0s", metadata.Failures[1].Error)
+
+	verifier.AssertNumberOfCalls(t, "Verify", 2)
+	mock.AssertExpectationsForObjects(t, task, verifier)
+}
+
+func TestVerificationAverageLatency(t *testing.T) {
+	c := newAbstractCycle("name", "test", nil, "collection", "origin", time.Minute, nil)
+	c.updateVerification("uuid-1", "tid_1", 10*time.Second, nil)
+	c.updateVerification("uuid-2", "tid_2", 20*time.Second, nil)
+	c.updateVerification("uuid-3", "tid_3", 0, errors.New("not found"))
+
+	assert.Equal(t, &VerificationMetadata{Verified: 2, Unverified: 1, LastLatency: "20s", AverageLatency: "15s"}, c.Metadata().Verification)
+}
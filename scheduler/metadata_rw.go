@@ -15,53 +15,93 @@ const defaultContentType = "application/json"
 type MetadataReadWriter interface {
 	LoadMetadata(id string) (CycleMetadata, error)
 	WriteMetadata(id string, config CycleConfig, metadata CycleMetadata) error
+	LoadCycleJournal() ([]CycleJournalEntry, error)
+	WriteCycleJournal(entries []CycleJournalEntry) error
 }
 
 type s3MetadataReadWriter struct {
 	s3rw s3.ReadWriter
 }
 
+const cycleJournalID = "cycle-journal"
+
 type s3Metadata struct {
 	Config   CycleConfig   `json:"config"`
 	Metadata CycleMetadata `json:"metadata"`
 }
 
+type s3CycleJournal struct {
+	Entries []CycleJournalEntry `json:"entries"`
+}
+
 func NewS3MetadataReadWriter(rw s3.ReadWriter) MetadataReadWriter {
 	return &s3MetadataReadWriter{s3rw: rw}
 }
 
 func (s *s3MetadataReadWriter) LoadMetadata(id string) (CycleMetadata, error) {
+	fromS3 := &s3Metadata{}
+	err := s.readLatest(id, fromS3)
+	return fromS3.Metadata, err
+}
+
+func (s *s3MetadataReadWriter) WriteMetadata(id string, config CycleConfig, metadata CycleMetadata) error {
+	return s.write(id, &s3Metadata{config, metadata})
+}
+
+// LoadCycleJournal returns the latest journal of cycles created, modified or deleted through the API. An empty journal is returned if none has been written yet.
+func (s *s3MetadataReadWriter) LoadCycleJournal() ([]CycleJournalEntry, error) {
+	key, err := s.s3rw.GetLatestKeyForID(cycleJournalID)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(key) == "" {
+		return []CycleJournalEntry{}, nil
+	}
+
+	fromS3 := &s3CycleJournal{}
+	err = s.read(cycleJournalID, key, fromS3)
+	return fromS3.Entries, err
+}
+
+// WriteCycleJournal persists the full cycle journal
+func (s *s3MetadataReadWriter) WriteCycleJournal(entries []CycleJournalEntry) error {
+	return s.write(cycleJournalID, &s3CycleJournal{entries})
+}
+
+func (s *s3MetadataReadWriter) readLatest(id string, v interface{}) error {
 	key, err := s.s3rw.GetLatestKeyForID(id)
 	if err != nil {
-		return CycleMetadata{}, err
+		return err
 	}
 
 	if strings.TrimSpace(key) == "" {
-		return CycleMetadata{}, errors.New(`No key found for id "` + id + `"`)
+		return errors.New(`No key found for id "` + id + `"`)
 	}
 
+	return s.read(id, key, v)
+}
+
+func (s *s3MetadataReadWriter) read(id string, key string, v interface{}) error {
 	found, body, contentType, err := s.s3rw.Read(key)
 	if err != nil {
-		return CycleMetadata{}, err
+		return err
 	}
 
 	if !found {
-		return CycleMetadata{}, fmt.Errorf(`No state found for "%v"`, id)
+		return fmt.Errorf(`No state found for "%v"`, id)
 	}
 
 	if contentType == nil || strings.TrimSpace(*contentType) != "application/json" {
-		return CycleMetadata{}, fmt.Errorf(`Failed to load state for "%v". Content was in an unexpected Content-Type "%v"`, id, contentType)
+		return fmt.Errorf(`Failed to load state for "%v". Content was in an unexpected Content-Type "%v"`, id, contentType)
 	}
 
-	fromS3 := &s3Metadata{}
 	dec := json.NewDecoder(body)
-	err = dec.Decode(fromS3)
-
-	return fromS3.Metadata, err
+	return dec.Decode(v)
 }
 
-func (s *s3MetadataReadWriter) WriteMetadata(id string, config CycleConfig, metadata CycleMetadata) error {
-	b, err := json.Marshal(&s3Metadata{config, metadata})
+func (s *s3MetadataReadWriter) write(id string, v interface{}) error {
+	b, err := json.Marshal(v)
 	if err != nil {
 		return err
 	}
@@ -107,3 +107,54 @@ type nopCloser struct {
 }
 
 func (nopCloser) Close() error { return nil }
+
+func TestLoadCycleJournal(t *testing.T) {
+	key := "cycle-journal/20170101T00000000"
+	contentType := "application/json"
+
+	journal := `{"entries":[{"id":"5118842b62670d2b","action":"upsert","config":{"name":"test-cycle","type":"ThrottledWholeCollection","origin":"test-origin","collection":"test-collection","coolDown":"1m0s","throttle":"1s"},"time":"2017-01-01T00:00:00Z"},{"id":"7085a0ac743eddd8","action":"delete","time":"2017-01-01T00:01:00Z"}]}`
+
+	s3rw := new(s3.MockReadWriter)
+	s3rw.On("GetLatestKeyForID", cycleJournalID).Return(key, nil)
+	s3rw.On("Read", key).Return(true, nopCloser{strings.NewReader(journal)}, &contentType, nil)
+
+	rw := s3MetadataReadWriter{s3rw}
+	entries, err := rw.LoadCycleJournal()
+
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "5118842b62670d2b", entries[0].ID)
+	assert.Equal(t, upsertCycleAction, entries[0].Action)
+	assert.Equal(t, "test-collection", entries[0].Config.Collection)
+	assert.Equal(t, deleteCycleAction, entries[1].Action)
+	assert.Nil(t, entries[1].Config)
+
+	s3rw.AssertExpectations(t)
+}
+
+func TestLoadCycleJournalNotWrittenYet(t *testing.T) {
+	s3rw := new(s3.MockReadWriter)
+	s3rw.On("GetLatestKeyForID", cycleJournalID).Return("", nil)
+
+	rw := s3MetadataReadWriter{s3rw}
+	entries, err := rw.LoadCycleJournal()
+
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+	s3rw.AssertExpectations(t)
+}
+
+func TestWriteCycleJournal(t *testing.T) {
+	s3rw := new(s3.MockReadWriter)
+	s3rw.On("Write",
+		cycleJournalID,
+		mock.MatchedBy(func(actual string) bool { return regexp.MustCompile(`\d{8}T\d{8}`).MatchString(actual) }),
+		mock.MatchedBy(func(actual []byte) bool { return strings.Contains(string(actual), `"action":"delete"`) }),
+		"application/json").Return(nil)
+
+	rw := s3MetadataReadWriter{s3rw}
+	err := rw.WriteCycleJournal([]CycleJournalEntry{{ID: "7085a0ac743eddd8", Action: deleteCycleAction}})
+
+	assert.NoError(t, err)
+	s3rw.AssertExpectations(t)
+}
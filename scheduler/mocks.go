@@ -3,6 +3,9 @@ package scheduler
 import (
 	"time"
 
+	"github.com/Financial-Times/publish-carousel/native"
+	"github.com/Financial-Times/publish-carousel/tasks"
+
 	"github.com/stretchr/testify/mock"
 )
 
@@ -21,6 +24,16 @@ func (m *MockMetadataRW) WriteMetadata(id string, config CycleConfig, metadata C
 	return args.Error(0)
 }
 
+func (m *MockMetadataRW) LoadCycleJournal() ([]CycleJournalEntry, error) {
+	args := m.Called()
+	return args.Get(0).([]CycleJournalEntry), args.Error(1)
+}
+
+func (m *MockMetadataRW) WriteCycleJournal(entries []CycleJournalEntry) error {
+	args := m.Called(entries)
+	return args.Error(0)
+}
+
 type MockScheduler struct {
 	mock.Mock
 }
@@ -146,6 +159,15 @@ func (m *MockCycle) TransformToConfig() CycleConfig {
 	return args.Get(0).(CycleConfig)
 }
 
+func (m *MockCycle) Source() string {
+	args := m.Called()
+	return args.String(0)
+}
+
+func (m *MockCycle) SetSource(source string) {
+	m.Called(source)
+}
+
 func (m *MockCycle) State() []string {
 	args := m.Called()
 	return args.Get(0).([]string)
@@ -168,3 +190,31 @@ func (m *MockThrottle) Interval() time.Duration {
 	args := m.Called()
 	return args.Get(0).(time.Duration)
 }
+
+type MockExplainableCycle struct {
+	MockCycle
+}
+
+func (m *MockExplainableCycle) PublishTask() tasks.Task {
+	return m.Called().Get(0).(tasks.Task)
+}
+
+func (m *MockExplainableCycle) Filter(content *native.Content) (string, bool) {
+	args := m.Called(content)
+	return args.String(0), args.Bool(1)
+}
+
+func (m *MockExplainableCycle) Transform(content *native.Content) (*native.Content, []string) {
+	args := m.Called(content)
+	return args.Get(0).(*native.Content), args.Get(1).([]string)
+}
+
+func (m *MockExplainableCycle) TransactionID(uuid string, content *native.Content) (string, bool) {
+	args := m.Called(uuid, content)
+	return args.String(0), args.Bool(1)
+}
+
+func (m *MockExplainableCycle) Position(uuid string) (int, bool, bool) {
+	args := m.Called(uuid)
+	return args.Int(0), args.Bool(1), args.Bool(2)
+}
@@ -0,0 +1,112 @@
+package scheduler
+
+import (
+	"strings"
+
+	"github.com/Financial-Times/publish-carousel/native"
+	"github.com/Financial-Times/publish-carousel/tasks"
+)
+
+// prefetchingCollection reads ahead of the wrapped collection, and asks the publish task to prefetch the content for the upcoming uuids in a single batch
+type prefetchingCollection struct {
+	native.UUIDCollection
+	collection string
+	prefetcher tasks.Prefetcher
+	size       int
+	upcoming   []string
+	tokens     []string
+	token      string
+	finished   bool
+	err        error
+}
+
+// withPrefetching wraps the collection if the publish task supports prefetching content
+func withPrefetching(collection native.UUIDCollection, dbCollection string, task tasks.Task) native.UUIDCollection {
+	prefetcher, ok := task.(tasks.Prefetcher)
+	if !ok || prefetcher.PrefetchSize() <= 1 {
+		return collection
+	}
+
+	return &prefetchingCollection{UUIDCollection: collection, collection: dbCollection, prefetcher: prefetcher, size: prefetcher.PrefetchSize()}
+}
+
+func (p *prefetchingCollection) Next() (bool, string, error) {
+	if len(p.upcoming) == 0 && !p.finished {
+		p.readAhead()
+	}
+
+	if len(p.upcoming) == 0 {
+		return true, "", p.err
+	}
+
+	uuid := p.upcoming[0]
+	p.upcoming = p.upcoming[1:]
+	p.token, p.tokens = p.tokens[0], p.tokens[1:]
+	return false, uuid, nil
+}
+
+// ResumeToken is the token of the last uuid returned by Next, rather than of the uuids which have been read ahead
+func (p *prefetchingCollection) ResumeToken() string {
+	return p.token
+}
+
+func (p *prefetchingCollection) readAhead() {
+	var uuids []string
+	for len(p.upcoming) < p.size {
+		finished, uuid, err := p.UUIDCollection.Next()
+		if finished {
+			p.finished = true
+			p.err = err
+			break
+		}
+
+		p.upcoming = append(p.upcoming, uuid)
+		p.tokens = append(p.tokens, p.wrappedResumeToken())
+		if strings.TrimSpace(uuid) != "" {
+			uuids = append(uuids, uuid)
+		}
+	}
+
+	if len(uuids) > 0 {
+		p.prefetcher.Prefetch(p.collection, uuids)
+	}
+}
+
+// Skipped includes the documents skipped while reading ahead, if the wrapped collection skips documents
+func (p *prefetchingCollection) Skipped() map[string]int {
+	if skipping, ok := p.UUIDCollection.(native.SkippingUUIDCollection); ok {
+		return skipping.Skipped()
+	}
+	return nil
+}
+
+func (p *prefetchingCollection) wrappedResumeToken() string {
+	if resumable, ok := p.UUIDCollection.(native.ResumableUUIDCollection); ok {
+		return resumable.ResumeToken()
+	}
+	return ""
+}
+
+func (p *prefetchingCollection) Done() bool {
+	return len(p.upcoming) == 0 && (p.finished || p.UUIDCollection.Done())
+}
+
+// position includes the uuids which have been read ahead, if the wrapped collection can tell where a uuid sits
+func (p *prefetchingCollection) position(uuid string) (int, bool, bool) {
+	positioned, ok := p.UUIDCollection.(native.PositionedUUIDCollection)
+	if !ok {
+		return -1, false, false
+	}
+
+	for pos, u := range p.upcoming {
+		if u == uuid {
+			return pos, true, true
+		}
+	}
+
+	pos, pending := positioned.Position(uuid)
+	if !pending {
+		return -1, false, true
+	}
+	return len(p.upcoming) + pos, true, true
+}
@@ -0,0 +1,101 @@
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/Financial-Times/publish-carousel/native"
+	"github.com/Financial-Times/publish-carousel/tasks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithPrefetchingIgnoresTasksWhichCannotPrefetch(t *testing.T) {
+	collection := native.NewMockUUIDCollection("uuid-1")
+	assert.Equal(t, collection, withPrefetching(collection, "methode", new(tasks.MockTask)))
+
+	task := new(tasks.MockPrefetchingTask)
+	task.On("PrefetchSize").Return(1)
+	assert.Equal(t, collection, withPrefetching(collection, "methode", task))
+}
+
+func TestPrefetchingCollection(t *testing.T) {
+	task := new(tasks.MockPrefetchingTask)
+	task.On("PrefetchSize").Return(2)
+	task.On("Prefetch", "methode", []string{"uuid-1", "uuid-2"}).Return().Once()
+	task.On("Prefetch", "methode", []string{"uuid-3"}).Return().Once()
+
+	collection := withPrefetching(native.NewMockUUIDCollection("uuid-1", "uuid-2", " ", "uuid-3"), "methode", task)
+
+	var actual []string
+	for !collection.Done() {
+		finished, uuid, err := collection.Next()
+		assert.NoError(t, err)
+		if finished {
+			break
+		}
+		actual = append(actual, uuid)
+	}
+
+	assert.Equal(t, []string{"uuid-1", "uuid-2", " ", "uuid-3"}, actual, "blank uuids are passed through, but not prefetched")
+
+	finished, _, err := collection.Next()
+	assert.True(t, finished)
+	assert.NoError(t, err)
+
+	task.AssertExpectations(t)
+}
+
+func TestPrefetchingCollectionPosition(t *testing.T) {
+	task := new(tasks.MockPrefetchingTask)
+	task.On("PrefetchSize").Return(2)
+	task.On("Prefetch", "methode", []string{"uuid-1", "uuid-2"}).Return()
+
+	collection := withPrefetching(native.NewMockPositionedUUIDCollection("uuid-1", "uuid-2", "uuid-3", "uuid-4"), "methode", task).(*prefetchingCollection)
+	collection.Next()
+
+	pos, pending, ok := collection.position("uuid-2")
+	assert.True(t, ok)
+	assert.True(t, pending)
+	assert.Equal(t, 0, pos, "read ahead uuids are published first")
+
+	pos, pending, ok = collection.position("uuid-4")
+	assert.True(t, ok)
+	assert.True(t, pending)
+	assert.Equal(t, 2, pos)
+
+	_, pending, ok = collection.position("uuid-1")
+	assert.True(t, ok)
+	assert.False(t, pending)
+
+	_, _, ok = withPrefetching(native.NewMockUUIDCollection("uuid-1"), "methode", task).(*prefetchingCollection).position("uuid-1")
+	assert.False(t, ok, "position is unknown unless the wrapped collection is positioned")
+}
+
+type resumableCollection struct {
+	*native.MockUUIDCollection
+	last string
+}
+
+func (r *resumableCollection) Next() (bool, string, error) {
+	finished, uuid, err := r.MockUUIDCollection.Next()
+	if !finished {
+		r.last = uuid
+	}
+	return finished, uuid, err
+}
+
+func (r *resumableCollection) ResumeToken() string {
+	return "after-" + r.last
+}
+
+func TestPrefetchingCollectionResumeToken(t *testing.T) {
+	task := new(tasks.MockPrefetchingTask)
+	task.On("PrefetchSize").Return(2)
+	task.On("Prefetch", "methode", []string{"uuid-1", "uuid-2"}).Return()
+
+	collection := withPrefetching(&resumableCollection{MockUUIDCollection: native.NewMockUUIDCollection("uuid-1", "uuid-2", "uuid-3")}, "methode", task)
+
+	_, uuid, err := collection.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "uuid-1", uuid)
+	assert.Equal(t, "after-uuid-1", collection.(native.ResumableUUIDCollection).ResumeToken(), "uuids which have been read ahead are not resumed after")
+}
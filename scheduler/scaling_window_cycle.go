@@ -36,17 +36,27 @@ func NewScalingWindowCycle(
 }
 
 func (s *ScalingWindowCycle) Start() {
+	if s.isCompleted() {
+		log.WithField("id", s.CycleID).WithField("name", s.CycleName).WithField("collection", s.DBCollection).Info("Not starting scaling window cycle, as it has already completed.")
+		s.complete()
+		return
+	}
+
 	log.WithField("id", s.CycleID).WithField("name", s.CycleName).WithField("collection", s.DBCollection).WithField("coolDown", s.CoolDown).WithField("timeWindow", s.TimeWindow).Info("Starting scaling window cycle.")
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := s.newContext()
 	s.cancel = cancel
 	s.UpdateState(startingState)
 
 	throttle := func(publishes int) (Throttle, context.CancelFunc) {
 		return NewCappedDynamicThrottle(s.timeWindow, s.minimumThrottle, s.maximumThrottle, publishes, 1)
 	}
-	go s.start(ctx, throttle)
+	go s.supervise(ctx, func(ctx context.Context) { s.start(ctx, throttle) })
 }
 
 func (s *ScalingWindowCycle) TransformToConfig() CycleConfig {
-	return CycleConfig{Name: s.CycleName, Type: s.CycleType, Collection: s.DBCollection, TimeWindow: s.TimeWindow, CoolDown: s.CoolDown, MinimumThrottle: s.MinimumThrottle, MaximumThrottle: s.MaximumThrottle}
+	config := s.baseConfig()
+	config.TimeWindow = s.TimeWindow
+	config.MinimumThrottle = s.MinimumThrottle
+	config.MaximumThrottle = s.MaximumThrottle
+	return config
 }
@@ -8,9 +8,15 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Financial-Times/publish-carousel/ledger"
 	"github.com/Financial-Times/publish-carousel/native"
 	"github.com/Financial-Times/publish-carousel/tasks"
+	"github.com/Financial-Times/publish-carousel/tracing"
+	"github.com/Financial-Times/publish-carousel/transform"
+	"github.com/Financial-Times/publish-carousel/txid"
+	"github.com/Financial-Times/publish-carousel/verify"
 	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Scheduler is the main component of the publish carousel,
@@ -41,10 +47,22 @@ type defaultScheduler struct {
 	toggleHandlerLock     *sync.Mutex
 	defaultThrottle       time.Duration
 	checkpointHandler     *checkpointHandler
+	journal               *cycleJournal
+	restartPolicy         RestartPolicy
+	ledger                ledger.Ledger
+	taskRegistry          *tasks.Registry
+	verifier              verify.Verifier
+	tracerProvider        trace.TracerProvider
 }
 
-// NewScheduler returns a new instance of the cycles scheduler
-func NewScheduler(uuidCollectionBuilder *native.NativeUUIDCollectionBuilder, publishTask tasks.Task, metadataReadWriter MetadataReadWriter, defaultThrottle time.Duration, checkpointInterval time.Duration) Scheduler {
+// NewScheduler returns a new instance of the cycles scheduler. Successful publishes are recorded in the republish ledger, which may be nil.
+// Cycles use the publish task, unless they select another task by name from the task registry, which may also be nil. Cycles which verify their publishes require the verifier.
+// Cycles record a trace of every publish with the tracer provider, which may be nil if publishes are not traced.
+func NewScheduler(uuidCollectionBuilder *native.NativeUUIDCollectionBuilder, publishTask tasks.Task, metadataReadWriter MetadataReadWriter, defaultThrottle time.Duration, checkpointInterval time.Duration, restartPolicy RestartPolicy, republishLedger ledger.Ledger, taskRegistry *tasks.Registry, verifier verify.Verifier, tracerProvider trace.TracerProvider) Scheduler {
+	return newDefaultScheduler(uuidCollectionBuilder, publishTask, metadataReadWriter, defaultThrottle, checkpointInterval, restartPolicy, republishLedger, taskRegistry, verifier, tracerProvider)
+}
+
+func newDefaultScheduler(uuidCollectionBuilder *native.NativeUUIDCollectionBuilder, publishTask tasks.Task, metadataReadWriter MetadataReadWriter, defaultThrottle time.Duration, checkpointInterval time.Duration, restartPolicy RestartPolicy, republishLedger ledger.Ledger, taskRegistry *tasks.Registry, verifier verify.Verifier, tracerProvider trace.TracerProvider) *defaultScheduler {
 	return &defaultScheduler{
 		uuidCollectionBuilder: uuidCollectionBuilder,
 		publishTask:           publishTask,
@@ -55,6 +73,12 @@ func NewScheduler(uuidCollectionBuilder *native.NativeUUIDCollectionBuilder, pub
 		toggleHandlerLock:     &sync.Mutex{},
 		defaultThrottle:       defaultThrottle,
 		checkpointHandler:     newCheckpointHandler(checkpointInterval),
+		journal:               newCycleJournal(metadataReadWriter),
+		restartPolicy:         restartPolicy,
+		ledger:                republishLedger,
+		taskRegistry:          taskRegistry,
+		verifier:              verifier,
+		tracerProvider:        tracerProvider,
 	}
 }
 
@@ -74,6 +98,11 @@ func (s *defaultScheduler) AddCycle(c Cycle) error {
 
 	s.cycles[c.ID()] = c
 
+	if s.journal.isEnabled() {
+		c.SetSource(APICycleSource)
+		s.journal.recordUpsert(c.ID(), c.TransformToConfig())
+	}
+
 	if s.state.isEnabled() && s.state.isRunning() {
 		c.Start()
 	}
@@ -91,9 +120,81 @@ func (s *defaultScheduler) DeleteCycle(cycleID string) error {
 
 	c.Stop()
 	delete(s.cycles, cycleID)
+	s.journal.recordDelete(cycleID)
 	return nil
 }
 
+// removeCompletedCycle removes the given cycle, unless it has already been replaced or removed from the scheduler.
+// It is journaled as completed rather than deleted, so that a cycle from the configuration file is loaded again on restart.
+func (s *defaultScheduler) removeCompletedCycle(c Cycle) {
+	s.cycleLock.Lock()
+	defer s.cycleLock.Unlock()
+
+	if current, ok := s.cycles[c.ID()]; !ok || current != c {
+		return
+	}
+
+	log.WithField("id", c.ID()).WithField("name", c.Name()).Info("Removing completed cycle from the scheduler.")
+	c.Stop()
+	delete(s.cycles, c.ID())
+	s.journal.recordCompleted(c.ID())
+}
+
+// replayCycleJournal applies the cycles created, modified or deleted through the API on top of the cycles loaded from file.
+// Journal entries take precedence over file configuration for cycles with the same ID.
+// If the journal cannot be loaded, journalling stays disabled so that the persisted journal is not overwritten, and the error is returned.
+func (s *defaultScheduler) replayCycleJournal() []error {
+	s.cycleLock.Lock()
+	defer s.cycleLock.Unlock()
+
+	entries, err := s.journal.load()
+	if err != nil {
+		log.WithError(err).Warn("Failed to load the cycle journal - cycles created through the API will not be restored, and changes made through the API will not be journaled.")
+		return []error{fmt.Errorf("Failed to load the cycle journal: %v", err)}
+	}
+
+	var errs []error
+	for _, entry := range entries {
+		switch entry.Action {
+		case deleteCycleAction:
+			if _, ok := s.cycles[entry.ID]; !ok {
+				s.journal.forget(entry.ID)
+				continue
+			}
+			log.WithField("id", entry.ID).Info("Removing cycle deleted through the API.")
+			delete(s.cycles, entry.ID)
+
+		case completedCycleAction:
+			s.journal.forget(entry.ID)
+			if _, ok := s.cycles[entry.ID]; ok {
+				log.WithField("id", entry.ID).Info("Loading cycle from file, which removed itself on completion before the restart.")
+			}
+
+		case upsertCycleAction:
+			if entry.Config == nil {
+				continue
+			}
+
+			cycle, err := s.NewCycle(*entry.Config)
+			if err != nil {
+				log.WithError(err).WithField("cycleName", entry.Config.Name).Warn("Skipping journaled cycle")
+				errs = append(errs, err)
+				continue
+			}
+
+			if _, ok := s.cycles[cycle.ID()]; ok {
+				log.WithField("id", cycle.ID()).WithField("cycleName", entry.Config.Name).Info("Overriding file configuration with cycle modified through the API.")
+			}
+
+			cycle.SetSource(APICycleSource)
+			s.cycles[cycle.ID()] = cycle
+		}
+	}
+
+	s.journal.enable()
+	return errs
+}
+
 func (s *defaultScheduler) saveCycleMetadata() {
 	log.Info("Saving cycle metadata to S3.")
 
@@ -158,6 +259,7 @@ func (s *defaultScheduler) Start() error {
 		defer s.cycleLock.RUnlock()
 
 		s.saveCycleMetadata()
+		s.persistLedger()
 	})
 
 	return nil
@@ -205,9 +307,21 @@ func (s *defaultScheduler) Shutdown() error {
 	s.state.setState(stopped)
 	s.checkpointHandler.stop()
 	s.saveCycleMetadata()
+	s.persistLedger()
 	return nil
 }
 
+func (s *defaultScheduler) persistLedger() {
+	if s.ledger == nil {
+		return
+	}
+
+	log.Info("Saving republish ledger to S3.")
+	if err := s.ledger.Persist(); err != nil {
+		log.WithError(err).Error("republish ledger not saved")
+	}
+}
+
 const (
 	automatic = iota
 	manual
@@ -332,6 +446,15 @@ func (s *defaultScheduler) NewCycle(config CycleConfig) (Cycle, error) {
 		return nil, err
 	}
 
+	task, err := s.taskFor(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Verification != nil && s.verifier == nil {
+		return nil, fmt.Errorf("Cycle %v verifies its publishes, but no verifier is configured", config.Name)
+	}
+
 	var c Cycle
 	coolDown, _ := time.ParseDuration(config.CoolDown)
 
@@ -345,14 +468,94 @@ func (s *defaultScheduler) NewCycle(config CycleConfig) (Cycle, error) {
 			throttleInterval, _ = time.ParseDuration(config.Throttle)
 		}
 		t, _ := NewThrottle(throttleInterval, 1)
-		c = NewThrottledWholeCollectionCycle(config.Name, s.uuidCollectionBuilder, config.Collection, config.Origin, coolDown, t, s.publishTask)
+		c = NewThrottledWholeCollectionCycle(config.Name, s.uuidCollectionBuilder, config.Collection, config.Origin, coolDown, t, task)
+		c.(*ThrottledWholeCollectionCycle).UUIDCollection = config.UUIDCollection
+
+	case "stalestfirst":
+		if s.ledger == nil {
+			return nil, fmt.Errorf("Cycle %v requires the republish ledger, which is not configured", config.Name)
+		}
+
+		throttleInterval := s.defaultThrottle
+		if config.Throttle != "" {
+			throttleInterval, _ = time.ParseDuration(config.Throttle)
+		}
+		t, _ := NewThrottle(throttleInterval, 1)
+		c = NewStalestFirstCycle(config.Name, s.uuidCollectionBuilder, config.Collection, config.Origin, coolDown, t, task, s.ledger)
 
 	case "scalingwindow":
 		timeWindow, _ := time.ParseDuration(config.TimeWindow)
 		minimumThrottle, _ := time.ParseDuration(config.MinimumThrottle)
 		maximumThrottle, _ := time.ParseDuration(config.MaximumThrottle)
-		c = NewScalingWindowCycle(config.Name, s.uuidCollectionBuilder, config.Collection, config.Origin, timeWindow, coolDown, minimumThrottle, maximumThrottle, s.publishTask)
+		c = NewScalingWindowCycle(config.Name, s.uuidCollectionBuilder, config.Collection, config.Origin, timeWindow, coolDown, minimumThrottle, maximumThrottle, task)
+	}
+
+	if cc, ok := c.(configurableCycle); ok {
+		cc.setOptions(s.optionsFor(config, func() { s.removeCompletedCycle(c) }))
 	}
 
 	return c, nil
 }
+
+// optionsFor returns the options for a cycle built from the config, which has already been validated. The cycle calls onCompleted when it completes.
+func (s *defaultScheduler) optionsFor(config CycleConfig, onCompleted func()) cycleOptions {
+	expiresAt, _ := config.expiryTime()
+	filters, _ := config.filterChain()
+	transformations, _ := transform.NewChain(config.Transformations)
+	readOptions, _ := config.readOptions()
+
+	opts := cycleOptions{
+		MaxIterations:         config.MaxIterations,
+		RemoveOnCompletion:    config.RemoveOnCompletion,
+		Labels:                config.Labels,
+		Task:                  config.Task,
+		TaskOptions:           config.TaskOptions,
+		Filters:               config.Filters,
+		PublishImages:         config.PublishImages,
+		Transformations:       config.Transformations,
+		TransactionIDTemplate: config.TransactionIDTemplate,
+		ReadPreference:        config.ReadPreference,
+		ReadConcern:           config.ReadConcern,
+		MaxStaleness:          config.MaxStaleness,
+		expiresAt:             expiresAt,
+		onCompleted:           onCompleted,
+		ledger:                s.ledger,
+		filters:               filters,
+		transformations:       transformations,
+		restartPolicy:         s.restartPolicy,
+		readOptions:           readOptions,
+		tracer:                tracing.Tracer(s.tracerProvider),
+	}
+
+	if expiresAt != nil {
+		opts.ExpiresAt = expiresAt.Format(time.RFC3339)
+	}
+
+	if config.Verification != nil {
+		opts.Verification = config.Verification
+		opts.verifier = s.verifier
+	}
+
+	if config.TransactionIDTemplate != "" {
+		opts.txIDs, _ = txid.NewTemplate(config.TransactionIDTemplate)
+	}
+
+	return opts
+}
+
+// taskFor returns the task for the cycle, which is the publish task unless the cycle selects a task from the registry
+func (s *defaultScheduler) taskFor(config CycleConfig) (tasks.Task, error) {
+	if strings.TrimSpace(config.Task) == "" {
+		return s.publishTask, nil
+	}
+
+	if s.taskRegistry == nil {
+		return nil, fmt.Errorf("Cycle %v selects the task %v, but no tasks are registered", config.Name, config.Task)
+	}
+
+	task, err := s.taskRegistry.NewTask(config.Task, config.TaskOptions)
+	if err != nil {
+		return nil, fmt.Errorf("Cycle %v: %v", config.Name, err)
+	}
+	return task, nil
+}
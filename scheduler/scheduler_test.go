@@ -5,9 +5,14 @@ import (
 	"time"
 
 	"github.com/Financial-Times/publish-carousel/blacklist"
+	"github.com/Financial-Times/publish-carousel/filter"
+	"github.com/Financial-Times/publish-carousel/ledger"
 	"github.com/Financial-Times/publish-carousel/native"
 	"github.com/Financial-Times/publish-carousel/tasks"
+	"github.com/Financial-Times/publish-carousel/transform"
+	"github.com/Financial-Times/publish-carousel/verify"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestSchedulerShouldStartWhenEnabled(t *testing.T) {
@@ -19,7 +24,7 @@ func TestSchedulerShouldStartWhenEnabled(t *testing.T) {
 	db := new(native.MockDB)
 	uuidCollectionBuilder := native.NewNativeUUIDCollectionBuilder(db, nil, blacklist.NoOpBlacklist)
 
-	s := NewScheduler(uuidCollectionBuilder, &tasks.MockTask{}, &MockMetadataRW{}, 1*time.Minute, 1*time.Minute)
+	s := NewScheduler(uuidCollectionBuilder, &tasks.MockTask{}, &MockMetadataRW{}, 1*time.Minute, 1*time.Minute, DefaultRestartPolicy, nil, nil, nil, nil)
 
 	c1 := new(MockCycle)
 	c2 := new(MockCycle)
@@ -55,7 +60,7 @@ func TestSchedulerDoNotStartWhenDisabled(t *testing.T) {
 	db := new(native.MockDB)
 	uuidCollectionBuilder := native.NewNativeUUIDCollectionBuilder(db, nil, blacklist.NoOpBlacklist)
 
-	s := NewScheduler(uuidCollectionBuilder, &tasks.MockTask{}, &MockMetadataRW{}, 1*time.Minute, 1*time.Minute)
+	s := NewScheduler(uuidCollectionBuilder, &tasks.MockTask{}, &MockMetadataRW{}, 1*time.Minute, 1*time.Minute, DefaultRestartPolicy, nil, nil, nil, nil)
 
 	c1 := new(MockCycle)
 	c2 := new(MockCycle)
@@ -82,7 +87,7 @@ func TestSchedulerResumeAfterDisable(t *testing.T) {
 	db := new(native.MockDB)
 	uuidCollectionBuilder := native.NewNativeUUIDCollectionBuilder(db, nil, blacklist.NoOpBlacklist)
 
-	s := NewScheduler(uuidCollectionBuilder, &tasks.MockTask{}, &MockMetadataRW{}, 1*time.Minute, 1*time.Minute)
+	s := NewScheduler(uuidCollectionBuilder, &tasks.MockTask{}, &MockMetadataRW{}, 1*time.Minute, 1*time.Minute, DefaultRestartPolicy, nil, nil, nil, nil)
 
 	c1 := new(MockCycle)
 	c2 := new(MockCycle)
@@ -150,7 +155,7 @@ func TestSchedulerResumeAfterDisable(t *testing.T) {
 func TestSchedulerInvalidToggleValue(t *testing.T) {
 	db := new(native.MockDB)
 	uuidCollectionBuilder := native.NewNativeUUIDCollectionBuilder(db, nil, blacklist.NoOpBlacklist)
-	s := NewScheduler(uuidCollectionBuilder, &tasks.MockTask{}, &MockMetadataRW{}, 1*time.Minute, 1*time.Minute)
+	s := NewScheduler(uuidCollectionBuilder, &tasks.MockTask{}, &MockMetadataRW{}, 1*time.Minute, 1*time.Minute, DefaultRestartPolicy, nil, nil, nil, nil)
 
 	c1 := new(MockCycle)
 	c2 := new(MockCycle)
@@ -176,7 +181,7 @@ func TestAutomaticToggleDisabledAndManualToggleEnabled(t *testing.T) {
 
 	db := new(native.MockDB)
 	uuidCollectionBuilder := native.NewNativeUUIDCollectionBuilder(db, nil, blacklist.NoOpBlacklist)
-	s := NewScheduler(uuidCollectionBuilder, &tasks.MockTask{}, &MockMetadataRW{}, 1*time.Minute, 1*time.Minute)
+	s := NewScheduler(uuidCollectionBuilder, &tasks.MockTask{}, &MockMetadataRW{}, 1*time.Minute, 1*time.Minute, DefaultRestartPolicy, nil, nil, nil, nil)
 
 	c1 := new(MockCycle)
 	c2 := new(MockCycle)
@@ -264,7 +269,7 @@ func TestAutomaticToggleEnabledAndManualToggleDisabled(t *testing.T) {
 	}
 	db := new(native.MockDB)
 	uuidCollectionBuilder := native.NewNativeUUIDCollectionBuilder(db, nil, blacklist.NoOpBlacklist)
-	s := NewScheduler(uuidCollectionBuilder, &tasks.MockTask{}, &MockMetadataRW{}, 1*time.Minute, 1*time.Minute)
+	s := NewScheduler(uuidCollectionBuilder, &tasks.MockTask{}, &MockMetadataRW{}, 1*time.Minute, 1*time.Minute, DefaultRestartPolicy, nil, nil, nil, nil)
 
 	c1 := new(MockCycle)
 	c2 := new(MockCycle)
@@ -352,7 +357,7 @@ func TestAutomaticToggleFlappingAndManualToggleDisabled(t *testing.T) {
 	}
 	db := new(native.MockDB)
 	uuidCollectionBuilder := native.NewNativeUUIDCollectionBuilder(db, nil, blacklist.NoOpBlacklist)
-	s := NewScheduler(uuidCollectionBuilder, &tasks.MockTask{}, &MockMetadataRW{}, 1*time.Minute, 1*time.Minute)
+	s := NewScheduler(uuidCollectionBuilder, &tasks.MockTask{}, &MockMetadataRW{}, 1*time.Minute, 1*time.Minute, DefaultRestartPolicy, nil, nil, nil, nil)
 
 	c1 := new(MockCycle)
 	c2 := new(MockCycle)
@@ -394,7 +399,7 @@ func TestAutomaticToggleDisabledAndManualToggleFlapping(t *testing.T) {
 	}
 	db := new(native.MockDB)
 	uuidCollectionBuilder := native.NewNativeUUIDCollectionBuilder(db, nil, blacklist.NoOpBlacklist)
-	s := NewScheduler(uuidCollectionBuilder, &tasks.MockTask{}, &MockMetadataRW{}, 1*time.Minute, 1*time.Minute)
+	s := NewScheduler(uuidCollectionBuilder, &tasks.MockTask{}, &MockMetadataRW{}, 1*time.Minute, 1*time.Minute, DefaultRestartPolicy, nil, nil, nil, nil)
 
 	c1 := new(MockCycle)
 	c2 := new(MockCycle)
@@ -451,7 +456,7 @@ func TestSaveCycleMetadata(t *testing.T) {
 	rw := MockMetadataRW{}
 	rw.On("WriteMetadata", id2, c2.TransformToConfig(), c2.Metadata()).Return(nil)
 
-	s := NewScheduler(uuidCollectionBuilder, &tasks.MockTask{}, &rw, 1*time.Minute, 1*time.Minute)
+	s := NewScheduler(uuidCollectionBuilder, &tasks.MockTask{}, &rw, 1*time.Minute, 1*time.Minute, DefaultRestartPolicy, nil, nil, nil, nil)
 
 	s.AddCycle(c1)
 	s.AddCycle(c2)
@@ -484,7 +489,7 @@ func TestCalculateArchiveCycleStartInterval(t *testing.T) {
 
 	rw := MockMetadataRW{}
 
-	s := NewScheduler(uuidCollectionBuilder, &tasks.MockTask{}, &rw, 1*time.Minute, 1*time.Minute)
+	s := NewScheduler(uuidCollectionBuilder, &tasks.MockTask{}, &rw, 1*time.Minute, 1*time.Minute, DefaultRestartPolicy, nil, nil, nil, nil)
 
 	s.AddCycle(c1)
 	s.AddCycle(c2)
@@ -497,3 +502,191 @@ func TestCalculateArchiveCycleStartInterval(t *testing.T) {
 	expected, _ := time.ParseDuration("500ms")
 	assert.Equal(expected, testIterval, "test interval should be 500ms")
 }
+
+func TestSchedulerRemovesCompletedCycle(t *testing.T) {
+	db := new(native.MockDB)
+	uuidCollectionBuilder := native.NewNativeUUIDCollectionBuilder(db, nil, blacklist.NoOpBlacklist)
+
+	s := NewScheduler(uuidCollectionBuilder, &tasks.MockTask{}, &MockMetadataRW{}, 1*time.Minute, 1*time.Minute, DefaultRestartPolicy, nil, nil, nil, nil)
+
+	c, err := s.NewCycle(CycleConfig{Name: "wordpress-once", Type: "ThrottledWholeCollection", Origin: "wordpress", Collection: "wordpress", CoolDown: "5m", Throttle: "1s", ExpiresAt: "2017-03-06T09:00:00Z", RemoveOnCompletion: true})
+	assert.NoError(t, err)
+
+	err = s.AddCycle(c)
+	assert.NoError(t, err)
+	assert.Len(t, s.Cycles(), 1)
+
+	c.Start()
+	time.Sleep(50 * time.Millisecond)
+
+	assert.Len(t, s.Cycles(), 0, "the expired cycle should have removed itself")
+	db.AssertExpectations(t)
+}
+
+func TestSchedulerNewCycleWithReadOptions(t *testing.T) {
+	uuidCollectionBuilder := native.NewNativeUUIDCollectionBuilder(new(native.MockDB), nil, blacklist.NoOpBlacklist)
+	s := NewScheduler(uuidCollectionBuilder, &tasks.MockTask{}, &MockMetadataRW{}, 1*time.Minute, 1*time.Minute, DefaultRestartPolicy, nil, nil, nil, nil)
+
+	config := CycleConfig{Name: "methode-whole-archive", Type: "ThrottledWholeCollection", Origin: "methode-web-pub", Collection: "methode", CoolDown: "5m", Throttle: "1s", ReadPreference: "secondaryPreferred", ReadConcern: "majority", MaxStaleness: "2m"}
+	c, err := s.NewCycle(config)
+	assert.NoError(t, err)
+
+	cycle := c.(*ThrottledWholeCollectionCycle)
+	assert.Equal(t, "secondaryPreferred", cycle.ReadPreference)
+	assert.False(t, cycle.uuidCollectionBuilder == uuidCollectionBuilder, "the cycle should use its own uuid collection builder")
+
+	actual := c.TransformToConfig()
+	assert.Equal(t, "secondaryPreferred", actual.ReadPreference)
+	assert.Equal(t, "majority", actual.ReadConcern)
+	assert.Equal(t, "2m", actual.MaxStaleness)
+
+	c, err = s.NewCycle(CycleConfig{Name: "methode-whole-archive", Type: "ThrottledWholeCollection", Origin: "methode-web-pub", Collection: "methode", CoolDown: "5m", Throttle: "1s"})
+	assert.NoError(t, err)
+	assert.True(t, c.(*ThrottledWholeCollectionCycle).uuidCollectionBuilder == uuidCollectionBuilder, "cycles without read options should use the default builder")
+}
+
+func TestSchedulerNewStalestFirstCycle(t *testing.T) {
+	uuidCollectionBuilder := native.NewNativeUUIDCollectionBuilder(new(native.MockDB), nil, blacklist.NoOpBlacklist)
+	republishLedger := new(ledger.MockLedger)
+	s := NewScheduler(uuidCollectionBuilder, &tasks.MockTask{}, &MockMetadataRW{}, 1*time.Minute, 1*time.Minute, DefaultRestartPolicy, republishLedger, nil, nil, nil)
+
+	config := CycleConfig{Name: "methode-stalest-first", Type: "StalestFirst", Origin: "methode-web-pub", Collection: "methode", CoolDown: "5m", Throttle: "1s"}
+	c, err := s.NewCycle(config)
+	assert.NoError(t, err)
+
+	cycle := c.(*StalestFirstCycle)
+	assert.True(t, cycle.ledger == republishLedger)
+	assert.Equal(t, CycleConfig{Name: "methode-stalest-first", Type: "StalestFirst", Origin: "methode-web-pub", Collection: "methode", CoolDown: "5m0s", Throttle: "1s"}, c.TransformToConfig())
+
+	whole, err := s.NewCycle(CycleConfig{Name: "methode-whole-archive", Type: "ThrottledWholeCollection", Origin: "methode-web-pub", Collection: "methode", CoolDown: "5m", Throttle: "1s"})
+	assert.NoError(t, err)
+	assert.True(t, whole.(*ThrottledWholeCollectionCycle).ledger == republishLedger, "every cycle should record its publishes in the ledger")
+}
+
+func TestSchedulerNewStalestFirstCycleWithoutLedger(t *testing.T) {
+	uuidCollectionBuilder := native.NewNativeUUIDCollectionBuilder(new(native.MockDB), nil, blacklist.NoOpBlacklist)
+	s := NewScheduler(uuidCollectionBuilder, &tasks.MockTask{}, &MockMetadataRW{}, 1*time.Minute, 1*time.Minute, DefaultRestartPolicy, nil, nil, nil, nil)
+
+	_, err := s.NewCycle(CycleConfig{Name: "methode-stalest-first", Type: "StalestFirst", Origin: "methode-web-pub", Collection: "methode", CoolDown: "5m"})
+	assert.EqualError(t, err, "Cycle methode-stalest-first requires the republish ledger, which is not configured")
+}
+
+func TestSchedulerPersistsLedger(t *testing.T) {
+	uuidCollectionBuilder := native.NewNativeUUIDCollectionBuilder(new(native.MockDB), nil, blacklist.NoOpBlacklist)
+
+	republishLedger := new(ledger.MockLedger)
+	republishLedger.On("Persist").Return(nil).Once()
+
+	s := newDefaultScheduler(uuidCollectionBuilder, &tasks.MockTask{}, &MockMetadataRW{}, 1*time.Minute, 1*time.Minute, DefaultRestartPolicy, republishLedger, nil, nil, nil)
+	s.persistLedger()
+	republishLedger.AssertExpectations(t)
+
+	newDefaultScheduler(uuidCollectionBuilder, &tasks.MockTask{}, &MockMetadataRW{}, 1*time.Minute, 1*time.Minute, DefaultRestartPolicy, nil, nil, nil, nil).persistLedger()
+}
+
+func TestSchedulerNewCycleWithRegisteredTask(t *testing.T) {
+	uuidCollectionBuilder := native.NewNativeUUIDCollectionBuilder(new(native.MockDB), nil, blacklist.NoOpBlacklist)
+
+	defaultTask := &tasks.MockTask{}
+	sinkTask := &tasks.MockTask{}
+
+	registry := tasks.NewRegistry()
+	registry.Register(tasks.HTTPSinkTaskName, tasks.Definition{
+		NewOptions: func() tasks.TaskOptions { return &tasks.HTTPSinkOptions{} },
+		New: func(options tasks.TaskOptions) (tasks.Task, error) {
+			assert.Equal(t, "http://localhost:8080/sink", options.(*tasks.HTTPSinkOptions).URL)
+			return sinkTask, nil
+		},
+	})
+
+	s := NewScheduler(uuidCollectionBuilder, defaultTask, &MockMetadataRW{}, 1*time.Minute, 1*time.Minute, DefaultRestartPolicy, nil, registry, nil, nil)
+
+	config := CycleConfig{Name: "methode-sink", Type: "ThrottledWholeCollection", Origin: "methode-web-pub", Collection: "methode", CoolDown: "5m", Throttle: "1s", Task: "http", TaskOptions: tasks.Options{"url": "http://localhost:8080/sink"}}
+	c, err := s.NewCycle(config)
+	require.NoError(t, err)
+
+	assert.True(t, c.(*ThrottledWholeCollectionCycle).publishTask == sinkTask)
+	assert.Equal(t, "http", c.TransformToConfig().Task)
+	assert.Equal(t, tasks.Options{"url": "http://localhost:8080/sink"}, c.TransformToConfig().TaskOptions)
+
+	c, err = s.NewCycle(CycleConfig{Name: "methode-whole-archive", Type: "ThrottledWholeCollection", Origin: "methode-web-pub", Collection: "methode", CoolDown: "5m", Throttle: "1s"})
+	require.NoError(t, err)
+	assert.True(t, c.(*ThrottledWholeCollectionCycle).publishTask == defaultTask, "cycles which do not select a task use the default")
+
+	_, err = s.NewCycle(CycleConfig{Name: "methode-sink", Type: "ThrottledWholeCollection", Origin: "methode-web-pub", Collection: "methode", CoolDown: "5m", Task: "http", TaskOptions: tasks.Options{"url": "not a url"}})
+	assert.EqualError(t, err, "Cycle methode-sink: Invalid options for task http: Please provide an absolute http url, not not a url")
+
+	_, err = s.NewCycle(CycleConfig{Name: "methode-sqs", Type: "ThrottledWholeCollection", Origin: "methode-web-pub", Collection: "methode", CoolDown: "5m", Task: "sqs"})
+	assert.EqualError(t, err, "Cycle methode-sqs: Unknown task sqs, please use one of http")
+}
+
+func TestSchedulerNewCycleWithoutTaskRegistry(t *testing.T) {
+	uuidCollectionBuilder := native.NewNativeUUIDCollectionBuilder(new(native.MockDB), nil, blacklist.NoOpBlacklist)
+	s := NewScheduler(uuidCollectionBuilder, &tasks.MockTask{}, &MockMetadataRW{}, 1*time.Minute, 1*time.Minute, DefaultRestartPolicy, nil, nil, nil, nil)
+
+	_, err := s.NewCycle(CycleConfig{Name: "methode-sink", Type: "ThrottledWholeCollection", Origin: "methode-web-pub", Collection: "methode", CoolDown: "5m", Task: "http"})
+	assert.EqualError(t, err, "Cycle methode-sink selects the task http, but no tasks are registered")
+}
+
+func TestSchedulerNewCycleFilters(t *testing.T) {
+	uuidCollectionBuilder := native.NewNativeUUIDCollectionBuilder(new(native.MockDB), nil, blacklist.NoOpBlacklist)
+	s := NewScheduler(uuidCollectionBuilder, &tasks.MockTask{}, &MockMetadataRW{}, 1*time.Minute, 1*time.Minute, DefaultRestartPolicy, nil, nil, nil, nil)
+
+	c, err := s.NewCycle(CycleConfig{Name: "methode-whole-archive", Type: "ThrottledWholeCollection", Origin: "methode-web-pub", Collection: "methode", CoolDown: "5m", Throttle: "1s"})
+	require.NoError(t, err)
+
+	image := &native.Content{Body: map[string]interface{}{"type": "image"}}
+	rule, skipped := c.(ExplainableCycle).Filter(image)
+	assert.True(t, skipped, "cycles without filters skip images")
+	assert.Equal(t, "images", rule)
+	assert.Nil(t, c.TransformToConfig().Filters)
+
+	filters := []filter.Rule{{Name: "articles", Action: "include", Condition: filter.Condition{Field: "type", Values: []string{"Article"}}}}
+	c, err = s.NewCycle(CycleConfig{Name: "methode-articles", Type: "ScalingWindow", Origin: "methode-web-pub", Collection: "methode", CoolDown: "5m", TimeWindow: "1h", MinimumThrottle: "1s", MaximumThrottle: "1m", Filters: filters})
+	require.NoError(t, err)
+
+	rule, skipped = c.(ExplainableCycle).Filter(image)
+	assert.True(t, skipped, "cycles with filters still skip images")
+	assert.Equal(t, "images", rule)
+
+	_, skipped = c.(ExplainableCycle).Filter(&native.Content{Body: map[string]interface{}{"type": "Article"}})
+	assert.False(t, skipped)
+	assert.Equal(t, filters, c.TransformToConfig().Filters)
+
+	c, err = s.NewCycle(CycleConfig{Name: "methode-articles-and-images", Type: "ThrottledWholeCollection", Origin: "methode-web-pub", Collection: "methode", CoolDown: "5m", Throttle: "1s", Filters: filters, PublishImages: true})
+	require.NoError(t, err)
+
+	rule, skipped = c.(ExplainableCycle).Filter(image)
+	assert.True(t, skipped)
+	assert.Equal(t, filter.UnmatchedRule, rule)
+	assert.True(t, c.TransformToConfig().PublishImages)
+}
+
+func TestSchedulerNewCycleTransformations(t *testing.T) {
+	uuidCollectionBuilder := native.NewNativeUUIDCollectionBuilder(new(native.MockDB), nil, blacklist.NoOpBlacklist)
+	s := NewScheduler(uuidCollectionBuilder, &tasks.MockTask{}, &MockMetadataRW{}, 1*time.Minute, 1*time.Minute, DefaultRestartPolicy, nil, nil, nil, nil)
+
+	transformations := []transform.Rule{{Name: "internal", Action: "remove", Field: "internalNotes"}}
+	c, err := s.NewCycle(CycleConfig{Name: "methode-whole-archive", Type: "ThrottledWholeCollection", Origin: "methode-web-pub", Collection: "methode", CoolDown: "5m", Throttle: "1s", Transformations: transformations})
+	require.NoError(t, err)
+
+	content := &native.Content{Body: map[string]interface{}{"type": "Article", "internalNotes": "notes"}}
+	transformed, applied := c.(ExplainableCycle).Transform(content)
+	assert.Equal(t, []string{"internal"}, applied)
+	assert.Equal(t, map[string]interface{}{"type": "Article"}, transformed.Body)
+	assert.Equal(t, transformations, c.TransformToConfig().Transformations)
+}
+
+func TestSchedulerNewCycleVerification(t *testing.T) {
+	uuidCollectionBuilder := native.NewNativeUUIDCollectionBuilder(new(native.MockDB), nil, blacklist.NoOpBlacklist)
+	config := CycleConfig{Name: "methode-whole-archive", Type: "ThrottledWholeCollection", Origin: "methode-web-pub", Collection: "methode", CoolDown: "5m", Throttle: "1s", Verification: &verify.Config{URL: "{readURL}/__document-store-api/content/{uuid}", Delay: "1m"}}
+
+	s := NewScheduler(uuidCollectionBuilder, &tasks.MockTask{}, &MockMetadataRW{}, 1*time.Minute, 1*time.Minute, DefaultRestartPolicy, nil, nil, nil, nil)
+	_, err := s.NewCycle(config)
+	assert.EqualError(t, err, "Cycle methode-whole-archive verifies its publishes, but no verifier is configured")
+
+	s = NewScheduler(uuidCollectionBuilder, &tasks.MockTask{}, &MockMetadataRW{}, 1*time.Minute, 1*time.Minute, DefaultRestartPolicy, nil, nil, new(verify.MockVerifier), nil)
+	c, err := s.NewCycle(config)
+	require.NoError(t, err)
+	assert.Equal(t, config.Verification, c.TransformToConfig().Verification)
+}
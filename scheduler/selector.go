@@ -0,0 +1,91 @@
+package scheduler
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Selector matches cycles by label, or by one of the name, type, origin, collection or source fields of the cycle. Labels take precedence over fields with the same key.
+type Selector []selectorRequirement
+
+type selectorRequirement struct {
+	key    string
+	value  string
+	equals bool
+}
+
+// ParseSelector parses a comma separated list of requirements, such as "origin=methode,type!=ScalingWindow". All requirements must match for a cycle to be selected.
+func ParseSelector(selector string) (Selector, error) {
+	var s Selector
+	if strings.TrimSpace(selector) == "" {
+		return s, nil
+	}
+
+	for _, requirement := range strings.Split(selector, ",") {
+		equals := true
+		keyValue := strings.SplitN(requirement, "!=", 2)
+		if len(keyValue) == 2 {
+			equals = false
+		} else {
+			keyValue = strings.SplitN(strings.Replace(requirement, "==", "=", 1), "=", 2)
+		}
+
+		if len(keyValue) != 2 || strings.TrimSpace(keyValue[0]) == "" {
+			return nil, fmt.Errorf(`Invalid selector requirement "%v" - should be in the format key=value or key!=value`, requirement)
+		}
+
+		s = append(s, selectorRequirement{key: strings.TrimSpace(keyValue[0]), value: strings.TrimSpace(keyValue[1]), equals: equals})
+	}
+
+	return s, nil
+}
+
+// Empty returns true if the selector has no requirements, and therefore matches every cycle
+func (s Selector) Empty() bool {
+	return len(s) == 0
+}
+
+// Matches returns true if the cycle satisfies every requirement of the selector
+func (s Selector) Matches(cycle Cycle) bool {
+	if s.Empty() {
+		return true
+	}
+
+	config := cycle.TransformToConfig()
+	for _, requirement := range s {
+		value, ok := config.Labels[requirement.key]
+		if !ok {
+			value = cycleField(cycle, config, requirement.key)
+		}
+
+		if (value == requirement.value) != requirement.equals {
+			return false
+		}
+	}
+	return true
+}
+
+func cycleField(cycle Cycle, config CycleConfig, key string) string {
+	switch key {
+	case "name":
+		return config.Name
+	case "type":
+		return config.Type
+	case "origin":
+		return config.Origin
+	case "collection":
+		return config.Collection
+	case "source":
+		return cycle.Source()
+	}
+	return ""
+}
+
+func validateLabels(name string, labels map[string]string) error {
+	for key := range labels {
+		if strings.TrimSpace(key) == "" || strings.ContainsAny(key, "=!,") {
+			return fmt.Errorf(`Invalid label "%v" for cycle %v - label keys cannot be empty, or contain "=", "!" or ","`, key, name)
+		}
+	}
+	return nil
+}
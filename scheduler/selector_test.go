@@ -0,0 +1,61 @@
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSelector(t *testing.T) {
+	s, err := ParseSelector("origin=methode, type!=ScalingWindow,team==dynpub")
+	assert.NoError(t, err)
+	assert.Equal(t, Selector{
+		{key: "origin", value: "methode", equals: true},
+		{key: "type", value: "ScalingWindow", equals: false},
+		{key: "team", value: "dynpub", equals: true},
+	}, s)
+
+	s, err = ParseSelector("")
+	assert.NoError(t, err)
+	assert.True(t, s.Empty())
+
+	_, err = ParseSelector("origin")
+	assert.Error(t, err)
+
+	_, err = ParseSelector("=methode")
+	assert.Error(t, err)
+}
+
+func TestSelectorMatches(t *testing.T) {
+	cycle := new(MockCycle)
+	cycle.On("TransformToConfig").Return(CycleConfig{Name: "methode-whole-archive", Type: "ThrottledWholeCollection", Origin: "methode-web-pub", Collection: "methode", Labels: map[string]string{"origin": "methode", "speed": "slow"}})
+	cycle.On("Source").Return(APICycleSource)
+
+	tests := map[string]bool{
+		"":                       true,
+		"origin=methode":         true,
+		"origin=methode-web-pub": false,
+		"collection=methode":     true,
+		"speed=slow,type=ThrottledWholeCollection": true,
+		"speed=slow,type=ScalingWindow":            false,
+		"speed!=fast":                              true,
+		"speed!=slow":                              false,
+		"source=api":                               true,
+		"unknown=":                                 true,
+		"unknown=value":                            false,
+	}
+
+	for selector, expected := range tests {
+		s, err := ParseSelector(selector)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, s.Matches(cycle), selector)
+	}
+}
+
+func TestValidateLabels(t *testing.T) {
+	config := CycleConfig{Name: "methode-whole-archive", Type: "ThrottledWholeCollection", Origin: "methode-web-pub", Collection: "methode", CoolDown: "5m", Labels: map[string]string{"origin": "methode"}}
+	assert.NoError(t, config.Validate())
+
+	config.Labels["a=b"] = "c"
+	assert.Error(t, config.Validate())
+}
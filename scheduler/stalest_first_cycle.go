@@ -0,0 +1,73 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/Financial-Times/publish-carousel/ledger"
+	"github.com/Financial-Times/publish-carousel/native"
+	"github.com/Financial-Times/publish-carousel/tasks"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	StalestFirstType = "StalestFirst"
+)
+
+// StalestFirstCycle republishes the whole collection, ordered so that the content which has gone longest without a carousel republish is published first.
+// The order is recomputed from the republish ledger every iteration, so content which failed to publish, or was missed by a restart, is retried first.
+type StalestFirstCycle struct {
+	*abstractCycle
+	Throttle Throttle `json:"throttle"`
+}
+
+func NewStalestFirstCycle(name string, uuidCollectionBuilder *native.NativeUUIDCollectionBuilder, dbCollection string, origin string, coolDown time.Duration, throttle Throttle, publishTask tasks.Task, republishLedger ledger.Ledger) Cycle {
+	cycle := &StalestFirstCycle{abstractCycle: newAbstractCycle(name, StalestFirstType, uuidCollectionBuilder, dbCollection, origin, coolDown, publishTask), Throttle: throttle}
+	cycle.ledger = republishLedger
+	return cycle
+}
+
+func (l *StalestFirstCycle) Start() {
+	if l.isCompleted() {
+		log.WithField("id", l.CycleID).WithField("name", l.CycleName).WithField("collection", l.DBCollection).Info("Not starting stalest first cycle, as it has already completed.")
+		l.complete()
+		return
+	}
+
+	log.WithField("id", l.CycleID).WithField("name", l.CycleName).WithField("collection", l.DBCollection).Info("Starting stalest first cycle.")
+	ctx, cancel := l.newContext()
+	l.cancel = cancel
+	l.UpdateState(startingState)
+	go l.supervise(ctx, l.start)
+}
+
+func (l *StalestFirstCycle) start(ctx context.Context) {
+	b := true
+	for b {
+		b = l.publishCollectionCycle(ctx)
+	}
+}
+
+func (l *StalestFirstCycle) publishCollectionCycle(ctx context.Context) bool {
+	uuidCollection, err := l.uuidCollectionBuilder.NewPrioritisedUUIDCollection(ctx, l.DBCollection, func(uuids []string) {
+		l.ledger.OrderByStaleness(l.DBCollection, uuids)
+	})
+	if err != nil {
+		log.WithField("id", l.CycleID).WithField("name", l.CycleName).WithField("collection", l.DBCollection).WithError(err).Warn("Failed to load the Native UUID Collection.")
+		l.UpdateState(stoppedState, unhealthyState)
+		return false
+	}
+	defer uuidCollection.Close()
+
+	iterationStart := time.Now()
+
+	previous := l.Metadata()
+	metadata := CycleMetadata{State: []string{runningState}, Iteration: previous.Iteration + 1, Attempts: previous.Attempts + 1, Total: uuidCollection.Length(), IterationStart: &iterationStart, Restarts: previous.Restarts, Panics: previous.Panics, LastPanic: previous.LastPanic}
+	return l.publishWholeCollection(ctx, uuidCollection, l.Throttle, metadata)
+}
+
+func (s *StalestFirstCycle) TransformToConfig() CycleConfig {
+	config := s.baseConfig()
+	config.Throttle = s.Throttle.Interval().String()
+	return config
+}
@@ -0,0 +1,101 @@
+package scheduler
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Financial-Times/publish-carousel/blacklist"
+	"github.com/Financial-Times/publish-carousel/ledger"
+	"github.com/Financial-Times/publish-carousel/native"
+	"github.com/Financial-Times/publish-carousel/tasks"
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func mockIterOf(uuids []string, closed chan struct{}) *native.MockDBIter {
+	iter := new(native.MockDBIter)
+
+	i := 0
+	iter.On("Next", mock.AnythingOfType("*map[string]interface {}")).Return(true).Run(func(args mock.Arguments) {
+		result := args.Get(0).(*map[string]interface{})
+		(*result)["uuid"] = bson.Binary{Kind: 0x04, Data: []byte(uuid.Parse(uuids[i]))}
+		i++
+	}).Times(len(uuids))
+	iter.On("Next", mock.AnythingOfType("*map[string]interface {}")).Return(false)
+
+	iter.On("Close").Run(func(arg1 mock.Arguments) {
+		closed <- struct{}{}
+	}).Return(nil)
+
+	happyIter(iter)
+	return iter
+}
+
+func TestStalestFirstCycleRun(t *testing.T) {
+	recent := uuid.NewUUID().String()
+	stalest := uuid.NewUUID().String()
+	never := uuid.NewUUID().String()
+
+	throttleCalled := make(chan struct{}, 1)
+	opened := make(chan struct{}, 1)
+	closed := make(chan struct{}, 1)
+
+	throttle := mockThrottle(time.Millisecond*50, throttleCalled)
+
+	iter := mockIterOf([]string{recent, stalest, never}, closed)
+	tx := mockTx(iter, nil)
+	db := mockDB(opened, tx, nil)
+
+	republishLedger := new(ledger.MockLedger)
+	republishLedger.On("OrderByStaleness", "collection", []string{recent, stalest, never}).Run(func(args mock.Arguments) {
+		uuids := args.Get(1).([]string)
+		uuids[0], uuids[1], uuids[2] = never, stalest, recent
+	}).Return()
+	republishLedger.On("Record", mock.MatchedBy(func(entry ledger.Entry) bool {
+		return entry.UUID == never && entry.TransactionID == "tid_"+never
+	})).Return()
+
+	task := mockTask(never, nil, nil)
+
+	uuidCollectionBuilder := native.NewNativeUUIDCollectionBuilder(db, nil, blacklist.NoOpBlacklist)
+	cycle := NewStalestFirstCycle("name", uuidCollectionBuilder, "collection", "origin", time.Millisecond*50, throttle, task, republishLedger)
+
+	cycle.SetMetadata(CycleMetadata{Iteration: 4, Attempts: 7})
+	cycle.Start()
+
+	<-opened
+	<-closed
+	<-throttleCalled
+
+	cycle.Stop()
+
+	<-throttleCalled
+
+	assert.Contains(t, cycle.State(), stoppedState)
+	mock.AssertExpectationsForObjects(t, throttle, iter, tx, db, task, republishLedger)
+
+	metadata := cycle.Metadata()
+	assert.Equal(t, 5, metadata.Iteration)
+	assert.Equal(t, 8, metadata.Attempts)
+	assert.Equal(t, 3, metadata.Total)
+	assert.Equal(t, 1, metadata.Completed)
+}
+
+func TestStalestFirstCycleMongoUnavailable(t *testing.T) {
+	opened := make(chan struct{}, 1)
+	db := mockDB(opened, new(native.MockTX), errors.New("no reachable servers"))
+
+	uuidCollectionBuilder := native.NewNativeUUIDCollectionBuilder(db, nil, blacklist.NoOpBlacklist)
+	cycle := NewStalestFirstCycle("name", uuidCollectionBuilder, "collection", "origin", time.Minute, new(MockThrottle), new(tasks.MockTask), new(ledger.MockLedger))
+
+	cycle.Start()
+	<-opened
+	time.Sleep(50 * time.Millisecond)
+
+	assert.Contains(t, cycle.State(), stoppedState)
+	assert.Contains(t, cycle.State(), unhealthyState)
+	db.AssertExpectations(t)
+}
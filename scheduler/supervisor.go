@@ -0,0 +1,134 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// RestartPolicy configures how unhealthy cycles are automatically restarted. Restarts are delayed with exponential backoff, starting at InitialBackoff and capped at MaxBackoff.
+// A cycle which has failed MaxRestarts consecutive times is left stopped and unhealthy until it is manually resumed. A MaxRestarts of 0 disables automatic restarts.
+type RestartPolicy struct {
+	MaxRestarts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// DefaultRestartPolicy restarts unhealthy cycles up to 5 times, starting after 30 seconds and backing off to a maximum of 30 minutes
+var DefaultRestartPolicy = RestartPolicy{MaxRestarts: 5, InitialBackoff: 30 * time.Second, MaxBackoff: 30 * time.Minute}
+
+// backoff returns the delay before the given restart, where the first restart is 0
+func (p RestartPolicy) backoff(restart int) time.Duration {
+	backoff := p.InitialBackoff
+	for i := 0; i < restart && backoff < p.MaxBackoff; i++ {
+		backoff *= 2
+	}
+
+	if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return backoff
+}
+
+// supervise runs the cycle until it is stopped, recovering from any panics, and restarts the cycle with exponential backoff whenever it becomes unhealthy.
+// The count of consecutive restarts is reset whenever the cycle successfully publishes content.
+// The workers which verify the cycle's publishes run for as long as it is supervised.
+func (a *abstractCycle) supervise(ctx context.Context, run func(ctx context.Context)) {
+	a.startVerifiers(ctx)
+
+	restarts := 0
+	for {
+		started := time.Now()
+		a.runRecovered(ctx, run)
+
+		if ctx.Err() != nil || !a.isUnhealthy() {
+			return
+		}
+
+		if a.publishedSince(started) {
+			restarts = 0
+		}
+
+		if restarts >= a.restartPolicy.MaxRestarts {
+			if a.restartPolicy.MaxRestarts > 0 {
+				log.WithField("id", a.CycleID).WithField("name", a.CycleName).WithField("collection", a.DBCollection).WithField("restarts", restarts).Error("Cycle is still unhealthy after the maximum number of restarts, and will need to be resumed manually.")
+			}
+			return
+		}
+
+		backoff := a.restartPolicy.backoff(restarts)
+		restarts++
+
+		next := time.Now().Add(backoff)
+		a.setNextRestart(&next)
+		log.WithField("id", a.CycleID).WithField("name", a.CycleName).WithField("collection", a.DBCollection).WithField("backoff", backoff.String()).WithField("restart", restarts).Warn("Cycle is unhealthy, scheduling restart.")
+
+		if !waitForRestart(ctx, backoff) {
+			a.setNextRestart(nil)
+			return
+		}
+
+		a.metadataLock.Lock()
+		a.CycleMetadata.Restarts++
+		a.CycleMetadata.NextRestart = nil
+		a.metadataLock.Unlock()
+
+		log.WithField("id", a.CycleID).WithField("name", a.CycleName).WithField("collection", a.DBCollection).WithField("restart", restarts).Info("Restarting unhealthy cycle.")
+		a.UpdateState(startingState)
+	}
+}
+
+// waitForRestart waits for the backoff, and returns false if the context is cancelled first
+func waitForRestart(ctx context.Context, backoff time.Duration) bool {
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// runRecovered runs the cycle, and marks it as stopped and unhealthy if it panics
+func (a *abstractCycle) runRecovered(ctx context.Context, run func(ctx context.Context)) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.WithField("id", a.CycleID).WithField("name", a.CycleName).WithField("collection", a.DBCollection).WithField("panic", r).WithField("stack", string(debug.Stack())).Error("Recovered from panic in cycle.")
+
+			a.metadataLock.Lock()
+			a.CycleMetadata.Panics++
+			a.CycleMetadata.LastPanic = fmt.Sprint(r)
+			a.metadataLock.Unlock()
+
+			a.UpdateState(stoppedState, unhealthyState)
+		}
+	}()
+
+	run(ctx)
+}
+
+func (a *abstractCycle) isUnhealthy() bool {
+	for _, state := range a.State() {
+		if state == unhealthyState {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *abstractCycle) publishedSince(t time.Time) bool {
+	a.metadataLock.RLock()
+	defer a.metadataLock.RUnlock()
+	return a.lastPublish.After(t)
+}
+
+func (a *abstractCycle) setNextRestart(next *time.Time) {
+	a.metadataLock.Lock()
+	defer a.metadataLock.Unlock()
+	a.CycleMetadata.NextRestart = next
+}
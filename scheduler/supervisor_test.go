@@ -0,0 +1,148 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Financial-Times/publish-carousel/blacklist"
+	"github.com/Financial-Times/publish-carousel/native"
+	"github.com/Financial-Times/publish-carousel/tasks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRestartPolicyBackoff(t *testing.T) {
+	policy := RestartPolicy{MaxRestarts: 10, InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}
+
+	assert.Equal(t, time.Second, policy.backoff(0))
+	assert.Equal(t, 2*time.Second, policy.backoff(1))
+	assert.Equal(t, 4*time.Second, policy.backoff(2))
+	assert.Equal(t, 5*time.Second, policy.backoff(3))
+	assert.Equal(t, 5*time.Second, policy.backoff(100))
+}
+
+func newSupervisedTestCycle(policy RestartPolicy) *abstractCycle {
+	c := newAbstractCycle("name", "type", nil, "collection", "origin", time.Minute, nil)
+	c.setOptions(cycleOptions{restartPolicy: policy})
+	return c
+}
+
+func TestSuperviseRecoversFromPanic(t *testing.T) {
+	c := newSupervisedTestCycle(RestartPolicy{})
+
+	c.supervise(context.Background(), func(ctx context.Context) {
+		panic("invalid uuid")
+	})
+
+	metadata := c.Metadata()
+	assert.Equal(t, 1, metadata.Panics)
+	assert.Equal(t, "invalid uuid", metadata.LastPanic)
+	assert.Equal(t, 0, metadata.Restarts)
+	assert.Equal(t, []string{stoppedState, unhealthyState}, c.State())
+}
+
+func TestSuperviseRestartsUnhealthyCycle(t *testing.T) {
+	c := newSupervisedTestCycle(RestartPolicy{MaxRestarts: 5, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond})
+
+	runs := 0
+	c.supervise(context.Background(), func(ctx context.Context) {
+		runs++
+		if runs < 3 {
+			c.UpdateState(stoppedState, unhealthyState)
+			return
+		}
+		c.UpdateState(stoppedState)
+	})
+
+	assert.Equal(t, 3, runs)
+	assert.Equal(t, 2, c.Metadata().Restarts)
+	assert.Nil(t, c.Metadata().NextRestart)
+	assert.Equal(t, []string{stoppedState}, c.State())
+}
+
+func TestSuperviseGivesUpAfterMaxRestarts(t *testing.T) {
+	c := newSupervisedTestCycle(RestartPolicy{MaxRestarts: 2, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond})
+
+	runs := 0
+	c.supervise(context.Background(), func(ctx context.Context) {
+		runs++
+		if runs == 1 {
+			panic("boom")
+		}
+		c.UpdateState(stoppedState, unhealthyState)
+	})
+
+	assert.Equal(t, 3, runs)
+	assert.Equal(t, 2, c.Metadata().Restarts)
+	assert.Equal(t, 1, c.Metadata().Panics)
+	assert.Equal(t, []string{stoppedState, unhealthyState}, c.State())
+}
+
+func TestSuperviseResetsRestartsAfterSuccessfulPublish(t *testing.T) {
+	c := newSupervisedTestCycle(RestartPolicy{MaxRestarts: 1, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+
+	runs := 0
+	c.supervise(context.Background(), func(ctx context.Context) {
+		runs++
+		if runs < 4 {
+			c.updateProgress("a-uuid", "tid_test", nil)
+		}
+		c.UpdateState(stoppedState, unhealthyState)
+	})
+
+	assert.Equal(t, 4, runs, "restarts should be reset while the cycle is publishing, and then stop once it fails after the only allowed restart")
+	assert.Equal(t, 3, c.Metadata().Restarts)
+}
+
+func TestSuperviseDoesNotRestartStoppedCycle(t *testing.T) {
+	c := newSupervisedTestCycle(RestartPolicy{MaxRestarts: 5, InitialBackoff: time.Hour, MaxBackoff: time.Hour})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		c.supervise(ctx, func(ctx context.Context) {
+			c.UpdateState(stoppedState, unhealthyState)
+		})
+		close(done)
+	}()
+
+	for c.Metadata().NextRestart == nil {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		assert.Fail(t, "supervisor should stop when the cycle is stopped")
+	}
+
+	assert.Nil(t, c.Metadata().NextRestart)
+	assert.Equal(t, 0, c.Metadata().Restarts)
+}
+
+func TestWholeCollectionCycleRestartsAfterMongoDBConnectionError(t *testing.T) {
+	opened := make(chan struct{}, 2)
+
+	tx := new(native.MockTX)
+	db := mockDB(opened, tx, errors.New("nein"))
+
+	uuidCollectionBuilder := native.NewNativeUUIDCollectionBuilder(db, nil, blacklist.NoOpBlacklist)
+
+	c := NewThrottledWholeCollectionCycle("name", uuidCollectionBuilder, "collection", "origin", time.Millisecond*50, new(MockThrottle), new(tasks.MockTask))
+	c.(configurableCycle).setOptions(cycleOptions{restartPolicy: RestartPolicy{MaxRestarts: 1, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}})
+
+	c.Start()
+	<-opened
+	<-opened
+
+	time.Sleep(50 * time.Millisecond)
+
+	assert.Equal(t, 1, c.Metadata().Restarts)
+	assert.Equal(t, []string{stoppedState, unhealthyState}, c.State())
+	db.AssertNumberOfCalls(t, "Open", 2)
+	mock.AssertExpectationsForObjects(t, tx, db)
+}
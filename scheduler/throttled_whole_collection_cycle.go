@@ -15,19 +15,26 @@ const (
 
 type ThrottledWholeCollectionCycle struct {
 	*abstractCycle
-	Throttle Throttle `json:"throttle"`
+	Throttle       Throttle `json:"throttle"`
+	UUIDCollection string   `json:"uuidCollection,omitempty"`
 }
 
 func NewThrottledWholeCollectionCycle(name string, uuidCollectionBuilder *native.NativeUUIDCollectionBuilder, dbCollection string, origin string, coolDown time.Duration, throttle Throttle, publishTask tasks.Task) Cycle {
-	return &ThrottledWholeCollectionCycle{newAbstractCycle(name, ThrottledWholeCollectionType, uuidCollectionBuilder, dbCollection, origin, coolDown, publishTask), throttle}
+	return &ThrottledWholeCollectionCycle{abstractCycle: newAbstractCycle(name, ThrottledWholeCollectionType, uuidCollectionBuilder, dbCollection, origin, coolDown, publishTask), Throttle: throttle}
 }
 
 func (l *ThrottledWholeCollectionCycle) Start() {
+	if l.isCompleted() {
+		log.WithField("id", l.CycleID).WithField("name", l.CycleName).WithField("collection", l.DBCollection).Info("Not starting throttled whole collection cycle, as it has already completed.")
+		l.complete()
+		return
+	}
+
 	log.WithField("id", l.CycleID).WithField("name", l.CycleName).WithField("collection", l.DBCollection).Info("Starting throttled whole collection cycle.")
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := l.newContext()
 	l.cancel = cancel
 	l.UpdateState(startingState)
-	go l.start(ctx)
+	go l.supervise(ctx, l.start)
 }
 
 func (l *ThrottledWholeCollectionCycle) start(ctx context.Context) {
@@ -40,42 +47,51 @@ func (l *ThrottledWholeCollectionCycle) start(ctx context.Context) {
 }
 
 func (l *ThrottledWholeCollectionCycle) publishCollectionCycle(ctx context.Context, skip int) (int, bool) {
-	uuidCollection, err := l.uuidCollectionBuilder.NewNativeUUIDCollection(ctx, l.DBCollection, skip)
+	previous := l.Metadata()
+
+	var resumeToken string
+	if skip > 0 {
+		resumeToken = previous.ResumeToken
+	}
 
+	uuidCollection, err := l.newUUIDCollection(ctx, skip, resumeToken)
 	if err != nil {
 		log.WithField("id", l.CycleID).WithField("name", l.CycleName).WithField("collection", l.DBCollection).WithError(err).Warn("Failed to consume UUIDs from the Native UUID Collection.")
 		l.UpdateState(stoppedState, unhealthyState)
 		return skip, false
 	}
+	defer uuidCollection.Close()
+
+	iteration := previous.Iteration
+	iterationStart := previous.IterationStart
+	if skip == 0 || iterationStart == nil {
+		now := time.Now()
+		iterationStart = &now
+	}
 
-	iteration := l.CycleMetadata.Iteration
 	if skip == 0 {
 		iteration++
 	}
 
-	metadata := CycleMetadata{Completed: skip, State: []string{runningState}, Iteration: iteration, Attempts: l.CycleMetadata.Attempts + 1, Total: uuidCollection.Length()}
-	l.SetMetadata(metadata)
-
-	if uuidCollection.Length() == 0 {
-		l.UpdateState(stoppedState, unhealthyState) // assume unhealthy, as the whole archive should *always* have content
+	metadata := CycleMetadata{Completed: skip, ResumeToken: resumeToken, State: []string{runningState}, Iteration: iteration, Attempts: previous.Attempts + 1, Total: uuidCollection.Length(), IterationStart: iterationStart, Restarts: previous.Restarts, Panics: previous.Panics, LastPanic: previous.LastPanic}
+	if !l.publishWholeCollection(ctx, uuidCollection, l.Throttle, metadata) {
 		return skip, false
 	}
 
-	stopped, err := l.publishCollection(ctx, uuidCollection, l.Throttle)
-	if stopped {
-		l.UpdateState(stoppedState)
-		return skip, false
-	}
+	return 0, true
+}
 
-	if err != nil {
-		log.WithField("id", l.CycleID).WithField("name", l.CycleName).WithField("collection", l.DBCollection).WithError(err).Error("Unexpected error occurred while publishing collection.")
-		l.UpdateState(stoppedState, unhealthyState)
-		return skip, false
+// newUUIDCollection opens the collection, skipping the uuids which were published before the cycle was stopped. Streaming collections resume after the _id of the resume token instead, if there is one.
+func (l *ThrottledWholeCollectionCycle) newUUIDCollection(ctx context.Context, skip int, resumeToken string) (native.UUIDCollection, error) {
+	if l.UUIDCollection == native.StreamingUUIDCollectionType {
+		return l.uuidCollectionBuilder.NewStreamingUUIDCollection(l.DBCollection, skip, resumeToken)
 	}
-
-	return 0, true
+	return l.uuidCollectionBuilder.NewNativeUUIDCollection(ctx, l.DBCollection, skip)
 }
 
 func (s *ThrottledWholeCollectionCycle) TransformToConfig() CycleConfig {
-	return CycleConfig{Name: s.CycleName, Type: s.CycleType, Collection: s.DBCollection, CoolDown: s.CoolDown, Origin: s.Origin, Throttle: s.Throttle.Interval().String()}
+	config := s.baseConfig()
+	config.Throttle = s.Throttle.Interval().String()
+	config.UUIDCollection = s.UUIDCollection
+	return config
 }
@@ -1,6 +1,7 @@
 package scheduler
 
 import (
+	"context"
 	"errors"
 	"sync"
 	"testing"
@@ -433,3 +434,195 @@ func TestWholeCollectionCycleCanBeStoppedEvenIfNotStarted(t *testing.T) {
 
 	mock.AssertExpectationsForObjects(t, db, task, throttle)
 }
+
+func TestWholeCollectionCycleCompletesAfterMaxIterations(t *testing.T) {
+	expectedUUID := uuid.NewUUID().String()
+	collectionSize := 3
+
+	task := mockTask(expectedUUID, nil, nil)
+
+	throttleCalled := make(chan struct{}, collectionSize+1)
+	opened := make(chan struct{}, 2)
+	closed := make(chan struct{}, 2)
+
+	throttle := mockThrottle(time.Millisecond, throttleCalled)
+
+	iter := mockIterWithCollectionSize(expectedUUID, collectionSize, closed)
+	happyIter(iter)
+
+	tx := mockTx(iter, nil)
+	db := mockDB(opened, tx, nil)
+
+	uuidCollectionBuilder := native.NewNativeUUIDCollectionBuilder(db, nil, blacklist.NoOpBlacklist)
+
+	c := NewThrottledWholeCollectionCycle("name", uuidCollectionBuilder, "collection", "origin", time.Millisecond*50, throttle, task)
+	c.(configurableCycle).setOptions(cycleOptions{MaxIterations: 1})
+
+	c.Start()
+
+	<-opened
+	<-closed
+
+	for i := 0; i < collectionSize; i++ {
+		<-throttleCalled
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	assert.Equal(t, []string{completedState}, c.State())
+	assert.Equal(t, 1, c.Metadata().Iteration)
+	db.AssertNumberOfCalls(t, "Open", 1)
+
+	c.Start()
+	time.Sleep(50 * time.Millisecond)
+
+	assert.Equal(t, []string{completedState}, c.State(), "a completed cycle should not be restarted")
+	db.AssertNumberOfCalls(t, "Open", 1)
+}
+
+func TestWholeCollectionCycleDoesNotStartWhenExpired(t *testing.T) {
+	db := new(native.MockDB)
+	task := new(tasks.MockTask)
+	throttle := new(MockThrottle)
+
+	uuidCollectionBuilder := native.NewNativeUUIDCollectionBuilder(db, nil, blacklist.NoOpBlacklist)
+
+	c := NewThrottledWholeCollectionCycle("test-cycle", uuidCollectionBuilder, "a-collection", "a-origin-id", 1*time.Second, throttle, task)
+
+	expiresAt := time.Now().Add(-1 * time.Minute)
+	c.(configurableCycle).setOptions(cycleOptions{expiresAt: &expiresAt})
+
+	c.Start()
+
+	assert.Equal(t, []string{completedState}, c.State())
+	mock.AssertExpectationsForObjects(t, db, task, throttle)
+}
+
+func TestWholeCollectionCycleCompletesWhenExpired(t *testing.T) {
+	expectedUUID := uuid.NewUUID().String()
+
+	task := mockTask(expectedUUID, nil, nil)
+
+	throttleCalled := make(chan struct{}, 100)
+	opened := make(chan struct{}, 1)
+	closed := make(chan struct{}, 1)
+
+	throttle := mockThrottle(time.Millisecond*10, throttleCalled)
+
+	iter := mockIterWithCollectionSize(expectedUUID, 100, closed)
+	happyIter(iter)
+
+	tx := mockTx(iter, nil)
+	db := mockDB(opened, tx, nil)
+
+	uuidCollectionBuilder := native.NewNativeUUIDCollectionBuilder(db, nil, blacklist.NoOpBlacklist)
+
+	c := NewThrottledWholeCollectionCycle("name", uuidCollectionBuilder, "collection", "origin", time.Millisecond*50, throttle, task)
+
+	expiresAt := time.Now().Add(300 * time.Millisecond)
+	c.(configurableCycle).setOptions(cycleOptions{expiresAt: &expiresAt})
+
+	c.Start()
+
+	<-opened
+	<-closed
+
+	time.Sleep(500 * time.Millisecond)
+
+	assert.Equal(t, []string{completedState}, c.State())
+	assert.True(t, c.Metadata().Completed > 0)
+	assert.True(t, c.Metadata().Completed < 100)
+}
+
+func TestWholeCollectionCycleWithStreamingUUIDCollection(t *testing.T) {
+	expectedUUID := uuid.NewUUID().String()
+	task := mockTask(expectedUUID, nil, nil)
+
+	iter := new(native.MockDBIter)
+	iter.On("Next", mock.AnythingOfType("*map[string]interface {}")).Run(func(args mock.Arguments) {
+		result := args.Get(0).(*map[string]interface{})
+		(*result)["_id"] = 1
+		(*result)["uuid"] = bson.Binary{Kind: 0x04, Data: []byte(uuid.Parse(expectedUUID))}
+	}).Return(true).Once()
+	iter.On("Next", mock.AnythingOfType("*map[string]interface {}")).Return(false)
+	iter.On("Close").Return(nil)
+	happyIter(iter)
+
+	closed := make(chan struct{}, 1)
+	tx := new(native.MockTX)
+	tx.On("FindUUIDsAfterID", "collection", nil, 0, 1000).Return(iter, 1, nil)
+	tx.On("Close").Run(func(arg1 mock.Arguments) {
+		closed <- struct{}{}
+	}).Return()
+
+	opened := make(chan struct{}, 1)
+	db := mockDB(opened, tx, nil)
+
+	throttleCalled := make(chan struct{}, 2)
+	throttle := mockThrottle(time.Millisecond, throttleCalled)
+
+	uuidCollectionBuilder := native.NewNativeUUIDCollectionBuilder(db, nil, blacklist.NoOpBlacklist)
+
+	c := NewThrottledWholeCollectionCycle("name", uuidCollectionBuilder, "collection", "origin", time.Millisecond*50, throttle, task)
+	c.(*ThrottledWholeCollectionCycle).UUIDCollection = native.StreamingUUIDCollectionType
+	c.(configurableCycle).setOptions(cycleOptions{MaxIterations: 1})
+
+	c.Start()
+	<-opened
+	<-closed
+
+	time.Sleep(50 * time.Millisecond)
+
+	assert.Equal(t, []string{completedState}, c.State())
+	assert.Equal(t, 1, c.Metadata().Total)
+	assert.Equal(t, native.StreamingUUIDCollectionType, c.(*ThrottledWholeCollectionCycle).UUIDCollection)
+
+	mock.AssertExpectationsForObjects(t, db, tx, iter, task)
+}
+
+func TestWholeCollectionCycleResumesStreamingUUIDCollectionAfterLastID(t *testing.T) {
+	expectedUUID := uuid.NewUUID().String()
+	task := mockTask(expectedUUID, nil, nil)
+
+	iter := new(native.MockDBIter)
+	iter.On("Next", mock.AnythingOfType("*map[string]interface {}")).Run(func(args mock.Arguments) {
+		result := args.Get(0).(*map[string]interface{})
+		(*result)["_id"] = 7
+		(*result)["uuid"] = bson.Binary{Kind: 0x04, Data: []byte(uuid.Parse(expectedUUID))}
+	}).Return(true).Once()
+	iter.On("Next", mock.AnythingOfType("*map[string]interface {}")).Return(false)
+	iter.On("Close").Return(nil)
+	happyIter(iter)
+
+	resumed := new(native.MockDBIter)
+	resumed.On("Next", mock.AnythingOfType("*map[string]interface {}")).Return(false)
+	resumed.On("Close").Return(nil)
+	happyIter(resumed)
+
+	tx := new(native.MockTX)
+	tx.On("FindUUIDsAfterID", "collection", nil, 0, 1000).Return(iter, 3, nil).Once()
+	tx.On("FindUUIDsAfterID", "collection", 7, 0, 1000).Return(resumed, 3, nil).Once()
+	tx.On("Close").Return()
+
+	db := new(native.MockDB)
+	db.On("Open").Return(tx, nil)
+
+	throttle := new(MockThrottle)
+	throttle.On("Queue").Return(nil)
+
+	uuidCollectionBuilder := native.NewNativeUUIDCollectionBuilder(db, nil, blacklist.NoOpBlacklist)
+
+	c := NewThrottledWholeCollectionCycle("name", uuidCollectionBuilder, "collection", "origin", time.Minute, throttle, task).(*ThrottledWholeCollectionCycle)
+	c.UUIDCollection = native.StreamingUUIDCollectionType
+	c.setOptions(cycleOptions{MaxIterations: 1})
+
+	c.publishCollectionCycle(context.Background(), 0)
+	token := c.Metadata().ResumeToken
+	assert.NotEmpty(t, token, "the _id of the last published uuid is recorded")
+
+	c.SetMetadata(CycleMetadata{Completed: 1, Iteration: 1, ResumeToken: token})
+	c.publishCollectionCycle(context.Background(), 1)
+
+	assert.Equal(t, token, c.Metadata().ResumeToken)
+	mock.AssertExpectationsForObjects(t, db, tx, iter, resumed, task)
+}
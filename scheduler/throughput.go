@@ -0,0 +1,72 @@
+package scheduler
+
+import (
+	"sync"
+	"time"
+)
+
+const throughputWindow = 10 * time.Minute
+
+// throughput tracks the times of recent publishes, in order to compute the observed publish rate over a sliding window
+type throughput struct {
+	sync.Mutex
+	window    time.Duration
+	since     time.Time
+	publishes []time.Time
+}
+
+func newThroughput(window time.Duration) *throughput {
+	return &throughput{window: window}
+}
+
+func (t *throughput) record(at time.Time) {
+	t.Lock()
+	defer t.Unlock()
+
+	if t.since.IsZero() {
+		t.since = at
+	}
+
+	t.publishes = append(t.publishes, at)
+	t.prune(at)
+}
+
+// rate returns the number of publishes per minute within the window. If the rate has been observed for less time than the window, only the observed time is used.
+func (t *throughput) rate(now time.Time) float64 {
+	t.Lock()
+	defer t.Unlock()
+
+	t.prune(now)
+	if len(t.publishes) == 0 {
+		return 0
+	}
+
+	observed := now.Sub(t.since)
+	if observed > t.window {
+		observed = t.window
+	}
+
+	if observed <= 0 {
+		return 0
+	}
+
+	return float64(len(t.publishes)) / observed.Minutes()
+}
+
+func (t *throughput) reset() {
+	t.Lock()
+	defer t.Unlock()
+
+	t.since = time.Time{}
+	t.publishes = nil
+}
+
+func (t *throughput) prune(now time.Time) {
+	cutoff := now.Add(-1 * t.window)
+
+	i := 0
+	for i < len(t.publishes) && t.publishes[i].Before(cutoff) {
+		i++
+	}
+	t.publishes = t.publishes[i:]
+}
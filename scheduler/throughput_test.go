@@ -0,0 +1,43 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThroughputRate(t *testing.T) {
+	tp := newThroughput(10 * time.Minute)
+	start := time.Now()
+
+	for i := 0; i < 10; i++ {
+		tp.record(start.Add(time.Duration(i) * 30 * time.Second))
+	}
+
+	assert.InDelta(t, 2.0, tp.rate(start.Add(5*time.Minute)), 0.001, "10 publishes in 5 minutes")
+}
+
+func TestThroughputRateSlidesWindow(t *testing.T) {
+	tp := newThroughput(time.Minute)
+	start := time.Now()
+
+	for i := 0; i < 60; i++ {
+		tp.record(start.Add(time.Duration(i) * time.Second))
+	}
+
+	assert.InDelta(t, 60.0, tp.rate(start.Add(time.Minute)), 1, "all publishes are within the window")
+	assert.InDelta(t, 30.0, tp.rate(start.Add(90*time.Second)), 1, "half of the publishes have left the window")
+	assert.Equal(t, 0.0, tp.rate(start.Add(5*time.Minute)), "all publishes have left the window")
+}
+
+func TestThroughputReset(t *testing.T) {
+	tp := newThroughput(time.Minute)
+	start := time.Now()
+
+	tp.record(start)
+	tp.record(start.Add(time.Second))
+	tp.reset()
+
+	assert.Equal(t, 0.0, tp.rate(start.Add(2*time.Second)))
+}
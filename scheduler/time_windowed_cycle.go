@@ -48,13 +48,24 @@ func (s *abstractTimeWindowedCycle) publishCollectionCycle(ctx context.Context,
 	defer uuidCollection.Close()
 
 	copiedTime := startTime // Copy so that we don't change the time for the cycle
+	iterationStart := time.Now()
 
-	metadata := CycleMetadata{State: []string{runningState}, Attempts: s.CycleMetadata.Attempts + 1, Total: uuidCollection.Length(), Start: &copiedTime, End: &endTime}
+	previous := s.Metadata()
+	metadata := CycleMetadata{State: []string{runningState}, Iteration: previous.Iteration + 1, Attempts: previous.Attempts + 1, Total: uuidCollection.Length(), Start: &copiedTime, End: &endTime, IterationStart: &iterationStart, Restarts: previous.Restarts, Panics: previous.Panics, LastPanic: previous.LastPanic}
 	s.SetMetadata(metadata)
 
 	if uuidCollection.Length() == 0 {
-		endTime = s.performCooldown(coolDownState)
-		return endTime, true
+		if s.reachedMaxIterations() {
+			s.complete()
+			return endTime, false
+		}
+
+		if ctx.Err() != nil {
+			s.stopped(ctx)
+			return endTime, false
+		}
+
+		return s.performCooldown(ctx, coolDownState)
 	}
 
 	t, cancel := throttle(uuidCollection.Length() + 1) // add one to the length to increase the wait time
@@ -62,7 +73,7 @@ func (s *abstractTimeWindowedCycle) publishCollectionCycle(ctx context.Context,
 
 	cancel()
 	if stopped {
-		s.UpdateState(stoppedState)
+		s.stopped(ctx)
 		return endTime, false
 	}
 
@@ -72,12 +83,36 @@ func (s *abstractTimeWindowedCycle) publishCollectionCycle(ctx context.Context,
 		return endTime, false
 	}
 
+	if s.reachedMaxIterations() {
+		s.complete()
+		return endTime, false
+	}
+
 	t.Queue() // ensure we wait a reasonable amount of time before the next iteration
 	return time.Now(), true
 }
 
-func (s *abstractTimeWindowedCycle) performCooldown(states ...string) time.Time {
+// performCooldown waits for the cool down before the next window, and returns false if the cycle was stopped while it waited
+func (s *abstractTimeWindowedCycle) performCooldown(ctx context.Context, states ...string) (time.Time, bool) {
 	s.UpdateState(states...)
-	time.Sleep(s.coolDown)
-	return time.Now()
+
+	timer := time.NewTimer(s.coolDown)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		s.stopped(ctx)
+		return time.Now(), false
+	case <-timer.C:
+		return time.Now(), true
+	}
+}
+
+// stopped records why the cycle stopped once its context is done, which completes it if its deadline was reached
+func (s *abstractTimeWindowedCycle) stopped(ctx context.Context) {
+	if ctx.Err() == context.DeadlineExceeded {
+		s.complete()
+	} else {
+		s.UpdateState(stoppedState)
+	}
 }
@@ -0,0 +1,45 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPerformCooldownStopsWhenCycleIsStopped(t *testing.T) {
+	s := newAbstractTimeWindowedCycle(newAbstractCycle("name", "test", nil, "collection", "origin", time.Hour, nil), time.Minute, time.Second, time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+
+	start := time.Now()
+	_, carryOn := s.performCooldown(ctx, coolDownState)
+	assert.False(t, carryOn)
+	assert.True(t, time.Since(start) < time.Minute, "the cool down is cut short")
+	assert.Equal(t, []string{stoppedState}, s.State())
+}
+
+func TestPerformCooldownCompletesWhenDeadlineIsReached(t *testing.T) {
+	s := newAbstractTimeWindowedCycle(newAbstractCycle("name", "test", nil, "collection", "origin", time.Hour, nil), time.Minute, time.Second, time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, carryOn := s.performCooldown(ctx, coolDownState)
+	assert.False(t, carryOn)
+	assert.Equal(t, []string{completedState}, s.State())
+}
+
+func TestPerformCooldown(t *testing.T) {
+	s := newAbstractTimeWindowedCycle(newAbstractCycle("name", "test", nil, "collection", "origin", 10*time.Millisecond, nil), time.Minute, time.Second, time.Minute)
+
+	end, carryOn := s.performCooldown(context.Background(), coolDownState)
+	assert.True(t, carryOn)
+	assert.False(t, end.IsZero())
+	assert.Equal(t, []string{coolDownState}, s.State())
+}
@@ -0,0 +1,168 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// pendingVerification is a publish which is waiting to be verified
+type pendingVerification struct {
+	uuid      string
+	txID      string
+	published time.Time
+}
+
+// startVerifiers starts the workers which verify the cycle's publishes, with a new queue. The workers run until the context is cancelled, after which any publishes which are still queued are discarded.
+func (a *abstractCycle) startVerifiers(ctx context.Context) {
+	if a.verifier == nil || a.Verification == nil {
+		return
+	}
+
+	workers, size := a.Verification.Pool()
+	queue := make(chan pendingVerification, size)
+
+	a.verificationLock.Lock()
+	a.verificationQueue = queue
+	a.verificationLock.Unlock()
+
+	running := &sync.WaitGroup{}
+	for i := 0; i < workers; i++ {
+		running.Add(1)
+		go a.verifyQueued(ctx, queue, running)
+	}
+
+	go func() {
+		<-ctx.Done()
+		a.closeVerificationQueue(queue)
+		running.Wait()
+		a.discardVerifications(queue)
+	}()
+}
+
+// stopVerifiers stops any more publishes being queued for verification. It does not wait for the workers, which finish and discard the rest of the queue once the cycle's context is cancelled, as a verification can take minutes.
+func (a *abstractCycle) stopVerifiers() {
+	a.verificationLock.Lock()
+	defer a.verificationLock.Unlock()
+	a.verificationQueue = nil
+}
+
+// closeVerificationQueue stops any more publishes being added to the queue, unless the cycle has already been restarted with a new queue
+func (a *abstractCycle) closeVerificationQueue(queue chan pendingVerification) {
+	a.verificationLock.Lock()
+	defer a.verificationLock.Unlock()
+
+	if a.verificationQueue == queue {
+		a.verificationQueue = nil
+	}
+}
+
+// discardVerifications empties a queue which has been closed, once its workers have finished
+func (a *abstractCycle) discardVerifications(queue chan pendingVerification) {
+	for {
+		select {
+		case <-queue:
+			a.verifications.Done()
+		default:
+			return
+		}
+	}
+}
+
+// enqueueVerification queues the publish to be verified. If the queue is full, the publish is not verified, and is counted as dropped. Publishes are not verified once the cycle has stopped.
+func (a *abstractCycle) enqueueVerification(uuid string, txId string, published time.Time) {
+	a.verificationLock.Lock()
+	defer a.verificationLock.Unlock()
+
+	if a.verificationQueue == nil {
+		return
+	}
+
+	a.verifications.Add(1)
+
+	select {
+	case a.verificationQueue <- pendingVerification{uuid: uuid, txID: txId, published: published}:
+	default:
+		a.verifications.Done()
+		log.WithField("id", a.CycleID).WithField("name", a.Name()).WithField("collection", a.DBCollection).WithField("uuid", uuid).WithField("transaction_id", txId).Warn("Verification queue is full, so the publish will not be verified.")
+		a.updateVerificationDropped()
+	}
+}
+
+func (a *abstractCycle) verifyQueued(ctx context.Context, queue chan pendingVerification, running *sync.WaitGroup) {
+	defer running.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case v := <-queue:
+			if ctx.Err() != nil {
+				a.verifications.Done()
+				return
+			}
+			a.verify(ctx, v)
+		}
+	}
+}
+
+// verify reads the published content back from the verification url, and records whether it was verified. Verifications which are cancelled by the cycle stopping are not recorded.
+func (a *abstractCycle) verify(ctx context.Context, v pendingVerification) {
+	defer a.verifications.Done()
+	defer func() {
+		if r := recover(); r != nil {
+			log.WithField("id", a.CycleID).WithField("name", a.Name()).WithField("collection", a.DBCollection).WithField("uuid", v.uuid).WithField("panic", r).WithField("stack", string(debug.Stack())).Error("Recovered from panic while verifying publish.")
+			a.updateVerification(v.uuid, v.txID, 0, fmt.Errorf("Verification panicked: %v", r))
+		}
+	}()
+
+	err := a.verifier.Verify(ctx, *a.Verification, v.uuid, v.txID, v.published)
+	if ctx.Err() != nil {
+		return
+	}
+
+	if err != nil {
+		log.WithField("id", a.CycleID).WithField("name", a.Name()).WithField("collection", a.DBCollection).WithField("uuid", v.uuid).WithField("transaction_id", v.txID).WithError(err).Warn("Failed to verify publish.")
+	}
+	a.updateVerification(v.uuid, v.txID, time.Since(v.published), err)
+}
+
+// updateVerification counts the outcome of a verification. Unverified publishes are also recorded as failures.
+func (a *abstractCycle) updateVerification(uuid string, txId string, latency time.Duration, err error) {
+	a.metadataLock.Lock()
+	defer a.metadataLock.Unlock()
+
+	verification := a.verificationMetadata()
+	if err != nil {
+		verification.Unverified++
+		a.CycleMetadata.Failures = recordFailure(a.CycleMetadata.Failures, PublishFailure{UUID: uuid, TransactionID: txId, Error: err.Error(), Time: time.Now()})
+	} else {
+		average, _ := time.ParseDuration(verification.AverageLatency)
+		verification.Verified++
+		verification.LastLatency = latency.String()
+		verification.AverageLatency = (average + (latency-average)/time.Duration(verification.Verified)).String()
+	}
+
+	a.CycleMetadata.Verification = &verification
+}
+
+// updateVerificationDropped counts a publish which was not verified, because the verification queue was full
+func (a *abstractCycle) updateVerificationDropped() {
+	a.metadataLock.Lock()
+	defer a.metadataLock.Unlock()
+
+	verification := a.verificationMetadata()
+	verification.Dropped++
+	a.CycleMetadata.Verification = &verification
+}
+
+func (a *abstractCycle) verificationMetadata() VerificationMetadata {
+	if a.CycleMetadata.Verification != nil {
+		return *a.CycleMetadata.Verification
+	}
+	return VerificationMetadata{}
+}
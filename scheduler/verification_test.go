@@ -0,0 +1,111 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Financial-Times/publish-carousel/verify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerificationQueueDropsPublishesWhenFull(t *testing.T) {
+	config := &verify.Config{URL: "http://document-store-api:8080/content/{uuid}", Workers: 1, Queue: 1}
+	started := make(chan struct{})
+	verifier := new(verify.MockVerifier)
+	verifier.On("Verify", mock.Anything, *config, "uuid-1", "tid_1", mock.AnythingOfType("time.Time")).Run(func(args mock.Arguments) {
+		close(started)
+		<-args.Get(0).(context.Context).Done()
+	}).Return(context.Canceled)
+
+	c := newAbstractCycle("name", "test", nil, "collection", "origin", time.Minute, nil)
+	c.setOptions(cycleOptions{Verification: config, verifier: verifier})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	c.startVerifiers(ctx)
+	queue := c.verificationQueue
+
+	c.enqueueVerification("uuid-1", "tid_1", time.Now())
+	<-started
+	c.enqueueVerification("uuid-2", "tid_2", time.Now())
+	c.enqueueVerification("uuid-3", "tid_3", time.Now())
+
+	metadata := c.Metadata()
+	require.NotNil(t, metadata.Verification)
+	assert.Equal(t, 1, metadata.Verification.Dropped)
+
+	c.Stop()
+	c.verifications.Wait()
+	assert.Len(t, queue, 0, "queued publishes are discarded when the cycle stops")
+}
+
+func TestVerificationWorkersRecoverFromPanics(t *testing.T) {
+	config := &verify.Config{URL: "http://document-store-api:8080/content/{uuid}", Workers: 1}
+	verifier := new(verify.MockVerifier)
+	verifier.On("Verify", mock.Anything, *config, "uuid-1", "tid_1", mock.AnythingOfType("time.Time")).Run(func(mock.Arguments) {
+		panic("computer says no")
+	})
+	verifier.On("Verify", mock.Anything, *config, "uuid-2", "tid_2", mock.AnythingOfType("time.Time")).Return(nil)
+
+	c := newAbstractCycle("name", "test", nil, "collection", "origin", time.Minute, nil)
+	c.setOptions(cycleOptions{Verification: config, verifier: verifier})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	c.startVerifiers(ctx)
+
+	c.enqueueVerification("uuid-1", "tid_1", time.Now())
+	c.enqueueVerification("uuid-2", "tid_2", time.Now())
+	c.verifications.Wait()
+	c.Stop()
+
+	metadata := c.Metadata()
+	require.NotNil(t, metadata.Verification)
+	assert.Equal(t, 1, metadata.Verification.Verified, "the worker carries on after a panic")
+	assert.Equal(t, 1, metadata.Verification.Unverified)
+	require.Len(t, metadata.Failures, 1)
+	assert.Equal(t, "Verification panicked: computer says no", metadata.Failures[0].Error)
+}
+
+func TestStopDoesNotWaitForVerificationWorkers(t *testing.T) {
+	config := &verify.Config{URL: "http://document-store-api:8080/content/{uuid}", Workers: 2}
+	started := make(chan struct{})
+	release := make(chan struct{})
+	verifier := new(verify.MockVerifier)
+	verifier.On("Verify", mock.Anything, *config, "uuid-1", "tid_1", mock.AnythingOfType("time.Time")).Run(func(args mock.Arguments) {
+		close(started)
+		<-release
+	}).Return(context.Canceled)
+
+	c := newAbstractCycle("name", "test", nil, "collection", "origin", time.Minute, nil)
+	c.setOptions(cycleOptions{Verification: config, verifier: verifier})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	c.startVerifiers(ctx)
+
+	c.enqueueVerification("uuid-1", "tid_1", time.Now())
+	<-started
+
+	stopped := make(chan struct{})
+	go func() {
+		c.Stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("stop should not wait for a verification in progress")
+	}
+
+	c.enqueueVerification("uuid-2", "tid_2", time.Now())
+	close(release)
+
+	c.verifications.Wait()
+	assert.Nil(t, c.Metadata().Verification, "verifications which are cancelled by the cycle stopping are not counted, and nothing is queued once the cycle has stopped")
+	verifier.AssertNotCalled(t, "Verify", mock.Anything, *config, "uuid-2", "tid_2", mock.Anything)
+}
@@ -0,0 +1,133 @@
+package schema
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/Financial-Times/publish-carousel/native"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// ManifestFile lists the schemas of a schemas directory, and the content they validate
+const ManifestFile = "schemas.yml"
+
+// Mapping selects the content which a schema file validates, by content type or origin system id
+type Mapping struct {
+	File          string   `yaml:"file"`
+	ContentTypes  []string `yaml:"contentTypes"`
+	OriginSystems []string `yaml:"originSystems"`
+}
+
+type manifest struct {
+	Schemas []Mapping `yaml:"schemas"`
+}
+
+type loadedSchema struct {
+	Mapping
+	schema *Schema
+}
+
+// Registry holds the schemas which native content is validated against before it is published
+type Registry struct {
+	schemas []loadedSchema
+}
+
+// ValidationError lists every way in which some native content breaks its schema
+type ValidationError struct {
+	Schema   string
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("Content does not match the schema %v: %v", e.Schema, strings.Join(e.Problems, "; "))
+}
+
+// LoadDir compiles the schemas listed in the schemas.yml manifest of the directory, which may $ref each other by relative path. If no directory is provided, no content is validated.
+func LoadDir(dir string) (*Registry, error) {
+	if strings.TrimSpace(dir) == "" {
+		return nil, nil
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil, err
+	}
+
+	m := manifest{}
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+
+	registry := &Registry{}
+	compiler := jsonschema.NewCompiler()
+	for _, mapping := range m.Schemas {
+		if strings.TrimSpace(mapping.File) == "" {
+			return nil, fmt.Errorf("Please provide the file of every schema in %v", ManifestFile)
+		}
+
+		if len(mapping.ContentTypes) == 0 && len(mapping.OriginSystems) == 0 {
+			return nil, fmt.Errorf("Please provide the content types or origin systems which schema %v validates", mapping.File)
+		}
+
+		schema, err := compile(compiler, filepath.Join(dir, mapping.File))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid schema %v: %v", mapping.File, err)
+		}
+
+		registry.schemas = append(registry.schemas, loadedSchema{Mapping: mapping, schema: schema})
+	}
+
+	return registry, nil
+}
+
+// For returns the schema which validates the content, and the name of its file. Schemas for the content type of the content take precedence over schemas for its origin system.
+func (r *Registry) For(content *native.Content) (*Schema, string, bool) {
+	if r == nil {
+		return nil, "", false
+	}
+
+	contentType := strings.TrimSpace(strings.Split(content.ContentType, ";")[0])
+	for _, s := range r.schemas {
+		if oneOf(contentType, s.ContentTypes) {
+			return s.schema, s.File, true
+		}
+	}
+
+	for _, s := range r.schemas {
+		if oneOf(content.OriginSystemID, s.OriginSystems) {
+			return s.schema, s.File, true
+		}
+	}
+
+	return nil, "", false
+}
+
+// Validate checks the body of the content against its schema, returning a *ValidationError if it does not match. Content without a schema is always valid, as is all content for a nil registry.
+func (r *Registry) Validate(content *native.Content) error {
+	schema, name, ok := r.For(content)
+	if !ok {
+		return nil
+	}
+
+	problems, err := schema.Validate(content.Body)
+	if err != nil {
+		return fmt.Errorf("Failed to validate content against the schema %v: %v", name, err)
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Schema: name, Problems: problems}
+	}
+	return nil
+}
+
+func oneOf(val string, options []string) bool {
+	for _, option := range options {
+		if strings.EqualFold(val, option) {
+			return true
+		}
+	}
+	return false
+}
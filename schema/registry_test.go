@@ -0,0 +1,109 @@
+package schema
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Financial-Times/publish-carousel/native"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeSchemasDir(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "schemas")
+	require.NoError(t, err)
+
+	for name, data := range files {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0644))
+	}
+	return dir
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := writeSchemasDir(t, map[string]string{
+		ManifestFile: `
+schemas:
+   - file: article.json
+     contentTypes:
+        - application/vnd.ft-upp-article+json
+   - file: methode.json
+     originSystems:
+        - http://cmdb.ft.com/systems/methode-web-pub
+`,
+		"article.json": articleSchema,
+		"methode.json": `{"type": "object", "required": ["uuid"]}`,
+	})
+	defer os.RemoveAll(dir)
+
+	registry, err := LoadDir(dir)
+	require.NoError(t, err)
+
+	methode := &native.Content{Body: map[string]interface{}{"uuid": "5f2d6c2e-2a5c-11e7-9ec8-168383da43b7"}, ContentType: "application/json", OriginSystemID: "http://cmdb.ft.com/systems/methode-web-pub"}
+	_, name, ok := registry.For(methode)
+	assert.True(t, ok)
+	assert.Equal(t, "methode.json", name)
+	assert.NoError(t, registry.Validate(methode))
+
+	article := &native.Content{Body: map[string]interface{}{"uuid": "5f2d6c2e-2a5c-11e7-9ec8-168383da43b7"}, ContentType: "application/vnd.ft-upp-article+json; charset=utf-8", OriginSystemID: "http://cmdb.ft.com/systems/methode-web-pub"}
+	_, name, ok = registry.For(article)
+	assert.True(t, ok)
+	assert.Equal(t, "article.json", name, "schemas for the content type take precedence")
+
+	err = registry.Validate(article)
+	require.IsType(t, &ValidationError{}, err)
+	assert.EqualError(t, err, "Content does not match the schema article.json: $: missing properties: 'title'")
+
+	video := &native.Content{Body: map[string]interface{}{}, ContentType: "application/json", OriginSystemID: "http://cmdb.ft.com/systems/next-video-editor"}
+	_, _, ok = registry.For(video)
+	assert.False(t, ok)
+	assert.NoError(t, registry.Validate(video), "content without a schema is not validated")
+}
+
+func TestLoadNoDir(t *testing.T) {
+	registry, err := LoadDir("")
+	assert.NoError(t, err)
+	assert.Nil(t, registry)
+	assert.NoError(t, registry.Validate(&native.Content{Body: map[string]interface{}{}}))
+}
+
+func TestLoadInvalidDir(t *testing.T) {
+	tests := map[string]map[string]string{
+		"Please provide the file of every schema in schemas.yml":                                 {ManifestFile: "schemas:\n   - contentTypes: [application/json]\n"},
+		"Please provide the content types or origin systems which schema article.json validates": {ManifestFile: "schemas:\n   - file: article.json\n", "article.json": articleSchema},
+	}
+
+	for expected, files := range tests {
+		dir := writeSchemasDir(t, files)
+		_, err := LoadDir(dir)
+		assert.EqualError(t, err, expected)
+		os.RemoveAll(dir)
+	}
+
+	dir := writeSchemasDir(t, map[string]string{ManifestFile: "schemas:\n   - file: article.json\n     contentTypes: [application/json]\n", "article.json": `{"$ref": "#/definitions/article"}`})
+	defer os.RemoveAll(dir)
+
+	_, err := LoadDir(dir)
+	require.Error(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "Invalid schema article.json: "), "schemas which cannot be compiled are rejected on startup")
+
+	_, err = LoadDir("/does/not/exist")
+	assert.Error(t, err)
+}
+
+func TestLoadDirWithReferences(t *testing.T) {
+	dir := writeSchemasDir(t, map[string]string{
+		ManifestFile:   "schemas:\n   - file: article.json\n     contentTypes: [application/json]\n",
+		"article.json": `{"type": "object", "properties": {"brands": {"type": "array", "items": {"$ref": "brand.json"}}}}`,
+		"brand.json":   `{"type": "object", "required": ["id"]}`,
+	})
+	defer os.RemoveAll(dir)
+
+	registry, err := LoadDir(dir)
+	require.NoError(t, err)
+
+	err = registry.Validate(&native.Content{Body: map[string]interface{}{"brands": []interface{}{map[string]interface{}{}}}, ContentType: "application/json"})
+	assert.EqualError(t, err, "Content does not match the schema article.json: $.brands[0]: missing properties: 'id'")
+}
@@ -0,0 +1,87 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// Schema is a compiled JSON schema. Every keyword of drafts 4, 6, 7, 2019-09 and 2020-12 is supported, including $ref to the other schema files of the schemas directory.
+// Schemas without a $schema keyword are treated as the latest draft, for which format is only an annotation.
+type Schema struct {
+	compiled *jsonschema.Schema
+}
+
+func compile(compiler *jsonschema.Compiler, file string) (*Schema, error) {
+	compiled, err := compiler.Compile(file)
+	if err != nil {
+		return nil, err
+	}
+	return &Schema{compiled: compiled}, nil
+}
+
+// Validate returns every way in which the document breaks the schema, as a path into the document followed by the problem, i.e. "$.title: missing properties: 'title'". The document is compared as json, so it is marshalled first.
+func (s *Schema) Validate(document interface{}) ([]string, error) {
+	data, err := json.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	err = s.compiled.Validate(doc)
+	if err == nil {
+		return nil, nil
+	}
+
+	validationErr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return nil, err
+	}
+
+	problems := problemsOf(validationErr)
+	sort.Strings(problems)
+	return problems, nil
+}
+
+// problemsOf flattens the validation error into the problems at its leaves, which name the keyword that failed
+func problemsOf(err *jsonschema.ValidationError) []string {
+	if len(err.Causes) == 0 {
+		return []string{fmt.Sprintf("%v: %v", jsonPath(err.InstanceLocation), err.Message)}
+	}
+
+	var problems []string
+	for _, cause := range err.Causes {
+		problems = append(problems, problemsOf(cause)...)
+	}
+	return problems
+}
+
+// jsonPath converts a json pointer into the document, i.e. /brands/0/id, into the path $.brands[0].id
+func jsonPath(pointer string) string {
+	path := "$"
+	if pointer == "" {
+		return path
+	}
+
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		token = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+		if _, err := strconv.Atoi(token); err == nil {
+			path += "[" + token + "]"
+			continue
+		}
+		path += "." + token
+	}
+	return path
+}
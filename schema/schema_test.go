@@ -0,0 +1,126 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const articleSchema = `{
+	"$schema": "http://json-schema.org/draft-07/schema#",
+	"title": "Article",
+	"type": "object",
+	"required": ["uuid", "title"],
+	"properties": {
+		"uuid": {"type": "string", "pattern": "^[0-9a-f-]{36}$"},
+		"title": {"type": "string", "minLength": 1, "maxLength": 10},
+		"type": {"enum": ["Article", "Video"]},
+		"wordCount": {"type": "integer", "minimum": 0, "maximum": 10000},
+		"brands": {"type": "array", "minItems": 1, "items": {"type": "object", "required": ["id"], "additionalProperties": false, "properties": {"id": {"type": "string"}}}},
+		"standfirst": {"type": ["string", "null"]}
+	}
+}`
+
+func parse(t *testing.T, data string) *Schema {
+	compiler := jsonschema.NewCompiler()
+	require.NoError(t, compiler.AddResource("article.json", strings.NewReader(data)))
+
+	s, err := compile(compiler, "article.json")
+	require.NoError(t, err)
+	return s
+}
+
+func TestValidDocument(t *testing.T) {
+	s := parse(t, articleSchema)
+
+	problems, err := s.Validate(map[string]interface{}{
+		"uuid":       "5f2d6c2e-2a5c-11e7-9ec8-168383da43b7",
+		"title":      "Title",
+		"type":       "Article",
+		"wordCount":  500,
+		"brands":     []interface{}{bson.M{"id": "ft"}},
+		"standfirst": nil,
+		"other":      true,
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, problems)
+}
+
+func TestInvalidDocument(t *testing.T) {
+	s := parse(t, articleSchema)
+
+	problems, err := s.Validate(map[string]interface{}{
+		"uuid":       "not-a-uuid",
+		"type":       "Image",
+		"wordCount":  12.5,
+		"brands":     []interface{}{map[string]interface{}{"name": "ft"}},
+		"standfirst": 1,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"$.brands[0]: additionalProperties 'name' not allowed",
+		"$.brands[0]: missing properties: 'id'",
+		"$.standfirst: expected string or null, but got number",
+		`$.type: value must be one of "Article", "Video"`,
+		"$.uuid: does not match pattern '^[0-9a-f-]{36}$'",
+		"$.wordCount: expected integer, but got number",
+		"$: missing properties: 'title'",
+	}, problems)
+}
+
+func TestStringAndArrayBounds(t *testing.T) {
+	s := parse(t, articleSchema)
+
+	problems, err := s.Validate(map[string]interface{}{"uuid": "5f2d6c2e-2a5c-11e7-9ec8-168383da43b7", "title": "A much longer title", "brands": []interface{}{}, "wordCount": -1})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"$.brands: minimum 1 items required, but found 0 items",
+		"$.title: length must be <= 10, but got 19",
+		"$.wordCount: must be >= 0 but found -1",
+	}, problems)
+}
+
+func TestCombinedSchemas(t *testing.T) {
+	s := parse(t, `{
+		"definitions": {
+			"brand": {"type": "object", "required": ["id"]}
+		},
+		"type": "object",
+		"properties": {
+			"brands": {"type": "array", "items": {"$ref": "#/definitions/brand"}},
+			"body": {"anyOf": [{"type": "string"}, {"type": "null"}]},
+			"identifiers": {"allOf": [{"type": "array"}, {"minItems": 1}]}
+		}
+	}`)
+
+	problems, err := s.Validate(map[string]interface{}{"brands": []interface{}{map[string]interface{}{}}, "body": 1, "identifiers": []interface{}{}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"$.body: expected null, but got number",
+		"$.body: expected string, but got number",
+		"$.brands[0]: missing properties: 'id'",
+		"$.identifiers: minimum 1 items required, but found 0 items",
+	}, problems)
+}
+
+func TestJSONPath(t *testing.T) {
+	assert.Equal(t, "$", jsonPath(""))
+	assert.Equal(t, "$.brands[0].id", jsonPath("/brands/0/id"))
+	assert.Equal(t, "$.a/b.c~d", jsonPath("/a~1b/c~0d"))
+}
+
+func TestInvalidSchema(t *testing.T) {
+	compiler := jsonschema.NewCompiler()
+	require.NoError(t, compiler.AddResource("article.json", strings.NewReader(`{"type": "text"}`)))
+
+	_, err := compile(compiler, "article.json")
+	assert.Error(t, err)
+
+	require.NoError(t, compiler.AddResource("brand.json", strings.NewReader(`{"$ref": "#/definitions/missing"}`)))
+	_, err = compile(compiler, "brand.json")
+	assert.Error(t, err)
+}
@@ -0,0 +1,121 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Financial-Times/publish-carousel/cms"
+	"github.com/Financial-Times/publish-carousel/native"
+	log "github.com/sirupsen/logrus"
+)
+
+// AnnotationsTaskName is the registry name of the annotations only publish task
+const AnnotationsTaskName = "annotations"
+
+// AnnotationsOptions are the options of the annotations only publish task
+type AnnotationsOptions struct {
+	// Field is the field of the native content which contains the annotations
+	Field string `json:"field"`
+	// ContentType is sent to the cms notifier in place of the content type of the native content
+	ContentType string `json:"contentType"`
+}
+
+func (o *AnnotationsOptions) Validate() error {
+	if strings.TrimSpace(o.Field) == "" {
+		return errors.New("Please provide the field which contains the annotations")
+	}
+
+	if strings.TrimSpace(o.ContentType) == "" {
+		return errors.New("Please provide a content type for the annotations")
+	}
+	return nil
+}
+
+type annotationsTask struct {
+	nativeReader native.Reader
+	cmsNotifier  cms.Notifier
+	options      AnnotationsOptions
+}
+
+// NewAnnotationsPublishTask publishes only the annotations of the native content to the cms notifier, skipping content which has no annotations
+func NewAnnotationsPublishTask(reader native.Reader, notifier cms.Notifier, options AnnotationsOptions) Task {
+	return &annotationsTask{nativeReader: reader, cmsNotifier: notifier, options: options}
+}
+
+// NewAnnotationsDefinition registers the annotations only publish task, which by default publishes the annotations field as json
+func NewAnnotationsDefinition(reader native.Reader, notifier cms.Notifier) Definition {
+	return Definition{
+		NewOptions: func() TaskOptions { return &AnnotationsOptions{Field: "annotations", ContentType: "application/json"} },
+		New: func(options TaskOptions) (Task, error) {
+			return NewAnnotationsPublishTask(reader, notifier, *options.(*AnnotationsOptions)), nil
+		},
+	}
+}
+
+func (t *annotationsTask) Prepare(collection string, uuid string) (*native.Content, string, error) {
+	return t.PrepareContext(context.Background(), collection, uuid)
+}
+
+// PrepareContext reads the native content of the uuid within the trace of the context
+func (t *annotationsTask) PrepareContext(ctx context.Context, collection string, uuid string) (*native.Content, string, error) {
+	content, err := native.GetContext(ctx, t.nativeReader, collection, uuid)
+	if err != nil {
+		log.WithField("uuid", uuid).WithError(err).Warn("Failed to read from native reader")
+		return nil, "", err
+	}
+
+	if content.Body == nil {
+		log.WithField("uuid", uuid).Warn("No Content found for uuid. Skipping.")
+		return nil, "", fmt.Errorf(`Skipping uuid "%v" as it has no content`, uuid)
+	}
+
+	annotations, ok := content.Body[t.options.Field]
+	if !ok || annotations == nil {
+		log.WithField("uuid", uuid).WithField("collection", collection).Info("No annotations found for uuid. Skipping.")
+		return nil, "", fmt.Errorf(`Skipping uuid "%v" as it has no annotations`, uuid)
+	}
+
+	return content, transactionID(content), nil
+}
+
+// TransactionID reuses the publish reference of the content if it has one, or otherwise generates a new transaction id
+func (t *annotationsTask) TransactionID(content *native.Content) string {
+	return transactionID(content)
+}
+
+// Execute publishes the annotations of the native content as {"uuid": ..., "<field>": ...}
+func (t *annotationsTask) Execute(uuid string, content *native.Content, origin string, tid string) error {
+	_, err := t.ExecuteAttempts(context.Background(), uuid, content, origin, tid)
+	return err
+}
+
+// ExecuteAttempts publishes the annotations of the content, and returns the number of attempts the cms notifier took
+func (t *annotationsTask) ExecuteAttempts(ctx context.Context, uuid string, content *native.Content, origin string, tid string) (int, error) {
+	body := map[string]interface{}{"uuid": uuid, t.options.Field: content.Body[t.options.Field]}
+	annotations := &native.Content{Body: body, ContentType: t.options.ContentType, OriginSystemID: content.OriginSystemID, Collection: content.Collection}
+	return notify(ctx, t.cmsNotifier, uuid, annotations, origin, tid)
+}
+
+// PrefetchSize returns the number of upcoming uuids which should be prefetched at once, or 0 if the native reader does not support batching
+func (t *annotationsTask) PrefetchSize() int {
+	return prefetchSize(t.nativeReader)
+}
+
+// Prefetch reads the native content for the upcoming uuids in batches
+func (t *annotationsTask) Prefetch(collection string, uuids []string) {
+	prefetch(t.nativeReader, collection, uuids)
+}
+
+// WithReadOptions returns a copy of the task, which reads native content with the given read options
+func (t *annotationsTask) WithReadOptions(opts native.ReadOptions) Task {
+	reader, ok := withReadOptions(t.nativeReader, opts)
+	if !ok {
+		return t
+	}
+
+	task := *t
+	task.nativeReader = reader
+	return &task
+}
@@ -0,0 +1,70 @@
+package tasks
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Financial-Times/publish-carousel/cms"
+	"github.com/Financial-Times/publish-carousel/native"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var annotationsOptions = AnnotationsOptions{Field: "annotations", ContentType: "application/vnd.ft-upp-annotations+json"}
+
+func TestAnnotationsPublish(t *testing.T) {
+	reader := new(native.MockReader)
+	notifier := new(cms.MockNotifier)
+
+	annotations := []interface{}{map[string]interface{}{"id": "http://api.ft.com/things/1234", "predicate": "about"}}
+	content := &native.Content{Body: map[string]interface{}{"title": "ignored", "annotations": annotations, publishReferenceAttr: "tid_1234"}, ContentType: "application/json", OriginSystemID: "http://cmdb.ft.com/systems/methode-web-pub"}
+	reader.On("Get", "methode", "uuid-1").Return(content, nil)
+
+	task := NewAnnotationsPublishTask(reader, notifier, annotationsOptions)
+	prepared, tid, err := task.Prepare("methode", "uuid-1")
+	require.NoError(t, err)
+
+	assert.Regexp(t, carouselTidRegex, tid)
+	assert.True(t, prepared == content, "the native content is prepared, so that the cycle can filter it")
+
+	expected := &native.Content{Body: map[string]interface{}{"uuid": "uuid-1", "annotations": annotations, publishReferenceAttr: tid}, ContentType: "application/vnd.ft-upp-annotations+json", OriginSystemID: "http://cmdb.ft.com/systems/methode-web-pub"}
+	notifier.On("Notify", "origin", tid, expected, mock.AnythingOfType("string")).Return(nil)
+	require.NoError(t, task.Execute("uuid-1", prepared, "origin", tid))
+
+	reader.AssertExpectations(t)
+	notifier.AssertExpectations(t)
+}
+
+func TestAnnotationsPublishSkipsContentWithoutAnnotations(t *testing.T) {
+	reader := new(native.MockReader)
+	reader.On("Get", "methode", "uuid-1").Return(&native.Content{Body: map[string]interface{}{"title": "no annotations"}}, nil)
+
+	task := NewAnnotationsPublishTask(reader, new(cms.MockNotifier), annotationsOptions)
+	_, _, err := task.Prepare("methode", "uuid-1")
+	assert.EqualError(t, err, `Skipping uuid "uuid-1" as it has no annotations`)
+}
+
+func TestAnnotationsPublishSkipsMissingContent(t *testing.T) {
+	reader := new(native.MockReader)
+	reader.On("Get", "methode", "uuid-1").Return(&native.Content{}, nil)
+
+	task := NewAnnotationsPublishTask(reader, new(cms.MockNotifier), annotationsOptions)
+	_, _, err := task.Prepare("methode", "uuid-1")
+	assert.EqualError(t, err, `Skipping uuid "uuid-1" as it has no content`)
+}
+
+func TestAnnotationsPublishReaderFails(t *testing.T) {
+	reader := new(native.MockReader)
+	reader.On("Get", "methode", "uuid-1").Return(&native.Content{}, errors.New("mongo is down"))
+
+	task := NewAnnotationsPublishTask(reader, new(cms.MockNotifier), annotationsOptions)
+	_, _, err := task.Prepare("methode", "uuid-1")
+	assert.EqualError(t, err, "mongo is down")
+}
+
+func TestAnnotationsOptionsValidate(t *testing.T) {
+	assert.NoError(t, (&AnnotationsOptions{Field: "annotations", ContentType: "application/json"}).Validate())
+	assert.EqualError(t, (&AnnotationsOptions{ContentType: "application/json"}).Validate(), "Please provide the field which contains the annotations")
+	assert.EqualError(t, (&AnnotationsOptions{Field: "annotations"}).Validate(), "Please provide a content type for the annotations")
+}
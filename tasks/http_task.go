@@ -0,0 +1,183 @@
+package tasks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Financial-Times/publish-carousel/cluster"
+	"github.com/Financial-Times/publish-carousel/cms"
+	"github.com/Financial-Times/publish-carousel/native"
+	log "github.com/sirupsen/logrus"
+)
+
+// HTTPSinkTaskName is the registry name of the generic http sink task
+const HTTPSinkTaskName = "http"
+
+const uuidPlaceholder = "{uuid}"
+
+// HTTPSinkOptions are the options of the generic http sink task
+type HTTPSinkOptions struct {
+	// URL receives the native content. Any {uuid} in the url is replaced by the uuid of the content.
+	URL string `json:"url"`
+	// Method is either POST (the default) or PUT
+	Method string `json:"method"`
+	// Headers are added to every request
+	Headers map[string]string `json:"headers"`
+	// Timeout is the maximum duration of each request, i.e. 10s
+	Timeout string `json:"timeout"`
+}
+
+func (o *HTTPSinkOptions) Validate() error {
+	u, err := url.Parse(strings.Replace(o.URL, uuidPlaceholder, "uuid", -1))
+	if err != nil {
+		return err
+	}
+
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("Please provide an absolute http url, not %v", o.URL)
+	}
+
+	switch strings.ToUpper(o.Method) {
+	case "", http.MethodPost, http.MethodPut:
+	default:
+		return fmt.Errorf("Please provide a method of either POST or PUT, not %v", o.Method)
+	}
+
+	if o.Timeout != "" {
+		timeout, err := time.ParseDuration(o.Timeout)
+		if err != nil {
+			return err
+		}
+
+		if timeout <= 0 {
+			return errors.New("Please provide a positive timeout")
+		}
+	}
+	return nil
+}
+
+type httpSinkTask struct {
+	nativeReader native.Reader
+	client       cluster.HttpClient
+	options      HTTPSinkOptions
+	timeout      time.Duration
+}
+
+// NewHTTPSinkTask sends the native content to the configured url, with the same headers as the cms notifier
+func NewHTTPSinkTask(reader native.Reader, client cluster.HttpClient, options HTTPSinkOptions) Task {
+	timeout, _ := time.ParseDuration(options.Timeout)
+	if options.Method == "" {
+		options.Method = http.MethodPost
+	}
+	return &httpSinkTask{nativeReader: reader, client: client, options: options, timeout: timeout}
+}
+
+// NewHTTPSinkDefinition registers the generic http sink task
+func NewHTTPSinkDefinition(reader native.Reader, client cluster.HttpClient) Definition {
+	return Definition{
+		NewOptions: func() TaskOptions { return &HTTPSinkOptions{} },
+		New: func(options TaskOptions) (Task, error) {
+			return NewHTTPSinkTask(reader, client, *options.(*HTTPSinkOptions)), nil
+		},
+	}
+}
+
+func (t *httpSinkTask) Prepare(collection string, uuid string) (*native.Content, string, error) {
+	return t.PrepareContext(context.Background(), collection, uuid)
+}
+
+// PrepareContext reads the native content of the uuid within the trace of the context
+func (t *httpSinkTask) PrepareContext(ctx context.Context, collection string, uuid string) (*native.Content, string, error) {
+	content, err := native.GetContext(ctx, t.nativeReader, collection, uuid)
+	if err != nil {
+		log.WithField("uuid", uuid).WithError(err).Warn("Failed to read from native reader")
+		return nil, "", err
+	}
+
+	if content.Body == nil {
+		log.WithField("uuid", uuid).Warn("No Content found for uuid. Skipping.")
+		return nil, "", fmt.Errorf(`Skipping uuid "%v" as it has no content`, uuid)
+	}
+
+	return content, transactionID(content), nil
+}
+
+// TransactionID reuses the publish reference of the content if it has one, or otherwise generates a new transaction id
+func (t *httpSinkTask) TransactionID(content *native.Content) string {
+	return transactionID(content)
+}
+
+func (t *httpSinkTask) Execute(uuid string, content *native.Content, origin string, tid string) error {
+	hash, err := nativeHash(content)
+	if err != nil {
+		return err
+	}
+
+	content.Body[publishReferenceAttr] = tid
+
+	b := new(bytes.Buffer)
+	if err := json.NewEncoder(b).Encode(content.Body); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(strings.ToUpper(t.options.Method), strings.Replace(t.options.URL, uuidPlaceholder, url.PathEscape(uuid), -1), b)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Add("User-Agent", "UPP Publish Carousel")
+	req.Header.Add("Content-Type", content.ContentType)
+	req.Header.Add("X-Request-Id", tid)
+	req.Header.Add("X-Native-Hash", hash)
+	req.Header.Add("X-Origin-System-Id", cms.Origin(origin, content))
+	for key, value := range t.options.Headers {
+		req.Header.Set(key, value)
+	}
+
+	if t.timeout > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), t.timeout)
+		defer cancel()
+		req = req.WithContext(ctx)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		log.WithField("uuid", uuid).WithField("transaction_id", tid).WithError(err).Warn("Failed to send content to http sink")
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("A non 2xx error code was received by the http sink! Status: %v", resp.StatusCode)
+	}
+	return nil
+}
+
+// PrefetchSize returns the number of upcoming uuids which should be prefetched at once, or 0 if the native reader does not support batching
+func (t *httpSinkTask) PrefetchSize() int {
+	return prefetchSize(t.nativeReader)
+}
+
+// Prefetch reads the native content for the upcoming uuids in batches
+func (t *httpSinkTask) Prefetch(collection string, uuids []string) {
+	prefetch(t.nativeReader, collection, uuids)
+}
+
+// WithReadOptions returns a copy of the task, which reads native content with the given read options
+func (t *httpSinkTask) WithReadOptions(opts native.ReadOptions) Task {
+	reader, ok := withReadOptions(t.nativeReader, opts)
+	if !ok {
+		return t
+	}
+
+	task := *t
+	task.nativeReader = reader
+	return &task
+}
@@ -0,0 +1,82 @@
+package tasks
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Financial-Times/publish-carousel/native"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPSinkExecute(t *testing.T) {
+	var received *http.Request
+	var body map[string]interface{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		json.NewDecoder(r.Body).Decode(&body)
+	}))
+	defer server.Close()
+
+	reader := new(native.MockReader)
+	content, _ := mockContent("")
+	content.Body["title"] = "content"
+	hash, _ := nativeHash(content)
+	reader.On("Get", "methode", "uuid-1").Return(content, nil)
+
+	task := NewHTTPSinkTask(reader, http.DefaultClient, HTTPSinkOptions{URL: server.URL + "/sink/{uuid}", Method: "put", Headers: map[string]string{"Authorization": "Basic dXNlcjpwYXNz"}, Timeout: "5s"})
+
+	prepared, tid, err := task.Prepare("methode", "uuid-1")
+	require.NoError(t, err)
+	assert.Regexp(t, carouselGentxTidRegex, tid)
+
+	require.NoError(t, task.Execute("uuid-1", prepared, "http://cmdb.ft.com/systems/methode-web-pub", tid))
+
+	assert.Equal(t, "PUT", received.Method)
+	assert.Equal(t, "/sink/uuid-1", received.URL.Path)
+	assert.Equal(t, "application/json", received.Header.Get("Content-Type"))
+	assert.Equal(t, tid, received.Header.Get("X-Request-Id"))
+	assert.Equal(t, hash, received.Header.Get("X-Native-Hash"))
+	assert.Equal(t, "http://cmdb.ft.com/systems/methode-web-pub", received.Header.Get("X-Origin-System-Id"))
+	assert.Equal(t, "Basic dXNlcjpwYXNz", received.Header.Get("Authorization"))
+	assert.Equal(t, map[string]interface{}{"title": "content", publishReferenceAttr: tid}, body)
+}
+
+func TestHTTPSinkNon2xx(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	content, _ := mockContent("tid_1234")
+	task := NewHTTPSinkTask(new(native.MockReader), http.DefaultClient, HTTPSinkOptions{URL: server.URL})
+
+	err := task.Execute("uuid-1", content, "origin", "tid_1234_carousel_1493640000")
+	assert.EqualError(t, err, "A non 2xx error code was received by the http sink! Status: 503")
+}
+
+func TestHTTPSinkTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	content, _ := mockContent("tid_1234")
+	task := NewHTTPSinkTask(new(native.MockReader), http.DefaultClient, HTTPSinkOptions{URL: server.URL, Timeout: "10ms"})
+
+	err := task.Execute("uuid-1", content, "origin", "tid_1234_carousel_1493640000")
+	assert.Error(t, err)
+}
+
+func TestHTTPSinkOptionsValidate(t *testing.T) {
+	assert.NoError(t, (&HTTPSinkOptions{URL: "https://example.com/content/{uuid}"}).Validate())
+	assert.EqualError(t, (&HTTPSinkOptions{URL: "/content"}).Validate(), "Please provide an absolute http url, not /content")
+	assert.EqualError(t, (&HTTPSinkOptions{URL: "http://example.com", Method: "DELETE"}).Validate(), "Please provide a method of either POST or PUT, not DELETE")
+	assert.Error(t, (&HTTPSinkOptions{URL: "http://example.com", Timeout: "soon"}).Validate())
+	assert.EqualError(t, (&HTTPSinkOptions{URL: "http://example.com", Timeout: "-1s"}).Validate(), "Please provide a positive timeout")
+}
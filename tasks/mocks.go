@@ -1,6 +1,8 @@
 package tasks
 
 import (
+	"context"
+
 	"github.com/Financial-Times/publish-carousel/native"
 
 	"github.com/stretchr/testify/mock"
@@ -19,3 +21,40 @@ func (m *MockTask) Execute(uuid string, content *native.Content, origin string,
 	args := m.Called(uuid, content, origin, txId)
 	return args.Error(0)
 }
+
+type MockPrefetchingTask struct {
+	MockTask
+}
+
+func (m *MockPrefetchingTask) PrefetchSize() int {
+	return m.Called().Int(0)
+}
+
+func (m *MockPrefetchingTask) Prefetch(collection string, uuids []string) {
+	m.Called(collection, uuids)
+}
+
+type MockAttemptsTask struct {
+	MockTask
+}
+
+func (m *MockAttemptsTask) ExecuteAttempts(ctx context.Context, uuid string, content *native.Content, origin string, txId string) (int, error) {
+	args := m.Called(uuid, content, origin, txId)
+	return args.Int(0), args.Error(1)
+}
+
+type MockTransactionIDTask struct {
+	MockTask
+}
+
+func (m *MockTransactionIDTask) TransactionID(content *native.Content) string {
+	return m.Called(content).String(0)
+}
+
+type MockValidatingTask struct {
+	MockTask
+}
+
+func (m *MockValidatingTask) Validate(uuid string, content *native.Content) error {
+	return m.Called(uuid, content).Error(0)
+}
@@ -1,16 +1,15 @@
 package tasks
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/Financial-Times/publish-carousel/cms"
-	"github.com/Financial-Times/publish-carousel/image"
 	"github.com/Financial-Times/publish-carousel/native"
-	tid "github.com/Financial-Times/transactionid-utils-go"
+	"github.com/Financial-Times/publish-carousel/schema"
+	"github.com/Financial-Times/publish-carousel/txid"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -19,21 +18,124 @@ type Task interface {
 	Execute(uuid string, content *native.Content, origin string, txId string) error
 }
 
+// Prefetcher is implemented by tasks which can prepare the content for several upcoming uuids at once
+type Prefetcher interface {
+	PrefetchSize() int
+	Prefetch(collection string, uuids []string)
+}
+
+// ReadOptionsTask is implemented by tasks which can read native content with different read options
+type ReadOptionsTask interface {
+	WithReadOptions(opts native.ReadOptions) Task
+}
+
+// ContextTask is implemented by tasks which record the reads they prepare content with as spans of the trace of the context
+type ContextTask interface {
+	PrepareContext(ctx context.Context, collection string, uuid string) (*native.Content, string, error)
+}
+
+// Prepare prepares the content with the task, within the trace of the context if the task supports it
+func Prepare(ctx context.Context, task Task, collection string, uuid string) (*native.Content, string, error) {
+	if t, ok := task.(ContextTask); ok {
+		return t.PrepareContext(ctx, collection, uuid)
+	}
+	return task.Prepare(collection, uuid)
+}
+
+// AttemptsTask is implemented by tasks which can report how many attempts a publish took, and which stop retrying once the context is cancelled
+type AttemptsTask interface {
+	ExecuteAttempts(ctx context.Context, uuid string, content *native.Content, origin string, txId string) (attempts int, err error)
+}
+
+// ExecuteAttempts executes the task, and returns the number of attempts it took. Tasks which do not report their attempts are counted as a single attempt.
+func ExecuteAttempts(ctx context.Context, task Task, uuid string, content *native.Content, origin string, txId string) (int, error) {
+	if t, ok := task.(AttemptsTask); ok {
+		return t.ExecuteAttempts(ctx, uuid, content, origin, txId)
+	}
+	return 1, task.Execute(uuid, content, origin, txId)
+}
+
+// TransactionIDTask is implemented by tasks which derive the transaction id from the content, so that it can be worked out again once a cycle has transformed the content
+type TransactionIDTask interface {
+	TransactionID(content *native.Content) string
+}
+
+// TransactionID returns the transaction id which the task would publish the content with. Tasks which do not derive it from the content keep the transaction id from Prepare.
+func TransactionID(task Task, content *native.Content, txId string) string {
+	if t, ok := task.(TransactionIDTask); ok {
+		return t.TransactionID(content)
+	}
+	return txId
+}
+
+// Validator is implemented by tasks which check the content before it is executed. Cycles validate the content after transforming it, so the content which is validated is the content which is published.
+type Validator interface {
+	Validate(uuid string, content *native.Content) error
+}
+
+// Validate validates the content with the task, if the task is a Validator
+func Validate(task Task, uuid string, content *native.Content) error {
+	if v, ok := task.(Validator); ok {
+		return v.Validate(uuid, content)
+	}
+	return nil
+}
+
+// Explainer is implemented by tasks which can explain how they would publish some native content, without publishing it
+type Explainer interface {
+	Explain(uuid string, content *native.Content) Explanation
+}
+
+// Explanation describes the checks the publish task makes on some native content, and the transaction id and native hash it would publish the content with
+type Explanation struct {
+	MissingBody    bool   `json:"missingBody"`
+	OriginSystemID string `json:"originSystemId,omitempty"`
+	ContentType    string `json:"contentType,omitempty"`
+	TransactionID  string `json:"transactionId,omitempty"`
+	NativeHash     string `json:"nativeHash,omitempty"`
+	HashError      string `json:"hashError,omitempty"`
+	SchemaError    string `json:"schemaError,omitempty"`
+	Skipped        bool   `json:"skipped"`
+}
+
 type nativeContentTask struct {
 	nativeReader native.Reader
 	cmsNotifier  cms.Notifier
-	isImage      image.Filter
+	schemas      *schema.Registry
 }
 
-// NewNativeContentPublishTask publishes the native content from mongo to the cms notifier, if the uuid has not been blacklisted.
-func NewNativeContentPublishTask(reader native.Reader, notifier cms.Notifier, isImage image.Filter) Task {
-	return &nativeContentTask{nativeReader: reader, cmsNotifier: notifier, isImage: isImage}
+// NewNativeContentPublishTask publishes the native content from mongo to the cms notifier. Content is filtered by the cycle, which skips images by default. If schemas are provided, content which does not match its schema is skipped.
+func NewNativeContentPublishTask(reader native.Reader, notifier cms.Notifier, schemas *schema.Registry) Task {
+	return &nativeContentTask{nativeReader: reader, cmsNotifier: notifier, schemas: schemas}
+}
+
+// NativeContentTaskName is the registry name of the native content publish task, which is used by cycles which do not select a task
+const NativeContentTaskName = "nativeContent"
+
+// NativeContentOptions are the options of the native content publish task, which currently has none
+type NativeContentOptions struct{}
+
+func (o *NativeContentOptions) Validate() error {
+	return nil
+}
+
+// NewNativeContentDefinition registers the native content publish task, which shares the given task between all the cycles that select it
+func NewNativeContentDefinition(task Task) Definition {
+	return Definition{
+		NewOptions: func() TaskOptions { return &NativeContentOptions{} },
+		New:        func(options TaskOptions) (Task, error) { return task, nil },
+	}
 }
 
 const publishReferenceAttr = "publishReference"
 
 func (t *nativeContentTask) Prepare(collection string, uuid string) (*native.Content, string, error) {
-	content, err := t.nativeReader.Get(collection, uuid)
+	return t.PrepareContext(context.Background(), collection, uuid)
+}
+
+// PrepareContext reads the native content of the uuid within the trace of the context
+func (t *nativeContentTask) PrepareContext(ctx context.Context, collection string, uuid string) (*native.Content, string, error) {
+	content, err := native.GetContext(ctx, t.nativeReader, collection, uuid)
 	if err != nil {
 		log.WithField("uuid", uuid).WithError(err).Warn("Failed to read from native reader")
 		return nil, "", err
@@ -44,61 +146,142 @@ func (t *nativeContentTask) Prepare(collection string, uuid string) (*native.Con
 		return nil, "", fmt.Errorf(`Skipping uuid "%v" as it has no content`, uuid)
 	}
 
-	invalid, err := t.isImage(uuid, content)
-	if err != nil {
-		log.WithField("uuid", uuid).WithField("collection", collection).WithError(err).Warn("Blacklist check failed.")
-		return nil, "", err
-	}
+	return content, transactionID(content), nil
+}
 
-	if invalid {
-		log.WithField("uuid", uuid).WithField("collection", collection).Info("This UUID contains an image. Skipping republish.")
-		return nil, "", fmt.Errorf(`Skipping uuid "%v" as it is an image`, uuid)
+// Validate checks the content against its schema. It is called by the cycle once the content has been transformed, just before it is executed.
+func (t *nativeContentTask) Validate(uuid string, content *native.Content) error {
+	if err := t.schemas.Validate(content); err != nil {
+		log.WithField("uuid", uuid).WithError(err).Warn("Content failed schema validation. Skipping.")
+		return fmt.Errorf(`Skipping uuid "%v" as it is invalid: %v`, uuid, err)
 	}
+	return nil
+}
 
-	tid, ok := content.Body[publishReferenceAttr].(string)
-	if !ok || strings.TrimSpace(tid) == "" {
-		tid = generateCarouselTXID()
-	} else {
-		tid = toCarouselTXID(tid)
-	}
+// TransactionID reuses the publish reference of the content if it has one, or otherwise generates a new transaction id
+func (t *nativeContentTask) TransactionID(content *native.Content) string {
+	return transactionID(content)
+}
 
-	return content, tid, nil
+// transactionID reuses the publish reference of the content if it has one, or otherwise generates a new transaction id. Cycles can replace it with their own transaction id strategy.
+func transactionID(content *native.Content) string {
+	return txid.Carousel().TransactionID(txid.Context{PublishReference: PublishReference(content), Time: time.Now()})
 }
 
-func (t *nativeContentTask) Execute(uuid string, content *native.Content, origin string, tid string) error {
+// PublishReference returns the transaction id which the content was last published with, if it has one
+func PublishReference(content *native.Content) string {
+	ref, _ := content.Body[publishReferenceAttr].(string)
+	return ref
+}
+
+func nativeHash(content *native.Content) (string, error) {
 	data, err := json.Marshal(content.Body)
 	if err != nil {
-		return err
+		return "", err
 	}
+	return native.Hash(data)
+}
 
-	hash, err := native.Hash(data)
-	if err != nil {
-		return err
+// Explain runs the same checks as Prepare and Validate on the content, and computes the transaction id and native hash it would be published with. The transaction id is generated afresh, so will differ from the one used by the next publish.
+func (t *nativeContentTask) Explain(uuid string, content *native.Content) Explanation {
+	explanation := Explanation{OriginSystemID: content.OriginSystemID, ContentType: content.ContentType}
+
+	if content.Body == nil {
+		explanation.MissingBody = true
+		explanation.Skipped = true
+		return explanation
 	}
 
-	content.Body[publishReferenceAttr] = tid
+	if err := t.schemas.Validate(content); err != nil {
+		explanation.SchemaError = err.Error()
+		explanation.Skipped = true
+		return explanation
+	}
 
-	err = t.cmsNotifier.Notify(origin, tid, content, hash)
+	explanation.TransactionID = transactionID(content)
+
+	hash, err := nativeHash(content)
 	if err != nil {
-		log.WithField("uuid", uuid).WithError(err).Warn("Failed to post to cms notifier")
-		return err
+		explanation.HashError = err.Error()
+		return explanation
 	}
 
-	return nil
+	explanation.NativeHash = hash
+	return explanation
+}
+
+// PrefetchSize returns the number of upcoming uuids which should be prefetched at once, or 0 if the native reader does not support batching
+func (t *nativeContentTask) PrefetchSize() int {
+	return prefetchSize(t.nativeReader)
+}
+
+// Prefetch reads the native content for the upcoming uuids in batches. Any content which fails to prefetch is read individually during Prepare.
+func (t *nativeContentTask) Prefetch(collection string, uuids []string) {
+	prefetch(t.nativeReader, collection, uuids)
+}
+
+func prefetchSize(nativeReader native.Reader) int {
+	if reader, ok := nativeReader.(native.BatchReader); ok {
+		return reader.BatchSize()
+	}
+	return 0
 }
 
-const genTXSuffix = "_gentx"
+func prefetch(nativeReader native.Reader, collection string, uuids []string) {
+	reader, ok := nativeReader.(native.BatchReader)
+	if !ok {
+		return
+	}
 
-func generateCarouselTXID() string {
-	return tid.NewTransactionID() + generateTXIDSuffix() + genTXSuffix
+	if err := reader.Prefetch(collection, uuids); err != nil {
+		log.WithField("collection", collection).WithField("uuids", len(uuids)).WithError(err).Warn("Failed to prefetch native content")
+	}
 }
 
-func toCarouselTXID(tid string) string {
-	return tid + generateTXIDSuffix()
+func withReadOptions(nativeReader native.Reader, opts native.ReadOptions) (native.Reader, bool) {
+	reader, ok := nativeReader.(native.ReadOptionsReader)
+	if !ok {
+		return nativeReader, false
+	}
+	return reader.WithReadOptions(opts), true
 }
 
-const carouselIntrafix = "_carousel_"
+// WithReadOptions returns a copy of the task, which reads native content with the given read options
+func (t *nativeContentTask) WithReadOptions(opts native.ReadOptions) Task {
+	reader, ok := withReadOptions(t.nativeReader, opts)
+	if !ok {
+		return t
+	}
+
+	task := *t
+	task.nativeReader = reader
+	return &task
+}
+
+func (t *nativeContentTask) Execute(uuid string, content *native.Content, origin string, tid string) error {
+	_, err := t.ExecuteAttempts(context.Background(), uuid, content, origin, tid)
+	return err
+}
+
+// ExecuteAttempts publishes the content, and returns the number of attempts the cms notifier took
+func (t *nativeContentTask) ExecuteAttempts(ctx context.Context, uuid string, content *native.Content, origin string, tid string) (int, error) {
+	return notify(ctx, t.cmsNotifier, uuid, content, origin, tid)
+}
+
+// notify posts the content to the cms notifier with the transaction id as its publish reference, and returns the number of attempts it took
+func notify(ctx context.Context, notifier cms.Notifier, uuid string, content *native.Content, origin string, tid string) (int, error) {
+	hash, err := nativeHash(content)
+	if err != nil {
+		return 0, err
+	}
+
+	content.Body[publishReferenceAttr] = tid
+
+	attempts, err := cms.NotifyAttempts(ctx, notifier, origin, tid, content, hash)
+	if err != nil {
+		log.WithField("uuid", uuid).WithField("attempts", attempts).WithError(err).Warn("Failed to post to cms notifier")
+		return attempts, err
+	}
 
-func generateTXIDSuffix() string {
-	return carouselIntrafix + strconv.FormatInt(time.Now().Unix(), 10)
+	return attempts, nil
 }
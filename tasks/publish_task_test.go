@@ -3,13 +3,16 @@ package tasks
 import (
 	"encoding/json"
 	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"testing"
 
 	"github.com/Financial-Times/publish-carousel/cms"
-	"github.com/Financial-Times/publish-carousel/image"
 	"github.com/Financial-Times/publish-carousel/native"
+	"github.com/Financial-Times/publish-carousel/schema"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -59,7 +62,7 @@ func TestPublishWithTID(t *testing.T) {
 	reader.On("Get", testCollection, testUUID).Return(content, nil)
 	notifier.On("Notify", origin, carouselTidMatcher, content, hash).Return(nil)
 
-	task := NewNativeContentPublishTask(reader, notifier, image.NoOpImageFilter)
+	task := NewNativeContentPublishTask(reader, notifier, nil)
 
 	content, txID, err := task.Prepare(testCollection, testUUID)
 	require.NoError(t, err)
@@ -84,7 +87,7 @@ func TestPublishWithGeneratedTID(t *testing.T) {
 	reader.On("Get", testCollection, testUUID).Return(content, nil)
 	notifier.On("Notify", origin, carouselGentxTidMatcher, content, hash).Return(nil)
 
-	task := NewNativeContentPublishTask(reader, notifier, image.NoOpImageFilter)
+	task := NewNativeContentPublishTask(reader, notifier, nil)
 
 	content, txID, err := task.Prepare(testCollection, testUUID)
 	require.NoError(t, err)
@@ -108,7 +111,7 @@ func TestPublishJSONMarshalFails(t *testing.T) {
 	testBody["errrr"] = func() {}
 	content := native.Content{Body: testBody, ContentType: "application/vnd.expect-this"}
 
-	task := NewNativeContentPublishTask(reader, notifier, image.NoOpImageFilter)
+	task := NewNativeContentPublishTask(reader, notifier, nil)
 
 	err := task.Execute(testUUID, &content, origin, txID)
 	assert.Error(t, err)
@@ -125,7 +128,7 @@ func TestFailedReader(t *testing.T) {
 
 	reader.On("Get", testCollection, testUUID).Return(content, errors.New("fail"))
 
-	task := NewNativeContentPublishTask(reader, notifier, image.NoOpImageFilter)
+	task := NewNativeContentPublishTask(reader, notifier, nil)
 
 	_, _, err := task.Prepare(testCollection, testUUID)
 	assert.Error(t, err)
@@ -145,7 +148,7 @@ func TestEmptyContent(t *testing.T) {
 
 	reader.On("Get", testCollection, testUUID).Return(content, nil)
 
-	task := NewNativeContentPublishTask(reader, notifier, image.NoOpImageFilter)
+	task := NewNativeContentPublishTask(reader, notifier, nil)
 	_, _, err := task.Prepare(testCollection, testUUID)
 	assert.Error(t, err)
 
@@ -166,7 +169,7 @@ func TestFailedNotify(t *testing.T) {
 	reader.On("Get", testCollection, testUUID).Return(content, nil)
 	notifier.On("Notify", origin, carouselTidMatcher, content, hash).Return(errors.New("fail"))
 
-	task := NewNativeContentPublishTask(reader, notifier, image.NoOpImageFilter)
+	task := NewNativeContentPublishTask(reader, notifier, nil)
 
 	content, txID, err := task.Prepare(testCollection, testUUID)
 	assert.NoError(t, err)
@@ -178,25 +181,16 @@ func TestFailedNotify(t *testing.T) {
 	notifier.AssertExpectations(t)
 }
 
-func TestUUIDIsImage(t *testing.T) {
+func TestImagesAreLeftToTheCycleFilters(t *testing.T) {
 	notifier := new(cms.MockNotifier)
 	reader := new(native.MockReader)
 
 	testCollection := "testing123"
 	testUUID := "i am a uuid"
 
-	imageFilterCalled := false
-
-	isImage := func(uuid string, content *native.Content) (bool, error) {
-		imageFilterCalled = true
-		if uuid == testUUID {
-			return true, nil
-		}
-		return false, nil
-	}
-
 	body := make(map[string]interface{})
 	body[publishReferenceAttr] = "tid_1234"
+	body["type"] = "Image"
 
 	testContent := &native.Content{
 		Body:        body,
@@ -205,48 +199,143 @@ func TestUUIDIsImage(t *testing.T) {
 
 	reader.On("Get", testCollection, testUUID).Return(testContent, nil)
 
-	task := NewNativeContentPublishTask(reader, notifier, isImage)
+	task := NewNativeContentPublishTask(reader, notifier, nil)
 
-	_, _, err := task.Prepare(testCollection, testUUID)
-	require.Error(t, err)
+	content, _, err := task.Prepare(testCollection, testUUID)
+	require.NoError(t, err)
+	assert.Equal(t, testContent, content)
 
 	reader.AssertExpectations(t)
 	notifier.AssertExpectations(t)
-	assert.True(t, imageFilterCalled)
 }
 
-func TestBlacklistFails(t *testing.T) {
-	notifier := new(cms.MockNotifier)
+func TestPrefetchWithBatchReader(t *testing.T) {
+	reader := new(native.MockBatchReader)
+	reader.On("BatchSize").Return(10)
+	reader.On("Prefetch", "methode", []string{"uuid-1", "uuid-2"}).Return(errors.New("prefetch failures are logged"))
+
+	task := NewNativeContentPublishTask(reader, new(cms.MockNotifier), nil).(Prefetcher)
+	assert.Equal(t, 10, task.PrefetchSize())
+
+	task.Prefetch("methode", []string{"uuid-1", "uuid-2"})
+	reader.AssertExpectations(t)
+}
+
+func TestPrefetchWithoutBatchReader(t *testing.T) {
 	reader := new(native.MockReader)
 
-	testCollection := "testing123"
-	testUUID := "i am a uuid"
+	task := NewNativeContentPublishTask(reader, new(cms.MockNotifier), nil).(Prefetcher)
+	assert.Equal(t, 0, task.PrefetchSize())
 
-	imageFilterCalled := false
+	task.Prefetch("methode", []string{"uuid-1"})
+	reader.AssertExpectations(t)
+}
 
-	isImage := func(uuid string, content *native.Content) (bool, error) {
-		imageFilterCalled = true
-		if uuid == testUUID {
-			return false, errors.New("oh dear")
-		}
-		return false, nil
-	}
+func TestWithReadOptions(t *testing.T) {
+	db := new(native.MockDB)
+	reader := native.NewMongoNativeReader(db, nil)
+	task := NewNativeContentPublishTask(reader, new(cms.MockNotifier), nil)
 
-	body := make(map[string]interface{})
-	body[publishReferenceAttr] = "tid_1234"
+	withOpts := task.(ReadOptionsTask).WithReadOptions(native.ReadOptions{ReadPreference: "nearest"})
+	assert.False(t, task == withOpts, "a copy of the task should be returned")
+	assert.False(t, reader == withOpts.(*nativeContentTask).nativeReader, "the copy should have its own reader")
 
-	content := &native.Content{
-		Body:        body,
-		ContentType: "application/json",
-	}
+	plain := NewNativeContentPublishTask(new(native.MockReader), new(cms.MockNotifier), nil)
+	assert.True(t, plain == plain.(ReadOptionsTask).WithReadOptions(native.ReadOptions{ReadPreference: "nearest"}), "readers without read options are used as they are")
+}
 
-	reader.On("Get", testCollection, testUUID).Return(content, nil)
-	task := NewNativeContentPublishTask(reader, notifier, isImage)
+func TestExplain(t *testing.T) {
+	notifier := new(cms.MockNotifier)
+	reader := new(native.MockReader)
 
-	_, _, err := task.Prepare(testCollection, testUUID)
-	assert.Error(t, err)
+	content, hash := mockContent("tid_1234")
+	content.OriginSystemID = "systemOriginId"
+
+	task := NewNativeContentPublishTask(reader, notifier, nil).(Explainer)
+	explanation := task.Explain("i am a uuid", content)
+
+	assert.False(t, explanation.Skipped)
+	assert.False(t, explanation.MissingBody)
+	assert.Equal(t, hash, explanation.NativeHash)
+	assert.Equal(t, "systemOriginId", explanation.OriginSystemID)
+	assert.Equal(t, "application/json", explanation.ContentType)
+	assert.True(t, carouselTidRegex.MatchString(explanation.TransactionID))
+	assert.True(t, strings.HasPrefix(explanation.TransactionID, "tid_1234"))
+
+	assert.Equal(t, "tid_1234", content.Body[publishReferenceAttr], "explaining should not modify the content")
 
 	reader.AssertExpectations(t)
 	notifier.AssertExpectations(t)
-	assert.True(t, imageFilterCalled)
+}
+
+func TestExplainGeneratedTID(t *testing.T) {
+	content, _ := mockContent("")
+
+	task := NewNativeContentPublishTask(new(native.MockReader), new(cms.MockNotifier), nil).(Explainer)
+	explanation := task.Explain("i am a uuid", content)
+	assert.True(t, carouselGentxTidRegex.MatchString(explanation.TransactionID))
+}
+
+func TestTransactionIDOfTransformedContent(t *testing.T) {
+	task := NewNativeContentPublishTask(new(native.MockReader), new(cms.MockNotifier), nil)
+
+	content := &native.Content{Body: map[string]interface{}{"uuid": "i am a uuid"}}
+	assert.True(t, carouselGentxTidRegex.MatchString(TransactionID(task, content, "tid_prepared")))
+
+	content.Body["publishReference"] = "tid_legacy"
+	assert.Regexp(t, `^tid_legacy_carousel_[\d]{10}$`, TransactionID(task, content, "tid_prepared"))
+
+	assert.Equal(t, "tid_prepared", TransactionID(new(MockTask), content, "tid_prepared"), "tasks which do not derive the transaction id from the content keep the prepared one")
+}
+
+func TestExplainMissingBody(t *testing.T) {
+	task := NewNativeContentPublishTask(new(native.MockReader), new(cms.MockNotifier), nil).(Explainer)
+	explanation := task.Explain("i am a uuid", &native.Content{})
+
+	assert.True(t, explanation.MissingBody)
+	assert.True(t, explanation.Skipped)
+	assert.Empty(t, explanation.TransactionID)
+	assert.Empty(t, explanation.NativeHash)
+}
+
+func loadSchemas(t *testing.T) *schema.Registry {
+	dir, err := ioutil.TempDir("", "schemas")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, schema.ManifestFile), []byte("schemas:\n   - file: content.json\n     contentTypes:\n        - application/json\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "content.json"), []byte(`{"type": "object", "required": ["title"]}`), 0644))
+
+	schemas, err := schema.LoadDir(dir)
+	require.NoError(t, err)
+	return schemas
+}
+
+func TestValidateSkipsContentWhichFailsSchemaValidation(t *testing.T) {
+	reader := new(native.MockReader)
+	task := NewNativeContentPublishTask(reader, new(cms.MockNotifier), loadSchemas(t))
+
+	invalid, _ := mockContent("tid_1234")
+	reader.On("Get", "methode", "invalid-uuid").Return(invalid, nil)
+
+	content, _, err := task.Prepare("methode", "invalid-uuid")
+	assert.NoError(t, err, "content is validated after the cycle has transformed it, rather than when it is prepared")
+	assert.EqualError(t, Validate(task, "invalid-uuid", content), `Skipping uuid "invalid-uuid" as it is invalid: Content does not match the schema content.json: $: missing properties: 'title'`)
+
+	content.Body["title"] = "A title"
+	assert.NoError(t, Validate(task, "invalid-uuid", content))
+	assert.NoError(t, Validate(new(MockTask), "invalid-uuid", invalid), "tasks which are not validators accept all content")
+
+	reader.AssertExpectations(t)
+}
+
+func TestExplainSchemaValidation(t *testing.T) {
+	content, _ := mockContent("tid_1234")
+
+	task := NewNativeContentPublishTask(new(native.MockReader), new(cms.MockNotifier), loadSchemas(t)).(Explainer)
+	explanation := task.Explain("i am a uuid", content)
+
+	assert.True(t, explanation.Skipped)
+	assert.Equal(t, "Content does not match the schema content.json: $: missing properties: 'title'", explanation.SchemaError)
+	assert.Empty(t, explanation.NativeHash)
 }
@@ -0,0 +1,118 @@
+package tasks
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Options is the options block of a task, as configured for a cycle in cycles.yml or through the API
+type Options map[string]interface{}
+
+// UnmarshalYAML converts the nested yaml maps of the options block into maps with string keys, so that the options can be written as json
+func (o *Options) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	raw := make(map[string]interface{})
+	if err := unmarshal(&raw); err != nil {
+		return err
+	}
+
+	options := make(Options)
+	for key, value := range raw {
+		options[key] = stringKeys(value)
+	}
+
+	*o = options
+	return nil
+}
+
+func stringKeys(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{})
+		for key, val := range v {
+			m[fmt.Sprintf("%v", key)] = stringKeys(val)
+		}
+		return m
+	case []interface{}:
+		for i, val := range v {
+			v[i] = stringKeys(val)
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+// TaskOptions is implemented by the typed options of each task, which are validated when the cycle is loaded
+type TaskOptions interface {
+	Validate() error
+}
+
+// Definition describes how to create a named task from its options
+type Definition struct {
+	// NewOptions returns a pointer to the default options of the task, into which the options block of the cycle is decoded
+	NewOptions func() TaskOptions
+	// New creates the task from its validated options
+	New func(options TaskOptions) (Task, error)
+}
+
+// Registry holds the tasks which cycles can select by name
+type Registry struct {
+	definitions map[string]Definition
+}
+
+// NewRegistry returns an empty task registry
+func NewRegistry() *Registry {
+	return &Registry{definitions: make(map[string]Definition)}
+}
+
+// Register adds the named task to the registry, replacing any task which was registered with the same name
+func (r *Registry) Register(name string, definition Definition) {
+	r.definitions[strings.ToLower(name)] = definition
+}
+
+// Names returns the names of the registered tasks, in alphabetical order
+func (r *Registry) Names() []string {
+	var names []string
+	for name := range r.definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewTask decodes and validates the options for the named task, and creates the task. Unknown options are rejected.
+func (r *Registry) NewTask(name string, options Options) (Task, error) {
+	definition, ok := r.definitions[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("Unknown task %v, please use one of %v", name, strings.Join(r.Names(), ", "))
+	}
+
+	typed := definition.NewOptions()
+	if err := decodeOptions(options, typed); err != nil {
+		return nil, fmt.Errorf("Invalid options for task %v: %v", name, err)
+	}
+
+	if err := typed.Validate(); err != nil {
+		return nil, fmt.Errorf("Invalid options for task %v: %v", name, err)
+	}
+
+	return definition.New(typed)
+}
+
+func decodeOptions(options Options, typed TaskOptions) error {
+	if len(options) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(options)
+	if err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(typed)
+}
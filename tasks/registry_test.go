@@ -0,0 +1,96 @@
+package tasks
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Financial-Times/publish-carousel/cms"
+	"github.com/Financial-Times/publish-carousel/native"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func testRegistry() (*Registry, Task) {
+	reader := new(native.MockReader)
+	notifier := new(cms.MockNotifier)
+	defaultTask := NewNativeContentPublishTask(reader, notifier, nil)
+
+	registry := NewRegistry()
+	registry.Register(NativeContentTaskName, NewNativeContentDefinition(defaultTask))
+	registry.Register(AnnotationsTaskName, NewAnnotationsDefinition(reader, notifier))
+	registry.Register(HTTPSinkTaskName, NewHTTPSinkDefinition(reader, nil))
+	return registry, defaultTask
+}
+
+func TestRegistryNames(t *testing.T) {
+	registry, _ := testRegistry()
+	assert.Equal(t, []string{"annotations", "http", "nativecontent"}, registry.Names())
+}
+
+func TestRegistryNativeContentTask(t *testing.T) {
+	registry, defaultTask := testRegistry()
+
+	task, err := registry.NewTask("nativeContent", nil)
+	require.NoError(t, err)
+	assert.True(t, task == defaultTask, "the native content task is shared")
+}
+
+func TestRegistryUnknownTask(t *testing.T) {
+	registry, _ := testRegistry()
+
+	_, err := registry.NewTask("sqs", nil)
+	assert.EqualError(t, err, "Unknown task sqs, please use one of annotations, http, nativecontent")
+}
+
+func TestRegistryDecodesTypedOptions(t *testing.T) {
+	registry, _ := testRegistry()
+
+	task, err := registry.NewTask("annotations", Options{"field": "metadata"})
+	require.NoError(t, err)
+	assert.Equal(t, AnnotationsOptions{Field: "metadata", ContentType: "application/json"}, task.(*annotationsTask).options, "unset options keep their defaults")
+}
+
+func TestRegistryRejectsUnknownOptions(t *testing.T) {
+	registry, _ := testRegistry()
+
+	_, err := registry.NewTask("nativeContent", Options{"field": "metadata"})
+	assert.EqualError(t, err, `Invalid options for task nativeContent: json: unknown field "field"`)
+}
+
+func TestRegistryRejectsMistypedOptions(t *testing.T) {
+	registry, _ := testRegistry()
+
+	_, err := registry.NewTask("http", Options{"url": "http://localhost:8080/sink", "headers": "Authorization: Basic"})
+	assert.Error(t, err)
+}
+
+func TestRegistryValidatesOptions(t *testing.T) {
+	registry, _ := testRegistry()
+
+	_, err := registry.NewTask("http", Options{"method": "PUT"})
+	assert.EqualError(t, err, "Invalid options for task http: Please provide an absolute http url, not ")
+}
+
+func TestOptionsFromYAML(t *testing.T) {
+	config := struct {
+		Options Options `yaml:"taskOptions"`
+	}{}
+
+	err := yaml.Unmarshal([]byte(`
+taskOptions:
+   url: http://localhost:8080/sink/{uuid}
+   headers:
+      Authorization: Basic dXNlcjpwYXNz
+`), &config)
+	require.NoError(t, err)
+
+	data, err := json.Marshal(config.Options)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"url":"http://localhost:8080/sink/{uuid}","headers":{"Authorization":"Basic dXNlcjpwYXNz"}}`, string(data))
+
+	registry, _ := testRegistry()
+	task, err := registry.NewTask("http", config.Options)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"Authorization": "Basic dXNlcjpwYXNz"}, task.(*httpSinkTask).options.Headers)
+}
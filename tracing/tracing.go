@@ -0,0 +1,82 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// The names of the spans which the carousel records for every publish
+const (
+	PublishSpan   = "carousel.publish"
+	ReadSpan      = "mongo.read"
+	FilterSpan    = "carousel.filter"
+	TransformSpan = "carousel.transform"
+	NotifySpan    = "cms.notify"
+)
+
+// DefaultOTLPEndpoint is the traces endpoint of an OpenTelemetry collector running locally
+const DefaultOTLPEndpoint = "http://localhost:4318/v1/traces"
+
+const instrumentationName = "github.com/Financial-Times/publish-carousel"
+
+// Propagator writes the W3C traceparent header of the current span
+var Propagator = propagation.TraceContext{}
+
+// NewTracerProvider returns the tracer provider which exports spans in batches with the named exporter, which is none, stdout or otlp.
+// With none, spans are not recorded, but they are still given ids, so a traceparent header is sent with every publish.
+func NewTracerProvider(exporter string, client *http.Client, otlpEndpoint string, serviceName string) (*sdktrace.TracerProvider, error) {
+	res := resource.NewSchemaless(attribute.String("service.name", serviceName))
+
+	switch strings.ToLower(strings.TrimSpace(exporter)) {
+	case "", "none":
+		return sdktrace.NewTracerProvider(sdktrace.WithResource(res), sdktrace.WithSampler(sdktrace.NeverSample())), nil
+	case "stdout":
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return nil, err
+		}
+		return sdktrace.NewTracerProvider(sdktrace.WithResource(res), sdktrace.WithBatcher(exp)), nil
+	case "otlp":
+		if _, err := url.ParseRequestURI(otlpEndpoint); err != nil {
+			return nil, fmt.Errorf("Please provide a valid OTLP endpoint, not %v", otlpEndpoint)
+		}
+
+		exp, err := otlptracehttp.New(context.Background(), otlptracehttp.WithEndpointURL(otlpEndpoint), otlptracehttp.WithHTTPClient(client))
+		if err != nil {
+			return nil, err
+		}
+		return sdktrace.NewTracerProvider(sdktrace.WithResource(res), sdktrace.WithBatcher(exp)), nil
+	}
+	return nil, fmt.Errorf("Unknown trace exporter %v, please use none, stdout or otlp", exporter)
+}
+
+// Tracer returns the carousel's tracer from the provider. Without a provider, spans are not recorded, but the span of the context is still propagated.
+func Tracer(provider trace.TracerProvider) trace.Tracer {
+	if provider == nil {
+		provider = noop.NewTracerProvider()
+	}
+	return provider.Tracer(instrumentationName)
+}
+
+// End ends the span, recording the error if there is one
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,84 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestNewTracerProviderWithoutAnExporter(t *testing.T) {
+	provider, err := NewTracerProvider("none", http.DefaultClient, DefaultOTLPEndpoint, "publish-carousel")
+	require.NoError(t, err)
+	defer provider.Shutdown(context.Background())
+
+	_, span := Tracer(provider).Start(context.Background(), PublishSpan)
+	defer span.End()
+
+	assert.True(t, span.SpanContext().IsValid(), "the span still has ids to send in the traceparent header")
+	assert.False(t, span.SpanContext().IsSampled())
+	assert.False(t, span.IsRecording())
+}
+
+func TestNewTracerProviderExportsToOTLP(t *testing.T) {
+	exported := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		exported <- r.URL.Path
+		w.Header().Set("Content-Type", "application/x-protobuf")
+	}))
+	defer server.Close()
+
+	provider, err := NewTracerProvider("otlp", server.Client(), server.URL+"/v1/traces", "publish-carousel")
+	require.NoError(t, err)
+	defer provider.Shutdown(context.Background())
+
+	_, span := Tracer(provider).Start(context.Background(), PublishSpan)
+	span.End()
+
+	require.NoError(t, provider.ForceFlush(context.Background()))
+	assert.Equal(t, "/v1/traces", <-exported)
+}
+
+func TestNewTracerProviderErrors(t *testing.T) {
+	_, err := NewTracerProvider("zipkin", http.DefaultClient, DefaultOTLPEndpoint, "publish-carousel")
+	assert.EqualError(t, err, "Unknown trace exporter zipkin, please use none, stdout or otlp")
+
+	_, err = NewTracerProvider("otlp", http.DefaultClient, "localhost", "publish-carousel")
+	assert.EqualError(t, err, "Please provide a valid OTLP endpoint, not localhost")
+}
+
+func TestTracerWithoutAProviderKeepsTheParentSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	ctx, parent := Tracer(provider).Start(context.Background(), PublishSpan)
+	_, span := Tracer(nil).Start(ctx, NotifySpan)
+
+	assert.False(t, span.IsRecording())
+	assert.Equal(t, parent.SpanContext(), trace.SpanContextFromContext(trace.ContextWithSpan(ctx, span)))
+}
+
+func TestEndRecordsTheError(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	_, failed := Tracer(provider).Start(context.Background(), NotifySpan)
+	End(failed, errors.New("computer says no"))
+
+	_, succeeded := Tracer(provider).Start(context.Background(), NotifySpan)
+	End(succeeded, nil)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, "computer says no", spans[0].Status().Description)
+	assert.Equal(t, codes.Unset, spans[1].Status().Code)
+}
@@ -0,0 +1,57 @@
+package transform
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Financial-Times/publish-carousel/native"
+)
+
+// Chain transforms the body of the native content which a cycle publishes. The rules are applied in order, so each rule sees the changes made by the rules before it.
+type Chain struct {
+	rules []compiledRule
+}
+
+// NewChain validates the rules, which must have unique names
+func NewChain(rules []Rule) (*Chain, error) {
+	chain := &Chain{}
+	names := make(map[string]bool)
+
+	for _, rule := range rules {
+		compiled, err := rule.compile()
+		if err != nil {
+			return nil, err
+		}
+
+		name := strings.ToLower(rule.Name)
+		if names[name] {
+			return nil, fmt.Errorf("Transformation names must be unique: %v", rule.Name)
+		}
+		names[name] = true
+
+		chain.rules = append(chain.rules, compiled)
+	}
+
+	return chain, nil
+}
+
+// Apply returns a transformed copy of the content, and the names of the rules which changed it. If no rule changes the content, the content itself is returned. A nil chain never changes the content.
+func (c *Chain) Apply(content *native.Content) (*native.Content, []string) {
+	if c == nil || len(c.rules) == 0 || content.Body == nil {
+		return content, nil
+	}
+
+	transformed := &native.Content{Body: copyValue(content.Body).(map[string]interface{}), ContentType: content.ContentType, OriginSystemID: content.OriginSystemID, Collection: content.Collection}
+
+	var applied []string
+	for _, rule := range c.rules {
+		if rule.apply(transformed) {
+			applied = append(applied, rule.Name)
+		}
+	}
+
+	if len(applied) == 0 {
+		return content, nil
+	}
+	return transformed, applied
+}
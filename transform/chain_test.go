@@ -0,0 +1,123 @@
+package transform
+
+import (
+	"testing"
+
+	"github.com/Financial-Times/publish-carousel/filter"
+	"github.com/Financial-Times/publish-carousel/native"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/mgo.v2/bson"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func article(body map[string]interface{}) *native.Content {
+	return &native.Content{Body: body, ContentType: "application/json", OriginSystemID: "http://cmdb.ft.com/systems/methode-web-pub"}
+}
+
+func TestApplyTransformations(t *testing.T) {
+	chain, err := NewChain([]Rule{
+		{Name: "canBeSyndicated", Action: "default", Field: "canBeSyndicated", Value: "verify"},
+		{Name: "byline", Action: "rename", Field: "editorial.byline", To: "byline"},
+		{Name: "internal", Action: "remove", Field: "internalNotes"},
+		{Name: "flags", Action: "set", Field: "flags.republished", Value: true},
+	})
+	require.NoError(t, err)
+
+	original := article(map[string]interface{}{"uuid": "uuid", "editorial": bson.M{"byline": "By someone"}, "internalNotes": "notes"})
+	transformed, applied := chain.Apply(original)
+
+	assert.Equal(t, []string{"canBeSyndicated", "byline", "internal", "flags"}, applied)
+	assert.Equal(t, map[string]interface{}{
+		"uuid":            "uuid",
+		"canBeSyndicated": "verify",
+		"byline":          "By someone",
+		"editorial":       bson.M{},
+		"flags":           map[string]interface{}{"republished": true},
+	}, transformed.Body)
+	assert.Equal(t, original.ContentType, transformed.ContentType)
+	assert.Equal(t, original.OriginSystemID, transformed.OriginSystemID)
+
+	assert.Equal(t, map[string]interface{}{"uuid": "uuid", "editorial": bson.M{"byline": "By someone"}, "internalNotes": "notes"}, original.Body, "the original content is never changed")
+}
+
+func TestApplyOnlyReportsRulesWhichChangeTheContent(t *testing.T) {
+	chain, err := NewChain([]Rule{
+		{Name: "canBeSyndicated", Action: "default", Field: "canBeSyndicated", Value: "verify"},
+		{Name: "byline", Action: "rename", Field: "editorial.byline", To: "byline"},
+		{Name: "internal", Action: "remove", Field: "internalNotes"},
+		{Name: "nested", Action: "set", Field: "title.text", Value: "title"},
+	})
+	require.NoError(t, err)
+
+	content := article(map[string]interface{}{"canBeSyndicated": "yes", "title": "not an object"})
+	transformed, applied := chain.Apply(content)
+
+	assert.Empty(t, applied)
+	assert.True(t, transformed == content, "the content is returned as is when nothing changes")
+}
+
+func TestApplyWhenConditionMatches(t *testing.T) {
+	chain, err := NewChain([]Rule{{Name: "wordpress", Action: "set", Field: "type", Value: "Article", When: &filter.Condition{OriginSystems: []string{"http://cmdb.ft.com/systems/wordpress"}}}})
+	require.NoError(t, err)
+
+	_, applied := chain.Apply(article(map[string]interface{}{"type": "Post"}))
+	assert.Empty(t, applied)
+
+	content := article(map[string]interface{}{"type": "Post"})
+	content.OriginSystemID = "http://cmdb.ft.com/systems/wordpress"
+
+	transformed, applied := chain.Apply(content)
+	assert.Equal(t, []string{"wordpress"}, applied)
+	assert.Equal(t, "Article", transformed.Body["type"])
+}
+
+func TestNilChainChangesNothing(t *testing.T) {
+	var chain *Chain
+	content := article(map[string]interface{}{"type": "Article"})
+
+	transformed, applied := chain.Apply(content)
+	assert.Empty(t, applied)
+	assert.True(t, transformed == content)
+}
+
+func TestUnmarshalRulesFromYAML(t *testing.T) {
+	var rules []Rule
+	err := yaml.Unmarshal([]byte(`
+- name: brand
+  action: default
+  field: brand
+  value:
+    id: ft
+    tags: [a, b]
+  when:
+    contentTypes: [application/json]
+`), &rules)
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+
+	assert.Equal(t, map[string]interface{}{"id": "ft", "tags": []interface{}{"a", "b"}}, rules[0].Value)
+	assert.Equal(t, []string{"application/json"}, rules[0].When.ContentTypes)
+
+	_, err = NewChain(rules)
+	assert.NoError(t, err)
+}
+
+func TestInvalidTransformations(t *testing.T) {
+	tests := map[string][]Rule{
+		"Please provide a name for every transformation":                                          {{Action: "remove", Field: "type"}},
+		"Please provide an action for transformation type, one of set, remove, rename or default": {{Name: "type", Action: "replace", Field: "type"}},
+		"Transformation type: Please provide a field path":                                        {{Name: "type", Action: "remove"}},
+		"Transformation type: Invalid field path brands[0].id":                                    {{Name: "type", Action: "remove", Field: "brands[0].id"}},
+		"Please provide the value for transformation type":                                        {{Name: "type", Action: "set", Field: "type"}},
+		"Transformation byline: Please provide a field path":                                      {{Name: "byline", Action: "rename", Field: "byline"}},
+		"Please rename the field of transformation byline to a different field":                   {{Name: "byline", Action: "rename", Field: "byline", To: "$.byline"}},
+		"Transformation type: Please provide at least one condition":                              {{Name: "type", Action: "remove", Field: "type", When: &filter.Condition{}}},
+		"Transformation names must be unique: Type":                                               {{Name: "type", Action: "remove", Field: "type"}, {Name: "Type", Action: "remove", Field: "kind"}},
+	}
+
+	for expected, rules := range tests {
+		_, err := NewChain(rules)
+		assert.EqualError(t, err, expected)
+	}
+}
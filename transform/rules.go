@@ -0,0 +1,251 @@
+package transform
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Financial-Times/publish-carousel/filter"
+	"github.com/Financial-Times/publish-carousel/native"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	// SetAction sets the field to the value, replacing any existing value
+	SetAction = "set"
+	// RemoveAction removes the field
+	RemoveAction = "remove"
+	// RenameAction moves the value of the field to the field named by To
+	RenameAction = "rename"
+	// DefaultAction sets the field to the value, unless the field already has a value
+	DefaultAction = "default"
+)
+
+// Rule changes a single field of the body of the native content. If When is given, the rule only applies to content which matches the condition.
+type Rule struct {
+	Name   string `yaml:"name" json:"name"`
+	Action string `yaml:"action" json:"action"`
+	// Field is a dotted path into the body of the content, i.e. editorial.byline. Missing parent fields are created by set and default.
+	Field string            `yaml:"field" json:"field"`
+	To    string            `yaml:"to" json:"to,omitempty"`
+	Value interface{}       `yaml:"value" json:"value,omitempty"`
+	When  *filter.Condition `yaml:"when" json:"when,omitempty"`
+}
+
+// UnmarshalYAML converts the nested yaml maps of the value into maps with string keys, so that the value can be written as json
+func (r *Rule) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain Rule
+	if err := unmarshal((*plain)(r)); err != nil {
+		return err
+	}
+
+	r.Value = stringKeys(r.Value)
+	return nil
+}
+
+type compiledRule struct {
+	Name   string
+	action string
+	field  []string
+	to     []string
+	value  interface{}
+	when   *filter.Predicate
+}
+
+func (r Rule) compile() (compiledRule, error) {
+	compiled := compiledRule{Name: r.Name, action: strings.ToLower(r.Action), value: r.Value}
+
+	if strings.TrimSpace(r.Name) == "" {
+		return compiled, fmt.Errorf("Please provide a name for every transformation")
+	}
+
+	field, err := parseField(r.Field)
+	if err != nil {
+		return compiled, fmt.Errorf("Transformation %v: %v", r.Name, err)
+	}
+	compiled.field = field
+
+	switch compiled.action {
+	case SetAction, DefaultAction:
+		if r.Value == nil {
+			return compiled, fmt.Errorf("Please provide the value for transformation %v", r.Name)
+		}
+	case RenameAction:
+		to, err := parseField(r.To)
+		if err != nil {
+			return compiled, fmt.Errorf("Transformation %v: %v", r.Name, err)
+		}
+		if strings.Join(to, ".") == strings.Join(field, ".") {
+			return compiled, fmt.Errorf("Please rename the field of transformation %v to a different field", r.Name)
+		}
+		compiled.to = to
+	case RemoveAction:
+	default:
+		return compiled, fmt.Errorf("Please provide an action for transformation %v, one of %v, %v, %v or %v", r.Name, SetAction, RemoveAction, RenameAction, DefaultAction)
+	}
+
+	if r.When != nil {
+		when, err := filter.NewPredicate(*r.When)
+		if err != nil {
+			return compiled, fmt.Errorf("Transformation %v: %v", r.Name, err)
+		}
+		compiled.when = when
+	}
+
+	return compiled, nil
+}
+
+// parseField splits a dotted path into the body of the native content. Array indexes are not supported, as transformations change a single field.
+func parseField(path string) ([]string, error) {
+	path = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(path), "$"), ".")
+	if path == "" {
+		return nil, fmt.Errorf("Please provide a field path")
+	}
+
+	fields := strings.Split(path, ".")
+	for _, field := range fields {
+		if field == "" || strings.ContainsAny(field, "[]*") {
+			return nil, fmt.Errorf("Invalid field path %v", path)
+		}
+	}
+	return fields, nil
+}
+
+// apply changes the body, and returns true if the rule changed anything
+func (r compiledRule) apply(content *native.Content) bool {
+	if r.when != nil && !r.when.Matches(content) {
+		return false
+	}
+
+	switch r.action {
+	case SetAction:
+		return set(content.Body, r.field, copyValue(r.value))
+	case DefaultAction:
+		if _, ok := get(content.Body, r.field); ok {
+			return false
+		}
+		return set(content.Body, r.field, copyValue(r.value))
+	case RemoveAction:
+		return remove(content.Body, r.field)
+	case RenameAction:
+		val, ok := get(content.Body, r.field)
+		if !ok || !set(content.Body, r.to, val) {
+			return false
+		}
+		return remove(content.Body, r.field)
+	}
+	return false
+}
+
+func get(body map[string]interface{}, path []string) (interface{}, bool) {
+	var current interface{} = body
+	for _, field := range path {
+		m, ok := asMap(current)
+		if !ok {
+			return nil, false
+		}
+
+		current, ok = m[field]
+		if !ok || current == nil {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// set creates any missing parents of the field, but does not replace parents which are not objects
+func set(body map[string]interface{}, path []string, value interface{}) bool {
+	parent := body
+	for _, field := range path[:len(path)-1] {
+		next, ok := parent[field]
+		if !ok || next == nil {
+			child := make(map[string]interface{})
+			parent[field] = child
+			parent = child
+			continue
+		}
+
+		if parent, ok = asMap(next); !ok {
+			return false
+		}
+	}
+
+	parent[path[len(path)-1]] = value
+	return true
+}
+
+func remove(body map[string]interface{}, path []string) bool {
+	parent := body
+	if len(path) > 1 {
+		val, ok := get(body, path[:len(path)-1])
+		if !ok {
+			return false
+		}
+
+		if parent, ok = asMap(val); !ok {
+			return false
+		}
+	}
+
+	field := path[len(path)-1]
+	if _, ok := parent[field]; !ok {
+		return false
+	}
+
+	delete(parent, field)
+	return true
+}
+
+func asMap(val interface{}) (map[string]interface{}, bool) {
+	switch m := val.(type) {
+	case map[string]interface{}:
+		return m, true
+	case bson.M:
+		return m, true
+	}
+	return nil, false
+}
+
+// copyValue deep copies the body of the content, so that transformations never change the content held by the native reader
+func copyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			m[key] = copyValue(val)
+		}
+		return m
+	case bson.M:
+		m := make(bson.M, len(v))
+		for key, val := range v {
+			m[key] = copyValue(val)
+		}
+		return m
+	case []interface{}:
+		arr := make([]interface{}, len(v))
+		for i, val := range v {
+			arr[i] = copyValue(val)
+		}
+		return arr
+	default:
+		return value
+	}
+}
+
+func stringKeys(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{})
+		for key, val := range v {
+			m[fmt.Sprintf("%v", key)] = stringKeys(val)
+		}
+		return m
+	case []interface{}:
+		arr := make([]interface{}, len(v))
+		for i, val := range v {
+			arr[i] = stringKeys(val)
+		}
+		return arr
+	default:
+		return value
+	}
+}
@@ -0,0 +1,131 @@
+package txid
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	tid "github.com/Financial-Times/transactionid-utils-go"
+)
+
+const (
+	carouselIntrafix = "_carousel_"
+	genTXSuffix      = "_gentx"
+)
+
+// Context describes the publish which a transaction id is built for
+type Context struct {
+	UUID             string
+	Collection       string
+	Cycle            string
+	Iteration        int
+	PublishReference string
+	Time             time.Time
+}
+
+// Strategy builds the transaction id which some content is published with
+type Strategy interface {
+	TransactionID(c Context) string
+}
+
+type carouselStrategy struct{}
+
+// Carousel returns the default strategy, which reuses the publish reference of the content, or otherwise generates a new transaction id with a _gentx suffix, and appends _carousel_ and the unix time of the publish, i.e. tid_1234_carousel_1494343423
+func Carousel() Strategy {
+	return carouselStrategy{}
+}
+
+func (carouselStrategy) TransactionID(c Context) string {
+	ref, generated := reference(c.PublishReference)
+	txID := ref + carouselIntrafix + strconv.FormatInt(publishTime(c).Unix(), 10)
+	if generated {
+		txID += genTXSuffix
+	}
+	return txID
+}
+
+// Fields are the values which a transaction id template can use. Cycle names are made safe for a transaction id by replacing anything other than letters, digits, dashes and underscores with dashes.
+type Fields struct {
+	TID        string
+	Generated  bool
+	UUID       string
+	Collection string
+	Cycle      string
+	Iteration  int
+	Unix       int64
+}
+
+type templateStrategy struct {
+	tmpl *template.Template
+}
+
+var unsafeChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// NewTemplate returns a strategy which builds transaction ids from a text/template of the Fields, i.e. {{.TID}}_carousel_{{.Cycle}}_{{.Iteration}}_{{.Unix}}{{if .Generated}}_gentx{{end}}
+func NewTemplate(text string) (Strategy, error) {
+	tmpl, err := template.New("transactionId").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid transaction id template: %v", err)
+	}
+
+	s := &templateStrategy{tmpl: tmpl}
+	sample, err := s.execute(Context{UUID: "uuid", Collection: "collection", Cycle: "cycle", PublishReference: "tid_sample", Time: time.Now()})
+	if err != nil {
+		return nil, fmt.Errorf("Invalid transaction id template: %v", err)
+	}
+
+	if !strings.Contains(text, ".TID") {
+		return nil, fmt.Errorf("Please include {{.TID}} in the transaction id template, so every publish has a unique transaction id")
+	}
+
+	if strings.TrimSpace(sample) != sample || strings.ContainsAny(sample, " \t\r\n") {
+		return nil, fmt.Errorf("The transaction id template must not produce whitespace")
+	}
+	return s, nil
+}
+
+func (s *templateStrategy) TransactionID(c Context) string {
+	txID, err := s.execute(c)
+	if err != nil { // templates are executed when they are created, so this should never happen
+		return Carousel().TransactionID(c)
+	}
+	return txID
+}
+
+func (s *templateStrategy) execute(c Context) (string, error) {
+	ref, generated := reference(c.PublishReference)
+	fields := Fields{
+		TID:        ref,
+		Generated:  generated,
+		UUID:       c.UUID,
+		Collection: c.Collection,
+		Cycle:      strings.Trim(unsafeChars.ReplaceAllString(c.Cycle, "-"), "-"),
+		Iteration:  c.Iteration,
+		Unix:       publishTime(c).Unix(),
+	}
+
+	b := new(bytes.Buffer)
+	if err := s.tmpl.Execute(b, fields); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// reference returns the publish reference, or a newly generated transaction id and true if there is none
+func reference(publishReference string) (string, bool) {
+	if strings.TrimSpace(publishReference) == "" {
+		return tid.NewTransactionID(), true
+	}
+	return publishReference, false
+}
+
+func publishTime(c Context) time.Time {
+	if c.Time.IsZero() {
+		return time.Now()
+	}
+	return c.Time
+}
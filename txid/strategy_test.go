@@ -0,0 +1,48 @@
+package txid
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var published = time.Date(2017, time.May, 1, 12, 0, 0, 0, time.UTC)
+
+func TestCarouselTransactionID(t *testing.T) {
+	assert.Equal(t, "tid_1234_carousel_1493640000", Carousel().TransactionID(Context{PublishReference: "tid_1234", Time: published}))
+	assert.Regexp(t, `^tid_[\S]+_carousel_1493640000_gentx$`, Carousel().TransactionID(Context{Time: published}))
+	assert.Regexp(t, `^tid_1234_carousel_\d{10}$`, Carousel().TransactionID(Context{PublishReference: "tid_1234"}))
+}
+
+func TestTemplateTransactionID(t *testing.T) {
+	s, err := NewTemplate("{{.TID}}_carousel_{{.Cycle}}_{{.Iteration}}_{{.Unix}}{{if .Generated}}_gentx{{end}}")
+	require.NoError(t, err)
+
+	c := Context{UUID: "uuid-1", Collection: "methode", Cycle: "Methode Whole Archive!", Iteration: 3, PublishReference: "tid_1234", Time: published}
+	assert.Equal(t, "tid_1234_carousel_Methode-Whole-Archive_3_1493640000", s.TransactionID(c))
+
+	c.PublishReference = ""
+	assert.Regexp(t, `^tid_[\S]+_carousel_Methode-Whole-Archive_3_1493640000_gentx$`, s.TransactionID(c))
+}
+
+func TestTemplateUUIDAndCollection(t *testing.T) {
+	s, err := NewTemplate("{{.TID}}_{{.Collection}}_{{.UUID}}")
+	require.NoError(t, err)
+	assert.Equal(t, "tid_1234_methode_uuid-1", s.TransactionID(Context{UUID: "uuid-1", Collection: "methode", PublishReference: "tid_1234"}))
+}
+
+func TestInvalidTemplates(t *testing.T) {
+	_, err := NewTemplate("{{.TID")
+	assert.Error(t, err)
+
+	_, err = NewTemplate("{{.TID}}_{{.Missing}}")
+	assert.Error(t, err)
+
+	_, err = NewTemplate("{{.Cycle}}_{{.Iteration}}")
+	assert.EqualError(t, err, "Please include {{.TID}} in the transaction id template, so every publish has a unique transaction id")
+
+	_, err = NewTemplate("{{.TID}} {{.Cycle}}")
+	assert.EqualError(t, err, "The transaction id template must not produce whitespace")
+}
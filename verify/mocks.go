@@ -0,0 +1,17 @@
+package verify
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockVerifier struct {
+	mock.Mock
+}
+
+func (m *MockVerifier) Verify(ctx context.Context, config Config, uuid string, tid string, published time.Time) error {
+	args := m.Called(ctx, config, uuid, tid, published)
+	return args.Error(0)
+}
@@ -0,0 +1,246 @@
+package verify
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Financial-Times/publish-carousel/cluster"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultDelay is the time to wait after a publish before first reading the content back
+	DefaultDelay = 30 * time.Second
+	// DefaultInterval is the time between reads of content which has not been verified yet
+	DefaultInterval = 10 * time.Second
+	// DefaultTimeout is the time after the publish at which unverified content is given up on
+	DefaultTimeout = 5 * time.Minute
+	// DefaultWorkers is the number of publishes each cycle verifies at once
+	DefaultWorkers = 20
+	// DefaultQueue is the number of publishes each cycle queues for verification, before further publishes are not verified
+	DefaultQueue = 1000
+
+	uuidPlaceholder    = "{uuid}"
+	readURLPlaceholder = "{readURL}"
+	maxBodySize        = 10 << 20
+)
+
+// Config configures the verification of the content a cycle publishes. The URL must contain {uuid}, and can start with {readURL} to read the content back from every delivery cluster.
+type Config struct {
+	URL      string `yaml:"url" json:"url"`
+	Delay    string `yaml:"delay" json:"delay,omitempty"`
+	Interval string `yaml:"interval" json:"interval,omitempty"`
+	Timeout  string `yaml:"timeout" json:"timeout,omitempty"`
+	Workers  int    `yaml:"workers" json:"workers,omitempty"`
+	Queue    int    `yaml:"queue" json:"queue,omitempty"`
+}
+
+// Validate checks the url and durations of the config
+func (c Config) Validate() error {
+	if !strings.Contains(c.URL, uuidPlaceholder) {
+		return fmt.Errorf("Please provide a verification url which contains %v", uuidPlaceholder)
+	}
+
+	if strings.Contains(strings.TrimPrefix(c.URL, readURLPlaceholder), readURLPlaceholder) {
+		return fmt.Errorf("The verification url can only start with %v", readURLPlaceholder)
+	}
+
+	if c.Workers < 0 || c.Queue < 0 {
+		return fmt.Errorf("Please provide a number of verification workers and a verification queue size which are not negative")
+	}
+
+	_, _, _, err := c.durations()
+	return err
+}
+
+// Pool returns the number of publishes to verify at once, and the number of publishes which can wait to be verified
+func (c Config) Pool() (workers int, queue int) {
+	workers, queue = c.Workers, c.Queue
+	if workers == 0 {
+		workers = DefaultWorkers
+	}
+	if queue == 0 {
+		queue = DefaultQueue
+	}
+	return workers, queue
+}
+
+func (c Config) durations() (time.Duration, time.Duration, time.Duration, error) {
+	delay, interval, timeout := DefaultDelay, DefaultInterval, DefaultTimeout
+	for _, d := range []struct {
+		val    string
+		target *time.Duration
+	}{{c.Delay, &delay}, {c.Interval, &interval}, {c.Timeout, &timeout}} {
+		if d.val == "" {
+			continue
+		}
+
+		parsed, err := time.ParseDuration(d.val)
+		if err != nil || parsed < 0 {
+			return 0, 0, 0, fmt.Errorf("Please provide a valid verification duration, not %v", d.val)
+		}
+		*d.target = parsed
+	}
+
+	if interval == 0 {
+		return 0, 0, 0, fmt.Errorf("Please provide a verification interval greater than zero")
+	}
+
+	if timeout < delay {
+		return 0, 0, 0, fmt.Errorf("Please provide a verification timeout which is no shorter than the delay")
+	}
+	return delay, interval, timeout, nil
+}
+
+// Verifier reads published content back from a read endpoint, to check that the publish reached it
+type Verifier interface {
+	Verify(ctx context.Context, config Config, uuid string, tid string, published time.Time) error
+}
+
+type httpVerifier struct {
+	client       cluster.HttpClient
+	environments func() []cluster.ReadEnvironment
+}
+
+// NewVerifier returns a verifier which reads content over http. The read environments are only needed for urls which start with {readURL}.
+func NewVerifier(client cluster.HttpClient, environments func() []cluster.ReadEnvironment) Verifier {
+	return &httpVerifier{client: client, environments: environments}
+}
+
+type target struct {
+	url      string
+	username string
+	password string
+}
+
+// Verify waits for the delay after the publish, then polls the read url until the transaction id of the publish is found in the response from every target, or the timeout after the publish is reached.
+// It returns nil once the content is verified, or an error describing the targets which were not verified.
+func (v *httpVerifier) Verify(ctx context.Context, config Config, uuid string, tid string, published time.Time) error {
+	delay, interval, timeout, err := config.durations()
+	if err != nil {
+		return err
+	}
+
+	if err := sleep(ctx, time.Until(published.Add(delay))); err != nil {
+		return err
+	}
+
+	targets, err := v.targets(config.URL, uuid)
+	if err != nil {
+		return err
+	}
+
+	problems := make(map[string]string)
+	for {
+		for _, t := range targets {
+			if problem, ok := problems[t.url]; ok && problem == "" {
+				continue
+			}
+			problems[t.url] = v.check(ctx, t, uuid, tid)
+		}
+
+		unverified := unverifiedTargets(problems)
+		if len(unverified) == 0 {
+			return nil
+		}
+
+		if time.Since(published)+interval > timeout {
+			return fmt.Errorf("Not verified within %v: %v", timeout, strings.Join(unverified, "; "))
+		}
+
+		if err := sleep(ctx, interval); err != nil {
+			return err
+		}
+	}
+}
+
+func (v *httpVerifier) targets(url string, uuid string) ([]target, error) {
+	url = strings.Replace(url, uuidPlaceholder, uuid, -1)
+	if !strings.HasPrefix(url, readURLPlaceholder) {
+		return []target{{url: url}}, nil
+	}
+
+	var envs []cluster.ReadEnvironment
+	if v.environments != nil {
+		envs = v.environments()
+	}
+
+	if len(envs) == 0 {
+		return nil, fmt.Errorf("No read environments are configured for the verification url %v", url)
+	}
+
+	var targets []target
+	for _, env := range envs {
+		targets = append(targets, target{url: strings.TrimSuffix(env.ReadURL.String(), "/") + strings.TrimPrefix(url, readURLPlaceholder), username: env.Username, password: env.Password})
+	}
+	return targets, nil
+}
+
+// check returns an empty string if the response from the target contains the transaction id, otherwise what went wrong
+func (v *httpVerifier) check(ctx context.Context, t target, uuid string, tid string) string {
+	req, err := http.NewRequest("GET", t.url, nil)
+	if err != nil {
+		return err.Error()
+	}
+	req = req.WithContext(ctx)
+
+	if t.username != "" {
+		req.SetBasicAuth(t.username, t.password)
+	}
+	req.Header.Add("User-Agent", "UPP Publish Carousel")
+	req.Header.Add("X-Request-Id", tid)
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		log.WithField("uuid", uuid).WithField("transaction_id", tid).WithError(err).Debug("Failed to read content for verification")
+		return fmt.Sprintf("%v: %v", t.url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Sprintf("%v returned %v", t.url, resp.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		return fmt.Sprintf("%v: %v", t.url, err)
+	}
+
+	if !strings.Contains(string(body), tid) {
+		return fmt.Sprintf("%v does not have the transaction id %v", t.url, tid)
+	}
+	return ""
+}
+
+func unverifiedTargets(problems map[string]string) []string {
+	var unverified []string
+	for _, problem := range problems {
+		if problem != "" {
+			unverified = append(unverified, problem)
+		}
+	}
+	sort.Strings(unverified)
+	return unverified
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,116 @@
+package verify
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Financial-Times/publish-carousel/cluster"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigValidate(t *testing.T) {
+	assert.NoError(t, Config{URL: "http://document-store-api:8080/content/{uuid}"}.Validate())
+	assert.NoError(t, Config{URL: "{readURL}/__document-store-api/content/{uuid}", Delay: "0s", Interval: "1s", Timeout: "1m"}.Validate())
+
+	tests := map[string]Config{
+		"Please provide a verification url which contains {uuid}":                                              {URL: "http://document-store-api:8080/content"},
+		"The verification url can only start with {readURL}":                                                   {URL: "http://proxy/{readURL}/content/{uuid}"},
+		"Please provide a valid verification duration, not soon":                                               {URL: "{uuid}", Delay: "soon"},
+		"Please provide a verification interval greater than zero":                                             {URL: "{uuid}", Interval: "0s"},
+		"Please provide a verification timeout which is no shorter than the delay":                             {URL: "{uuid}", Delay: "1m", Timeout: "30s"},
+		"Please provide a number of verification workers and a verification queue size which are not negative": {URL: "{uuid}", Workers: -1},
+	}
+
+	for expected, config := range tests {
+		assert.EqualError(t, config.Validate(), expected)
+	}
+}
+
+func TestConfigPool(t *testing.T) {
+	workers, queue := Config{}.Pool()
+	assert.Equal(t, DefaultWorkers, workers)
+	assert.Equal(t, DefaultQueue, queue)
+
+	workers, queue = Config{Workers: 5, Queue: 50}.Pool()
+	assert.Equal(t, 5, workers)
+	assert.Equal(t, 50, queue)
+}
+
+func TestVerifyPollsUntilTheTransactionIDIsFound(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/content/a-uuid", r.URL.Path)
+		assert.Equal(t, "tid_1234", r.Header.Get("X-Request-Id"))
+
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.Write([]byte(`{"uuid": "a-uuid", "publishReference": "tid_older"}`))
+			return
+		}
+		w.Write([]byte(`{"uuid": "a-uuid", "publishReference": "tid_1234"}`))
+	}))
+	defer server.Close()
+
+	verifier := NewVerifier(http.DefaultClient, nil)
+	err := verifier.Verify(context.Background(), Config{URL: server.URL + "/content/{uuid}", Delay: "0s", Interval: "10ms", Timeout: "1s"}, "a-uuid", "tid_1234", time.Now())
+
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestVerifyTimesOut(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	verifier := NewVerifier(http.DefaultClient, nil)
+	err := verifier.Verify(context.Background(), Config{URL: server.URL + "/content/{uuid}", Delay: "0s", Interval: "10ms", Timeout: "50ms"}, "a-uuid", "tid_1234", time.Now())
+
+	assert.EqualError(t, err, "Not verified within 50ms: "+server.URL+"/content/a-uuid returned 404")
+}
+
+func TestVerifyEveryReadEnvironment(t *testing.T) {
+	verified := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "user", username)
+		assert.Equal(t, "pass", password)
+		assert.Equal(t, "/__document-store-api/content/a-uuid", r.URL.Path)
+		w.Write([]byte(`{"publishReference": "tid_1234"}`))
+	}))
+	defer verified.Close()
+
+	stale := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"publishReference": "tid_older"}`))
+	}))
+	defer stale.Close()
+
+	verifiedURL, _ := url.Parse(verified.URL)
+	staleURL, _ := url.Parse(stale.URL + "/")
+	environments := func() []cluster.ReadEnvironment {
+		return []cluster.ReadEnvironment{{Name: "eu", ReadURL: verifiedURL, Username: "user", Password: "pass"}, {Name: "us", ReadURL: staleURL}}
+	}
+
+	verifier := NewVerifier(http.DefaultClient, environments)
+	err := verifier.Verify(context.Background(), Config{URL: "{readURL}/__document-store-api/content/{uuid}", Delay: "0s", Interval: "10ms", Timeout: "30ms"}, "a-uuid", "tid_1234", time.Now())
+
+	require.Error(t, err)
+	assert.Equal(t, "Not verified within 30ms: "+stale.URL+"/__document-store-api/content/a-uuid does not have the transaction id tid_1234", err.Error())
+
+	err = NewVerifier(http.DefaultClient, nil).Verify(context.Background(), Config{URL: "{readURL}/content/{uuid}", Delay: "0s"}, "a-uuid", "tid_1234", time.Now())
+	assert.EqualError(t, err, "No read environments are configured for the verification url {readURL}/content/a-uuid")
+}
+
+func TestVerifyCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := NewVerifier(http.DefaultClient, nil).Verify(ctx, Config{URL: "http://localhost/{uuid}", Delay: "1m"}, "a-uuid", "tid_1234", time.Now())
+	assert.Equal(t, context.Canceled, err)
+}